@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// AdoptCmd represents the "adopt" subcommand.
+type AdoptCmd struct {
+	Target      string `arg:"" predictor:"prefix" help:"Ref or prefix (trailing /) whose unmanaged entries get bundr's managed tags"`
+	StoreMode   string `name:"store-mode" enum:"raw,json," default:"" help:"Store mode for every adopted entry (default: json for JSON objects and arrays, raw otherwise)"`
+	DryRun      bool   `name:"dry-run" help:"Print the plan without changing tags"`
+	Concurrency int    `name:"concurrency" default:"4" help:"Number of entries tagged in parallel"`
+
+	out io.Writer // for testing; nil means os.Stdout
+}
+
+// adoptAction actions shown in the plan.
+const (
+	adoptActionAdopt = "adopt"
+	adoptActionSkip  = "skip"
+)
+
+// adoptItem is one entry of the adopt plan.
+type adoptItem struct {
+	ref       string
+	action    string
+	storeMode string
+	reason    string
+}
+
+// Run executes the adopt command.
+func (c *AdoptCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	ref, prefix, err := parseTagTarget(c.Target)
+	if err != nil {
+		return fmt.Errorf("adopt command failed: %w", err)
+	}
+	b, err := appCtx.BackendFactory(ref.Type)
+	if err != nil {
+		return fmt.Errorf("adopt command failed: create backend: %w", err)
+	}
+	tagger, err := backend.AsTagger(b)
+	if err != nil {
+		return fmt.Errorf("adopt command failed: %w", err)
+	}
+	ctx := context.Background()
+
+	plan, err := c.plan(ctx, b, tagger, ref, prefix)
+	if err != nil {
+		return fmt.Errorf("adopt command failed: %w", err)
+	}
+
+	var errs []error
+	if !c.DryRun {
+		errs = forEachConcurrent(len(plan), c.Concurrency, func(i int) error {
+			if plan[i].action != adoptActionAdopt {
+				return nil
+			}
+			// 管理タグのみ追加する（既存のユーザータグは変更しない）
+			return tagger.AddTags(ctx, plan[i].ref, tags.ManagedTags(plan[i].storeMode))
+		})
+	}
+
+	if err := c.report(appCtx.Output, plan, errs); err != nil {
+		return fmt.Errorf("adopt command failed: %w", err)
+	}
+	return nil
+}
+
+// plan lists the target entries and decides, for each, whether to adopt it and with which
+// store mode. Entries that already carry cli=bundr are skipped. Under a prefix the tags and
+// raw values come from the walk itself; a single ref is read with one Tags and one Get call.
+func (c *AdoptCmd) plan(ctx context.Context, b backend.Backend, tagger backend.Tagger, ref backend.Ref, prefix bool) ([]adoptItem, error) {
+	var plan []adoptItem
+	add := func(r, value string, tagMap map[string]string) {
+		if tagMap[tags.TagCLI] == tags.TagCLIValue {
+			plan = append(plan, adoptItem{ref: r, action: adoptActionSkip, storeMode: tagMap[tags.TagStoreMode], reason: "already managed"})
+			return
+		}
+		// store mode を値から判定する
+		storeMode := c.StoreMode
+		if storeMode == "" {
+			storeMode = detectStoreMode(value)
+		}
+		plan = append(plan, adoptItem{ref: r, action: adoptActionAdopt, storeMode: storeMode})
+	}
+
+	if prefix {
+		err := backend.WalkPrefix(ctx, b, ref.Path, backend.GetByPrefixOptions{Recursive: true}, func(e backend.ParameterEntry) error {
+			r := string(ref.Type) + ":" + e.Path
+			tagMap := e.Tags
+			if tagMap == nil {
+				var err error
+				if tagMap, err = tagger.Tags(ctx, r); err != nil {
+					return err
+				}
+			}
+			add(r, e.Value, tagMap)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		tagMap, err := tagger.Tags(ctx, c.Target)
+		if err != nil {
+			return nil, err
+		}
+		var value string
+		if c.StoreMode == "" && tagMap[tags.TagCLI] != tags.TagCLIValue {
+			if value, err = b.Get(ctx, c.Target, backend.GetOptions{ForceRaw: true}); err != nil {
+				return nil, err
+			}
+		}
+		add(c.Target, value, tagMap)
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].ref < plan[j].ref })
+	return plan, nil
+}
+
+// report prints the plan (or the result) and a summary line. errs holds the error of each
+// plan item after applying; it is nil for a dry run.
+func (c *AdoptCmd) report(format string, plan []adoptItem, errs []error) error {
+	var adopted, skipped, failed int
+	byMode := map[string]int{}
+	var failures []string
+
+	rw := newRecordWriter(c.out, format, "ref", "action", "store_mode")
+	for i, it := range plan {
+		action, reason := it.action, it.reason
+		if errs != nil && errs[i] != nil {
+			action, reason = "failed", errs[i].Error()
+			failures = append(failures, fmt.Sprintf("%s: %v", it.ref, errs[i]))
+		}
+		switch action {
+		case adoptActionAdopt:
+			adopted++
+			byMode[it.storeMode]++
+		case adoptActionSkip:
+			skipped++
+		default:
+			failed++
+		}
+
+		if format != "" {
+			if err := rw.Write(record{"ref": it.ref, "action": action, "store_mode": it.storeMode, "reason": reason}); err != nil {
+				return err
+			}
+			continue
+		}
+		line := fmt.Sprintf("%s: %s", it.ref, action)
+		if action == adoptActionAdopt {
+			line += " store-mode=" + it.storeMode
+		}
+		if reason != "" {
+			line += " (" + reason + ")"
+		}
+		if c.DryRun {
+			line = "dry-run: " + line
+		}
+		fmt.Fprintln(c.out, line)
+	}
+	if err := rw.Close(); err != nil {
+		return err
+	}
+
+	if format == "" {
+		verb := "adopted"
+		if c.DryRun {
+			verb = "would adopt"
+		}
+		fmt.Fprintf(c.out, "%s %d (json: %d, raw: %d), skipped %d, failed %d\n",
+			verb, adopted, byMode[tags.StoreModeJSON], byMode[tags.StoreModeRaw], skipped, failed)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d entries failed: %s", len(failures), len(plan), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// detectStoreMode returns json for JSON objects and arrays and raw for anything else.
+// JSON scalars ("5432", "true") stay raw so that exec and get keep their text as is.
+func detectStoreMode(value string) string {
+	trimmed := strings.TrimSpace(value)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return tags.StoreModeJSON
+	}
+	return tags.StoreModeRaw
+}
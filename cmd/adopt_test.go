@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// newAdoptTestContext stores two unmanaged legacy parameters (one JSON object, one
+// scalar) carrying a user tag, and one managed parameter.
+func newAdoptTestContext(t *testing.T) (*backend.MockBackend, *Context) {
+	t.Helper()
+	mb := backend.NewMockBackend()
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/legacy/config", backend.PutOptions{Value: `{"host":"db"}`, StoreMode: tags.StoreModeRaw})
+	_ = mb.Put(ctx, "ps:/legacy/port", backend.PutOptions{Value: "5432", StoreMode: tags.StoreModeRaw})
+	_ = mb.Put(ctx, "ps:/legacy/managed", backend.PutOptions{Value: "x", StoreMode: tags.StoreModeRaw})
+	mb.SetTags("ps:/legacy/config", map[string]string{"team": "core"})
+	mb.SetTags("ps:/legacy/port", map[string]string{"team": "core"})
+	return mb, &Context{
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) {
+			return mb, nil
+		},
+	}
+}
+
+func TestAdoptCmd(t *testing.T) {
+	tests := []struct {
+		id        string
+		cmd       AdoptCmd
+		want      string
+		wantModes map[string]string // ref → cli-store-mode after the run ("" = unmanaged)
+	}{
+		{
+			id:  "detect",
+			cmd: AdoptCmd{Target: "ps:/legacy/"},
+			want: "ps:/legacy/config: adopt store-mode=json\n" +
+				"ps:/legacy/managed: skip (already managed)\n" +
+				"ps:/legacy/port: adopt store-mode=raw\n" +
+				"adopted 2 (json: 1, raw: 1), skipped 1, failed 0\n",
+			wantModes: map[string]string{"ps:/legacy/config": tags.StoreModeJSON, "ps:/legacy/port": tags.StoreModeRaw},
+		},
+		{
+			id:  "dry-run",
+			cmd: AdoptCmd{Target: "ps:/legacy/", DryRun: true},
+			want: "dry-run: ps:/legacy/config: adopt store-mode=json\n" +
+				"dry-run: ps:/legacy/managed: skip (already managed)\n" +
+				"dry-run: ps:/legacy/port: adopt store-mode=raw\n" +
+				"would adopt 2 (json: 1, raw: 1), skipped 1, failed 0\n",
+			wantModes: map[string]string{"ps:/legacy/config": "", "ps:/legacy/port": ""},
+		},
+		{
+			id:        "override",
+			cmd:       AdoptCmd{Target: "ps:/legacy/port", StoreMode: tags.StoreModeJSON},
+			want:      "ps:/legacy/port: adopt store-mode=json\nadopted 1 (json: 1, raw: 0), skipped 0, failed 0\n",
+			wantModes: map[string]string{"ps:/legacy/port": tags.StoreModeJSON, "ps:/legacy/config": ""},
+		},
+		{
+			id:        "single",
+			cmd:       AdoptCmd{Target: "ps:/legacy/config"},
+			want:      "ps:/legacy/config: adopt store-mode=json\nadopted 1 (json: 1, raw: 0), skipped 0, failed 0\n",
+			wantModes: map[string]string{"ps:/legacy/config": tags.StoreModeJSON, "ps:/legacy/port": ""},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			mb, appCtx := newAdoptTestContext(t)
+			var out bytes.Buffer
+			cmd := tc.cmd
+			cmd.out = &out
+			if err := cmd.Run(appCtx); err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output = %q, want %q", out.String(), tc.want)
+			}
+			// 前方一致では一覧の値を使い、値を読み直さない
+			if strings.HasSuffix(tc.cmd.Target, "/") && len(mb.GetCalls) != 0 {
+				t.Errorf("Get called %d times, want 0 (values come from the walk)", len(mb.GetCalls))
+			}
+			for ref, mode := range tc.wantModes {
+				got, _ := mb.Tags(context.Background(), ref)
+				if got[tags.TagStoreMode] != mode {
+					t.Errorf("%s cli-store-mode = %q, want %q", ref, got[tags.TagStoreMode], mode)
+				}
+				// user tags are never touched
+				if got["team"] != "core" {
+					t.Errorf("%s lost its user tag: %v", ref, got)
+				}
+				if mode != "" && (got[tags.TagCLI] != tags.TagCLIValue || got[tags.TagSchema] != tags.TagSchemaValue) {
+					t.Errorf("%s is missing managed tags: %v", ref, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAdoptCmd_Output(t *testing.T) {
+	_, appCtx := newAdoptTestContext(t)
+	appCtx.Output = OutputJSONL
+	var out bytes.Buffer
+	cmd := &AdoptCmd{Target: "ps:/legacy/", DryRun: true, out: &out}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || lines[0] != `{"action":"adopt","reason":"","ref":"ps:/legacy/config","store_mode":"json"}` {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+func TestDetectStoreMode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: `{"a":1}`, want: tags.StoreModeJSON},
+		{value: ` [1, 2] `, want: tags.StoreModeJSON},
+		{value: `{"a":`, want: tags.StoreModeRaw},
+		{value: `5432`, want: tags.StoreModeRaw},
+		{value: `"quoted"`, want: tags.StoreModeRaw},
+		{value: `plain text`, want: tags.StoreModeRaw},
+	}
+	for _, tc := range tests {
+		if got := detectStoreMode(tc.value); got != tc.want {
+			t.Errorf("detectStoreMode(%q) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
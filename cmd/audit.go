@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/youyo/bundr/internal/audit"
+	"github.com/youyo/bundr/internal/backend"
+)
+
+// auditFailExitCode is the exit status when findings reach --fail-on (errors exit with 1).
+const auditFailExitCode = 2
+
+// AuditCmd represents the "audit" subcommand.
+type AuditCmd struct {
+	Prefixes  []string `arg:"" optional:"" predictor:"prefix" help:"Prefixes to audit (default: ps:/ and sm:)"`
+	StaleDays int      `name:"stale-days" default:"365" help:"Report entries unchanged for more than this many days (0 disables the check)"`
+	FailOn    string   `name:"fail-on" enum:"low,medium,high,none" default:"high" help:"Exit with status 2 when a finding has this severity or higher (low|medium|high|none)"`
+	NoValues  bool     `name:"no-values" help:"Do not read values; skips the value-based checks (secret-looking values, duplicates)"`
+
+	out    io.Writer        // for testing; nil means os.Stdout
+	errOut io.Writer        // for testing; nil means os.Stderr
+	now    func() time.Time // for testing; nil means time.Now
+}
+
+// Run executes the audit command.
+func (c *AuditCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	if c.errOut == nil {
+		c.errOut = os.Stderr
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	failOn, err := audit.ParseSeverity(c.FailOn)
+	if err != nil {
+		return fmt.Errorf("audit command failed: %w", err)
+	}
+
+	prefixes := c.Prefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{"ps:/", "sm:"}
+	}
+	var items []audit.Item
+	for _, p := range prefixes {
+		scope, err := parseListScope(p)
+		if err != nil {
+			return fmt.Errorf("audit command failed: %w", err)
+		}
+		found, err := c.collect(appCtx, scope)
+		if err != nil {
+			return fmt.Errorf("audit command failed: %s: %w", p, err)
+		}
+		items = append(items, found...)
+	}
+
+	opts := audit.Options{Now: c.now()}
+	if appCtx.Config != nil {
+		opts.KMSKeyID = appCtx.Config.AWS.KMSKeyID
+	}
+	if c.StaleDays > 0 {
+		opts.StaleAfter = time.Duration(c.StaleDays) * 24 * time.Hour
+	}
+	findings := audit.Run(items, opts)
+
+	if err := c.report(appCtx.Output, findings, len(items)); err != nil {
+		return fmt.Errorf("audit command failed: %w", err)
+	}
+
+	if failOn > 0 {
+		failing := 0
+		for _, f := range findings {
+			if f.Severity >= failOn {
+				failing++
+			}
+		}
+		if failing > 0 {
+			fmt.Fprintf(c.errOut, "audit: %d finding(s) at or above %s\n", failing, failOn)
+			return &ExitCodeError{Code: auditFailExitCode}
+		}
+	}
+	return nil
+}
+
+// collect lists the entries under scope (with managed tags resolved) and, unless --no-values,
+// reads their raw values. Values are only used for the checks and never printed.
+func (c *AuditCmd) collect(appCtx *Context, scope backend.Ref) ([]audit.Item, error) {
+	b, err := appCtx.BackendFactory(scope.Type)
+	if err != nil {
+		return nil, fmt.Errorf("create backend: %w", err)
+	}
+	ctx := context.Background()
+
+	var items []audit.Item
+	err = backend.List(ctx, b, scope.Path, backend.ListOptions{Recursive: true, ResolveManaged: true}, func(e backend.ListEntry) error {
+		items = append(items, audit.Item{Ref: string(scope.Type) + ":" + e.Path, Type: scope.Type, Entry: e})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.NoValues || len(items) == 0 {
+		return items, nil
+	}
+
+	refs := make([]string, len(items))
+	for i, it := range items {
+		refs[i] = it.Ref
+	}
+	values, _, err := backend.GetMany(ctx, b, refs, backend.GetOptions{ForceRaw: true})
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Value, items[i].HasValue = values[items[i].Ref]
+	}
+	return items, nil
+}
+
+// report prints the findings. Without --output they are shown highest severity first,
+// followed by a summary line.
+func (c *AuditCmd) report(format string, findings []audit.Finding, audited int) error {
+	if format != "" {
+		rw := newRecordWriter(c.out, format, "ref", "severity", "check", "message")
+		for _, f := range findings {
+			r := record{"ref": f.Ref, "severity": f.Severity.String(), "check": f.Check, "message": f.Message}
+			if err := rw.Write(r); err != nil {
+				return err
+			}
+		}
+		return rw.Close()
+	}
+
+	counts := map[audit.Severity]int{}
+	if len(findings) > 0 {
+		tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SEVERITY\tCHECK\tREF\tMESSAGE")
+		for _, f := range findings {
+			counts[f.Severity]++
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", strings.ToUpper(f.Severity.String()), f.Check, f.Ref, f.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(c.out, "audited %d entries: %d findings (high: %d, medium: %d, low: %d)\n",
+		audited, len(findings), counts[audit.SeverityHigh], counts[audit.SeverityMedium], counts[audit.SeverityLow])
+	return err
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// newAuditTestContext stores a small account with one problem of each kind.
+func newAuditTestContext(t *testing.T) *Context {
+	t.Helper()
+	ctx := context.Background()
+	ps, sm := backend.NewMockBackend(), backend.NewMockBackend()
+	puts := []struct {
+		b    *backend.MockBackend
+		ref  string
+		opts backend.PutOptions
+	}{
+		{ps, "ps:/app/DB_PASSWORD", backend.PutOptions{Value: "hunter2", StoreMode: tags.StoreModeRaw, ValueType: "string"}},
+		{ps, "ps:/app/db_host", backend.PutOptions{Value: "db.internal", StoreMode: tags.StoreModeRaw, ValueType: "string"}},
+		{ps, "ps:/app/api", backend.PutOptions{Value: "q8Zr2LxV0pW7mNcT4kYs9HbJ", StoreMode: tags.StoreModeRaw, ValueType: "secure", KMSKeyID: "alias/app"}},
+		{sm, "sm:app-api", backend.PutOptions{Value: "q8Zr2LxV0pW7mNcT4kYs9HbJ", StoreMode: tags.StoreModeRaw, KMSKeyID: "alias/app"}},
+	}
+	for _, p := range puts {
+		if err := p.b.Put(ctx, p.ref, p.opts); err != nil {
+			t.Fatalf("Put(%s): %v", p.ref, err)
+		}
+	}
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, ref := range []string{"ps:/app/DB_PASSWORD", "ps:/app/api"} {
+		ps.SetLastModified(ref, now.AddDate(0, -1, 0))
+	}
+	ps.SetLastModified("ps:/app/db_host", now.AddDate(-2, 0, 0))
+	sm.SetLastModified("sm:app-api", now.AddDate(0, -1, 0))
+	ps.SetTags("ps:/app/db_host", map[string]string{"team": "core"})
+
+	return &Context{
+		Config: &config.Config{AWS: config.AWSConfig{KMSKeyID: "alias/app"}},
+		BackendFactory: func(bt backend.BackendType) (backend.Backend, error) {
+			if bt == backend.BackendTypeSM {
+				return sm, nil
+			}
+			return ps, nil
+		},
+	}
+}
+
+func auditTestNow() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+func TestAuditCmd(t *testing.T) {
+	tests := []struct {
+		id       string
+		cmd      AuditCmd
+		wantCode int // 0 = no error
+		want     []string
+		notWant  []string
+	}{
+		{
+			id:       "default fails on high",
+			cmd:      AuditCmd{StaleDays: 365, FailOn: "high"},
+			wantCode: auditFailExitCode,
+			want: []string{
+				"HIGH      plaintext-secret  ps:/app/DB_PASSWORD",
+				"duplicate-value   ps:/app/api",
+				"duplicate-value   sm:app-api",
+				"no-rotation       sm:app-api",
+				"stale             ps:/app/db_host      unchanged for 730 days",
+				"unmanaged         ps:/app/db_host",
+				"audited 4 entries: 6 findings (high: 1, medium: 3, low: 2)",
+			},
+			notWant: []string{"hunter2", "q8Zr2LxV0pW7mNcT4kYs9HbJ"},
+		},
+		{
+			id:   "fail-on none",
+			cmd:  AuditCmd{StaleDays: 365, FailOn: "none"},
+			want: []string{"audited 4 entries: 6 findings"},
+		},
+		{
+			id:   "prefix and no values",
+			cmd:  AuditCmd{Prefixes: []string{"ps:/app/"}, StaleDays: 0, FailOn: "medium", NoValues: true},
+			want: []string{"plaintext-secret  ps:/app/DB_PASSWORD", "audited 3 entries: 2 findings (high: 1, medium: 0, low: 1)"},
+			// high finding still fails with --fail-on medium
+			wantCode: auditFailExitCode,
+			notWant:  []string{"duplicate-value", "stale", "sm:"},
+		},
+		{
+			id:   "fail-on high passes medium",
+			cmd:  AuditCmd{Prefixes: []string{"sm:"}, StaleDays: 365, FailOn: "high"},
+			want: []string{"no-rotation", "audited 1 entries: 1 findings (high: 0, medium: 1, low: 0)"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			cmd := tc.cmd
+			cmd.out, cmd.errOut, cmd.now = &out, &errOut, auditTestNow
+			err := cmd.Run(newAuditTestContext(t))
+			if tc.wantCode == 0 && err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if tc.wantCode != 0 {
+				var exitErr *ExitCodeError
+				if !isExitCodeError(err, &exitErr) || exitErr.Code != tc.wantCode {
+					t.Fatalf("err = %v, want ExitCodeError{%d}", err, tc.wantCode)
+				}
+				if !strings.Contains(errOut.String(), "at or above") {
+					t.Errorf("stderr = %q", errOut.String())
+				}
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(out.String(), w) {
+					t.Errorf("output missing %q:\n%s", w, out.String())
+				}
+			}
+			for _, w := range tc.notWant {
+				if strings.Contains(out.String(), w) {
+					t.Errorf("output contains %q:\n%s", w, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestAuditCmd_Output(t *testing.T) {
+	var out bytes.Buffer
+	cmd := AuditCmd{Prefixes: []string{"sm:"}, FailOn: "none", out: &out, now: auditTestNow}
+	appCtx := newAuditTestContext(t)
+	appCtx.Output = OutputJSON
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	var got []map[string]string
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	want := map[string]string{"ref": "sm:app-api", "severity": "medium", "check": "no-rotation", "message": "rotation is not enabled"}
+	if len(got) != 1 || len(got[0]) != len(want) {
+		t.Fatalf("records = %v, want [%v]", got, want)
+	}
+	for k, v := range want {
+		if got[0][k] != v {
+			t.Errorf("%s = %q, want %q", k, got[0][k], v)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/backup"
+	"github.com/youyo/bundr/internal/envelope"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// passphraseEnv is the environment variable read for the backup passphrase.
+const passphraseEnv = "BUNDR_BACKUP_PASSPHRASE"
+
+// BackupCmd represents the "backup" subcommand.
+type BackupCmd struct {
+	Prefix         string   `arg:"" predictor:"prefix" help:"Prefix to back up (trailing /, or sm: for all secrets) or a single ref"`
+	Out            string   `name:"out" required:"" placeholder:"FILE" help:"Backup file to write (created with mode 0600)"`
+	Recipient      []string `name:"recipient" placeholder:"AGE_PUBLIC_KEY" help:"Encrypt to this age recipient (age1...; repeatable). Without --passphrase-file or $BUNDR_BACKUP_PASSPHRASE no passphrase is asked"`
+	PassphraseFile string   `name:"passphrase-file" placeholder:"FILE" help:"Read the passphrase from this file (default: $BUNDR_BACKUP_PASSPHRASE, or a prompt on the terminal)"`
+	Concurrency    int      `name:"concurrency" default:"4" help:"Number of entries whose tags are read in parallel"`
+
+	out        io.Writer                          // for testing; nil means os.Stdout
+	passphrase func(confirm bool) (string, error) // for testing; nil means a terminal prompt
+	seal       func(*envelope.SealOptions)        // for testing; lowers the scrypt work factor
+	now        func() time.Time                   // for testing; nil means time.Now
+}
+
+// Run executes the backup command.
+func (c *BackupCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	ref, prefix, err := parseTagTarget(c.Prefix)
+	if err != nil {
+		return fmt.Errorf("backup command failed: %w", err)
+	}
+
+	sealOpts := envelope.SealOptions{}
+	if len(c.Recipient) > 0 {
+		if sealOpts.Recipients, err = envelope.ParseRecipients(c.Recipient...); err != nil {
+			return fmt.Errorf("backup command failed: --recipient: %w", err)
+		}
+	}
+	if len(c.Recipient) == 0 || c.PassphraseFile != "" || os.Getenv(passphraseEnv) != "" {
+		if sealOpts.Passphrase, err = c.readPassphrase(); err != nil {
+			return fmt.Errorf("backup command failed: %w", err)
+		}
+	}
+	if c.seal != nil {
+		c.seal(&sealOpts)
+	}
+
+	b, err := appCtx.BackendFactory(ref.Type)
+	if err != nil {
+		return fmt.Errorf("backup command failed: create backend: %w", err)
+	}
+	entries, err := c.collect(context.Background(), b, ref, prefix)
+	if err != nil {
+		return fmt.Errorf("backup command failed: %w", err)
+	}
+	source := c.Prefix
+	if ref.Type == backend.BackendTypeSM && prefix && ref.Path == "" {
+		source = "sm:"
+	}
+	a := &backup.Archive{
+		Version: backup.FormatVersion,
+		Created: c.now().UTC(),
+		Source:  source,
+		Entries: entries,
+	}
+
+	// 失敗しても既存のバックアップを壊さないよう、一時ファイルに書いてから置き換える
+	var buf bytes.Buffer
+	if err := backup.Seal(&buf, a, sealOpts); err != nil {
+		return fmt.Errorf("backup command failed: %w", err)
+	}
+	if err := writeFileAtomic(c.Out, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("backup command failed: %w", err)
+	}
+	fmt.Fprintf(c.out, "backed up %d entries from %s to %s\n", len(entries), source, c.Out)
+	return nil
+}
+
+// collect reads every entry of the target with its metadata, tags and raw value.
+func (c *BackupCmd) collect(ctx context.Context, b backend.Backend, ref backend.Ref, prefix bool) ([]backup.Entry, error) {
+	// 単一 ref は親（SM は名前の前方一致）を一覧して一致するものだけ使う
+	listPrefix := ref.Path
+	if !prefix && ref.Type == backend.BackendTypePS {
+		listPrefix = strings.TrimSuffix(path.Dir(ref.Path), "/") + "/"
+	}
+
+	var entries []backup.Entry
+	err := backend.List(ctx, b, listPrefix, backend.ListOptions{Recursive: prefix}, func(e backend.ListEntry) error {
+		if !prefix && e.Path != ref.Path {
+			return nil
+		}
+		entry := backup.Entry{
+			Ref:         string(ref.Type) + ":" + e.Path,
+			Type:        e.Type,
+			Tier:        e.Tier,
+			KMSKeyID:    e.KMSKeyID,
+			Description: e.Description,
+			Policies:    e.Policies,
+		}
+		if e.RotationEnabled != nil {
+			entry.RotationEnabled = *e.RotationEnabled
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !prefix && len(entries) == 0 {
+		return nil, fmt.Errorf("%s:%s: %w", ref.Type, ref.Path, backend.ErrNotFound)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Ref < entries[j].Ref })
+
+	refs := make([]string, len(entries))
+	for i, e := range entries {
+		refs[i] = e.Ref
+	}
+	values, missing, err := backend.GetMany(ctx, b, refs, backend.GetOptions{ForceRaw: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s: %w (deleted while backing up?)", strings.Join(missing, ", "), backend.ErrNotFound)
+	}
+
+	tagger, tagErr := backend.AsTagger(b)
+	if tagErr != nil {
+		return nil, tagErr
+	}
+	errs := forEachConcurrent(len(entries), c.Concurrency, func(i int) error {
+		t, err := tagger.Tags(ctx, entries[i].Ref)
+		if err != nil {
+			return err
+		}
+		entries[i].Tags = t
+		entries[i].StoreMode = t[tags.TagStoreMode]
+		entries[i].Value = values[entries[i].Ref]
+		entries[i].SHA256 = backup.Checksum(entries[i].Value)
+		return nil
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entries[i].Ref, err)
+		}
+	}
+	return entries, nil
+}
+
+func (c *BackupCmd) readPassphrase() (string, error) {
+	return readPassphrase(c.PassphraseFile, c.passphrase, true)
+}
+
+// readPassphrase returns the passphrase from file, $BUNDR_BACKUP_PASSPHRASE, or prompt
+// (the terminal when prompt is nil). confirm asks for it twice.
+func readPassphrase(file string, prompt func(confirm bool) (string, error), confirm bool) (string, error) {
+	var p string
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("read passphrase: %w", err)
+		}
+		p = strings.TrimRight(string(data), "\r\n")
+	case os.Getenv(passphraseEnv) != "":
+		p = os.Getenv(passphraseEnv)
+	default:
+		if prompt == nil {
+			prompt = promptPassphrase
+		}
+		var err error
+		if p, err = prompt(confirm); err != nil {
+			return "", err
+		}
+	}
+	if p == "" {
+		return "", fmt.Errorf("the passphrase is empty")
+	}
+	return p, nil
+}
+
+// promptPassphrase reads a passphrase from the terminal without echo.
+func promptPassphrase(confirm bool) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no terminal to read the passphrase; use --passphrase-file or $%s", passphraseEnv)
+	}
+	defer tty.Close()
+
+	read := func(prompt string) (string, error) {
+		fmt.Fprint(tty, prompt)
+		p, err := term.ReadPassword(int(tty.Fd()))
+		fmt.Fprintln(tty)
+		return string(p), err
+	}
+	p, err := read("Passphrase: ")
+	if err != nil || !confirm {
+		return p, err
+	}
+	again, err := read("Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != p {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return p, nil
+}
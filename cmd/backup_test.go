@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/backup"
+	"github.com/youyo/bundr/internal/envelope"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func testPassphrase(p string) func(bool) (string, error) {
+	return func(bool) (string, error) { return p, nil }
+}
+
+// writeTestBackup fills mb with a small tree and backs target up to a file in t.TempDir().
+func writeTestBackup(t *testing.T, mb *backend.MockBackend, appCtx *Context, target string) string {
+	t.Helper()
+	ctx := context.Background()
+	puts := map[string]backend.PutOptions{
+		"ps:/app/db_host":     {Value: "db.internal", StoreMode: tags.StoreModeRaw, Description: "Primary database host"},
+		"ps:/app/password":    {Value: "s3cr3t", StoreMode: tags.StoreModeRaw, ValueType: backend.ValueTypeSecure, KMSKeyID: "alias/app", AdvancedTier: true},
+		"ps:/app/smtp/config": {Value: `{"host":"mail"}`, StoreMode: tags.StoreModeJSON},
+		"ps:/other/key":       {Value: "x", StoreMode: tags.StoreModeRaw},
+	}
+	for ref, opts := range puts {
+		if err := mb.Put(ctx, ref, opts); err != nil {
+			t.Fatalf("Put(%s): %v", ref, err)
+		}
+	}
+	mb.SetTags("ps:/app/db_host", map[string]string{"team": "core"})
+
+	file := filepath.Join(t.TempDir(), "app.bundr")
+	var out bytes.Buffer
+	cmd := &BackupCmd{
+		Prefix:      target,
+		Out:         file,
+		Concurrency: 2,
+		out:         &out,
+		passphrase:  testPassphrase("pw"),
+		seal:        func(o *envelope.SealOptions) { o.ScryptLogN = 10 },
+	}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	return file
+}
+
+func TestBackupCmd(t *testing.T) {
+	t.Setenv(passphraseEnv, "")
+	mb, appCtx := newExecTestContext(t)
+	file := writeTestBackup(t, mb, appCtx, "ps:/app/")
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	a, err := backup.Open(f, envelope.OpenOptions{Passphrase: "pw"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if a.Source != "ps:/app/" || len(a.Entries) != 3 {
+		t.Fatalf("archive = %s with %d entries, want ps:/app/ with 3", a.Source, len(a.Entries))
+	}
+	byRef := map[string]backup.Entry{}
+	for _, e := range a.Entries {
+		byRef[e.Ref] = e
+	}
+	pw := byRef["ps:/app/password"]
+	if pw.Value != "s3cr3t" || pw.Type != "SecureString" || pw.Tier != "Advanced" || pw.KMSKeyID != "alias/app" || pw.StoreMode != tags.StoreModeRaw {
+		t.Errorf("password entry = %+v", pw)
+	}
+	if h := byRef["ps:/app/db_host"]; h.Description != "Primary database host" || h.Tags["team"] != "core" {
+		t.Errorf("db_host entry = %+v", h)
+	}
+	if c := byRef["ps:/app/smtp/config"]; c.Value != `{"host":"mail"}` || c.StoreMode != tags.StoreModeJSON {
+		t.Errorf("smtp entry = %+v", c)
+	}
+}
+
+func TestBackupCmd_SingleRefNotFound(t *testing.T) {
+	t.Setenv(passphraseEnv, "")
+	_, appCtx := newExecTestContext(t)
+	cmd := &BackupCmd{
+		Prefix:     "ps:/app/missing",
+		Out:        filepath.Join(t.TempDir(), "x.bundr"),
+		out:        &bytes.Buffer{},
+		passphrase: testPassphrase("pw"),
+		seal:       func(o *envelope.SealOptions) { o.ScryptLogN = 10 },
+	}
+	if err := cmd.Run(appCtx); err == nil || !strings.Contains(err.Error(), "key not found") {
+		t.Fatalf("err = %v, want key not found", err)
+	}
+}
+
+func TestRestoreCmd(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		to         string
+		existing   map[string]string // refs already present in the destination
+		onConflict string
+		dryRun     bool
+		wantErr    string
+		wantLines  []string
+		wantValues map[string]string
+	}{
+		{
+			name:       "into an empty prefix",
+			to:         "ps:/restored/",
+			wantLines:  []string{"create ps:/restored/password (from ps:/app/password): verified", "restored 3 entries (3 created, 0 overwritten), 0 skipped, 0 failed; 3 verified"},
+			wantValues: map[string]string{"ps:/restored/db_host": "db.internal", "ps:/restored/smtp/config": `{"host":"mail"}`},
+		},
+		{
+			name:       "conflict fails before writing",
+			existing:   map[string]string{"ps:/app/db_host": "changed"},
+			onConflict: ConflictFail,
+			wantErr:    "1 targets already exist",
+			wantValues: map[string]string{"ps:/app/db_host": "changed"},
+		},
+		{
+			name:       "conflict skip",
+			existing:   map[string]string{"ps:/app/db_host": "changed"},
+			onConflict: ConflictSkip,
+			wantLines:  []string{"skip ps:/app/db_host", "restored 2 entries (2 created, 0 overwritten), 1 skipped, 0 failed; 2 verified"},
+			wantValues: map[string]string{"ps:/app/db_host": "changed"},
+		},
+		{
+			name:       "conflict overwrite",
+			existing:   map[string]string{"ps:/app/db_host": "changed"},
+			onConflict: ConflictOverwrite,
+			wantLines:  []string{"overwrite ps:/app/db_host: verified"},
+			wantValues: map[string]string{"ps:/app/db_host": "db.internal"},
+		},
+		{
+			name:       "dry-run",
+			to:         "ps:/restored/",
+			dryRun:     true,
+			wantLines:  []string{"dry-run: create ps:/restored/db_host (from ps:/app/db_host)", "dry-run: 3 to create, 0 to overwrite, 0 to skip"},
+			wantValues: map[string]string{"ps:/restored/db_host": ""},
+		},
+		{
+			name:       "single ref to a new name",
+			target:     "ps:/app/password",
+			to:         "ps:/copy/password2",
+			wantLines:  []string{"create ps:/copy/password2 (from ps:/app/password): verified"},
+			wantValues: map[string]string{"ps:/copy/password2": "s3cr3t"},
+		},
+		{
+			name:    "backend type mismatch",
+			to:      "sm:restored/",
+			wantErr: "restore them to a ps: target",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(passphraseEnv, "")
+			target := tt.target
+			if target == "" {
+				target = "ps:/app/"
+			}
+			src, srcCtx := newExecTestContext(t)
+			file := writeTestBackup(t, src, srcCtx, target)
+
+			// 復元先は空のバックエンドに既存分だけ置いたもの
+			mb, appCtx := newExecTestContext(t)
+			ctx := context.Background()
+			for ref, v := range tt.existing {
+				if err := mb.Put(ctx, ref, backend.PutOptions{Value: v, StoreMode: tags.StoreModeRaw}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out bytes.Buffer
+			onConflict := tt.onConflict
+			if onConflict == "" {
+				onConflict = ConflictFail
+			}
+			cmd := &RestoreCmd{
+				File:        file,
+				To:          tt.to,
+				DryRun:      tt.dryRun,
+				OnConflict:  onConflict,
+				Concurrency: 2,
+				out:         &out,
+				passphrase:  testPassphrase("pw"),
+			}
+			err := cmd.Run(appCtx)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("restore: %v\n%s", err, out.String())
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(out.String(), line+"\n") {
+					t.Errorf("output missing %q:\n%s", line, out.String())
+				}
+			}
+			for ref, want := range tt.wantValues {
+				got, err := mb.Get(ctx, ref, backend.GetOptions{ForceRaw: true})
+				if want == "" {
+					if err == nil {
+						t.Errorf("%s exists after dry-run", ref)
+					}
+					continue
+				}
+				if err != nil || got != want {
+					t.Errorf("%s = %q, %v; want %q", ref, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreCmd_PreservesMetadata(t *testing.T) {
+	t.Setenv(passphraseEnv, "")
+	mb, appCtx := newExecTestContext(t)
+	file := writeTestBackup(t, mb, appCtx, "ps:/app/")
+
+	cmd := &RestoreCmd{File: file, To: "ps:/restored/", OnConflict: ConflictFail, Concurrency: 1, out: &bytes.Buffer{}, passphrase: testPassphrase("pw")}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	calls := map[string]backend.PutOptions{}
+	for _, c := range mb.PutCalls {
+		calls[c.Ref] = c.Opts
+	}
+	pw := calls["ps:/restored/password"]
+	if pw.ValueType != backend.ValueTypeSecure || !pw.AdvancedTier || !pw.TierExplicit || pw.KMSKeyID != "alias/app" || !pw.ExactTags {
+		t.Errorf("password put = %+v", pw)
+	}
+	host := calls["ps:/restored/db_host"]
+	if host.Description != "Primary database host" || host.Tags["team"] != "core" {
+		t.Errorf("db_host put = %+v", host)
+	}
+	if smtp := calls["ps:/restored/smtp/config"]; smtp.StoreMode != tags.StoreModeJSON || smtp.Value != `{"host":"mail"}` {
+		t.Errorf("smtp put = %+v", smtp)
+	}
+}
+
+func TestRestoreCmd_WrongPassphrase(t *testing.T) {
+	t.Setenv(passphraseEnv, "")
+	mb, appCtx := newExecTestContext(t)
+	file := writeTestBackup(t, mb, appCtx, "ps:/app/")
+
+	cmd := &RestoreCmd{File: file, OnConflict: ConflictOverwrite, out: &bytes.Buffer{}, passphrase: testPassphrase("nope")}
+	err := cmd.Run(appCtx)
+	if err == nil || !strings.Contains(err.Error(), "no matching passphrase or identity") {
+		t.Fatalf("err = %v", err)
+	}
+}
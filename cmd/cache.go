@@ -72,7 +72,7 @@ func (c *CacheRefreshCmd) Run(appCtx *Context) error {
 	}
 
 	backendType := string(ref.Type)
-	if err := appCtx.CacheStore.Write(backendType, toCacheEntries(entries)); err != nil {
+	if err := appCtx.CacheStore.Write(backendType, ref.Path, toCacheEntries(entries)); err != nil {
 		return fmt.Errorf("cache refresh: write cache: %w", err)
 	}
 
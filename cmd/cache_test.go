@@ -16,6 +16,7 @@ type MockStore struct {
 	ReadFunc            func(backendType string) ([]cache.CacheEntry, error)
 	WriteFunc           func(backendType string, entries []cache.CacheEntry) error
 	LastRefreshedAtFunc func(backendType string) time.Time
+	ScopeFunc           func(backendType string) (string, bool)
 	ClearFunc           func() error
 	ReadCalls           []string
 	WriteCalls          []WriteCall
@@ -25,6 +26,7 @@ type MockStore struct {
 // WriteCall は Write 呼び出しの記録。
 type WriteCall struct {
 	BackendType string
+	Scope       string
 	Entries     []cache.CacheEntry
 }
 
@@ -36,8 +38,8 @@ func (m *MockStore) Read(backendType string) ([]cache.CacheEntry, error) {
 	return nil, cache.ErrCacheNotFound
 }
 
-func (m *MockStore) Write(backendType string, entries []cache.CacheEntry) error {
-	m.WriteCalls = append(m.WriteCalls, WriteCall{BackendType: backendType, Entries: entries})
+func (m *MockStore) Write(backendType, scope string, entries []cache.CacheEntry) error {
+	m.WriteCalls = append(m.WriteCalls, WriteCall{BackendType: backendType, Scope: scope, Entries: entries})
 	if m.WriteFunc != nil {
 		return m.WriteFunc(backendType, entries)
 	}
@@ -51,6 +53,13 @@ func (m *MockStore) LastRefreshedAt(backendType string) time.Time {
 	return time.Time{}
 }
 
+func (m *MockStore) Scope(backendType string) (string, bool) {
+	if m.ScopeFunc != nil {
+		return m.ScopeFunc(backendType)
+	}
+	return "", false
+}
+
 func (m *MockStore) Clear() error {
 	m.ClearCalls++
 	if m.ClearFunc != nil {
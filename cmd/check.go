@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/youyo/bundr/internal/manifest"
+)
+
+// checkFailExitCode is the exit status of check when the manifest is not satisfied (errors exit with 1).
+const checkFailExitCode = 2
+
+// CheckCmd represents the "check" subcommand.
+type CheckCmd struct {
+	From           []string `arg:"" predictor:"prefix" help:"Source prefixes, as for exec --from (e.g. ps:/app/prod/ or APP_=ps:/app/prod/)"`
+	Manifest       string   `required:"" placeholder:"FILE" help:"Manifest of expected keys: a dotenv file (e.g. .env.example) or TOML with a [required] keys list"`
+	Strict         bool     `name:"strict" help:"Also fail when the sources have keys the manifest does not list"`
+	NoFlatten      bool     `name:"no-flatten" help:"Disable JSON flattening"`
+	ArrayMode      string   `default:"join" enum:"join,index,json" help:"Array handling mode"`
+	ArrayJoinDelim string   `default:"," help:"Delimiter for array join mode"`
+	FlattenDelim   string   `default:"_" help:"Delimiter for flattened keys"`
+	Upper          bool     `default:"true" negatable:"" help:"Uppercase variable names"`
+	Sanitize       string   `name:"sanitize" default:"keep" enum:"replace,drop,error,keep" help:"Handling of invalid environment variable names (replace, drop, error, keep)"`
+
+	out    io.Writer // for testing; nil means os.Stdout
+	errOut io.Writer // for testing; nil means os.Stderr
+}
+
+// Run executes the check command.
+func (c *CheckCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	if c.errOut == nil {
+		c.errOut = os.Stderr
+	}
+	m, err := manifest.Load(c.Manifest)
+	if err != nil {
+		return fmt.Errorf("check command failed: %w", err)
+	}
+	opts := VarsBuildOptions{
+		FlattenDelim:   c.FlattenDelim,
+		ArrayMode:      c.ArrayMode,
+		ArrayJoinDelim: c.ArrayJoinDelim,
+		Upper:          c.Upper,
+		NoFlatten:      c.NoFlatten,
+	}
+	set, err := collectVars(context.Background(), appCtx, c.From, opts, c.Sanitize, c.errOut)
+	if err != nil {
+		return fmt.Errorf("check command failed: %w", err)
+	}
+
+	r := m.Check(set.values, manifestKeyNormalizer(opts, c.Sanitize))
+	if err := c.report(appCtx.Output, r, set.sources); err != nil {
+		return fmt.Errorf("check command failed: %w", err)
+	}
+	if !r.OK() || (c.Strict && len(r.Extra) > 0) {
+		return &ExitCodeError{Code: checkFailExitCode}
+	}
+	return nil
+}
+
+// report prints one line per problem and a summary, or one record per problem with --output.
+func (c *CheckCmd) report(format string, r manifest.Result, sources map[string]string) error {
+	type problem struct{ key, kind string }
+	var problems []problem
+	for _, k := range r.Missing {
+		problems = append(problems, problem{k, "missing"})
+	}
+	for _, k := range r.Empty {
+		problems = append(problems, problem{k, "empty"})
+	}
+	for _, k := range r.Extra {
+		problems = append(problems, problem{k, "extra"})
+	}
+
+	if format != "" {
+		rw := newRecordWriter(c.out, format, "key", "problem", "source")
+		for _, p := range problems {
+			if err := rw.Write(record{"key": p.key, "problem": p.kind, "source": sources[p.key]}); err != nil {
+				return err
+			}
+		}
+		return rw.Close()
+	}
+	for _, p := range problems {
+		if src := sources[p.key]; src != "" {
+			fmt.Fprintf(c.out, "%-8s %s (%s)\n", p.kind+":", p.key, src)
+		} else {
+			fmt.Fprintf(c.out, "%-8s %s\n", p.kind+":", p.key)
+		}
+	}
+	_, err := fmt.Fprintf(c.out, "checked %s: %d missing, %d empty, %d extra\n", c.Manifest, len(r.Missing), len(r.Empty), len(r.Extra))
+	return err
+}
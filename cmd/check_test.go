@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// newCheckTestContext stores app parameters (one empty, one JSON value flattened into
+// two keys) and returns the context with a manifest path.
+func newCheckTestContext(t *testing.T, manifest string) (*Context, string) {
+	t.Helper()
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	puts := map[string]backend.PutOptions{
+		"ps:/app/prod/db_host":  {Value: "db.internal", StoreMode: tags.StoreModeRaw},
+		"ps:/app/prod/api-key":  {Value: "", StoreMode: tags.StoreModeRaw},
+		"ps:/app/prod/old_flag": {Value: "1", StoreMode: tags.StoreModeRaw},
+		"ps:/app/prod/smtp":     {Value: `{"host":"mail","port":25}`, StoreMode: tags.StoreModeJSON},
+	}
+	for ref, opts := range puts {
+		if err := mb.Put(ctx, ref, opts); err != nil {
+			t.Fatalf("Put(%s): %v", ref, err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), ".env.example")
+	if strings.HasPrefix(manifest, "[") {
+		path = filepath.Join(t.TempDir(), "manifest.toml")
+	}
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return appCtx, path
+}
+
+func newCheckCmd(from []string, manifest string, out *bytes.Buffer) *CheckCmd {
+	return &CheckCmd{
+		From:           from,
+		Manifest:       manifest,
+		ArrayMode:      "join",
+		ArrayJoinDelim: ",",
+		FlattenDelim:   "_",
+		Upper:          true,
+		Sanitize:       SanitizeReplace,
+		out:            out,
+		errOut:         &bytes.Buffer{},
+	}
+}
+
+func TestCheckCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		from     []string
+		strict   bool
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "dotenv manifest",
+			manifest: "DB_HOST=\nDB_PORT=\napi-key=\nSMTP_HOST=\nSMTP_PORT=\n",
+			from:     []string{"ps:/app/prod/"},
+			want: "missing: DB_PORT\n" +
+				"empty:   API_KEY (ps:/app/prod/api-key)\n" +
+				"extra:   OLD_FLAG (ps:/app/prod/old_flag)\n" +
+				"checked MANIFEST: 1 missing, 1 empty, 1 extra\n",
+			wantCode: checkFailExitCode,
+		},
+		{
+			name:     "toml manifest with optional keys",
+			manifest: "[required]\nkeys = [\"DB_HOST\", \"SMTP_HOST\"]\n[optional]\nkeys = [\"API_KEY\", \"OLD_FLAG\", \"SMTP_PORT\"]\n",
+			from:     []string{"ps:/app/prod/"},
+			want:     "checked MANIFEST: 0 missing, 0 empty, 0 extra\n",
+		},
+		{
+			name:     "extra keys only fail with --strict",
+			manifest: "DB_HOST=\n",
+			from:     []string{"ps:/app/prod/db_host", "ps:/app/prod/old_flag"},
+			strict:   true,
+			want:     "extra:   OLD_FLAG (ps:/app/prod/old_flag)\nchecked MANIFEST: 0 missing, 0 empty, 1 extra\n",
+			wantCode: checkFailExitCode,
+		},
+		{
+			name:     "prefixed source",
+			manifest: "APP_DB_HOST=\n",
+			from:     []string{"APP_=ps:/app/prod/db_host"},
+			want:     "checked MANIFEST: 0 missing, 0 empty, 0 extra\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			appCtx, path := newCheckTestContext(t, tc.manifest)
+			var out bytes.Buffer
+			cmd := newCheckCmd(tc.from, path, &out)
+			cmd.Strict = tc.strict
+			err := cmd.Run(appCtx)
+			if tc.wantCode != 0 {
+				var exitErr *ExitCodeError
+				if !isExitCodeError(err, &exitErr) || exitErr.Code != tc.wantCode {
+					t.Fatalf("err = %v, want ExitCodeError{%d}", err, tc.wantCode)
+				}
+			} else if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if want := strings.ReplaceAll(tc.want, "MANIFEST", path); out.String() != want {
+				t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestCheckCmd_Output(t *testing.T) {
+	appCtx, path := newCheckTestContext(t, "DB_HOST=\nDB_PORT=\n")
+	appCtx.Output = OutputJSON
+	var out bytes.Buffer
+	if err := newCheckCmd([]string{"ps:/app/prod/db_host"}, path, &out).Run(appCtx); err == nil {
+		t.Fatal("expected ExitCodeError")
+	}
+	var got []map[string]string
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(got) != 1 || got[0]["key"] != "DB_PORT" || got[0]["problem"] != "missing" {
+		t.Errorf("records = %v", got)
+	}
+}
+
+func TestCheckCmd_Errors(t *testing.T) {
+	appCtx, _ := newCheckTestContext(t, "")
+	cmd := newCheckCmd([]string{"ps:/app/prod/"}, filepath.Join(t.TempDir(), "missing.env"), &bytes.Buffer{})
+	if err := cmd.Run(appCtx); err == nil || !strings.Contains(err.Error(), "check command failed: open manifest") {
+		t.Errorf("Run() error = %v", err)
+	}
+}
+
+func TestExecCmd_RequireManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		envFile  string
+		wantErr  string
+	}{
+		{name: "satisfied", manifest: "DB_HOST=\nSMTP_PORT=\n"},
+		{name: "missing and empty", manifest: "DB_HOST=\nDB_PORT=\nAPI_KEY=\n", wantErr: "environment does not satisfy manifest MANIFEST: missing DB_PORT; empty API_KEY"},
+		{name: "key from --env-file", manifest: "DB_HOST=\nDB_PORT=\n", envFile: "DB_PORT=5432\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			appCtx, path := newCheckTestContext(t, tc.manifest)
+			runner := &MockRunner{}
+			cmd := setupExecCmd([]string{"ps:/app/prod/"}, []string{"env"}, func(c *ExecCmd) {
+				c.runner = runner
+				c.RequireManifest = path
+				c.CleanEnv = true
+				c.errOut = &bytes.Buffer{}
+			})
+			if tc.envFile != "" {
+				envFile := filepath.Join(t.TempDir(), ".env")
+				if err := os.WriteFile(envFile, []byte(tc.envFile), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				cmd.EnvFile = []string{envFile}
+			}
+			err := cmd.Run(appCtx)
+			if tc.wantErr != "" {
+				if want := strings.ReplaceAll(tc.wantErr, "MANIFEST", path); err == nil || !strings.Contains(err.Error(), want) {
+					t.Fatalf("Run() error = %v, want %q", err, want)
+				}
+				if runner.Called() {
+					t.Error("command started with an incomplete environment")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if !runner.Called() {
+				t.Error("command was not started")
+			}
+		})
+	}
+}
@@ -2,10 +2,18 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/youyo/bundr/internal/childproc"
+	"github.com/youyo/bundr/internal/manifest"
+	"github.com/youyo/bundr/internal/redact"
 )
 
 // SubprocessRunner abstracts subprocess execution for testability.
@@ -13,23 +21,157 @@ type SubprocessRunner interface {
 	Run(name string, args []string, env []string) (int, error)
 }
 
-// OsExecRunner is the production implementation of SubprocessRunner.
+// ProcessStarter starts a child process that stays under bundr's control.
+// Used by "exec --watch" to restart or signal the child when parameters change.
+type ProcessStarter interface {
+	Start(name string, args []string, env []string) (ChildProcess, error)
+}
+
+// ChildProcess is a running child started by a ProcessStarter.
+type ChildProcess interface {
+	// Signal sends sig to the child's process group.
+	Signal(sig syscall.Signal) error
+	// Wait blocks until the child exits and returns its exit code.
+	Wait() (int, error)
+}
+
+// OsExecRunner is the production implementation of SubprocessRunner and ProcessStarter.
 // I/O is connected directly to os.Stdin/Stdout/Stderr.
-type OsExecRunner struct{}
+//
+// The child runs in its own process group and every signal bundr receives is
+// forwarded to that group. When bundr runs as PID 1 (container ENTRYPOINT), it also
+// reaps orphaned zombies while waiting for the child (see childproc).
+type OsExecRunner struct {
+	// Stdout and Stderr, when set, receive the child's output through a pipe
+	// (e.g. a redacting writer for --mask-output). nil connects os.Stdout/os.Stderr directly.
+	Stdout io.Writer
+	Stderr io.Writer
+}
 
-// Run executes the named program with args and env using os/exec.
+// Run executes the named program with args and env using os/exec and supervises it.
 func (r *OsExecRunner) Run(name string, args []string, env []string) (int, error) {
+	p, err := r.Start(name, args, env)
+	if err != nil {
+		return 0, err
+	}
+	return p.Wait()
+}
+
+// Start launches the named program in its own process group and starts forwarding signals to it.
+func (r *OsExecRunner) Start(name string, args []string, env []string) (ChildProcess, error) {
 	c := exec.Command(name, args...)
 	c.Env = env
 	c.Stdin = os.Stdin
 	c.Stdout = os.Stdout
 	c.Stderr = os.Stderr
-	if err := c.Run(); err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return exitErr.ExitCode(), err
+	var pipes []*outputPipe
+	for _, o := range []struct {
+		dst    io.Writer
+		target *io.Writer
+	}{{r.Stdout, &c.Stdout}, {r.Stderr, &c.Stderr}} {
+		if o.dst == nil {
+			continue
+		}
+		op, err := newOutputPipe(o.dst)
+		if err != nil {
+			closeOutputPipes(pipes)
+			return nil, err
 		}
-		return 1, err
+		pipes = append(pipes, op)
+		*o.target = op.w
+	}
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	ttyFd, foreground := foregroundTTY()
+	if foreground {
+		c.SysProcAttr.Foreground = true
+		c.SysProcAttr.Ctty = ttyFd
+	}
+
+	// Register before Start so that no signal arriving in between is lost.
+	sigCh := make(chan os.Signal, 32)
+	signal.Notify(sigCh)
+
+	if err := childproc.Start(c); err != nil {
+		signal.Stop(sigCh)
+		closeOutputPipes(pipes)
+		return nil, err
+	}
+	// The child holds its own copies of the write ends.
+	for _, op := range pipes {
+		_ = op.w.Close()
+	}
+
+	p := &osChildProcess{
+		cmd:        c,
+		sigCh:      sigCh,
+		done:       make(chan struct{}),
+		ttyFd:      ttyFd,
+		foreground: foreground,
+		pipes:      pipes,
+	}
+	go forwardSignals(sigCh, c.Process.Pid, p.done)
+	return p, nil
+}
+
+// osChildProcess is the ChildProcess returned by OsExecRunner.Start.
+type osChildProcess struct {
+	cmd        *exec.Cmd
+	sigCh      chan os.Signal
+	done       chan struct{}
+	ttyFd      int
+	foreground bool
+	pipes      []*outputPipe
+}
+
+// Signal sends sig to the child's process group.
+func (p *osChildProcess) Signal(sig syscall.Signal) error {
+	return syscall.Kill(-p.cmd.Process.Pid, sig)
+}
+
+// Wait waits for the child, stops signal forwarding and maps the wait status to an exit code.
+func (p *osChildProcess) Wait() (int, error) {
+	var ws syscall.WaitStatus
+	var waitErr error
+	if os.Getpid() == 1 {
+		ws, waitErr = waitPID(p.cmd.Process.Pid)
+	} else {
+		waitErr = p.cmd.Wait()
+		if p.cmd.ProcessState != nil {
+			ws, _ = p.cmd.ProcessState.Sys().(syscall.WaitStatus)
+			waitErr = nil
+		}
+	}
+	childproc.Untrack(p.cmd.Process.Pid)
+	close(p.done)
+	for _, op := range p.pipes {
+		<-op.done
+	}
+	if p.foreground {
+		restoreForeground(p.ttyFd)
+	}
+	signal.Stop(p.sigCh)
+	if waitErr != nil {
+		return 0, waitErr
+	}
+
+	if code := exitCodeFromStatus(ws); code != 0 {
+		return code, fmt.Errorf("exit status %d", code)
+	}
+	return 0, nil
+}
+
+// SyscallExecRunner replaces the bundr process with the child via execve(2).
+// Used by "exec --replace": signals, TTY and exit code then belong to the child directly.
+type SyscallExecRunner struct{}
+
+// Run resolves name on PATH and execs it. It only returns on failure.
+func (r *SyscallExecRunner) Run(name string, args []string, env []string) (int, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return 0, err
+	}
+	if err := syscall.Exec(path, append([]string{name}, args...), env); err != nil {
+		return 0, fmt.Errorf("exec %s: %w", name, err)
 	}
 	return 0, nil
 }
@@ -46,15 +188,44 @@ func (e *ExitCodeError) Error() string {
 
 // ExecCmd represents the "exec" subcommand.
 type ExecCmd struct {
-	From           []string `short:"f" name:"from" optional:"" predictor:"prefix" help:"Source prefixes (e.g. ps:/app/prod/); later entries take precedence"`
-	NoFlatten      bool     `name:"no-flatten" help:"Disable JSON flattening"`
-	ArrayMode      string   `default:"join" enum:"join,index,json" help:"Array handling mode"`
-	ArrayJoinDelim string   `default:"," help:"Delimiter for array join mode"`
-	FlattenDelim   string   `default:"_" help:"Delimiter for flattened keys"`
-	Upper          bool     `default:"true" negatable:"" help:"Uppercase variable names"`
-	Args           []string `arg:"" optional:"" passthrough:"" help:"Command and arguments to run"`
+	From             []string      `short:"f" name:"from" optional:"" predictor:"prefix" help:"Source prefixes (e.g. ps:/app/prod/ or APP_=ps:/app/prod/); later entries take precedence"`
+	NoFlatten        bool          `name:"no-flatten" help:"Disable JSON flattening"`
+	ArrayMode        string        `default:"join" enum:"join,index,json" help:"Array handling mode"`
+	ArrayJoinDelim   string        `default:"," help:"Delimiter for array join mode"`
+	FlattenDelim     string        `default:"_" help:"Delimiter for flattened keys"`
+	Upper            bool          `default:"true" negatable:"" help:"Uppercase variable names"`
+	CleanEnv         bool          `name:"clean-env" help:"Do not inherit the current environment (see --keep)"`
+	Keep             []string      `name:"keep" help:"Environment variables to keep with --clean-env (e.g. PATH,HOME)"`
+	Only             []string      `name:"only" help:"Only inject generated variables matching these globs"`
+	Exclude          []string      `name:"exclude" help:"Do not inject generated variables matching these globs"`
+	EnvFile          []string      `name:"env-file" help:"Load .env files as base layers; later files take precedence"`
+	NoOverride       bool          `name:"no-override" help:"Keep existing environment variables instead of overriding them with fetched values"`
+	Replace          bool          `name:"replace" help:"Replace the bundr process with the command (execve) instead of supervising it"`
+	Watch            bool          `name:"watch" help:"Keep polling --from sources and restart or signal the command when values change"`
+	WatchInterval    time.Duration `name:"watch-interval" default:"30s" help:"Polling interval for --watch"`
+	WatchJitter      time.Duration `name:"watch-jitter" default:"5s" help:"Maximum random delay added to each --watch poll"`
+	OnChange         string        `name:"on-change" default:"restart" enum:"restart,signal" help:"Action when values change in --watch mode (restart or signal)"`
+	WatchSignal      string        `name:"watch-signal" default:"SIGHUP" help:"Signal sent to the command with --on-change=signal"`
+	GracePeriod      time.Duration `name:"grace-period" default:"10s" help:"Time to wait after SIGTERM before SIGKILL when restarting"`
+	RestartBackoff   time.Duration `name:"restart-backoff" default:"1s" help:"Delay before restarting; doubles on rapid consecutive restarts"`
+	Files            bool          `name:"files" help:"Deliver values as 0400 files in a private directory and inject NAME_FILE=/path instead"`
+	FilesDir         string        `name:"files-dir" help:"Directory for --files (default: a private directory under $XDG_RUNTIME_DIR)"`
+	MaskOutput       bool          `name:"mask-output" help:"Replace injected values (and their base64/URL-encoded forms) in the command's output with ***"`
+	MaskMinLength    int           `name:"mask-min-length" default:"6" help:"Values shorter than this are not masked by --mask-output"`
+	Strict           bool          `name:"strict" help:"Fail when two sources produce the same variable name"`
+	ReportCollisions bool          `name:"report-collisions" help:"Also report overrides between --from entries (collisions within one entry are always reported)"`
+	Sanitize         string        `name:"sanitize" default:"keep" enum:"replace,drop,error,keep" help:"Handling of invalid environment variable names (replace, drop, error, keep)"`
+	RequireManifest  string        `name:"require-manifest" placeholder:"FILE" help:"Do not start the command unless every key in this manifest (.env.example or TOML [required] list) is set and non-empty"`
+	Args             []string      `arg:"" optional:"" passthrough:"" help:"Command and arguments to run"`
+
+	runner  SubprocessRunner // nil means OsExecRunner (injected for testing)
+	starter ProcessStarter   // nil means OsExecRunner; used by --watch (injected for testing)
+	out     io.Writer        // for testing; nil means os.Stdout
+	errOut  io.Writer        // for testing; nil means os.Stderr
+	signals chan os.Signal   // for testing; nil means SIGINT/SIGTERM delivered to bundr (--watch)
 
-	runner SubprocessRunner // nil means OsExecRunner (injected for testing)
+	secretFiles *secretFiles     // set by Run when --files / --files-dir is used
+	masks       []*redact.Writer // set by Run when --mask-output is used
 }
 
 // Run executes the exec command.
@@ -68,37 +239,84 @@ func (c *ExecCmd) Run(appCtx *Context) error {
 		return fmt.Errorf("exec command failed: no command specified")
 	}
 
-	runner := c.runner
-	if runner == nil {
-		runner = &OsExecRunner{}
-	}
-
-	// Process multiple From prefixes in order; later entries take precedence.
-	vars := make(map[string]string)
-	for _, from := range c.From {
-		result, err := buildVars(context.Background(), appCtx, VarsBuildOptions{
-			From:           from,
-			FlattenDelim:   c.FlattenDelim,
-			ArrayMode:      c.ArrayMode,
-			ArrayJoinDelim: c.ArrayJoinDelim,
-			Upper:          c.Upper,
-			NoFlatten:      c.NoFlatten,
-		})
+	if c.Watch && c.Replace {
+		return fmt.Errorf("exec command failed: --watch cannot be combined with --replace")
+	}
+
+	var held chan os.Signal
+	if c.Files || c.FilesDir != "" {
+		if c.Replace {
+			return fmt.Errorf("exec command failed: --files cannot be combined with --replace")
+		}
+		sf, err := newSecretFiles(c.FilesDir)
 		if err != nil {
 			return fmt.Errorf("exec command failed: %w", err)
 		}
-		for k, v := range result {
-			vars[k] = v
+		defer sf.Cleanup()
+		c.secretFiles = sf
+
+		// Termination signals must not kill bundr before the deferred cleanup runs.
+		// While the child runs they are forwarded to it; before that, they abort the command.
+		held = make(chan os.Signal, 1)
+		signal.Notify(held, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		defer signal.Stop(held)
+	}
+
+	vars, env, err := c.resolveEnv(context.Background(), appCtx)
+	if err != nil {
+		return fmt.Errorf("exec command failed: %w", err)
+	}
+
+	select {
+	case sig := <-held:
+		if s, ok := sig.(syscall.Signal); ok {
+			return &ExitCodeError{Code: 128 + int(s)}
+		}
+	default:
+	}
+
+	osRunner := &OsExecRunner{}
+	if c.MaskOutput {
+		if c.Replace {
+			return fmt.Errorf("exec command failed: --mask-output cannot be combined with --replace")
 		}
+		stdout, stderr := c.outputs()
+		stdoutMask := redact.NewWriter(stdout, c.MaskMinLength)
+		stderrMask := redact.NewWriter(stderr, c.MaskMinLength)
+		c.masks = []*redact.Writer{stdoutMask, stderrMask}
+		defer func() {
+			for _, m := range c.masks {
+				_ = m.Flush()
+			}
+		}()
+		c.addMaskValues(vars)
+		osRunner.Stdout = stdoutMask
+		osRunner.Stderr = stderrMask
 	}
 
-	// Start from current environment and append fetched vars.
-	env := os.Environ()
-	for k, v := range vars {
-		env = append(env, k+"="+v)
+	if c.Watch {
+		starter := c.starter
+		if starter == nil {
+			starter = osRunner
+		}
+		return c.runWatch(appCtx, starter, args, vars, env)
 	}
 
-	exitCode, err := runner.Run(args[0], args[1:], env)
+	runner := c.runner
+	if runner == nil {
+		if c.Replace {
+			runner = &SyscallExecRunner{}
+		} else {
+			runner = osRunner
+		}
+	}
+
+	return execResult(runner.Run(args[0], args[1:], env))
+}
+
+// execResult converts a child's exit code and error into the exec command's error.
+// A non-zero exit code becomes *ExitCodeError so that main.go exits with the same code.
+func execResult(exitCode int, err error) error {
 	if err != nil {
 		if exitCode != 0 {
 			return &ExitCodeError{Code: exitCode}
@@ -107,3 +325,143 @@ func (c *ExecCmd) Run(appCtx *Context) error {
 	}
 	return nil
 }
+
+// outputs returns the writers the child's output is finally written to.
+func (c *ExecCmd) outputs() (io.Writer, io.Writer) {
+	stdout, stderr := c.out, c.errOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	return stdout, stderr
+}
+
+// addMaskValues registers every variable value with the --mask-output redactors.
+func (c *ExecCmd) addMaskValues(vars map[string]string) {
+	values := make([]string, 0, len(vars))
+	for _, v := range vars {
+		values = append(values, v)
+	}
+	for _, m := range c.masks {
+		m.AddValues(values...)
+	}
+}
+
+// warnOut returns the writer for bundr's own warnings.
+func (c *ExecCmd) warnOut() io.Writer {
+	if c.errOut != nil {
+		return c.errOut
+	}
+	return os.Stderr
+}
+
+// reportCollisions prints variable name collisions to stderr, or fails with --strict.
+// Collisions inside a single --from entry are always reported because one of the values
+// is silently lost; overrides between --from entries are intentional layering and are
+// only reported with --report-collisions.
+func (c *ExecCmd) reportCollisions(collisions []Collision) error {
+	if len(collisions) == 0 {
+		return nil
+	}
+	if c.Strict {
+		msgs := make([]string, 0, len(collisions))
+		for _, col := range collisions {
+			msgs = append(msgs, col.String())
+		}
+		return fmt.Errorf("variable name collisions (--strict): %s", strings.Join(msgs, "; "))
+	}
+	for _, col := range collisions {
+		if col.CrossSource && !c.ReportCollisions {
+			continue
+		}
+		fmt.Fprintf(c.warnOut(), "bundr: warning: variable collision %s\n", col)
+	}
+	return nil
+}
+
+// resolveEnv fetches every --from source and builds the child environment.
+// It returns the generated variables (after --only/--exclude) and the full env slice.
+// With --files the env slice carries NAME_FILE paths while the returned vars keep the values.
+func (c *ExecCmd) resolveEnv(ctx context.Context, appCtx *Context) (map[string]string, []string, error) {
+	set, err := collectVars(ctx, appCtx, c.From, c.varsOptions(), c.Sanitize, c.warnOut())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.reportCollisions(set.collisions); err != nil {
+		return nil, nil, err
+	}
+
+	vars, err := filterVars(set.values, c.Only, c.Exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	injected := vars
+	if c.secretFiles != nil {
+		injected, err = c.secretFiles.Write(vars)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	env, err := buildEnv(os.Environ(), injected, EnvBuildOptions{
+		CleanEnv:   c.CleanEnv,
+		Keep:       c.Keep,
+		EnvFiles:   c.EnvFile,
+		NoOverride: c.NoOverride,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.RequireManifest != "" {
+		if err := c.checkManifest(vars, env); err != nil {
+			return nil, nil, err
+		}
+	}
+	return vars, env, nil
+}
+
+// varsOptions returns the buildVars options selected by the flags (From is set per entry).
+func (c *ExecCmd) varsOptions() VarsBuildOptions {
+	return VarsBuildOptions{
+		FlattenDelim:   c.FlattenDelim,
+		ArrayMode:      c.ArrayMode,
+		ArrayJoinDelim: c.ArrayJoinDelim,
+		Upper:          c.Upper,
+		NoFlatten:      c.NoFlatten,
+	}
+}
+
+// checkManifest fails when a key required by --require-manifest is missing from the
+// child's environment or empty. Keys may come from any layer (inherited environment,
+// --env-file or --from), so extra keys are not reported.
+func (c *ExecCmd) checkManifest(vars map[string]string, env []string) error {
+	m, err := manifest.Load(c.RequireManifest)
+	if err != nil {
+		return err
+	}
+	final := make(map[string]string, len(env))
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		final[k] = v
+	}
+	// --files は NAME_FILE を注入するため、値そのものは vars で確認する
+	for k, v := range vars {
+		final[k] = v
+	}
+	r := m.Check(final, manifestKeyNormalizer(c.varsOptions(), c.Sanitize))
+	if r.OK() {
+		return nil
+	}
+	var problems []string
+	if len(r.Missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(r.Missing, ", "))
+	}
+	if len(r.Empty) > 0 {
+		problems = append(problems, "empty "+strings.Join(r.Empty, ", "))
+	}
+	return fmt.Errorf("environment does not satisfy manifest %s: %s", c.RequireManifest, strings.Join(problems, "; "))
+}
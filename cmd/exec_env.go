@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/youyo/bundr/internal/dotenv"
+)
+
+// EnvBuildOptions holds options for buildEnv.
+type EnvBuildOptions struct {
+	CleanEnv   bool     // start from an empty environment instead of the inherited one
+	Keep       []string // variables kept from the inherited environment when CleanEnv is set
+	EnvFiles   []string // .env files layered on top of the base environment, in order
+	NoOverride bool     // existing variables (inherited or from EnvFiles) win over fetched ones
+}
+
+// parseFromSpec splits a --from value into an optional variable prefix and the ref.
+// "APP_=ps:/app/prod/" → ("APP_", "ps:/app/prod/"), "ps:/app/prod/" → ("", "ps:/app/prod/").
+// A "=" is only treated as a prefix separator when it appears before the backend ":".
+func parseFromSpec(s string) (string, string) {
+	idx := strings.IndexByte(s, '=')
+	if idx <= 0 || strings.Contains(s[:idx], ":") {
+		return "", s
+	}
+	return s[:idx], s[idx+1:]
+}
+
+// filterVars applies --only / --exclude glob patterns to generated variable names.
+// An empty only list keeps every name; exclude is applied after only.
+func filterVars(vars map[string]string, only, exclude []string) (map[string]string, error) {
+	if len(only) == 0 && len(exclude) == 0 {
+		return vars, nil
+	}
+	result := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if len(only) > 0 {
+			ok, err := matchAny(only, k)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --only pattern: %w", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		excluded, err := matchAny(exclude, k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --exclude pattern: %w", err)
+		}
+		if excluded {
+			continue
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
+// matchAny reports whether name matches any of the glob patterns.
+func matchAny(patterns []string, name string) (bool, error) {
+	for _, p := range patterns {
+		ok, err := path.Match(p, name)
+		if err != nil {
+			return false, fmt.Errorf("%q: %w", p, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// buildEnv merges the base environment, .env file layers and fetched vars into
+// a "KEY=VALUE" slice for the child process.
+//
+// Precedence (lowest → highest): base (or the --keep subset of it) → EnvFiles → vars.
+// With NoOverride, vars only fill in names that are not already set by the lower layers.
+func buildEnv(base []string, vars map[string]string, opts EnvBuildOptions) ([]string, error) {
+	var keys []string
+	values := make(map[string]string)
+	set := func(k, v string) {
+		if _, ok := values[k]; !ok {
+			keys = append(keys, k)
+		}
+		values[k] = v
+	}
+
+	keep := make(map[string]bool, len(opts.Keep))
+	for _, k := range opts.Keep {
+		keep[k] = true
+	}
+	for _, e := range base {
+		k, v, _ := strings.Cut(e, "=")
+		if opts.CleanEnv && !keep[k] {
+			continue
+		}
+		set(k, v)
+	}
+
+	for _, file := range opts.EnvFiles {
+		entries, err := readEnvFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			set(e.Key, e.Value)
+		}
+	}
+
+	// Sort fetched names so the child environment is deterministic.
+	names := make([]string, 0, len(vars))
+	for k := range vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if _, exists := values[k]; exists && opts.NoOverride {
+			continue
+		}
+		set(k, vars[k])
+	}
+
+	env := make([]string, 0, len(keys))
+	for _, k := range keys {
+		env = append(env, k+"="+values[k])
+	}
+	return env, nil
+}
+
+// readEnvFile parses a .env file with internal/dotenv.
+func readEnvFile(name string) ([]dotenv.Entry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open env file: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := dotenv.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse env file %s: %w", name, err)
+	}
+	return entries, nil
+}
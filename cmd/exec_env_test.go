@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func TestParseFromSpec(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantPrefix string
+		wantRef    string
+	}{
+		{in: "ps:/app/prod/", wantPrefix: "", wantRef: "ps:/app/prod/"},
+		{in: "APP_=ps:/app/prod/", wantPrefix: "APP_", wantRef: "ps:/app/prod/"},
+		{in: "ps:/app/a=b/", wantPrefix: "", wantRef: "ps:/app/a=b/"},
+		{in: "=ps:/app/", wantPrefix: "", wantRef: "=ps:/app/"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			prefix, ref := parseFromSpec(tc.in)
+			if prefix != tc.wantPrefix || ref != tc.wantRef {
+				t.Errorf("parseFromSpec(%q) = (%q, %q), want (%q, %q)", tc.in, prefix, ref, tc.wantPrefix, tc.wantRef)
+			}
+		})
+	}
+}
+
+func TestFilterVars(t *testing.T) {
+	vars := map[string]string{"DB_HOST": "h", "DB_PASSWORD": "p", "API_KEY": "k"}
+
+	tests := []struct {
+		id      string
+		only    []string
+		exclude []string
+		want    []string
+		wantErr bool
+	}{
+		{id: "no-filter", want: []string{"API_KEY", "DB_HOST", "DB_PASSWORD"}},
+		{id: "only", only: []string{"DB_*"}, want: []string{"DB_HOST", "DB_PASSWORD"}},
+		{id: "exclude", exclude: []string{"*_PASSWORD"}, want: []string{"API_KEY", "DB_HOST"}},
+		{id: "only-and-exclude", only: []string{"DB_*"}, exclude: []string{"*_PASSWORD"}, want: []string{"DB_HOST"}},
+		{id: "bad-pattern", only: []string{"["}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			got, err := filterVars(vars, tc.only, tc.exclude)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want keys %v", got, tc.want)
+			}
+			for _, k := range tc.want {
+				if _, ok := got[k]; !ok {
+					t.Errorf("missing key %q", k)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildEnv(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("FROM_FILE=file\nSHARED=file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	base := []string{"PATH=/bin", "HOME=/root", "SHARED=base", "SECRET=leak"}
+
+	t.Run("inherit-and-override", func(t *testing.T) {
+		env, err := buildEnv(base, map[string]string{"SHARED": "fetched"}, EnvBuildOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := envMap(env)
+		if got["SHARED"] != "fetched" || got["SECRET"] != "leak" {
+			t.Errorf("env = %v", got)
+		}
+		if len(env) != len(base) {
+			t.Errorf("duplicate entries in env: %v", env)
+		}
+	})
+
+	t.Run("clean-env-keep", func(t *testing.T) {
+		env, err := buildEnv(base, map[string]string{"DB_HOST": "h"}, EnvBuildOptions{CleanEnv: true, Keep: []string{"PATH", "HOME"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := envMap(env)
+		if _, ok := got["SECRET"]; ok {
+			t.Error("SECRET should not be inherited with --clean-env")
+		}
+		if got["PATH"] != "/bin" || got["HOME"] != "/root" || got["DB_HOST"] != "h" {
+			t.Errorf("env = %v", got)
+		}
+	})
+
+	t.Run("env-file-layer", func(t *testing.T) {
+		env, err := buildEnv(base, map[string]string{}, EnvBuildOptions{EnvFiles: []string{envFile}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := envMap(env)
+		if got["FROM_FILE"] != "file" || got["SHARED"] != "file" {
+			t.Errorf("env = %v", got)
+		}
+	})
+
+	t.Run("no-override", func(t *testing.T) {
+		env, err := buildEnv(base, map[string]string{"SHARED": "fetched", "NEW": "n"}, EnvBuildOptions{NoOverride: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := envMap(env)
+		if got["SHARED"] != "base" || got["NEW"] != "n" {
+			t.Errorf("env = %v", got)
+		}
+	})
+
+	t.Run("missing-env-file", func(t *testing.T) {
+		_, err := buildEnv(base, nil, EnvBuildOptions{EnvFiles: []string{filepath.Join(dir, "nope")}})
+		if err == nil || !strings.Contains(err.Error(), "open env file") {
+			t.Errorf("expected open env file error, got %v", err)
+		}
+	})
+}
+
+func TestExecCmd_EnvControl(t *testing.T) {
+	t.Setenv("BUNDR_TEST_UNRELATED", "secret")
+
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/prod/DB_HOST", backend.PutOptions{Value: "localhost", StoreMode: tags.StoreModeRaw})
+	_ = mb.Put(ctx, "ps:/app/prod/DB_PASSWORD", backend.PutOptions{Value: "pw", StoreMode: tags.StoreModeRaw})
+
+	mr := &MockRunner{}
+	cmd := setupExecCmd([]string{"APP_=ps:/app/prod/"}, []string{"env"}, func(c *ExecCmd) {
+		c.CleanEnv = true
+		c.Keep = []string{"PATH"}
+		c.Exclude = []string{"*_PASSWORD"}
+		c.runner = mr
+	})
+
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := envMap(mr.LastEnv())
+	if got["APP_DB_HOST"] != "localhost" {
+		t.Errorf("APP_DB_HOST = %q, want %q", got["APP_DB_HOST"], "localhost")
+	}
+	if _, ok := got["APP_DB_PASSWORD"]; ok {
+		t.Error("APP_DB_PASSWORD should be excluded")
+	}
+	if _, ok := got["BUNDR_TEST_UNRELATED"]; ok {
+		t.Error("BUNDR_TEST_UNRELATED should not be inherited with --clean-env")
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// secretFiles writes exec variables to individual 0400 files so that the child
+// receives NAME_FILE=/path instead of NAME=value (the "_FILE" convention used by
+// Docker official images). Values then never appear in /proc/<pid>/environ.
+type secretFiles struct {
+	dir     string
+	ownsDir bool            // true when bundr created dir and removes it on Cleanup
+	written map[string]bool // variable names currently written to dir by this run
+}
+
+// newSecretFiles prepares the directory for secret files.
+// An empty dir creates a private directory under $XDG_RUNTIME_DIR (usually a tmpfs),
+// falling back to the system temp directory.
+func newSecretFiles(dir string) (*secretFiles, error) {
+	sf := &secretFiles{written: make(map[string]bool)}
+
+	if dir == "" {
+		base := os.Getenv("XDG_RUNTIME_DIR")
+		if base == "" {
+			base = os.TempDir()
+		}
+		d, err := os.MkdirTemp(base, "bundr-")
+		if err != nil {
+			return nil, fmt.Errorf("create files dir: %w", err)
+		}
+		sf.dir = d
+		sf.ownsDir = true
+		return sf, nil
+	}
+
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create files dir: %w", err)
+		}
+		sf.ownsDir = true
+	case err != nil:
+		return nil, fmt.Errorf("stat files dir: %w", err)
+	case !info.IsDir():
+		return nil, fmt.Errorf("files dir %s is not a directory", dir)
+	}
+	sf.dir = dir
+	return sf, nil
+}
+
+// Write stores each value in its own read-only file and returns the NAME_FILE → path
+// variables to inject instead. Files for names that disappeared since the previous
+// call (--watch) are removed. Each file is replaced atomically via rename.
+// In a directory bundr did not create, files that this run did not write are never
+// overwritten (and therefore never removed by Cleanup).
+func (s *secretFiles) Write(vars map[string]string) (map[string]string, error) {
+	fileVars := make(map[string]string, len(vars))
+	for name := range vars {
+		if name == "" || filepath.Base(name) != name || name == "." || name == ".." {
+			return nil, fmt.Errorf("cannot write variable %q to a file", name)
+		}
+		if s.ownsDir || s.written[name] {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(s.dir, name)); err == nil {
+			return nil, fmt.Errorf("%s already exists in %s (bundr only replaces files it wrote)", name, s.dir)
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("stat %s: %w", name, err)
+		}
+	}
+	for name, value := range vars {
+		dst := filepath.Join(s.dir, name)
+		if err := writeFileAtomic(dst, []byte(value), 0o400); err != nil {
+			return nil, fmt.Errorf("write %s: %w", name, err)
+		}
+		fileVars[name+"_FILE"] = dst
+	}
+
+	for name := range s.written {
+		if _, ok := vars[name]; !ok {
+			_ = os.Remove(filepath.Join(s.dir, name))
+		}
+	}
+	s.written = make(map[string]bool, len(vars))
+	for name := range vars {
+		s.written[name] = true
+	}
+	return fileVars, nil
+}
+
+// Cleanup removes the files written by this run, and the directory itself if bundr created it.
+func (s *secretFiles) Cleanup() {
+	if s.ownsDir {
+		_ = os.RemoveAll(s.dir)
+		return
+	}
+	for name := range s.written {
+		_ = os.Remove(filepath.Join(s.dir, name))
+	}
+}
+
+// writeFileAtomic writes data to a temporary file with perm in the same directory
+// and renames it over name.
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".bundr-tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, name)
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// funcRunner is a SubprocessRunner backed by a function (inspects state while the "child" runs).
+type funcRunner func(name string, args []string, env []string) (int, error)
+
+func (f funcRunner) Run(name string, args []string, env []string) (int, error) {
+	return f(name, args, env)
+}
+
+func TestSecretFiles(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	sf, err := newSecretFiles("")
+	if err != nil {
+		t.Fatalf("newSecretFiles() error: %v", err)
+	}
+	if !strings.HasPrefix(sf.dir, os.Getenv("XDG_RUNTIME_DIR")) {
+		t.Errorf("dir = %q, want under XDG_RUNTIME_DIR", sf.dir)
+	}
+
+	fileVars, err := sf.Write(map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "k"})
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	path := fileVars["DB_PASSWORD_FILE"]
+	if path != filepath.Join(sf.dir, "DB_PASSWORD") {
+		t.Fatalf("DB_PASSWORD_FILE = %q", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "s3cret" {
+		t.Errorf("file content = %q, %v", data, err)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0o400 {
+		t.Errorf("file mode = %v, want 0400", info.Mode().Perm())
+	}
+
+	// Rewriting replaces read-only files and drops keys that disappeared.
+	if _, err := sf.Write(map[string]string{"DB_PASSWORD": "rotated"}); err != nil {
+		t.Fatalf("second Write() error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "rotated" {
+		t.Errorf("rotated content = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(sf.dir, "API_KEY")); !os.IsNotExist(err) {
+		t.Error("API_KEY file should be removed after it disappeared")
+	}
+
+	sf.Cleanup()
+	if _, err := os.Stat(sf.dir); !os.IsNotExist(err) {
+		t.Error("files dir should be removed by Cleanup")
+	}
+}
+
+func TestSecretFiles_ExistingDirKept(t *testing.T) {
+	dir := t.TempDir()
+	sf, err := newSecretFiles(dir)
+	if err != nil {
+		t.Fatalf("newSecretFiles() error: %v", err)
+	}
+	if _, err := sf.Write(map[string]string{"KEY": "v"}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if _, err := sf.Write(map[string]string{"../evil": "v"}); err == nil {
+		t.Error("expected error for a name containing a path separator")
+	}
+	sf.Cleanup()
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("user-provided dir should be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "KEY")); !os.IsNotExist(err) {
+		t.Error("KEY file should be removed by Cleanup")
+	}
+}
+
+// files that were in a user-provided dir before the run are neither replaced nor removed
+func TestSecretFiles_ExistingFileKept(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "API_KEY")
+	if err := os.WriteFile(existing, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sf, err := newSecretFiles(dir)
+	if err != nil {
+		t.Fatalf("newSecretFiles() error: %v", err)
+	}
+	if _, err := sf.Write(map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "k"}); err == nil || !strings.Contains(err.Error(), "API_KEY already exists") {
+		t.Fatalf("Write() error = %v, want API_KEY already exists", err)
+	}
+	sf.Cleanup()
+	if data, err := os.ReadFile(existing); err != nil || string(data) != "keep" {
+		t.Errorf("existing file = %q, %v; want it untouched", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "DB_PASSWORD")); !os.IsNotExist(err) {
+		t.Error("nothing should be written when a file already exists")
+	}
+}
+
+func TestExecCmd_FilesDir(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	_ = mb.Put(context.Background(), "ps:/app/prod/DB_PASSWORD", backend.PutOptions{Value: "s3cret", StoreMode: tags.StoreModeRaw})
+
+	dir := filepath.Join(t.TempDir(), "secrets")
+	var gotEnv map[string]string
+	var gotContent string
+	cmd := setupExecCmd([]string{"ps:/app/prod/"}, []string{"app"}, func(c *ExecCmd) {
+		c.FilesDir = dir
+		c.runner = funcRunner(func(_ string, _ []string, env []string) (int, error) {
+			gotEnv = envMap(env)
+			data, _ := os.ReadFile(gotEnv["DB_PASSWORD_FILE"])
+			gotContent = string(data)
+			return 0, nil
+		})
+	})
+
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := gotEnv["DB_PASSWORD"]; ok {
+		t.Error("DB_PASSWORD should not be passed as a plain variable")
+	}
+	if gotContent != "s3cret" {
+		t.Errorf("DB_PASSWORD_FILE content = %q, want %q", gotContent, "s3cret")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("files dir created by bundr should be removed after the child exits")
+	}
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -264,6 +267,118 @@ func TestExecCmd(t *testing.T) {
 	}
 }
 
+func TestExecCmd_FileBackend(t *testing.T) {
+	ctx := context.Background()
+	fb := backend.NewFileBackend(backend.FileKeys{Passphrase: "pw", ScryptLogN: 10})
+	file := filepath.Join(t.TempDir(), "secrets.bundr")
+	puts := map[string]backend.PutOptions{
+		"file:" + file + ":/app/dev/db_host": {Value: "localhost", StoreMode: tags.StoreModeRaw},
+		"file:" + file + ":/app/dev/CONFIG":  {Value: `{"port":5432}`, StoreMode: tags.StoreModeJSON},
+	}
+	for ref, opts := range puts {
+		if err := fb.Put(ctx, ref, opts); err != nil {
+			t.Fatalf("Put(%s): %v", ref, err)
+		}
+	}
+	appCtx := &Context{
+		Config: &config.Config{},
+		BackendFactory: func(bt backend.BackendType) (backend.Backend, error) {
+			if bt != backend.BackendTypeFile {
+				t.Fatalf("unexpected backend type %s", bt)
+			}
+			return fb, nil
+		},
+	}
+
+	// プレフィックスと単一 ref のどちらも ps: と同じ名前になる
+	mr := &MockRunner{}
+	cmd := setupExecCmd([]string{"file:" + file + ":/app/dev/", "PW_=file:" + file + ":/app/dev/db_host"}, []string{"env"})
+	cmd.runner = mr
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := envMap(mr.LastEnv())
+	for key, want := range map[string]string{"DB_HOST": "localhost", "CONFIG_PORT": "5432", "PW_DB_HOST": "localhost"} {
+		if got[key] != want {
+			t.Errorf("env[%q] = %q, want %q", key, got[key], want)
+		}
+	}
+}
+
+// localTestFactory routes env: and dotenv: refs to the real read-only backends and
+// everything else to mb.
+func localTestFactory(mb *backend.MockBackend) func(backend.BackendType) (backend.Backend, error) {
+	return func(bt backend.BackendType) (backend.Backend, error) {
+		switch bt {
+		case backend.BackendTypeEnv:
+			return backend.NewEnvBackend(), nil
+		case backend.BackendTypeDotenv:
+			return backend.NewDotenvBackend(), nil
+		}
+		return mb, nil
+	}
+}
+
+func TestExecCmd_LocalBackends(t *testing.T) {
+	mb := backend.NewMockBackend()
+	_ = mb.Put(context.Background(), "ps:/app/dev/DB_HOST", backend.PutOptions{Value: "ps-host", StoreMode: tags.StoreModeRaw})
+	t.Setenv("BUNDR_TEST_DB_HOST", "env-host")
+	t.Setenv("BUNDR_TEST_DB_USER", "env-user")
+	envFile := writeTempEnv(t, "DB_PASSWORD=dotenv-pw\nDB_USER=dotenv-user\n")
+	appCtx := &Context{Config: &config.Config{}, BackendFactory: localTestFactory(mb)}
+
+	// 後勝ち: ps → env → dotenv の順に上書きされる
+	mr := &MockRunner{}
+	cmd := setupExecCmd([]string{"ps:/app/dev/", "env:BUNDR_TEST_", "dotenv:" + envFile + ":", "SINGLE_=env:BUNDR_TEST_DB_HOST"}, []string{"env"})
+	cmd.runner = mr
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := envMap(mr.LastEnv())
+	want := map[string]string{
+		"DB_HOST":                   "env-host",
+		"DB_USER":                   "dotenv-user",
+		"DB_PASSWORD":               "dotenv-pw",
+		"SINGLE_BUNDR_TEST_DB_HOST": "env-host",
+	}
+	for key, w := range want {
+		if got[key] != w {
+			t.Errorf("env[%q] = %q, want %q", key, got[key], w)
+		}
+	}
+}
+
+func TestExecCmd_VaultAndPS(t *testing.T) {
+	vb := newVaultStub(t, map[string]map[string]any{
+		"app/db":      {"host": "vault-db", "password": "s3cret"},
+		"app/api_key": {"value": "k-123"},
+	})
+	mb := backend.NewMockBackend()
+	_ = mb.Put(context.Background(), "ps:/app/dev/LOG_LEVEL", backend.PutOptions{Value: "debug", StoreMode: tags.StoreModeRaw})
+	appCtx := &Context{
+		Config: &config.Config{},
+		BackendFactory: func(bt backend.BackendType) (backend.Backend, error) {
+			if bt == backend.BackendTypeVault {
+				return vb, nil
+			}
+			return mb, nil
+		},
+	}
+
+	mr := &MockRunner{}
+	cmd := setupExecCmd([]string{"ps:/app/dev/", "vault:secret/data/app/"}, []string{"env"})
+	cmd.runner = mr
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := envMap(mr.LastEnv())
+	for key, want := range map[string]string{"LOG_LEVEL": "debug", "DB_HOST": "vault-db", "DB_PASSWORD": "s3cret", "API_KEY": "k-123"} {
+		if got[key] != want {
+			t.Errorf("env[%q] = %q, want %q", key, got[key], want)
+		}
+	}
+}
+
 // ─── 異常系 ───────────────────────────────────────────────────────────────────
 
 func TestExecCmd_Errors(t *testing.T) {
@@ -454,3 +569,53 @@ func TestExecCmd_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestExecCmd_MaskOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	mb, appCtx := newExecTestContext(t)
+	_ = mb.Put(context.Background(), "ps:/app/prod/DB_PASSWORD", backend.PutOptions{Value: "hunter2-secret", StoreMode: tags.StoreModeRaw})
+
+	var stdout, stderr bytes.Buffer
+	cmd := setupExecCmd([]string{"ps:/app/prod/"}, []string{"sh", "-c", `echo "pw=$DB_PASSWORD"; echo "$DB_PASSWORD" >&2; exit 2`}, func(c *ExecCmd) {
+		c.MaskOutput = true
+		c.MaskMinLength = 6
+		c.out = &stdout
+		c.errOut = &stderr
+	})
+
+	err := cmd.Run(appCtx)
+	var exitErr *ExitCodeError
+	if !isExitCodeError(err, &exitErr) || exitErr.Code != 2 {
+		t.Fatalf("err = %v, want ExitCodeError{2}", err)
+	}
+	if stdout.String() != "pw=***\n" {
+		t.Errorf("stdout = %q, want %q", stdout.String(), "pw=***\n")
+	}
+	if stderr.String() != "***\n" {
+		t.Errorf("stderr = %q, want %q", stderr.String(), "***\n")
+	}
+}
+
+func TestExecCmd_ReplaceConflicts(t *testing.T) {
+	tests := []struct {
+		id      string
+		opt     func(*ExecCmd)
+		wantErr string
+	}{
+		{id: "mask-output", opt: func(c *ExecCmd) { c.MaskOutput = true }, wantErr: "--mask-output cannot be combined with --replace"},
+		{id: "files", opt: func(c *ExecCmd) { c.Files = true }, wantErr: "--files cannot be combined with --replace"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			_, appCtx := newExecTestContext(t)
+			cmd := setupExecCmd([]string{"ps:/app/prod/"}, []string{"env"}, func(c *ExecCmd) { c.Replace = true }, tc.opt)
+			err := cmd.Run(appCtx)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
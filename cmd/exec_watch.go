@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"golang.org/x/sys/unix"
+)
+
+// maxRestartBackoff caps the restart delay in --watch mode.
+// Restarts that happen within this window of the previous one double the delay.
+const maxRestartBackoff = time.Minute
+
+// childExit is the result of ChildProcess.Wait delivered asynchronously.
+type childExit struct {
+	code int
+	err  error
+}
+
+// waitAsync waits for the child in a goroutine and delivers the result on the returned channel.
+func waitAsync(p ChildProcess) <-chan childExit {
+	ch := make(chan childExit, 1)
+	go func() {
+		code, err := p.Wait()
+		ch <- childExit{code: code, err: err}
+	}()
+	return ch
+}
+
+// runWatch starts the command and keeps polling the --from sources.
+// When the generated variables change, the child is restarted with the new
+// environment (or signalled with --on-change=signal). Only key names are reported.
+// bundr exits with the child's exit code when the child exits on its own.
+func (c *ExecCmd) runWatch(appCtx *Context, starter ProcessStarter, args []string, vars map[string]string, env []string) error {
+	ctx := context.Background()
+	errOut := c.warnOut()
+
+	if c.WatchInterval <= 0 {
+		return fmt.Errorf("exec command failed: --watch-interval must be positive")
+	}
+	var changeSignal syscall.Signal
+	if c.OnChange == "signal" {
+		sig, err := parseSignal(c.WatchSignal)
+		if err != nil {
+			return fmt.Errorf("exec command failed: %w", err)
+		}
+		changeSignal = sig
+	}
+
+	snapshot, err := c.watchSnapshot(ctx, appCtx)
+	if err != nil {
+		return fmt.Errorf("exec command failed: %w", err)
+	}
+
+	// 子プロセスの実行中はシグナルを子に転送する。再起動の待ち時間にはこちらで受けて終了する
+	sigCh := c.signals
+	if sigCh == nil {
+		sigCh = make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(sigCh)
+	}
+
+	child, err := starter.Start(args[0], args[1:], env)
+	if err != nil {
+		return fmt.Errorf("exec command failed: %w", err)
+	}
+	exited := waitAsync(child)
+
+	backoff := c.RestartBackoff
+	var lastRestart time.Time
+
+	for {
+		select {
+		case res := <-exited:
+			return execResult(res.code, res.err)
+		case <-sigCh:
+			continue // forwarded to the child, which decides whether to exit
+		case <-time.After(c.nextPollDelay()):
+		}
+
+		// Cheap poll first (values only, no tag fetch); rebuild vars with tags only on change.
+		next, err := c.watchSnapshot(ctx, appCtx)
+		if err != nil {
+			fmt.Fprintf(errOut, "bundr: watch poll failed: %v\n", err)
+			continue
+		}
+		if maps.Equal(next, snapshot) {
+			continue
+		}
+
+		newVars, newEnv, err := c.resolveEnv(ctx, appCtx)
+		if err != nil {
+			fmt.Fprintf(errOut, "bundr: watch poll failed: %v\n", err)
+			continue
+		}
+		snapshot = next
+
+		changes := diffVarKeys(vars, newVars)
+		if len(changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(errOut, "bundr: parameters changed: %s\n", strings.Join(changes, ", "))
+		vars, env = newVars, newEnv
+		c.addMaskValues(vars) // rotated values are masked too; old ones stay masked
+
+		if c.OnChange == "signal" {
+			if err := child.Signal(changeSignal); err != nil {
+				fmt.Fprintf(errOut, "bundr: signal child: %v\n", err)
+			}
+			continue
+		}
+
+		c.stopChild(child, exited)
+
+		if !lastRestart.IsZero() && time.Since(lastRestart) < maxRestartBackoff {
+			backoff = min(backoff*2, maxRestartBackoff)
+		} else {
+			backoff = c.RestartBackoff
+		}
+		select {
+		case sig := <-sigCh:
+			fmt.Fprintf(errOut, "bundr: received %v while restarting, not restarting %s\n", sig, args[0])
+			code := 1
+			if s, ok := sig.(syscall.Signal); ok {
+				code = 128 + int(s)
+			}
+			return &ExitCodeError{Code: code}
+		case <-time.After(backoff):
+		}
+		lastRestart = time.Now()
+
+		fmt.Fprintf(errOut, "bundr: restarting %s\n", args[0])
+		child, err = starter.Start(args[0], args[1:], env)
+		if err != nil {
+			return fmt.Errorf("exec command failed: restart: %w", err)
+		}
+		exited = waitAsync(child)
+	}
+}
+
+// stopChild sends SIGTERM and escalates to SIGKILL after the grace period.
+func (c *ExecCmd) stopChild(child ChildProcess, exited <-chan childExit) {
+	_ = child.Signal(syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(c.GracePeriod):
+		_ = child.Signal(syscall.SIGKILL)
+		<-exited
+	}
+}
+
+// nextPollDelay returns the watch interval plus a random jitter.
+func (c *ExecCmd) nextPollDelay() time.Duration {
+	if c.WatchJitter <= 0 {
+		return c.WatchInterval
+	}
+	return c.WatchInterval + rand.N(c.WatchJitter)
+}
+
+// watchSnapshot fetches the raw values of every --from source keyed by ref.
+// Tags are not fetched (SkipTagFetch), so a poll costs one listing call per source.
+func (c *ExecCmd) watchSnapshot(ctx context.Context, appCtx *Context) (map[string]string, error) {
+	snapshot := make(map[string]string)
+	for _, from := range c.From {
+		_, rawRef := parseFromSpec(from)
+		ref, err := backend.ParseRef(rawRef)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ref: %w", err)
+		}
+		b, err := appCtx.BackendFactory(ref.Type)
+		if err != nil {
+			return nil, fmt.Errorf("create backend: %w", err)
+		}
+		var entries []backend.ParameterEntry
+		if ref.Field == "" {
+			entries, err = b.GetByPrefix(ctx, ref.Path, backend.GetByPrefixOptions{Recursive: true, SkipTagFetch: true})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(entries) == 0 && !strings.HasSuffix(ref.Path, "/") {
+			baseRef, _ := backend.SplitField(rawRef)
+			val, err := b.Get(ctx, baseRef, backend.GetOptions{ForceRaw: true})
+			if err != nil {
+				return nil, err
+			}
+			snapshot[rawRef] = val
+			continue
+		}
+		for _, e := range entries {
+			snapshot[string(ref.Type)+":"+e.Path] = e.Value
+		}
+	}
+	return snapshot, nil
+}
+
+// diffVarKeys reports which variable names were added, removed or changed, sorted by name.
+// Values are never included.
+func diffVarKeys(before, after map[string]string) []string {
+	var changes []string
+	for k, v := range after {
+		old, ok := before[k]
+		switch {
+		case !ok:
+			changes = append(changes, k+" (added)")
+		case old != v:
+			changes = append(changes, k+" (changed)")
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			changes = append(changes, k+" (removed)")
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// parseSignal converts "SIGHUP", "HUP" or "1" to a syscall.Signal.
+func parseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return syscall.Signal(n), nil
+	}
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return 0, fmt.Errorf("unknown signal %q", s)
+	}
+	return sig, nil
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// fakeChild is a ChildProcess controlled by the test.
+type fakeChild struct {
+	mu      sync.Mutex
+	env     []string
+	signals []syscall.Signal
+	exitCh  chan int
+	once    sync.Once
+}
+
+func (f *fakeChild) Signal(sig syscall.Signal) error {
+	f.mu.Lock()
+	f.signals = append(f.signals, sig)
+	f.mu.Unlock()
+	if sig == syscall.SIGTERM || sig == syscall.SIGKILL {
+		f.exit(128 + int(sig))
+	}
+	return nil
+}
+
+func (f *fakeChild) Wait() (int, error) {
+	code := <-f.exitCh
+	if code != 0 {
+		return code, fmt.Errorf("exit status %d", code)
+	}
+	return 0, nil
+}
+
+func (f *fakeChild) exit(code int) {
+	f.once.Do(func() { f.exitCh <- code })
+}
+
+func (f *fakeChild) Signals() []syscall.Signal {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]syscall.Signal(nil), f.signals...)
+}
+
+// fakeStarter records every started child.
+type fakeStarter struct {
+	mu       sync.Mutex
+	children []*fakeChild
+}
+
+func (s *fakeStarter) Start(_ string, _ []string, env []string) (ChildProcess, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	child := &fakeChild{env: env, exitCh: make(chan int, 1)}
+	s.children = append(s.children, child)
+	return child, nil
+}
+
+// waitChildren polls until at least n children were started.
+func (s *fakeStarter) waitChildren(t *testing.T, n int) []*fakeChild {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		if len(s.children) >= n {
+			children := append([]*fakeChild(nil), s.children...)
+			s.mu.Unlock()
+			return children
+		}
+		s.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d children", n)
+	return nil
+}
+
+func newWatchExecCmd(starter ProcessStarter, errOut *bytes.Buffer, opts ...func(*ExecCmd)) *ExecCmd {
+	cmd := setupExecCmd([]string{"ps:/app/prod/"}, []string{"server"}, func(c *ExecCmd) {
+		c.Watch = true
+		c.WatchInterval = 10 * time.Millisecond
+		c.OnChange = "restart"
+		c.WatchSignal = "SIGHUP"
+		c.GracePeriod = time.Second
+		c.RestartBackoff = time.Millisecond
+		c.starter = starter
+		c.errOut = errOut
+	})
+	for _, opt := range opts {
+		opt(cmd)
+	}
+	return cmd
+}
+
+func TestExecCmd_WatchRestart(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/prod/DB_PASSWORD", backend.PutOptions{Value: "old-secret", StoreMode: tags.StoreModeRaw})
+
+	starter := &fakeStarter{}
+	var errOut bytes.Buffer
+	cmd := newWatchExecCmd(starter, &errOut)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- cmd.Run(appCtx) }()
+
+	first := starter.waitChildren(t, 1)[0]
+	if got := envMap(first.env)["DB_PASSWORD"]; got != "old-secret" {
+		t.Fatalf("first child DB_PASSWORD = %q, want %q", got, "old-secret")
+	}
+
+	_ = mb.Put(ctx, "ps:/app/prod/DB_PASSWORD", backend.PutOptions{Value: "new-secret", StoreMode: tags.StoreModeRaw})
+
+	second := starter.waitChildren(t, 2)[1]
+	if got := envMap(second.env)["DB_PASSWORD"]; got != "new-secret" {
+		t.Errorf("restarted child DB_PASSWORD = %q, want %q", got, "new-secret")
+	}
+	if sigs := first.Signals(); len(sigs) == 0 || sigs[0] != syscall.SIGTERM {
+		t.Errorf("first child signals = %v, want SIGTERM", sigs)
+	}
+
+	second.exit(0)
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after child exit")
+	}
+
+	out := errOut.String()
+	if !strings.Contains(out, "DB_PASSWORD (changed)") {
+		t.Errorf("stderr = %q, want changed key report", out)
+	}
+	if strings.Contains(out, "secret") {
+		t.Errorf("stderr must not contain values: %q", out)
+	}
+}
+
+// a SIGTERM during the restart backoff ends the loop instead of starting another child
+func TestExecCmd_WatchSignalDuringBackoff(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/prod/DB_PASSWORD", backend.PutOptions{Value: "old-secret", StoreMode: tags.StoreModeRaw})
+
+	starter := &fakeStarter{}
+	var errOut bytes.Buffer
+	signals := make(chan os.Signal, 1)
+	cmd := newWatchExecCmd(starter, &errOut, func(c *ExecCmd) {
+		c.RestartBackoff = time.Hour
+		c.signals = signals
+	})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- cmd.Run(appCtx) }()
+
+	first := starter.waitChildren(t, 1)[0]
+	_ = mb.Put(ctx, "ps:/app/prod/DB_PASSWORD", backend.PutOptions{Value: "new-secret", StoreMode: tags.StoreModeRaw})
+	deadline := time.Now().Add(5 * time.Second)
+	for len(first.Signals()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("first child was not stopped")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	signals <- syscall.SIGTERM
+
+	select {
+	case err := <-errCh:
+		var exitErr *ExitCodeError
+		if !errors.As(err, &exitErr) || exitErr.Code != 128+int(syscall.SIGTERM) {
+			t.Errorf("Run() error = %v, want exit code %d", err, 128+int(syscall.SIGTERM))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after SIGTERM during the backoff")
+	}
+	starter.mu.Lock()
+	defer starter.mu.Unlock()
+	if len(starter.children) != 1 {
+		t.Errorf("started %d children, want 1", len(starter.children))
+	}
+}
+
+func TestExecCmd_WatchSignal(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/prod/FEATURE", backend.PutOptions{Value: "off", StoreMode: tags.StoreModeRaw})
+
+	starter := &fakeStarter{}
+	var errOut bytes.Buffer
+	cmd := newWatchExecCmd(starter, &errOut, func(c *ExecCmd) { c.OnChange = "signal" })
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- cmd.Run(appCtx) }()
+
+	child := starter.waitChildren(t, 1)[0]
+	_ = mb.Put(ctx, "ps:/app/prod/FEATURE", backend.PutOptions{Value: "on", StoreMode: tags.StoreModeRaw})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(child.Signals()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sigs := child.Signals(); len(sigs) != 1 || sigs[0] != syscall.SIGHUP {
+		t.Errorf("child signals = %v, want [SIGHUP]", sigs)
+	}
+
+	child.exit(3)
+	err := <-errCh
+	var exitErr *ExitCodeError
+	if !isExitCodeError(err, &exitErr) || exitErr.Code != 3 {
+		t.Errorf("err = %v, want ExitCodeError{3}", err)
+	}
+}
+
+func TestExecCmd_WatchWithReplace(t *testing.T) {
+	_, appCtx := newExecTestContext(t)
+	cmd := setupExecCmd([]string{"ps:/app/prod/"}, []string{"env"}, func(c *ExecCmd) {
+		c.Watch = true
+		c.Replace = true
+	})
+	err := cmd.Run(appCtx)
+	if err == nil || !strings.Contains(err.Error(), "--watch cannot be combined with --replace") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestDiffVarKeys(t *testing.T) {
+	before := map[string]string{"A": "1", "B": "2", "C": "3"}
+	after := map[string]string{"A": "1", "B": "changed", "D": "4"}
+	got := diffVarKeys(before, after)
+	want := []string{"B (changed)", "C (removed)", "D (added)"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("diffVarKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{in: "SIGHUP", want: syscall.SIGHUP},
+		{in: "hup", want: syscall.SIGHUP},
+		{in: "15", want: syscall.SIGTERM},
+		{in: "NOPE", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseSignal(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("parseSignal(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
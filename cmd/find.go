@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/cache"
+)
+
+// FindCmd represents the "find" subcommand.
+type FindCmd struct {
+	Pattern     string        `arg:"" help:"Substring (or regular expression with --regex) to match against names"`
+	Regex       bool          `short:"E" name:"regex" help:"Treat the pattern as a regular expression"`
+	IgnoreCase  bool          `short:"i" name:"ignore-case" help:"Match case-insensitively"`
+	Backend     []string      `name:"backend" enum:"ps,sm" default:"ps,sm" help:"Backends to search"`
+	Prefix      string        `name:"prefix" predictor:"prefix" help:"Only search under this prefix (e.g. ps:/app/); limits the search to its backend"`
+	Regions     []string      `name:"regions" help:"Regions to search (default: aws.regions from config, otherwise the current region)"`
+	Values      bool          `name:"values" help:"Also fetch values and match the pattern against them (values are never printed)"`
+	NoCache     bool          `name:"no-cache" help:"Always list live instead of using the completion cache"`
+	MaxCacheAge time.Duration `name:"max-cache-age" default:"5m" help:"Use the completion cache when it was refreshed within this duration"`
+
+	out    io.Writer // for testing; nil means os.Stdout
+	errOut io.Writer // for testing; nil means os.Stderr
+}
+
+// findMatch is one ref found by find.
+type findMatch struct {
+	ref    string
+	region string
+}
+
+// Run executes the find command.
+func (c *FindCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	if c.errOut == nil {
+		c.errOut = os.Stderr
+	}
+
+	match, err := c.matcher()
+	if err != nil {
+		return fmt.Errorf("find command failed: %w", err)
+	}
+	scopes, err := c.scopes()
+	if err != nil {
+		return fmt.Errorf("find command failed: %w", err)
+	}
+
+	regions := c.regions(appCtx)
+	var matches []findMatch
+	for _, region := range regions {
+		rctx, err := c.regionContext(appCtx, region)
+		if err != nil {
+			return fmt.Errorf("find command failed: %w", err)
+		}
+		for _, scope := range scopes {
+			found, err := c.search(rctx, scope, match)
+			if err != nil {
+				if region != "" {
+					return fmt.Errorf("find command failed: %s: %w", region, err)
+				}
+				return fmt.Errorf("find command failed: %w", err)
+			}
+			for _, ref := range found {
+				matches = append(matches, findMatch{ref: ref, region: region})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].region != matches[j].region {
+			return matches[i].region < matches[j].region
+		}
+		return matches[i].ref < matches[j].ref
+	})
+
+	if appCtx.Output != "" {
+		rw := newRecordWriter(c.out, appCtx.Output, "ref", "region")
+		for _, m := range matches {
+			if err := rw.Write(record{"ref": m.ref, "region": m.region}); err != nil {
+				return fmt.Errorf("find command failed: %w", err)
+			}
+		}
+		return rw.Close()
+	}
+
+	// テキスト出力は ref だけの一覧にする（リージョンは --output のレコードで返す）。
+	// 複数リージョンで同じ ref が見つかった場合は 1 行にまとめる
+	var refs []string
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if !seen[m.ref] {
+			seen[m.ref] = true
+			refs = append(refs, m.ref)
+		}
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		fmt.Fprintln(c.out, ref)
+	}
+	return nil
+}
+
+// matcher returns the predicate applied to names (and values with --values).
+func (c *FindCmd) matcher() (func(string) bool, error) {
+	expr := c.Pattern
+	if !c.Regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if c.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re.MatchString, nil
+}
+
+// scopes returns the backend and path prefix of every listing to search.
+func (c *FindCmd) scopes() ([]backend.Ref, error) {
+	if c.Prefix != "" {
+		ref, err := parseListScope(c.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		return []backend.Ref{ref}, nil
+	}
+
+	var scopes []backend.Ref
+	for _, name := range c.Backend {
+		switch backend.BackendType(name) {
+		case backend.BackendTypePS:
+			scopes = append(scopes, backend.Ref{Type: backend.BackendTypePS, Path: "/"})
+		case backend.BackendTypeSM:
+			scopes = append(scopes, backend.Ref{Type: backend.BackendTypeSM})
+		}
+	}
+	return scopes, nil
+}
+
+// parseListScope parses a prefix to list, such as ps:/app/ or sm: (all secrets).
+func parseListScope(prefix string) (backend.Ref, error) {
+	if prefix == "sm:" || prefix == "secretsmanager:" {
+		return backend.Ref{Type: backend.BackendTypeSM}, nil
+	}
+	ref, err := backend.ParseRef(prefix)
+	if err != nil {
+		return backend.Ref{}, fmt.Errorf("invalid prefix: %w", err)
+	}
+	if ref.Field != "" {
+		return backend.Ref{}, fmt.Errorf("a #field selector cannot be used with a prefix")
+	}
+	return ref, nil
+}
+
+// regions returns the regions to search. "" stands for the current region of appCtx.
+func (c *FindCmd) regions(appCtx *Context) []string {
+	if len(c.Regions) > 0 {
+		return c.Regions
+	}
+	if appCtx.Config != nil && len(appCtx.Config.AWS.Regions) > 0 {
+		return appCtx.Config.AWS.Regions
+	}
+	return []string{""}
+}
+
+// regionContext returns appCtx for the current region and RegionContext(region) otherwise.
+func (c *FindCmd) regionContext(appCtx *Context, region string) (*Context, error) {
+	if region == "" || (appCtx.Config != nil && appCtx.Config.AWS.Region == region) {
+		return appCtx, nil
+	}
+	if appCtx.RegionContext == nil {
+		return nil, fmt.Errorf("searching other regions is not available")
+	}
+	return appCtx.RegionContext(region)
+}
+
+// search returns the refs in scope whose name (or, with --values, value) matches.
+func (c *FindCmd) search(appCtx *Context, scope backend.Ref, match func(string) bool) ([]string, error) {
+	b, err := appCtx.BackendFactory(scope.Type)
+	if err != nil {
+		return nil, fmt.Errorf("create backend: %w", err)
+	}
+	paths, err := c.listPaths(appCtx, b, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var found, rest []string
+	for _, p := range paths {
+		ref := string(scope.Type) + ":" + p
+		if match(p) {
+			found = append(found, ref)
+		} else if c.Values {
+			rest = append(rest, ref)
+		}
+	}
+	if len(rest) == 0 {
+		return found, nil
+	}
+
+	values, _, err := backend.GetMany(context.Background(), b, rest, backend.GetOptions{ForceRaw: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range rest {
+		if v, ok := values[ref]; ok && match(v) {
+			found = append(found, ref)
+		}
+	}
+	return found, nil
+}
+
+// listPaths returns the paths under scope, from the completion cache when it was
+// refreshed within --max-cache-age by a listing that covered scope, otherwise from a
+// live listing. ls, exec and cache refresh rewrite the cache with only the prefix they
+// listed, so a cache of ps:/app/ never answers a search of the whole backend. A live
+// listing of a whole backend is written back to the cache.
+func (c *FindCmd) listPaths(appCtx *Context, b backend.Backend, scope backend.Ref) ([]string, error) {
+	bt := string(scope.Type)
+	wholeBackend := scope.Path == "" || scope.Path == "/"
+
+	if !c.NoCache && appCtx.CacheStore != nil {
+		refreshed := appCtx.CacheStore.LastRefreshedAt(bt)
+		cached, ok := appCtx.CacheStore.Scope(bt)
+		if ok && scopeCovers(cached, scope.Path) && !refreshed.IsZero() && time.Since(refreshed) <= c.MaxCacheAge {
+			if entries, err := appCtx.CacheStore.Read(bt); err == nil {
+				fmt.Fprintf(c.errOut, "find: using the %s: completion cache from %s ago (--no-cache for a live search)\n", bt, time.Since(refreshed).Round(time.Second))
+				var paths []string
+				for _, e := range entries {
+					if wholeBackend || strings.HasPrefix(e.Path, scope.Path) {
+						paths = append(paths, e.Path)
+					}
+				}
+				return paths, nil
+			}
+		}
+	}
+
+	var paths []string
+	err := backend.List(context.Background(), b, scope.Path, backend.ListOptions{Recursive: true}, func(e backend.ListEntry) error {
+		paths = append(paths, e.Path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if wholeBackend && appCtx.CacheStore != nil {
+		entries := make([]cache.CacheEntry, len(paths))
+		for i, p := range paths {
+			entries[i] = cache.CacheEntry{Path: p}
+		}
+		_ = appCtx.CacheStore.Write(bt, scope.Path, entries)
+	}
+	return paths, nil
+}
+
+// scopeCovers reports whether a recursive listing of the cached prefix includes every
+// path under prefix. "" and "/" stand for the whole backend.
+func scopeCovers(cached, prefix string) bool {
+	if cached == "" || cached == "/" {
+		return true
+	}
+	if prefix == "" || prefix == "/" {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimRight(prefix, "/")+"/", strings.TrimRight(cached, "/")+"/")
+}
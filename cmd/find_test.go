@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/cache"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// newFindTestContext returns a Context with separate ps: and sm: mock backends.
+func newFindTestContext(t *testing.T, entries map[string]string) *Context {
+	t.Helper()
+	ctx := context.Background()
+	ps, sm := backend.NewMockBackend(), backend.NewMockBackend()
+	for ref, value := range entries {
+		b := ps
+		if strings.HasPrefix(ref, "sm:") {
+			b = sm
+		}
+		if err := b.Put(ctx, ref, backend.PutOptions{Value: value, StoreMode: tags.StoreModeRaw}); err != nil {
+			t.Fatalf("Put(%s): %v", ref, err)
+		}
+	}
+	return &Context{
+		Config: &config.Config{},
+		BackendFactory: func(bt backend.BackendType) (backend.Backend, error) {
+			if bt == backend.BackendTypeSM {
+				return sm, nil
+			}
+			return ps, nil
+		},
+	}
+}
+
+func TestFindCmd(t *testing.T) {
+	entries := map[string]string{
+		"ps:/app/prod/db_host": "db.internal",
+		"ps:/app/prod/api_key": "k-123",
+		"ps:/other/DB_NAME":    "main",
+		"sm:prod/db":           `{"host":"db.internal"}`,
+	}
+
+	tests := []struct {
+		id      string
+		cmd     FindCmd
+		output  string
+		want    string
+		wantErr string
+	}{
+		{id: "substring", cmd: FindCmd{Pattern: "db"}, want: "ps:/app/prod/db_host\nsm:prod/db\n"},
+		{id: "ignore-case", cmd: FindCmd{Pattern: "db", IgnoreCase: true}, want: "ps:/app/prod/db_host\nps:/other/DB_NAME\nsm:prod/db\n"},
+		{id: "regex", cmd: FindCmd{Pattern: `^/app/.*_(host|key)$`, Regex: true}, want: "ps:/app/prod/api_key\nps:/app/prod/db_host\n"},
+		{id: "backend", cmd: FindCmd{Pattern: "db", Backend: []string{"sm"}}, want: "sm:prod/db\n"},
+		{id: "prefix", cmd: FindCmd{Pattern: "d", IgnoreCase: true, Prefix: "ps:/other/"}, want: "ps:/other/DB_NAME\n"},
+		// --values: names or values match; values are never printed
+		{id: "values", cmd: FindCmd{Pattern: "k-1", Values: true}, want: "ps:/app/prod/api_key\n"},
+		{id: "output-jsonl", cmd: FindCmd{Pattern: "api"}, output: OutputJSONL, want: `{"ref":"ps:/app/prod/api_key","region":""}` + "\n"},
+		{id: "invalid-regex", cmd: FindCmd{Pattern: "(", Regex: true}, wantErr: "invalid pattern"},
+		{id: "other-region", cmd: FindCmd{Pattern: "db", Regions: []string{"us-east-1", "eu-west-1"}}, wantErr: "searching other regions is not available"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			appCtx := newFindTestContext(t, entries)
+			appCtx.Output = tc.output
+			var out, errOut bytes.Buffer
+			cmd := tc.cmd
+			if cmd.Backend == nil {
+				cmd.Backend = []string{"ps", "sm"}
+			}
+			cmd.out, cmd.errOut = &out, &errOut
+			err := cmd.Run(appCtx)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Run() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output = %q, want %q", out.String(), tc.want)
+			}
+			if strings.Contains(out.String(), "db.internal") {
+				t.Error("values must never be printed")
+			}
+		})
+	}
+}
+
+// FIND-C: a fresh cache is used instead of a live listing; a stale one is refreshed
+func TestFindCmd_Cache(t *testing.T) {
+	appCtx := newFindTestContext(t, map[string]string{"ps:/live/db_host": "x"})
+
+	tests := []struct {
+		id         string
+		refreshed  time.Time
+		scope      string
+		noScope    bool
+		noCache    bool
+		want       string
+		wantWrites int
+	}{
+		{id: "fresh", refreshed: time.Now().Add(-time.Minute), want: "ps:/cached/db_host\n"},
+		{id: "partial", refreshed: time.Now().Add(-time.Minute), scope: "/cached/", want: "ps:/live/db_host\n", wantWrites: 1},
+		{id: "unknown scope", refreshed: time.Now().Add(-time.Minute), noScope: true, want: "ps:/live/db_host\n", wantWrites: 1},
+		{id: "stale", refreshed: time.Now().Add(-time.Hour), want: "ps:/live/db_host\n", wantWrites: 1},
+		{id: "no-cache", refreshed: time.Now(), noCache: true, want: "ps:/live/db_host\n", wantWrites: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			store := &MockStore{
+				ReadFunc: func(string) ([]cache.CacheEntry, error) {
+					return []cache.CacheEntry{{Path: "/cached/db_host"}}, nil
+				},
+				LastRefreshedAtFunc: func(string) time.Time { return tc.refreshed },
+				ScopeFunc:           func(string) (string, bool) { return tc.scope, !tc.noScope },
+			}
+			appCtx.CacheStore = store
+			var out, errOut bytes.Buffer
+			cmd := FindCmd{Pattern: "db", Backend: []string{"ps"}, NoCache: tc.noCache, MaxCacheAge: 5 * time.Minute, out: &out, errOut: &errOut}
+			if err := cmd.Run(appCtx); err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output = %q, want %q", out.String(), tc.want)
+			}
+			if len(store.WriteCalls) != tc.wantWrites {
+				t.Errorf("cache writes = %d, want %d", len(store.WriteCalls), tc.wantWrites)
+			}
+		})
+	}
+}
+
+// FIND-C2: a cache that ls wrote for one prefix does not stand in for the whole backend
+func TestFindCmd_PartialCache(t *testing.T) {
+	appCtx := newFindTestContext(t, map[string]string{
+		"ps:/app/prod/db_host": "x",
+		"ps:/other/db_name":    "y",
+	})
+	appCtx.CacheStore = cache.NewFileStoreWithDir(t.TempDir())
+
+	ls := &LsCmd{From: "ps:/app/", Recursive: true, out: &bytes.Buffer{}}
+	if err := ls.Run(appCtx); err != nil {
+		t.Fatalf("ls Run() error: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := FindCmd{Pattern: "db", Backend: []string{"ps"}, MaxCacheAge: 5 * time.Minute, out: &out, errOut: &bytes.Buffer{}}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("find Run() error: %v", err)
+	}
+	if want := "ps:/app/prod/db_host\nps:/other/db_name\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	// 全体の一覧で書き直されたキャッシュは --prefix 検索にも使える
+	out.Reset()
+	var errOut bytes.Buffer
+	cmd = FindCmd{Pattern: "db", Prefix: "ps:/other/", MaxCacheAge: 5 * time.Minute, out: &out, errOut: &errOut}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("find --prefix Run() error: %v", err)
+	}
+	if out.String() != "ps:/other/db_name\n" || !strings.Contains(errOut.String(), "completion cache") {
+		t.Errorf("find --prefix output = %q, stderr = %q; want ps:/other/db_name from the cache", out.String(), errOut.String())
+	}
+}
+
+// FIND-R: several regions are searched through RegionContext
+func TestFindCmd_Regions(t *testing.T) {
+	appCtx := newFindTestContext(t, map[string]string{"ps:/app/db_host": "x"})
+	appCtx.Config.AWS.Region = "us-east-1"
+	appCtx.Config.AWS.Regions = []string{"us-east-1", "eu-west-1"}
+	euCtx := newFindTestContext(t, map[string]string{"ps:/eu/db_host": "y"})
+	appCtx.RegionContext = func(region string) (*Context, error) {
+		if region != "eu-west-1" {
+			t.Fatalf("unexpected region %q", region)
+		}
+		return euCtx, nil
+	}
+
+	var out bytes.Buffer
+	cmd := FindCmd{Pattern: "db", Backend: []string{"ps"}, out: &out, errOut: &bytes.Buffer{}}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	want := "ps:/app/db_host\nps:/eu/db_host\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	// リージョンは --output のレコードにだけ入る
+	out.Reset()
+	appCtx.Output = OutputJSONL
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("Run(-o jsonl) error: %v", err)
+	}
+	want = `{"ref":"ps:/eu/db_host","region":"eu-west-1"}` + "\n" + `{"ref":"ps:/app/db_host","region":"us-east-1"}` + "\n"
+	if out.String() != want {
+		t.Errorf("jsonl output = %q, want %q", out.String(), want)
+	}
+}
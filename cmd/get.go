@@ -1,36 +1,59 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/jsonquery"
 )
 
 // GetCmd represents the "get" subcommand.
 type GetCmd struct {
-	Ref      string `arg:"" predictor:"ref" help:"Target ref (e.g. ps:/app/prod/DB_HOST, sm:secret-id)"`
-	Raw      bool   `help:"Force raw output (ignore cli-store-mode tag)"`
-	JSON     bool   `name:"json" help:"Force JSON decode output"`
-	Describe bool   `name:"describe" help:"Show metadata as JSON instead of value"`
+	Ref      string   `arg:"" predictor:"ref" help:"Target ref (e.g. ps:/app/prod/DB_HOST, sm:secret-id, sm:prod/db#password); '-' reads refs from stdin"`
+	Refs     []string `arg:"" optional:"" predictor:"ref" help:"More refs to fetch in the same batch"`
+	Raw      bool     `help:"Force raw output (ignore cli-store-mode tag)"`
+	JSON     bool     `name:"json" help:"Force JSON decode output"`
+	Describe bool     `name:"describe" help:"Show metadata as JSON instead of value"`
+	Field    string   `predictor:"field" help:"Print a top-level key of the JSON value (strings are printed unquoted)"`
+	Query    string   `short:"q" help:"Print the result of a jq-style path (e.g. '.replicas[0].host') applied to the JSON value"`
+
+	in io.Reader // for testing; nil means os.Stdin
 }
 
 // Run executes the get command.
 func (c *GetCmd) Run(appCtx *Context) error {
+	if len(c.Refs) > 0 || isStdio(c.Ref) {
+		return c.runBatch(appCtx)
+	}
+
 	ref, err := backend.ParseRef(c.Ref)
 	if err != nil {
 		return fmt.Errorf("get command failed: invalid ref: %w", err)
 	}
 
+	query, hasQuery, err := c.selector(ref)
+	if err != nil {
+		return fmt.Errorf("get command failed: %w", err)
+	}
+	if hasQuery && (c.Describe || ref.IsPrefix()) {
+		return fmt.Errorf("get command failed: field selection cannot be used with --describe or a prefix")
+	}
+
 	b, err := appCtx.BackendFactory(ref.Type)
 	if err != nil {
 		return fmt.Errorf("get command failed: create backend: %w", err)
 	}
 
-	// prefix モード（末尾 / の場合）
-	if strings.HasSuffix(ref.Path, "/") {
+	// prefix モード（末尾 / や env:APP_ の場合）
+	if ref.IsPrefix() {
+		if appCtx.Output != "" {
+			return c.writePrefix(appCtx.Output, b, ref)
+		}
 		entries, err := b.GetByPrefix(context.Background(), ref.Path, backend.GetByPrefixOptions{Recursive: true})
 		if err != nil {
 			return fmt.Errorf("get command failed: %w", err)
@@ -51,6 +74,13 @@ func (c *GetCmd) Run(appCtx *Context) error {
 		if err != nil {
 			return fmt.Errorf("get command failed: %w", err)
 		}
+		if appCtx.Output != "" {
+			rec := record{"ref": c.Ref}
+			for k, v := range meta {
+				rec[k] = v
+			}
+			return writeSingle(os.Stdout, appCtx.Output, rec, "ref")
+		}
 		return printJSON(os.Stdout, meta)
 	}
 
@@ -59,11 +89,213 @@ func (c *GetCmd) Run(appCtx *Context) error {
 		ForceJSON: c.JSON,
 	}
 
-	val, err := b.Get(context.Background(), c.Ref, opts)
+	baseRef, _ := backend.SplitField(c.Ref)
+	val, err := b.Get(context.Background(), baseRef, opts)
 	if err != nil {
 		return fmt.Errorf("get command failed: %w", err)
 	}
 
+	if hasQuery {
+		if val, err = jsonquery.Extract(val, query); err != nil {
+			return fmt.Errorf("get command failed: %s: %w", c.Ref, err)
+		}
+	}
+
+	if appCtx.Output != "" {
+		key := refKeyName(ref)
+		if hasQuery && query.Name() != "" {
+			key = query.Name()
+		}
+		return writeSingle(os.Stdout, appCtx.Output, record{"ref": c.Ref, "key": key, "value": val}, "ref", "key", "value")
+	}
+
 	fmt.Println(val)
 	return nil
 }
+
+// writePrefix writes every parameter under the prefix as a {ref, key, value} record.
+// Records are written as pages arrive, so --output jsonl streams large prefixes.
+func (c *GetCmd) writePrefix(format string, b backend.Backend, ref backend.Ref) error {
+	rw := newRecordWriter(os.Stdout, format, "ref", "key", "value")
+	err := backend.WalkPrefix(context.Background(), b, ref.Path, backend.GetByPrefixOptions{Recursive: true}, func(e backend.ParameterEntry) error {
+		key := strings.TrimPrefix(e.Path, ref.Path)
+		if key == "" {
+			return nil
+		}
+		return rw.Write(record{"ref": string(ref.Type) + ":" + e.Path, "key": key, "value": e.Value})
+	})
+	if err != nil {
+		return fmt.Errorf("get command failed: %w", err)
+	}
+	return rw.Close()
+}
+
+// selector returns the field query given by ref#field, --field or --query.
+// At most one of them may be used.
+func (c *GetCmd) selector(ref backend.Ref) (jsonquery.Query, bool, error) {
+	n := 0
+	for _, s := range []string{ref.Field, c.Field, c.Query} {
+		if s != "" {
+			n++
+		}
+	}
+	switch {
+	case n > 1:
+		return jsonquery.Query{}, false, fmt.Errorf("use only one of ref#field, --field and --query")
+	case c.Field != "":
+		return jsonquery.Key(c.Field), true, nil
+	case c.Query != "":
+		q, err := jsonquery.Parse(c.Query)
+		return q, err == nil, err
+	case ref.Field != "":
+		q, err := jsonquery.Parse(ref.Field)
+		return q, err == nil, err
+	}
+	return jsonquery.Query{}, false, nil
+}
+
+// runBatch fetches several refs in one invocation. Refs of the same backend are fetched
+// together (GetParameters / BatchGetSecretValue), and every missing ref is reported in a
+// single error after the values that were found have been written.
+func (c *GetCmd) runBatch(appCtx *Context) error {
+	if c.Describe {
+		return fmt.Errorf("get command failed: --describe cannot be used with multiple refs")
+	}
+	rawRefs, err := c.batchRefs()
+	if err != nil {
+		return fmt.Errorf("get command failed: %w", err)
+	}
+	if len(rawRefs) == 0 {
+		return fmt.Errorf("get command failed: no refs given")
+	}
+
+	type item struct {
+		raw      string
+		ref      backend.Ref
+		base     string
+		query    jsonquery.Query
+		hasQuery bool
+	}
+	var items []item
+	var order []backend.BackendType
+	bases := make(map[backend.BackendType][]string)
+	seen := make(map[string]bool)
+	for _, raw := range rawRefs {
+		if seen[raw] {
+			continue
+		}
+		seen[raw] = true
+		ref, err := backend.ParseRef(raw)
+		if err != nil {
+			return fmt.Errorf("get command failed: invalid ref %q: %w", raw, err)
+		}
+		if ref.IsPrefix() {
+			return fmt.Errorf("get command failed: %s: a prefix cannot be used with multiple refs", raw)
+		}
+		query, hasQuery, err := c.selector(ref)
+		if err != nil {
+			return fmt.Errorf("get command failed: %s: %w", raw, err)
+		}
+		base, _ := backend.SplitField(raw)
+		if _, ok := bases[ref.Type]; !ok {
+			order = append(order, ref.Type)
+		}
+		bases[ref.Type] = append(bases[ref.Type], base)
+		items = append(items, item{raw: raw, ref: ref, base: base, query: query, hasQuery: hasQuery})
+	}
+
+	// バックエンドごとにまとめて取得する
+	opts := backend.GetOptions{ForceRaw: c.Raw, ForceJSON: c.JSON}
+	values := make(map[string]string)
+	missing := make(map[string]bool)
+	for _, bt := range order {
+		b, err := appCtx.BackendFactory(bt)
+		if err != nil {
+			return fmt.Errorf("get command failed: create backend: %w", err)
+		}
+		got, notFound, err := backend.GetMany(context.Background(), b, bases[bt], opts)
+		if err != nil {
+			return fmt.Errorf("get command failed: %w", err)
+		}
+		for k, v := range got {
+			values[k] = v
+		}
+		for _, r := range notFound {
+			missing[r] = true
+		}
+	}
+
+	result := make(map[string]string, len(items))
+	var records []record
+	var notFound, problems []string
+	for _, it := range items {
+		val, ok := values[it.base]
+		if !ok || missing[it.base] {
+			notFound = append(notFound, it.raw)
+			continue
+		}
+		key := refKeyName(it.ref)
+		if it.hasQuery {
+			if val, err = jsonquery.Extract(val, it.query); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", it.raw, err))
+				continue
+			}
+			if it.query.Name() != "" {
+				key = it.query.Name()
+			}
+		}
+		result[it.raw] = val
+		records = append(records, record{"ref": it.raw, "key": key, "value": val})
+	}
+
+	if appCtx.Output != "" {
+		rw := newRecordWriter(os.Stdout, appCtx.Output, "ref", "key", "value")
+		for _, r := range records {
+			if err := rw.Write(r); err != nil {
+				return fmt.Errorf("get command failed: %w", err)
+			}
+		}
+		if err := rw.Close(); err != nil {
+			return fmt.Errorf("get command failed: %w", err)
+		}
+	} else if err := printJSON(os.Stdout, result); err != nil {
+		return fmt.Errorf("get command failed: %w", err)
+	}
+
+	failed := len(notFound) + len(problems)
+	if len(notFound) > 0 {
+		problems = append([]string{"not found: " + strings.Join(notFound, ", ")}, problems...)
+	}
+	if failed > 0 {
+		return fmt.Errorf("get command failed: %d of %d refs could not be read: %s", failed, len(items), strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// batchRefs returns the refs given on the command line, expanding "-" to the
+// newline-separated refs read from stdin (blank lines and # comments are skipped).
+func (c *GetCmd) batchRefs() ([]string, error) {
+	var refs []string
+	for _, raw := range append([]string{c.Ref}, c.Refs...) {
+		if !isStdio(raw) {
+			refs = append(refs, raw)
+			continue
+		}
+		in := c.in
+		if in == nil {
+			in = os.Stdin
+		}
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			refs = append(refs, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read refs from stdin: %w", err)
+		}
+	}
+	return refs, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/youyo/bundr/internal/backend"
@@ -218,6 +219,42 @@ func TestGetCmd_Describe_PS(t *testing.T) {
 	}
 }
 
+// GET-D-01b: get --describe shows the cli-flatten policy in Tags
+func TestGetCmd_Describe_FlattenTag(t *testing.T) {
+	mock := backend.NewMockBackend()
+	ctx := context.Background()
+
+	_ = mock.Put(ctx, "ps:/app/config", backend.PutOptions{
+		Value:     `{"a":1}`,
+		StoreMode: tags.StoreModeRaw,
+		Tags:      map[string]string{tags.TagFlatten: "depth=1"},
+	})
+
+	appCtx := &Context{
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) {
+			return mock, nil
+		},
+	}
+
+	cmd := &GetCmd{Ref: "ps:/app/config", Describe: true}
+
+	output := captureStdout(t, func() {
+		if err := cmd.Run(appCtx); err != nil {
+			t.Fatalf("Run() error: %v", err)
+		}
+	})
+
+	var result struct {
+		Tags map[string]string `json:"Tags"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("output is not valid JSON: %v\noutput: %s", err, output)
+	}
+	if result.Tags[tags.TagFlatten] != "depth=1" {
+		t.Errorf("Tags[cli-flatten] = %q, want %q", result.Tags[tags.TagFlatten], "depth=1")
+	}
+}
+
 // GET-D-02: get --describe with SM ref outputs valid JSON
 func TestGetCmd_Describe_SM(t *testing.T) {
 	mock := backend.NewMockBackend()
@@ -378,3 +415,175 @@ func captureStdout(t *testing.T, fn func()) string {
 	}
 	return buf.String()
 }
+
+// GET-F-01: --field / --query / ref#field extract values from a JSON document
+func TestGetCmd_FieldSelection(t *testing.T) {
+	mock := backend.NewMockBackend()
+	ctx := context.Background()
+	_ = mock.Put(ctx, "sm:prod/db", backend.PutOptions{
+		Value:     `{"password":"s3cr3t","port":5432,"replicas":[{"host":"r1"},{"host":"r2"}]}`,
+		StoreMode: tags.StoreModeRaw,
+	})
+
+	appCtx := &Context{
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) {
+			return mock, nil
+		},
+	}
+
+	tests := []struct {
+		id      string
+		cmd     GetCmd
+		want    string
+		wantErr bool
+	}{
+		{id: "field", cmd: GetCmd{Ref: "sm:prod/db", Field: "password"}, want: "s3cr3t\n"},
+		{id: "field-number", cmd: GetCmd{Ref: "sm:prod/db", Field: "port"}, want: "5432\n"},
+		{id: "query", cmd: GetCmd{Ref: "sm:prod/db", Query: ".replicas[1].host"}, want: "r2\n"},
+		{id: "query-iterate", cmd: GetCmd{Ref: "sm:prod/db", Query: ".replicas[].host"}, want: "r1\nr2\n"},
+		{id: "ref-fragment", cmd: GetCmd{Ref: "sm:prod/db#replicas[0]"}, want: "{\"host\":\"r1\"}\n"},
+		{id: "missing-key", cmd: GetCmd{Ref: "sm:prod/db", Field: "nope"}, wantErr: true},
+		{id: "conflict", cmd: GetCmd{Ref: "sm:prod/db#password", Field: "port"}, wantErr: true},
+		{id: "with-describe", cmd: GetCmd{Ref: "sm:prod/db", Field: "password", Describe: true}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			var runErr error
+			output := captureStdout(t, func() {
+				runErr = tc.cmd.Run(appCtx)
+			})
+			if (runErr != nil) != tc.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", runErr, tc.wantErr)
+			}
+			if !tc.wantErr && output != tc.want {
+				t.Errorf("output = %q, want %q", output, tc.want)
+			}
+		})
+	}
+}
+
+// GET-O-01: --output applies to single values, prefixes and --describe
+func TestGetCmd_Output(t *testing.T) {
+	mock := backend.NewMockBackend()
+	ctx := context.Background()
+	_ = mock.Put(ctx, "ps:/app/db_host", backend.PutOptions{Value: "localhost", StoreMode: tags.StoreModeRaw})
+	_ = mock.Put(ctx, "ps:/app/db_port", backend.PutOptions{Value: "5432", StoreMode: tags.StoreModeRaw})
+
+	appCtx := &Context{
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) {
+			return mock, nil
+		},
+	}
+
+	equals := func(want string) func(string) bool {
+		return func(out string) bool { return out == want }
+	}
+	containsAll := func(subs ...string) func(string) bool {
+		return func(out string) bool {
+			for _, s := range subs {
+				if !strings.Contains(out, s) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	tests := []struct {
+		id     string
+		cmd    GetCmd
+		output string
+		check  func(string) bool
+	}{
+		{id: "single-json", cmd: GetCmd{Ref: "ps:/app/db_host"}, output: OutputJSON,
+			check: equals("{\n  \"key\": \"db_host\",\n  \"ref\": \"ps:/app/db_host\",\n  \"value\": \"localhost\"\n}\n")},
+		{id: "single-env", cmd: GetCmd{Ref: "ps:/app/db_host"}, output: OutputEnv, check: equals("DB_HOST=localhost\n")},
+		// prefix output streams in backend order
+		{id: "prefix-env", cmd: GetCmd{Ref: "ps:/app/"}, output: OutputEnv, check: containsAll("DB_HOST=localhost\n", "DB_PORT=5432\n")},
+		{id: "prefix-tsv", cmd: GetCmd{Ref: "ps:/app/"}, output: OutputTSV, check: containsAll("ref\tkey\tvalue\n", "ps:/app/db_port\tdb_port\t5432\n")},
+		{id: "describe-yaml", cmd: GetCmd{Ref: "ps:/app/db_host", Describe: true}, output: OutputYAML, check: containsAll("ref: ps:/app/db_host\n", "cli-store-mode: raw\n")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			appCtx.Output = tc.output
+			output := captureStdout(t, func() {
+				if err := tc.cmd.Run(appCtx); err != nil {
+					t.Fatalf("Run() error: %v", err)
+				}
+			})
+			if !tc.check(output) {
+				t.Errorf("unexpected output: %q", output)
+			}
+		})
+	}
+}
+
+// GET-B-01: several refs (or '-' for stdin) are fetched as one batch keyed by ref
+func TestGetCmd_Batch(t *testing.T) {
+	mock := backend.NewMockBackend()
+	ctx := context.Background()
+	_ = mock.Put(ctx, "ps:/app/db_host", backend.PutOptions{Value: "localhost", StoreMode: tags.StoreModeRaw})
+	_ = mock.Put(ctx, "ps:/app/db_port", backend.PutOptions{Value: "5432", StoreMode: tags.StoreModeRaw})
+	_ = mock.Put(ctx, "sm:prod/db", backend.PutOptions{Value: `{"password":"s3cr3t"}`, StoreMode: tags.StoreModeRaw})
+
+	tests := []struct {
+		id      string
+		cmd     GetCmd
+		output  string
+		want    string
+		wantErr string
+	}{
+		{
+			id:   "args",
+			cmd:  GetCmd{Ref: "ps:/app/db_host", Refs: []string{"ps:/app/db_port", "sm:prod/db#password"}},
+			want: "{\n  \"ps:/app/db_host\": \"localhost\",\n  \"ps:/app/db_port\": \"5432\",\n  \"sm:prod/db#password\": \"s3cr3t\"\n}\n",
+		},
+		{
+			id:   "stdin",
+			cmd:  GetCmd{Ref: "-", in: strings.NewReader("ps:/app/db_host\n\n# comment\nps:/app/db_port\n")},
+			want: "{\n  \"ps:/app/db_host\": \"localhost\",\n  \"ps:/app/db_port\": \"5432\"\n}\n",
+		},
+		{
+			id:      "output-env",
+			cmd:     GetCmd{Ref: "ps:/app/db_host", Refs: []string{"sm:prod/db"}, Field: "password"},
+			output:  OutputEnv,
+			wantErr: "1 of 2 refs could not be read: ps:/app/db_host: value is not valid JSON",
+			want:    "PASSWORD=s3cr3t\n",
+		},
+		{
+			id:      "missing-reported-together",
+			cmd:     GetCmd{Ref: "ps:/app/nope", Refs: []string{"ps:/app/db_host", "sm:gone"}},
+			want:    "{\n  \"ps:/app/db_host\": \"localhost\"\n}\n",
+			wantErr: "2 of 3 refs could not be read: not found: ps:/app/nope, sm:gone",
+		},
+		{
+			id:      "prefix-rejected",
+			cmd:     GetCmd{Ref: "ps:/app/", Refs: []string{"ps:/app/db_host"}},
+			wantErr: "a prefix cannot be used with multiple refs",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			mock.GetCalls = nil
+			appCtx := &Context{
+				BackendFactory: func(_ backend.BackendType) (backend.Backend, error) {
+					return mock, nil
+				},
+				Output: tc.output,
+			}
+			var runErr error
+			output := captureStdout(t, func() {
+				runErr = tc.cmd.Run(appCtx)
+			})
+			if tc.wantErr == "" && runErr != nil {
+				t.Fatalf("Run() error: %v", runErr)
+			}
+			if tc.wantErr != "" && (runErr == nil || !strings.Contains(runErr.Error(), tc.wantErr)) {
+				t.Fatalf("Run() error = %v, want containing %q", runErr, tc.wantErr)
+			}
+			if output != tc.want {
+				t.Errorf("output = %q, want %q", output, tc.want)
+			}
+		})
+	}
+}
@@ -7,8 +7,9 @@ import (
 )
 
 // printJSON marshals v to indented JSON and writes it to w followed by a newline.
+// Timestamps are normalized to RFC 3339 in UTC (see normalizeValue).
 func printJSON(w io.Writer, v any) error {
-	out, err := json.MarshalIndent(v, "", "  ")
+	out, err := json.MarshalIndent(normalizeValue(v), "", "  ")
 	if err != nil {
 		return fmt.Errorf("json marshal: %w", err)
 	}
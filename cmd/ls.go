@@ -7,6 +7,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/youyo/bundr/internal/backend"
 )
@@ -17,7 +18,18 @@ type LsCmd struct {
 	Recursive bool   `name:"recursive" help:"List all parameters recursively (default: next-level view only)"`
 	Describe  bool   `name:"describe" help:"Show metadata as JSON array instead of refs"`
 
-	out io.Writer // for testing; nil means os.Stdout
+	Tag            []string `name:"tag" sep:"none" placeholder:"KEY=VALUE" help:"Only list entries with this tag (repeatable)"`
+	Type           string   `name:"type" enum:"String,StringList,SecureString," default:"" placeholder:"TYPE" help:"Only list Parameter Store entries of this type"`
+	ModifiedSince  string   `name:"modified-since" placeholder:"AGE|DATE" help:"Only list entries modified within AGE (e.g. 30d, 12h) or since DATE (2006-01-02 or RFC 3339)"`
+	ModifiedBefore string   `name:"modified-before" placeholder:"AGE|DATE" help:"Only list entries last modified more than AGE ago or before DATE"`
+	Managed        bool     `name:"managed" xor:"managed" help:"Only list entries managed by bundr (cli=bundr tag)"`
+	Unmanaged      bool     `name:"unmanaged" xor:"managed" help:"Only list entries without the cli=bundr tag"`
+	Long           bool     `short:"l" name:"long" xor:"view" help:"Long listing: type, tier, version, last modified and store mode"`
+	Size           bool     `name:"size" help:"With -l, add a size column (reads every value)"`
+	Tree           bool     `name:"tree" xor:"view" help:"Render the hierarchy as a tree (always recursive)"`
+
+	out io.Writer        // for testing; nil means os.Stdout
+	now func() time.Time // for testing; nil means time.Now
 }
 
 // Run executes the ls command.
@@ -36,6 +48,9 @@ func (c *LsCmd) Run(appCtx *Context) error {
 		if parseErr != nil {
 			return fmt.Errorf("ls command failed: invalid ref: %w", parseErr)
 		}
+		if ref.Field != "" {
+			return fmt.Errorf("ls command failed: a #field selector cannot be used with a prefix")
+		}
 	}
 
 	b, err := appCtx.BackendFactory(ref.Type)
@@ -43,64 +58,90 @@ func (c *LsCmd) Run(appCtx *Context) error {
 		return fmt.Errorf("ls command failed: create backend: %w", err)
 	}
 
-	if c.Describe {
-		return c.runDescribe(context.Background(), b, ref)
+	if c.Size && !c.Long {
+		return fmt.Errorf("ls command failed: --size requires -l")
 	}
-
-	entries, err := b.GetByPrefix(context.Background(), ref.Path, backend.GetByPrefixOptions{
-		Recursive:    true, // 常に全取得（次レベル表示に必要）
-		SkipTagFetch: true,
-	})
-	if err != nil {
-		return fmt.Errorf("ls command failed: %w", err)
+	if c.filtered() {
+		if c.Describe {
+			return fmt.Errorf("ls command failed: --describe cannot be combined with filters, -l or --tree")
+		}
+		return c.runFiltered(context.Background(), b, ref, appCtx.Output)
 	}
 
-	// コマンド実行後に即時キャッシュへ書き込む（Tab 補完の初回キャッシュミスを防ぐ）
-	if appCtx.CacheStore != nil {
-		_ = appCtx.CacheStore.Write(string(ref.Type), toCacheEntries(entries))
+	if c.Describe {
+		return c.runDescribe(context.Background(), b, ref, appCtx.Output)
 	}
 
+	// jsonl は受信したページ順にそのまま出力する（大きな prefix でもメモリに溜めない）
+	streaming := appCtx.Output == OutputJSONL
+	rw := newRecordWriter(c.out, appCtx.Output, "ref")
+
 	var refs []string
-	if c.Recursive {
-		// --recursive: 全パラメータをフラット表示
-		refs = make([]string, 0, len(entries))
-		for _, entry := range entries {
-			refs = append(refs, string(ref.Type)+":"+entry.Path)
-		}
-	} else {
-		// デフォルト: 次レベルのみ（ディレクトリ表示）
-		// ref.Path == "" は SM の全シークレット（プレフィックスなし）
-		// ref.Path != "" は PS/SM のパスプレフィックス（末尾 / で正規化）
-		var normalizedPrefix string
-		if ref.Path != "" {
-			normalizedPrefix = strings.TrimRight(ref.Path, "/") + "/"
-		}
-		seen := make(map[string]bool)
-		refs = make([]string, 0)
-		for _, entry := range entries {
+	var entries []backend.ParameterEntry
+	seen := make(map[string]bool)
+	// ref.Path == "" は SM の全シークレット（プレフィックスなし）
+	// ref.Path != "" は PS/SM のパスプレフィックス（末尾 / で正規化）
+	var normalizedPrefix string
+	if ref.Path != "" {
+		normalizedPrefix = strings.TrimRight(ref.Path, "/") + "/"
+	}
+	err = backend.WalkPrefix(context.Background(), b, ref.Path, backend.GetByPrefixOptions{
+		Recursive:    true, // 常に全取得（次レベル表示に必要）
+		SkipTagFetch: true,
+	}, func(entry backend.ParameterEntry) error {
+		entries = append(entries, backend.ParameterEntry{Path: entry.Path, StoreMode: entry.StoreMode})
+
+		var key string
+		if c.Recursive {
+			// --recursive: 全パラメータをフラット表示
+			key = string(ref.Type) + ":" + entry.Path
+		} else {
+			// デフォルト: 次レベルのみ（ディレクトリ表示）
 			var rel string
 			if normalizedPrefix == "" {
 				rel = entry.Path
 			} else {
 				if !strings.HasPrefix(entry.Path, normalizedPrefix) {
-					continue
+					return nil
 				}
 				rel = strings.TrimPrefix(entry.Path, normalizedPrefix)
 			}
-			var key string
 			if idx := strings.Index(rel, "/"); idx == -1 {
 				key = string(ref.Type) + ":" + entry.Path
 			} else {
 				key = string(ref.Type) + ":" + normalizedPrefix + rel[:idx]
 			}
-			if !seen[key] {
-				seen[key] = true
-				refs = append(refs, key)
-			}
 		}
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+		if streaming {
+			return rw.Write(record{"ref": key})
+		}
+		refs = append(refs, key)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ls command failed: %w", err)
 	}
+
+	// コマンド実行後に即時キャッシュへ書き込む（Tab 補完の初回キャッシュミスを防ぐ）
+	if appCtx.CacheStore != nil {
+		_ = appCtx.CacheStore.Write(string(ref.Type), ref.Path, toCacheEntries(entries))
+	}
+
 	sort.Strings(refs)
 
+	if appCtx.Output != "" {
+		for _, r := range refs {
+			if err := rw.Write(record{"ref": r}); err != nil {
+				return fmt.Errorf("ls command failed: %w", err)
+			}
+		}
+		return rw.Close()
+	}
+
 	for _, r := range refs {
 		fmt.Fprintln(c.out, r)
 	}
@@ -108,13 +149,35 @@ func (c *LsCmd) Run(appCtx *Context) error {
 	return nil
 }
 
-// runDescribe outputs entries as a JSON array with "ref" + metadata fields.
-func (c *LsCmd) runDescribe(ctx context.Context, b backend.Backend, ref backend.Ref) error {
-	entries, err := b.GetByPrefix(ctx, ref.Path, backend.GetByPrefixOptions{
+// runDescribe outputs entries as a JSON array with "ref" + metadata fields,
+// or as records in the --output format.
+func (c *LsCmd) runDescribe(ctx context.Context, b backend.Backend, ref backend.Ref, format string) error {
+	opts := backend.GetByPrefixOptions{
 		Recursive:       c.Recursive,
 		SkipTagFetch:    false,
 		IncludeMetadata: true,
-	})
+	}
+	toRecord := func(entry backend.ParameterEntry) record {
+		m := make(record, len(entry.Metadata)+1)
+		m["ref"] = string(ref.Type) + ":" + entry.Path
+		for k, v := range entry.Metadata {
+			m[k] = v
+		}
+		return m
+	}
+
+	if format != "" {
+		rw := newRecordWriter(c.out, format, "ref")
+		err := backend.WalkPrefix(ctx, b, ref.Path, opts, func(entry backend.ParameterEntry) error {
+			return rw.Write(toRecord(entry))
+		})
+		if err != nil {
+			return fmt.Errorf("ls command failed: %w", err)
+		}
+		return rw.Close()
+	}
+
+	entries, err := b.GetByPrefix(ctx, ref.Path, opts)
 	if err != nil {
 		return fmt.Errorf("ls command failed: %w", err)
 	}
@@ -124,14 +187,9 @@ func (c *LsCmd) runDescribe(ctx context.Context, b backend.Backend, ref backend.
 		return entries[i].Path < entries[j].Path
 	})
 
-	result := make([]map[string]any, 0, len(entries))
+	result := make([]record, 0, len(entries))
 	for _, entry := range entries {
-		m := make(map[string]any, len(entry.Metadata)+1)
-		m["ref"] = string(ref.Type) + ":" + entry.Path
-		for k, v := range entry.Metadata {
-			m[k] = v
-		}
-		result = append(result, m)
+		result = append(result, toRecord(entry))
 	}
 
 	return printJSON(c.out, result)
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// filtered reports whether any filter or view flag is set, which switches ls from
+// GetParametersByPath / ListSecrets to the metadata listing of backend.List.
+func (c *LsCmd) filtered() bool {
+	return len(c.Tag) > 0 || c.Type != "" || c.ModifiedSince != "" || c.ModifiedBefore != "" ||
+		c.Managed || c.Unmanaged || c.Long || c.Tree
+}
+
+// runFiltered lists the entries matching the filters. Tag, type and path filters are
+// pushed down to the backend; the modification time and --unmanaged are checked here.
+func (c *LsCmd) runFiltered(ctx context.Context, b backend.Backend, ref backend.Ref, format string) error {
+	if c.Tree && format != "" {
+		return fmt.Errorf("ls command failed: --tree cannot be used with --output")
+	}
+
+	opts := backend.ListOptions{
+		Recursive:      c.Recursive || c.Tree,
+		Type:           c.Type,
+		ResolveManaged: c.Unmanaged || c.Long,
+	}
+	if len(c.Tag) > 0 || c.Managed {
+		opts.Tags = make(map[string]string, len(c.Tag)+1)
+	}
+	for _, t := range c.Tag {
+		k, v, ok := strings.Cut(t, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("ls command failed: invalid --tag %q: expected KEY=VALUE", t)
+		}
+		opts.Tags[k] = v
+	}
+	if c.Managed {
+		opts.Tags[tags.TagCLI] = tags.TagCLIValue
+	}
+
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	since, err := parseTimeBound(c.ModifiedSince, now())
+	if err != nil {
+		return fmt.Errorf("ls command failed: invalid --modified-since: %w", err)
+	}
+	before, err := parseTimeBound(c.ModifiedBefore, now())
+	if err != nil {
+		return fmt.Errorf("ls command failed: invalid --modified-before: %w", err)
+	}
+
+	var entries []backend.ListEntry
+	err = backend.List(ctx, b, ref.Path, opts, func(e backend.ListEntry) error {
+		if c.Unmanaged && e.Managed {
+			return nil
+		}
+		if !since.IsZero() && (e.LastModified == nil || e.LastModified.Before(since)) {
+			return nil
+		}
+		if !before.IsZero() && (e.LastModified == nil || !e.LastModified.Before(before)) {
+			return nil
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ls command failed: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	refOf := func(e backend.ListEntry) string { return string(ref.Type) + ":" + e.Path }
+
+	switch {
+	case c.Tree:
+		paths := make([]string, len(entries))
+		for i, e := range entries {
+			paths[i] = e.Path
+		}
+		renderTree(c.out, c.From, ref.Path, paths)
+		return nil
+	case c.Long:
+		return c.writeLong(ctx, b, entries, refOf, format)
+	}
+
+	if format != "" {
+		rw := newRecordWriter(c.out, format, "ref")
+		for _, e := range entries {
+			if err := rw.Write(record{"ref": refOf(e)}); err != nil {
+				return fmt.Errorf("ls command failed: %w", err)
+			}
+		}
+		return rw.Close()
+	}
+	for _, e := range entries {
+		fmt.Fprintln(c.out, refOf(e))
+	}
+	return nil
+}
+
+// writeLong prints the long listing from the listing itself. With --size, the raw values
+// are also fetched in batches with backend.GetMany to add their sizes.
+func (c *LsCmd) writeLong(ctx context.Context, b backend.Backend, entries []backend.ListEntry, refOf func(backend.ListEntry) string, format string) error {
+	refs := make([]string, len(entries))
+	for i, e := range entries {
+		refs[i] = refOf(e)
+	}
+	columns := []string{"ref", "type", "tier", "version", "modified", "store_mode"}
+	var values map[string]string
+	if c.Size {
+		var err error
+		if values, _, err = backend.GetMany(ctx, b, refs, backend.GetOptions{ForceRaw: true}); err != nil {
+			return fmt.Errorf("ls command failed: %w", err)
+		}
+		columns = []string{"ref", "type", "tier", "version", "size", "modified", "store_mode"}
+	}
+
+	if format == "" {
+		format = OutputTable
+	}
+	rw := newRecordWriter(c.out, format, columns...)
+	for i, e := range entries {
+		r := record{
+			"ref":        refs[i],
+			"type":       e.Type,
+			"tier":       e.Tier,
+			"version":    nil,
+			"modified":   e.LastModified,
+			"store_mode": e.StoreMode,
+		}
+		if e.Version > 0 {
+			r["version"] = e.Version
+		}
+		if c.Size {
+			r["size"] = nil
+			if v, ok := values[refs[i]]; ok {
+				r["size"] = len(v)
+			}
+		}
+		if err := rw.Write(r); err != nil {
+			return fmt.Errorf("ls command failed: %w", err)
+		}
+	}
+	return rw.Close()
+}
+
+// parseTimeBound parses an age ("30d", "2w", "12h", any Go duration) relative to now,
+// or an absolute date ("2006-01-02" or RFC 3339). An empty string returns the zero time.
+func parseTimeBound(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if n, unit := s[:len(s)-1], s[len(s)-1]; unit == 'd' || unit == 'w' {
+		if days, err := strconv.Atoi(n); err == nil && days >= 0 {
+			if unit == 'w' {
+				days *= 7
+			}
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q: use an age such as 30d, 2w or 12h, or a date such as 2006-01-02", s)
+}
+
+// treeNode is one path segment of the --tree view.
+type treeNode struct {
+	children map[string]*treeNode
+	leaf     bool // a parameter exists at this path
+}
+
+// renderTree prints paths (all under prefix) as an indented tree rooted at label.
+func renderTree(w io.Writer, label, prefix string, paths []string) {
+	root := &treeNode{children: map[string]*treeNode{}}
+	normalizedPrefix := ""
+	if prefix != "" {
+		normalizedPrefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+	for _, p := range paths {
+		node := root
+		for _, seg := range strings.Split(strings.TrimPrefix(p, normalizedPrefix), "/") {
+			child, ok := node.children[seg]
+			if !ok {
+				child = &treeNode{children: map[string]*treeNode{}}
+				node.children[seg] = child
+			}
+			node = child
+		}
+		node.leaf = true
+	}
+
+	fmt.Fprintln(w, label)
+	writeTreeChildren(w, root, "")
+}
+
+// writeTreeChildren prints the children of node sorted by name. A segment that is both a
+// parameter and a parent of other parameters is shown twice: as "name" and "name/".
+func writeTreeChildren(w io.Writer, node *treeNode, indent string) {
+	type line struct {
+		name  string
+		child *treeNode // nil for a leaf line
+	}
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []line
+	for _, name := range names {
+		child := node.children[name]
+		if child.leaf {
+			lines = append(lines, line{name: name})
+		}
+		if len(child.children) > 0 {
+			lines = append(lines, line{name: name + "/", child: child})
+		}
+	}
+
+	for i, l := range lines {
+		branch, next := "├── ", "│   "
+		if i == len(lines)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintln(w, indent+branch+l.name)
+		if l.child != nil {
+			writeTreeChildren(w, l.child, indent+next)
+		}
+	}
+}
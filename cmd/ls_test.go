@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/youyo/bundr/internal/backend"
 	"github.com/youyo/bundr/internal/config"
@@ -322,3 +323,154 @@ func TestLsCmd_NilCacheStore(t *testing.T) {
 		t.Fatalf("Run() error: %v", err)
 	}
 }
+
+// L-OUT-01: --output で ref を構造化出力（jsonl は行ごと、table はヘッダー付き）
+func TestLsCmd_Output(t *testing.T) {
+	mb, appCtx := newLsTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/db_host", backend.PutOptions{Value: "localhost", StoreMode: tags.StoreModeRaw})
+	_ = mb.Put(ctx, "ps:/app/sub/key", backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw})
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, out string)
+	}{
+		{format: OutputJSONL, check: func(t *testing.T, out string) {
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want 2: %q", len(lines), out)
+			}
+			for _, l := range lines {
+				var rec map[string]any
+				if err := json.Unmarshal([]byte(l), &rec); err != nil {
+					t.Errorf("line %q is not JSON: %v", l, err)
+				}
+			}
+		}},
+		{format: OutputTable, check: func(t *testing.T, out string) {
+			want := "REF\nps:/app/db_host\nps:/app/sub\n"
+			if out != want {
+				t.Errorf("output = %q, want %q", out, want)
+			}
+		}},
+		{format: OutputEnv, check: nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			appCtx.Output = tc.format
+			cmd := &LsCmd{From: "ps:/app/", out: &buf}
+			err := cmd.Run(appCtx)
+			if tc.check == nil {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			tc.check(t, buf.String())
+		})
+	}
+}
+
+// L-F: filters, -l and --tree list entries through backend.List
+func TestLsCmd_Filters(t *testing.T) {
+	mb, appCtx := newLsTestContext(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	_ = mb.Put(ctx, "ps:/app/db_host", backend.PutOptions{Value: "localhost", StoreMode: tags.StoreModeRaw, Tags: map[string]string{"team": "core"}})
+	_ = mb.Put(ctx, "ps:/app/db_pass", backend.PutOptions{Value: "s3cr3t", StoreMode: tags.StoreModeJSON, ValueType: "secure"})
+	_ = mb.Put(ctx, "ps:/app/sub/legacy", backend.PutOptions{Value: "old", StoreMode: tags.StoreModeRaw})
+	mb.SetTags("ps:/app/sub/legacy", map[string]string{})
+	mb.SetLastModified("ps:/app/db_host", now.AddDate(0, 0, -2))
+	mb.SetLastModified("ps:/app/db_pass", now.AddDate(0, 0, -40))
+	mb.SetLastModified("ps:/app/sub/legacy", now.AddDate(-1, 0, 0))
+
+	tests := []struct {
+		id      string
+		cmd     LsCmd
+		want    string
+		wantErr string
+	}{
+		{id: "tag", cmd: LsCmd{Tag: []string{"team=core"}}, want: "ps:/app/db_host\n"},
+		{id: "type", cmd: LsCmd{Type: "SecureString", Recursive: true}, want: "ps:/app/db_pass\n"},
+		{id: "modified-since", cmd: LsCmd{ModifiedSince: "30d", Recursive: true}, want: "ps:/app/db_host\n"},
+		{id: "modified-before", cmd: LsCmd{ModifiedBefore: "2026-01-01", Recursive: true}, want: "ps:/app/sub/legacy\n"},
+		{id: "managed", cmd: LsCmd{Managed: true, Recursive: true}, want: "ps:/app/db_host\nps:/app/db_pass\n"},
+		{id: "unmanaged", cmd: LsCmd{Unmanaged: true, Recursive: true}, want: "ps:/app/sub/legacy\n"},
+		{
+			id:  "long",
+			cmd: LsCmd{Long: true},
+			want: "REF              TYPE          TIER      VERSION  MODIFIED              STORE_MODE\n" +
+				"ps:/app/db_host  String        Standard  1        2026-09-29T00:00:00Z  raw\n" +
+				"ps:/app/db_pass  SecureString  Standard  1        2026-08-22T00:00:00Z  json\n",
+		},
+		{
+			id:  "long-size",
+			cmd: LsCmd{Long: true, Size: true},
+			want: "REF              TYPE          TIER      VERSION  SIZE  MODIFIED              STORE_MODE\n" +
+				"ps:/app/db_host  String        Standard  1        9     2026-09-29T00:00:00Z  raw\n" +
+				"ps:/app/db_pass  SecureString  Standard  1        8     2026-08-22T00:00:00Z  json\n",
+		},
+		{
+			id:   "tree",
+			cmd:  LsCmd{Tree: true},
+			want: "ps:/app/\n├── db_host\n├── db_pass\n└── sub/\n    └── legacy\n",
+		},
+		{id: "invalid-tag", cmd: LsCmd{Tag: []string{"team"}}, wantErr: "expected KEY=VALUE"},
+		{id: "invalid-age", cmd: LsCmd{ModifiedSince: "soon"}, wantErr: "invalid --modified-since"},
+		{id: "describe", cmd: LsCmd{Describe: true, Tree: true}, wantErr: "--describe cannot be combined"},
+		{id: "size-without-long", cmd: LsCmd{Size: true}, wantErr: "--size requires -l"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := tc.cmd
+			cmd.From = "ps:/app/"
+			cmd.out = &buf
+			cmd.now = func() time.Time { return now }
+			err := cmd.Run(appCtx)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Run() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("output = %q, want %q", buf.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "", want: time.Time{}},
+		{in: "30d", want: now.AddDate(0, 0, -30)},
+		{in: "2w", want: now.AddDate(0, 0, -14)},
+		{in: "90m", want: now.Add(-90 * time.Minute)},
+		{in: "2026-01-02", want: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{in: "2026-01-02T03:04:05Z", want: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{in: "yesterday", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseTimeBound(tc.in, now)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseTimeBound(%q) error = %v, wantErr %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("parseTimeBound(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Output formats accepted by the global --output flag.
+// An empty format keeps each command's default output.
+const (
+	OutputJSON  = "json"
+	OutputJSONL = "jsonl"
+	OutputYAML  = "yaml"
+	OutputTable = "table"
+	OutputEnv   = "env"
+	OutputTSV   = "tsv"
+)
+
+// record is one item of structured output (a parameter, a metadata map, ...).
+// Conventional fields: "ref", "key" (name relative to the queried prefix) and "value".
+type record map[string]any
+
+// recordWriter renders records in one output format.
+// jsonl, env and tsv are written as each record arrives, so large prefixes stream;
+// json, yaml and table are buffered until Close.
+type recordWriter struct {
+	w       io.Writer
+	format  string
+	columns []string // leading columns in order; other fields follow sorted by name
+
+	buf    []record
+	header []string // tsv columns, fixed by the first record
+}
+
+// newRecordWriter returns a writer for format. columns are shown first in table
+// and tsv output.
+func newRecordWriter(w io.Writer, format string, columns ...string) *recordWriter {
+	return &recordWriter{w: w, format: format, columns: columns}
+}
+
+// Write renders or buffers a single record.
+func (rw *recordWriter) Write(r record) error {
+	r = normalizeValue(map[string]any(r)).(map[string]any)
+	switch rw.format {
+	case OutputJSONL:
+		out, err := marshalCompact(r)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(rw.w, out)
+		return err
+	case OutputEnv:
+		return writeEnvRecord(rw.w, r)
+	case OutputTSV:
+		if rw.header == nil {
+			rw.header = recordColumns(rw.columns, []record{r})
+			if _, err := fmt.Fprintln(rw.w, strings.Join(rw.header, "\t")); err != nil {
+				return err
+			}
+		}
+		cells := make([]string, len(rw.header))
+		for i, col := range rw.header {
+			cells[i] = escapeTSV(formatCell(r[col]))
+		}
+		_, err := fmt.Fprintln(rw.w, strings.Join(cells, "\t"))
+		return err
+	default:
+		rw.buf = append(rw.buf, r)
+		return nil
+	}
+}
+
+// Close flushes buffered formats. An empty result prints "[]" for json and yaml.
+// Buffered records are sorted by the first leading column; streamed formats keep
+// the order in which records arrived.
+func (rw *recordWriter) Close() error {
+	if len(rw.columns) > 0 {
+		first := rw.columns[0]
+		sort.SliceStable(rw.buf, func(i, j int) bool {
+			return formatCell(rw.buf[i][first]) < formatCell(rw.buf[j][first])
+		})
+	}
+	switch rw.format {
+	case OutputJSON:
+		list := rw.buf
+		if list == nil {
+			list = []record{}
+		}
+		return printJSON(rw.w, list)
+	case OutputYAML:
+		list := rw.buf
+		if list == nil {
+			list = []record{}
+		}
+		return writeYAML(rw.w, list)
+	case OutputTable:
+		if len(rw.buf) == 0 {
+			return nil
+		}
+		cols := recordColumns(rw.columns, rw.buf)
+		tw := tabwriter.NewWriter(rw.w, 0, 0, 2, ' ', 0)
+		header := make([]string, len(cols))
+		for i, c := range cols {
+			header[i] = strings.ToUpper(c)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, r := range rw.buf {
+			cells := make([]string, len(cols))
+			for i, c := range cols {
+				cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(formatCell(r[c]))
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// writeSingle renders one record: an object (not a list) for json and yaml,
+// the same as a one-item list for the other formats.
+func writeSingle(w io.Writer, format string, r record, columns ...string) error {
+	switch format {
+	case OutputJSON:
+		return printJSON(w, r)
+	case OutputYAML:
+		return writeYAML(w, normalizeValue(map[string]any(r)))
+	}
+	rw := newRecordWriter(w, format, columns...)
+	if err := rw.Write(r); err != nil {
+		return err
+	}
+	return rw.Close()
+}
+
+// recordColumns returns the leading columns followed by every other field name
+// found in records, sorted.
+func recordColumns(leading []string, records []record) []string {
+	cols := append([]string(nil), leading...)
+	seen := make(map[string]bool, len(leading))
+	for _, c := range leading {
+		seen[c] = true
+	}
+	var rest []string
+	for _, r := range records {
+		for k := range r {
+			if !seen[k] {
+				seen[k] = true
+				rest = append(rest, k)
+			}
+		}
+	}
+	sort.Strings(rest)
+	return append(cols, rest...)
+}
+
+// normalizeValue formats timestamps as RFC 3339 in UTC (nil stays nil) so that every
+// output format shows the same representation.
+func normalizeValue(v any) any {
+	switch val := v.(type) {
+	case time.Time:
+		return val.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return nil
+		}
+		return val.UTC().Format(time.RFC3339)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, e := range val {
+			out[k] = normalizeValue(e)
+		}
+		return out
+	case record:
+		return record(normalizeValue(map[string]any(val)).(map[string]any))
+	case []record:
+		out := make([]record, len(val))
+		for i, r := range val {
+			out[i] = normalizeValue(r).(record)
+		}
+		return out
+	case []map[string]any:
+		out := make([]map[string]any, len(val))
+		for i, m := range val {
+			out[i] = normalizeValue(m).(map[string]any)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// formatCell renders a field for table and tsv output.
+func formatCell(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case fmt.Stringer:
+		return val.String()
+	case map[string]any, map[string]string, []any, []string:
+		out, err := marshalCompact(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return out
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// escapeTSV escapes backslashes, tabs and newlines so each record stays on one line.
+func escapeTSV(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// writeEnvRecord writes a record as KEY=value. The key is derived from the "key" field
+// (uppercased, "/", ".", "-" → "_"); values are single-quoted when the shell needs it.
+func writeEnvRecord(w io.Writer, r record) error {
+	key, ok := r["key"].(string)
+	if !ok || key == "" {
+		return fmt.Errorf("--output env requires key/value output (not available for this command)")
+	}
+	value, ok := r["value"]
+	if !ok {
+		return fmt.Errorf("--output env requires key/value output (not available for this command)")
+	}
+	_, err := fmt.Fprintf(w, "%s=%s\n", envKey(key), shellQuote(formatCell(value)))
+	return err
+}
+
+// envKey converts a parameter key to an environment variable name.
+func envKey(key string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "_", ".", "_", "-", "_").Replace(key))
+}
+
+// shellQuote single-quotes s unless it only contains characters that are safe unquoted.
+func shellQuote(s string) string {
+	safe := s != ""
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-.,:/@%+=", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// marshalCompact encodes v as single-line JSON without HTML escaping.
+func marshalCompact(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// writeYAML encodes v as a YAML document.
+func writeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("yaml marshal: %w", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordWriter_Formats(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 12, 4, 5, 0, time.FixedZone("JST", 9*60*60))
+	records := []record{
+		{"ref": "ps:/app/db_port", "key": "db_port", "value": "5432"},
+		{"ref": "ps:/app/db_host", "key": "db_host", "value": "local host", "LastModifiedDate": &ts},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: OutputJSONL,
+			want: `{"key":"db_port","ref":"ps:/app/db_port","value":"5432"}` + "\n" +
+				`{"LastModifiedDate":"2026-01-02T03:04:05Z","key":"db_host","ref":"ps:/app/db_host","value":"local host"}` + "\n",
+		},
+		{
+			format: OutputEnv,
+			want:   "DB_PORT=5432\nDB_HOST='local host'\n",
+		},
+		{
+			format: OutputTSV,
+			want:   "ref\tkey\tvalue\nps:/app/db_port\tdb_port\t5432\nps:/app/db_host\tdb_host\tlocal host\n",
+		},
+		{
+			format: OutputTable,
+			want: "REF              KEY      VALUE       LASTMODIFIEDDATE\n" +
+				"ps:/app/db_host  db_host  local host  2026-01-02T03:04:05Z\n" +
+				"ps:/app/db_port  db_port  5432        \n",
+		},
+		{
+			format: OutputYAML,
+			want: "- LastModifiedDate: \"2026-01-02T03:04:05Z\"\n  key: db_host\n  ref: ps:/app/db_host\n  value: local host\n" +
+				"- key: db_port\n  ref: ps:/app/db_port\n  value: \"5432\"\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			rw := newRecordWriter(&buf, tc.format, "ref", "key", "value")
+			for _, r := range records {
+				if err := rw.Write(r); err != nil {
+					t.Fatalf("Write() error: %v", err)
+				}
+			}
+			if err := rw.Close(); err != nil {
+				t.Fatalf("Close() error: %v", err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("output =\n%s\nwant\n%s", buf.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestRecordWriter_JSONSortedAndEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	rw := newRecordWriter(&buf, OutputJSON, "ref")
+	_ = rw.Write(record{"ref": "ps:/b"})
+	_ = rw.Write(record{"ref": "ps:/a"})
+	_ = rw.Close()
+	if strings.Index(buf.String(), "ps:/a") > strings.Index(buf.String(), "ps:/b") {
+		t.Errorf("json output not sorted by ref: %s", buf.String())
+	}
+
+	buf.Reset()
+	rw = newRecordWriter(&buf, OutputJSON, "ref")
+	_ = rw.Close()
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty json output = %q, want []", buf.String())
+	}
+}
+
+func TestRecordWriter_EnvRequiresValues(t *testing.T) {
+	rw := newRecordWriter(&bytes.Buffer{}, OutputEnv, "ref")
+	if err := rw.Write(record{"ref": "ps:/app/"}); err == nil {
+		t.Error("expected error for env output without key/value, got nil")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":         "plain",
+		"":              "''",
+		"with space":    "'with space'",
+		"it's":          `'it'\''s'`,
+		"postgres://db": "postgres://db",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
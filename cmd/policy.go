@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/policy"
+)
+
+// policyViolationExitCode is the exit status of policy check when a rule is broken (errors exit with 1).
+const policyViolationExitCode = 2
+
+// PolicyCmd groups the policy subcommands.
+type PolicyCmd struct {
+	Check PolicyCheckCmd `cmd:"" help:"Check existing entries against the [[policy]] rules."`
+}
+
+// PolicyCheckCmd represents the "policy check" subcommand.
+type PolicyCheckCmd struct {
+	Target      string `arg:"" predictor:"prefix" help:"Ref or prefix (trailing /, or sm: for all secrets) to check"`
+	Concurrency int    `name:"concurrency" default:"4" help:"Number of entries whose tags are read in parallel"`
+
+	out io.Writer // for testing; nil means os.Stdout
+}
+
+// Run executes the policy check command.
+func (c *PolicyCheckCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	if appCtx.Config == nil || len(appCtx.Config.Policies) == 0 {
+		return fmt.Errorf("policy check failed: no [[policy]] rules are configured")
+	}
+	set, err := policy.Compile(appCtx.Config.Policies)
+	if err != nil {
+		return fmt.Errorf("policy check failed: %w", err)
+	}
+	ref, prefix, err := parseTagTarget(c.Target)
+	if err != nil {
+		return fmt.Errorf("policy check failed: %w", err)
+	}
+	b, err := appCtx.BackendFactory(ref.Type)
+	if err != nil {
+		return fmt.Errorf("policy check failed: create backend: %w", err)
+	}
+
+	objects, err := c.collect(context.Background(), b, set, ref, prefix)
+	if err != nil {
+		return fmt.Errorf("policy check failed: %w", err)
+	}
+	var violations []policy.Violation
+	for _, o := range objects {
+		violations = append(violations, set.Check(o)...)
+	}
+
+	if err := c.report(appCtx.Output, violations, len(objects)); err != nil {
+		return fmt.Errorf("policy check failed: %w", err)
+	}
+	if len(violations) > 0 {
+		return &ExitCodeError{Code: policyViolationExitCode}
+	}
+	return nil
+}
+
+// collect lists the entries of the target that some rule applies to, and reads their
+// sizes and tags when a matching rule needs them.
+func (c *PolicyCheckCmd) collect(ctx context.Context, b backend.Backend, set *policy.Set, ref backend.Ref, prefix bool) ([]policy.Object, error) {
+	// 単一 ref は親（SM は名前の前方一致）を一覧して一致するものだけ使う
+	listPrefix := ref.Path
+	if !prefix && ref.Type == backend.BackendTypePS {
+		listPrefix = strings.TrimSuffix(path.Dir(ref.Path), "/") + "/"
+	}
+
+	var objects []policy.Object
+	err := backend.List(ctx, b, listPrefix, backend.ListOptions{Recursive: prefix}, func(e backend.ListEntry) error {
+		if !prefix && e.Path != ref.Path {
+			return nil
+		}
+		o := policy.Object{
+			Type:      ref.Type,
+			Path:      e.Path,
+			ParamType: e.Type,
+			KMSKeyID:  e.KMSKeyID,
+			Tier:      e.Tier,
+			Size:      -1,
+		}
+		if set.Matches(o.Ref()) {
+			objects = append(objects, o)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !prefix && len(objects) == 0 {
+		target := string(ref.Type) + ":" + ref.Path
+		if !set.Matches(target) {
+			return nil, fmt.Errorf("no [[policy]] rule applies to %s", target)
+		}
+		return nil, fmt.Errorf("%s: %w", target, backend.ErrNotFound)
+	}
+
+	var sized []string
+	for _, o := range objects {
+		if set.RequiresSize(o.Ref()) {
+			sized = append(sized, o.Ref())
+		}
+	}
+	if len(sized) > 0 {
+		values, _, err := backend.GetMany(ctx, b, sized, backend.GetOptions{ForceRaw: true})
+		if err != nil {
+			return nil, err
+		}
+		for i := range objects {
+			if v, ok := values[objects[i].Ref()]; ok {
+				objects[i].Size = len(v)
+			}
+		}
+	}
+
+	tagger, tagErr := backend.AsTagger(b)
+	errs := forEachConcurrent(len(objects), c.Concurrency, func(i int) error {
+		if !set.RequiresTags(objects[i].Ref()) {
+			return nil
+		}
+		if tagErr != nil {
+			return tagErr
+		}
+		t, err := tagger.Tags(ctx, objects[i].Ref())
+		if err != nil {
+			return err
+		}
+		objects[i].Tags = t
+		return nil
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", objects[i].Ref(), err)
+		}
+	}
+	return objects, nil
+}
+
+// report prints one line per violation and a summary, or violation records with --output.
+func (c *PolicyCheckCmd) report(format string, violations []policy.Violation, checked int) error {
+	if format != "" {
+		rw := newRecordWriter(c.out, format, "ref", "policy", "message")
+		for _, v := range violations {
+			if err := rw.Write(record{"ref": v.Ref, "policy": v.Policy, "message": v.Message}); err != nil {
+				return err
+			}
+		}
+		return rw.Close()
+	}
+	for _, v := range violations {
+		fmt.Fprintln(c.out, v.String())
+	}
+	_, err := fmt.Fprintf(c.out, "checked %d entries: %d violations\n", checked, len(violations))
+	return err
+}
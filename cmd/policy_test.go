@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// newPolicyTestContext stores parameters under /app/prod/ and one secret, with rules
+// for SecureString, size and tags.
+func newPolicyTestContext(t *testing.T) *Context {
+	t.Helper()
+	ctx := context.Background()
+	ps, sm := backend.NewMockBackend(), backend.NewMockBackend()
+	puts := []struct {
+		b    *backend.MockBackend
+		ref  string
+		opts backend.PutOptions
+	}{
+		{ps, "ps:/app/prod/db_host", backend.PutOptions{Value: "db.internal", StoreMode: tags.StoreModeRaw}},
+		{ps, "ps:/app/prod/db_password", backend.PutOptions{Value: "s3cr3t", StoreMode: tags.StoreModeRaw, ValueType: backend.ValueTypeSecure, KMSKeyID: "alias/app"}},
+		{ps, "ps:/app/prod/cert", backend.PutOptions{Value: strings.Repeat("x", 100), StoreMode: tags.StoreModeRaw, ValueType: backend.ValueTypeSecure, KMSKeyID: "alias/app"}},
+		{ps, "ps:/app/dev/db_host", backend.PutOptions{Value: "localhost", StoreMode: tags.StoreModeRaw}},
+		{sm, "sm:app-db", backend.PutOptions{Value: "s3cr3t", StoreMode: tags.StoreModeRaw}},
+	}
+	for _, p := range puts {
+		if err := p.b.Put(ctx, p.ref, p.opts); err != nil {
+			t.Fatalf("Put(%s): %v", p.ref, err)
+		}
+	}
+	ps.SetTags("ps:/app/prod/db_password", map[string]string{"team": "core"})
+	ps.SetTags("ps:/app/prod/cert", map[string]string{"team": "core"})
+
+	return &Context{
+		Config: &config.Config{Policies: []config.PolicyRule{
+			{Name: "prod", Match: "ps:/**/prod/**", RequireSecure: true, KMSKeyID: "alias/app", MaxSize: 64, RequiredTags: []string{"team"}},
+			{Name: "secrets", Match: "sm:**", KMSKeyID: "alias/app"},
+		}},
+		BackendFactory: func(bt backend.BackendType) (backend.Backend, error) {
+			if bt == backend.BackendTypeSM {
+				return sm, nil
+			}
+			return ps, nil
+		},
+	}
+}
+
+func TestPolicyCheckCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		want     string
+		wantCode int
+		wantErr  string
+	}{
+		{
+			name:   "prefix",
+			target: "ps:/app/",
+			want: "ps:/app/prod/cert: value is 100 bytes (max 64) (policy prod)\n" +
+				"ps:/app/prod/db_host: must be SecureString (is String) (policy prod)\n" +
+				"ps:/app/prod/db_host: missing required tags: team (policy prod)\n" +
+				"checked 3 entries: 3 violations\n",
+			wantCode: policyViolationExitCode,
+		},
+		{
+			name:   "compliant ref",
+			target: "ps:/app/prod/db_password",
+			want:   "checked 1 entries: 0 violations\n",
+		},
+		{
+			name:     "all secrets",
+			target:   "sm:",
+			want:     "sm:app-db: must be encrypted with KMS key alias/app (uses the AWS managed key) (policy secrets)\nchecked 1 entries: 1 violations\n",
+			wantCode: policyViolationExitCode,
+		},
+		{
+			name:   "prefix outside rules",
+			target: "ps:/app/dev/",
+			want:   "checked 0 entries: 0 violations\n",
+		},
+		{name: "ref outside rules", target: "ps:/app/dev/db_host", wantErr: "no [[policy]] rule applies to ps:/app/dev/db_host"},
+		{name: "missing ref", target: "ps:/app/prod/nope", wantErr: "ps:/app/prod/nope: key not found"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := PolicyCheckCmd{Target: tc.target, Concurrency: 2, out: &out}
+			err := cmd.Run(newPolicyTestContext(t))
+			switch {
+			case tc.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Run() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			case tc.wantCode != 0:
+				var exitErr *ExitCodeError
+				if !isExitCodeError(err, &exitErr) || exitErr.Code != tc.wantCode {
+					t.Fatalf("err = %v, want ExitCodeError{%d}", err, tc.wantCode)
+				}
+			case err != nil:
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output =\n%s\nwant\n%s", out.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckCmd_Output(t *testing.T) {
+	var out bytes.Buffer
+	appCtx := newPolicyTestContext(t)
+	appCtx.Output = OutputJSON
+	cmd := PolicyCheckCmd{Target: "sm:", Concurrency: 1, out: &out}
+	if err := cmd.Run(appCtx); err == nil {
+		t.Fatal("expected ExitCodeError")
+	}
+	var got []map[string]string
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(got) != 1 || got[0]["ref"] != "sm:app-db" || got[0]["policy"] != "secrets" {
+		t.Errorf("records = %v", got)
+	}
+}
+
+func TestPolicyCheckCmd_NoRules(t *testing.T) {
+	appCtx := newPolicyTestContext(t)
+	appCtx.Config.Policies = nil
+	cmd := PolicyCheckCmd{Target: "ps:/app/", out: &bytes.Buffer{}}
+	if err := cmd.Run(appCtx); err == nil || !strings.Contains(err.Error(), "no [[policy]] rules are configured") {
+		t.Errorf("Run() error = %v", err)
+	}
+}
@@ -875,3 +875,47 @@ func TestNewPrefixPredictor_CacheMiss_WithFactory_PartialPath(t *testing.T) {
 		t.Errorf("expected ps:/stratalog/, got %s", candidates[0])
 	}
 }
+
+// ─── JSON キー補完 ────────────────────────────────────────────────────────────
+
+// jsonValueFactory は sm:prod/db に JSON 値を持つ MockBackend を返す factory を作る。
+func jsonValueFactory() BackendFactory {
+	mock := backend.NewMockBackend()
+	_ = mock.Put(context.Background(), "sm:prod/db", backend.PutOptions{
+		Value:     `{"username":"app","password":"s3cr3t","replicas":[]}`,
+		StoreMode: tags.StoreModeRaw,
+	})
+	return func(_ backend.BackendType) (backend.Backend, error) { return mock, nil }
+}
+
+// pred-cmd-F1: RefPredictor - "sm:prod/db#" → トップレベルキーを ref#key 形式で返す
+func TestNewRefPredictor_JSONFieldKeys(t *testing.T) {
+	fn := newRefPredictor(&MockStore{}, &MockBGLauncher{}, jsonValueFactory())
+	candidates := fn("sm:prod/db#pa")
+
+	want := []string{"sm:prod/db#password", "sm:prod/db#replicas", "sm:prod/db#username"}
+	if strings.Join(candidates, ",") != strings.Join(want, ",") {
+		t.Errorf("candidates = %v, want %v", candidates, want)
+	}
+	for _, c := range candidates {
+		if strings.Contains(c, "s3cr3t") {
+			t.Errorf("candidate leaks a value: %q", c)
+		}
+	}
+}
+
+// pred-cmd-F2: FieldPredictor - 入力済みの ref から --field のキー候補を返す
+func TestNewFieldPredictor(t *testing.T) {
+	p := NewFieldPredictor(jsonValueFactory())
+	candidates := p.Predict(complete.Args{Completed: []string{"get", "sm:prod/db", "--field"}})
+
+	want := []string{"password", "replicas", "username"}
+	if strings.Join(candidates, ",") != strings.Join(want, ",") {
+		t.Errorf("candidates = %v, want %v", candidates, want)
+	}
+
+	// 値が JSON オブジェクトでない・ref がない場合は空
+	if got := p.Predict(complete.Args{Completed: []string{"get", "--field"}}); len(got) != 0 {
+		t.Errorf("expected no candidates without a ref, got %v", got)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/posener/complete"
 	"github.com/youyo/bundr/internal/backend"
 	"github.com/youyo/bundr/internal/cache"
+	"github.com/youyo/bundr/internal/jsonquery"
 )
 
 // NewRefPredictor は ref-style 補完（ps:/path..., sm:name...）の
@@ -30,6 +31,55 @@ func NewPrefixPredictor(cacheStore cache.Store, bgLauncher BGLauncher, factory B
 	})
 }
 
+// NewFieldPredictor は get --field 用に、コマンドライン上の ref の値に含まれる
+// JSON のトップレベルキーを補完する complete.Predictor を返す。
+func NewFieldPredictor(factory BackendFactory) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		for i := len(a.Completed) - 1; i >= 0; i-- {
+			if _, err := backend.ParseRef(a.Completed[i]); err == nil {
+				return jsonKeyCandidates(factory, a.Completed[i], "")
+			}
+		}
+		return []string{}
+	})
+}
+
+// jsonKeyCandidates は ref の値を取得し、JSON オブジェクトのトップレベルキーを
+// candidatePrefix を付けて返す。値が JSON オブジェクトでない場合・エラー時は空リスト。
+// 値そのものは返さない（キー名のみ）。
+func jsonKeyCandidates(factory BackendFactory, ref, candidatePrefix string) []string {
+	parsed, err := backend.ParseRef(ref)
+	if err != nil || factory == nil {
+		return []string{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	b, err := factory(parsed.Type)
+	if err != nil {
+		return []string{}
+	}
+	base, _ := backend.SplitField(ref)
+	val, err := b.Get(ctx, base, backend.GetOptions{})
+	if err != nil {
+		return []string{}
+	}
+	doc, err := jsonquery.Decode(val)
+	if err != nil {
+		return []string{}
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return []string{}
+	}
+	keys := jsonquery.Keys(obj)
+	candidates := make([]string, 0, len(keys))
+	for _, k := range keys {
+		candidates = append(candidates, candidatePrefix+k)
+	}
+	return candidates
+}
+
 // liveFetchPath は fetchLive に渡す prefix を計算する。
 // PS/PSA パスは "/" 始まりの階層パスのため、末尾が "/" でない場合は親ディレクトリを返す。
 // SM シークレット名（"/" を含まない）はそのまま返す（AWS API の扱いが PS と異なるため）。
@@ -109,6 +159,11 @@ func hierarchicalFilter(refPath string, entries []cache.CacheEntry, refTypeStr s
 // newRefPredictor は ref-style 補完の内部関数（テスト用に cmd パッケージ内でアクセス可能）。
 func newRefPredictor(cacheStore cache.Store, bgLauncher BGLauncher, factory BackendFactory) func(string) []string {
 	return func(prefix string) []string {
+		// "sm:prod/db#" → 値の JSON キーを "sm:prod/db#password" の形で補完
+		if base, _, ok := strings.Cut(prefix, "#"); ok {
+			return jsonKeyCandidates(factory, base, base+"#")
+		}
+
 		// 1. prefix からバックエンドタイプを判定
 		ref, err := backend.ParseRef(prefix)
 		if err != nil {
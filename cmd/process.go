@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/youyo/bundr/internal/childproc"
+)
+
+// forwardSignals relays every signal delivered on sigCh to the process group pgid
+// until done is closed. SIGCHLD (child bookkeeping) and SIGURG (used internally by
+// the Go runtime for preemption) are never forwarded. As PID 1, a SIGCHLD reaps the
+// orphans bundr inherited.
+func forwardSignals(sigCh <-chan os.Signal, pgid int, done <-chan struct{}) {
+	for {
+		select {
+		case sig := <-sigCh:
+			s, ok := sig.(syscall.Signal)
+			if ok && s == syscall.SIGCHLD && os.Getpid() == 1 {
+				childproc.ReapOrphans()
+			}
+			if !ok || s == syscall.SIGCHLD || s == syscall.SIGURG {
+				continue
+			}
+			_ = syscall.Kill(-pgid, s)
+		case <-done:
+			return
+		}
+	}
+}
+
+// waitPID waits for pid only (bundr runs as PID 1 in containers and inherits orphaned
+// processes; those are reaped by childproc.ReapOrphans, never by waiting for any child,
+// which would take the exit status of plugins and other tracked children).
+// It then reaps the orphans that have already exited and returns the wait status of pid.
+func waitPID(pid int) (syscall.WaitStatus, error) {
+	for {
+		var ws syscall.WaitStatus
+		_, err := syscall.Wait4(pid, &ws, 0, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		childproc.ReapOrphans()
+		return ws, nil
+	}
+}
+
+// exitCodeFromStatus maps a wait status to a shell-style exit code.
+// A child killed by a signal yields 128+signal (e.g. SIGTERM → 143).
+func exitCodeFromStatus(ws syscall.WaitStatus) int {
+	switch {
+	case ws.Exited():
+		return ws.ExitStatus()
+	case ws.Signaled():
+		return 128 + int(ws.Signal())
+	default:
+		return 1
+	}
+}
+
+// foregroundTTY returns stdin's fd when it is the controlling terminal and bundr's
+// process group currently owns it. The child is then moved to the foreground so that
+// it can still read from the terminal after being placed in its own process group.
+func foregroundTTY() (int, bool) {
+	fd := int(os.Stdin.Fd())
+	pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	if err != nil || pgrp != syscall.Getpgrp() {
+		return 0, false
+	}
+	return fd, true
+}
+
+// restoreForeground gives the terminal back to bundr's process group after the child exits.
+// SIGTTOU is ignored during the call because bundr is a background process at that point.
+func restoreForeground(fd int) {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	_ = unix.IoctlSetPointerInt(fd, unix.TIOCSPGRP, syscall.Getpgrp())
+}
+
+// outputPipe connects a child's output stream to an arbitrary io.Writer.
+// The write end is passed to the child as an *os.File so that os/exec does not
+// start its own copy goroutine (which Wait would be required to collect, and which
+// the PID 1 reaping path never calls).
+type outputPipe struct {
+	w    *os.File
+	done chan struct{} // closed once everything written by the child was copied
+}
+
+// newOutputPipe creates the pipe and starts copying its read end to dst.
+func newOutputPipe(dst io.Writer) (*outputPipe, error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	op := &outputPipe{w: pw, done: make(chan struct{})}
+	go func() {
+		defer close(op.done)
+		defer pr.Close()
+		_, _ = io.Copy(dst, pr)
+	}()
+	return op, nil
+}
+
+// closeOutputPipes closes the write ends so that the copy goroutines finish.
+func closeOutputPipes(pipes []*outputPipe) {
+	for _, op := range pipes {
+		_ = op.w.Close()
+	}
+}
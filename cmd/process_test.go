@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestOsExecRunner_ExitCode(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	tests := []struct {
+		id       string
+		script   string
+		wantCode int
+		wantErr  bool
+	}{
+		{id: "success", script: "exit 0", wantCode: 0},
+		{id: "exit-3", script: "exit 3", wantCode: 3, wantErr: true},
+		{id: "killed-by-sigterm", script: "kill -TERM $$", wantCode: 128 + int(syscall.SIGTERM), wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			r := &OsExecRunner{}
+			code, err := r.Run("sh", []string{"-c", tc.script}, nil)
+			if code != tc.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tc.wantCode)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestOsExecRunner_StartFailure(t *testing.T) {
+	r := &OsExecRunner{}
+	code, err := r.Run("bundr-test-command-does-not-exist", nil, nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	// Start failures are reported as errors, not as a child exit code.
+	if code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+}
+
+func TestExitCodeFromStatus(t *testing.T) {
+	tests := []struct {
+		id   string
+		ws   syscall.WaitStatus
+		want int
+	}{
+		{id: "exited-0", ws: syscall.WaitStatus(0), want: 0},
+		{id: "exited-2", ws: syscall.WaitStatus(2 << 8), want: 2},
+		{id: "signaled-kill", ws: syscall.WaitStatus(syscall.SIGKILL), want: 137},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			if got := exitCodeFromStatus(tc.ws); got != tc.want {
+				t.Errorf("exitCodeFromStatus() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOsExecRunner_OutputWriters(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	var stdout, stderr bytes.Buffer
+	r := &OsExecRunner{Stdout: &stdout, Stderr: &stderr}
+	code, err := r.Run("sh", []string{"-c", "echo out; echo err >&2; exit 4"}, nil)
+	if code != 4 || err == nil {
+		t.Errorf("Run() = (%d, %v), want (4, error)", code, err)
+	}
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Errorf("stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+}
@@ -3,17 +3,24 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/flatten"
+	"github.com/youyo/bundr/internal/policy"
 	"github.com/youyo/bundr/internal/tags"
 )
 
 // PutCmd represents the "put" subcommand.
 type PutCmd struct {
-	Ref    string `arg:"" predictor:"ref" help:"Target ref (e.g. ps:/app/prod/DB_HOST, sm:secret-id)"`
-	Value  string `short:"v" required:"" help:"Value to store"`
-	Secure bool   `help:"Use SecureString (SSM Parameter Store only)"`
-	Tier   string `help:"Parameter Store tier override (standard|advanced). Omit to auto-detect existing tier." enum:"standard,advanced," default:""`
+	Ref             string   `arg:"" predictor:"ref" help:"Target ref (e.g. ps:/app/prod/DB_HOST, sm:secret-id)"`
+	Value           string   `short:"v" required:"" help:"Value to store"`
+	Secure          bool     `help:"Use SecureString (SSM Parameter Store only)"`
+	Tier            string   `help:"Parameter Store tier override (standard|advanced). Omit to auto-detect existing tier." enum:"standard,advanced," default:""`
+	Flatten         string   `xor:"flatten" help:"Per-parameter flatten policy stored in the cli-flatten tag (e.g. no, array=index, depth=1, delim=__)"`
+	NoFlattenPolicy bool     `name:"no-flatten-policy" xor:"flatten" help:"Remove the cli-flatten tag, so exec uses its command-line flattening options again"`
+	Tag             []string `name:"tag" sep:"none" placeholder:"KEY=VALUE" help:"Extra tag to set (repeatable)"`
+	Schema          string   `placeholder:"REF" help:"JSON Schema the value must match, stored in the cli-value-schema tag (a ps:/sm: ref holding the schema)"`
 }
 
 // Run executes the put command.
@@ -22,20 +29,78 @@ func (c *PutCmd) Run(appCtx *Context) error {
 	if err != nil {
 		return fmt.Errorf("put command failed: invalid ref: %w", err)
 	}
+	if ref.Field != "" {
+		return fmt.Errorf("put command failed: a #field selector cannot be written to")
+	}
+
+	var flatPolicy flatten.Policy
+	if c.Flatten != "" {
+		if flatPolicy, err = flatten.ParsePolicy(c.Flatten); err != nil {
+			return fmt.Errorf("put command failed: --flatten: %w", err)
+		}
+		// "," などは空のポリシーになり、空のタグ値を書いてしまう
+		if flatPolicy.String() == "" {
+			return fmt.Errorf("put command failed: --flatten %q has no settings (use --no-flatten-policy to remove the tag)", c.Flatten)
+		}
+	}
+	if c.NoFlattenPolicy && c.Flatten != "" {
+		return fmt.Errorf("put command failed: --flatten and --no-flatten-policy cannot be used together")
+	}
+	// タグは他のマシンや別ディレクトリからの書き込みでも読まれるため、ファイルパスは不可
+	if c.Schema != "" && !policy.IsRefSource(c.Schema) {
+		return fmt.Errorf("put command failed: --schema must be a ps: or sm: ref holding the schema, not a file path (the cli-value-schema tag is read by every later write)")
+	}
 
 	b, err := appCtx.BackendFactory(ref.Type)
 	if err != nil {
 		return fmt.Errorf("put command failed: create backend: %w", err)
 	}
+	// 上書きしても既存のタグは残るため、cli-flatten は明示的に外す
+	var tagger backend.Tagger
+	if c.NoFlattenPolicy {
+		if tagger, err = backend.AsTagger(b); err != nil {
+			return fmt.Errorf("put command failed: --no-flatten-policy: %w", err)
+		}
+	}
 
 	opts := backend.PutOptions{
 		Value:     c.Value,
 		StoreMode: tags.StoreModeRaw,
 	}
 
+	for _, t := range c.Tag {
+		k, v, ok := strings.Cut(t, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("put command failed: invalid tag %q: expected KEY=VALUE", t)
+		}
+		if tags.IsReserved(k) {
+			return fmt.Errorf("put command failed: tag %q is reserved for bundr", k)
+		}
+		if opts.Tags == nil {
+			opts.Tags = map[string]string{}
+		}
+		opts.Tags[k] = v
+	}
+	if c.Flatten != "" {
+		if opts.Tags == nil {
+			opts.Tags = map[string]string{}
+		}
+		opts.Tags[tags.TagFlatten] = flatPolicy.String()
+	}
+	if c.Schema != "" {
+		if opts.Tags == nil {
+			opts.Tags = map[string]string{}
+		}
+		opts.Tags[tags.TagValueSchema] = c.Schema
+	}
+
 	if c.Secure {
 		opts.ValueType = backend.ValueTypeSecure
 	}
+	// 設定の kms_key_id は暗号化される値（SecureString・シークレット）に使う
+	if (c.Secure || ref.Type == backend.BackendTypeSM) && appCtx.Config != nil {
+		opts.KMSKeyID = appCtx.Config.AWS.KMSKeyID
+	}
 
 	switch c.Tier {
 	case "advanced":
@@ -46,9 +111,25 @@ func (c *PutCmd) Run(appCtx *Context) error {
 		opts.TierExplicit = true
 	}
 
+	// --schema は [[schema]] 設定の有無にかかわらず書き込み前に検証する
+	if c.Schema != "" {
+		schemas, err := policy.CompileSchemas(nil, policy.SchemaLoader(appCtx.BackendFactory))
+		if err != nil {
+			return fmt.Errorf("put command failed: %w", err)
+		}
+		if err := schemas.Validate(c.Ref, c.Schema, c.Value); err != nil {
+			return fmt.Errorf("put command failed: %w", err)
+		}
+	}
+
 	if err := b.Put(context.Background(), c.Ref, opts); err != nil {
 		return fmt.Errorf("put command failed: %w", err)
 	}
+	if tagger != nil {
+		if err := tagger.RemoveTags(context.Background(), c.Ref, []string{tags.TagFlatten}); err != nil {
+			return fmt.Errorf("put command failed: remove %s tag: %w", tags.TagFlatten, err)
+		}
+	}
 
 	fmt.Println("OK")
 	return nil
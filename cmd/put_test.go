@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/youyo/bundr/internal/backend"
 	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/policy"
 	"github.com/youyo/bundr/internal/tags"
 )
 
@@ -216,3 +219,196 @@ func TestPutCmd_RunInvalidRef(t *testing.T) {
 		t.Error("Run() expected error for invalid ref, got nil")
 	}
 }
+
+func TestPutCmd_Flatten(t *testing.T) {
+	tests := []struct {
+		id      string
+		flatten string
+		wantTag string
+		wantErr bool
+	}{
+		{id: "no", flatten: "no", wantTag: "no"},
+		{id: "canonical", flatten: "delim=__,array=index", wantTag: "array=index delim=__"},
+		{id: "invalid", flatten: "array=zip", wantErr: true},
+		{id: "empty", flatten: ",", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			mock := backend.NewMockBackend()
+			appCtx := &Context{
+				Config:         &config.Config{},
+				BackendFactory: func(_ backend.BackendType) (backend.Backend, error) { return mock, nil },
+			}
+
+			cmd := &PutCmd{Ref: "ps:/app/test/CONFIG", Value: `{"a":[1,2]}`, Flatten: tc.flatten}
+			err := cmd.Run(appCtx)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if len(mock.PutCalls) != 0 {
+					t.Errorf("Put called %d times, want 0", len(mock.PutCalls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if got := mock.PutCalls[0].Opts.Tags[tags.TagFlatten]; got != tc.wantTag {
+				t.Errorf("cli-flatten tag = %q, want %q", got, tc.wantTag)
+			}
+		})
+	}
+}
+
+// --no-flatten-policy removes a cli-flatten tag left by an earlier put
+func TestPutCmd_NoFlattenPolicy(t *testing.T) {
+	mock := backend.NewMockBackend()
+	appCtx := &Context{
+		Config:         &config.Config{},
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) { return mock, nil },
+	}
+	ctx := context.Background()
+	if err := mock.Put(ctx, "ps:/app/test/CONFIG", backend.PutOptions{Value: "{}", StoreMode: tags.StoreModeRaw, Tags: map[string]string{tags.TagFlatten: "no"}}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	cmd := &PutCmd{Ref: "ps:/app/test/CONFIG", Value: `{"a":1}`, NoFlattenPolicy: true}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	got, err := mock.Tags(ctx, "ps:/app/test/CONFIG")
+	if err != nil {
+		t.Fatalf("Tags: %v", err)
+	}
+	if _, ok := got[tags.TagFlatten]; ok {
+		t.Errorf("tags = %v, want no %s tag", got, tags.TagFlatten)
+	}
+
+	cmd = &PutCmd{Ref: "ps:/app/test/CONFIG", Value: "x", Flatten: "no", NoFlattenPolicy: true}
+	if err := cmd.Run(appCtx); err == nil {
+		t.Error("expected error for --flatten with --no-flatten-policy")
+	}
+}
+
+func TestPutCmd_RejectsFieldRef(t *testing.T) {
+	mock := backend.NewMockBackend()
+	appCtx := &Context{
+		Config:         &config.Config{},
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) { return mock, nil },
+	}
+
+	cmd := &PutCmd{Ref: "sm:prod/db#password", Value: "x"}
+	if err := cmd.Run(appCtx); err == nil {
+		t.Fatal("expected error for #field ref, got nil")
+	}
+	if len(mock.PutCalls) != 0 {
+		t.Errorf("Put called %d times, want 0", len(mock.PutCalls))
+	}
+}
+
+func TestPutCmd_TagsAndKMSKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     PutCmd
+		wantKMS string
+		wantErr string
+	}{
+		{name: "secure uses configured key", cmd: PutCmd{Ref: "ps:/app/k", Value: "v", Secure: true, Tag: []string{"team=core"}}, wantKMS: "alias/app"},
+		{name: "plain string has no key", cmd: PutCmd{Ref: "ps:/app/k", Value: "v", Tag: []string{"team=core"}}},
+		{name: "secret uses configured key", cmd: PutCmd{Ref: "sm:app", Value: "v", Tag: []string{"team=core"}}, wantKMS: "alias/app"},
+		{name: "invalid tag", cmd: PutCmd{Ref: "ps:/app/k", Value: "v", Tag: []string{"team"}}, wantErr: `invalid tag "team"`},
+		{name: "reserved tag", cmd: PutCmd{Ref: "ps:/app/k", Value: "v", Tag: []string{"cli-store-mode=json"}}, wantErr: "reserved"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := backend.NewMockBackend()
+			appCtx := &Context{
+				Config:         &config.Config{AWS: config.AWSConfig{KMSKeyID: "alias/app"}},
+				BackendFactory: func(_ backend.BackendType) (backend.Backend, error) { return mock, nil },
+			}
+			cmd := tc.cmd
+			err := cmd.Run(appCtx)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Run() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			opts := mock.PutCalls[0].Opts
+			if opts.KMSKeyID != tc.wantKMS {
+				t.Errorf("KMSKeyID = %q, want %q", opts.KMSKeyID, tc.wantKMS)
+			}
+			if opts.Tags["team"] != "core" {
+				t.Errorf("tags = %v, want team=core", opts.Tags)
+			}
+		})
+	}
+}
+
+func TestPutCmd_PolicyViolation(t *testing.T) {
+	set, err := policy.Compile([]config.PolicyRule{{Name: "prod", Match: "ps:/app/prod/**", RequireSecure: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := backend.NewMockBackend()
+	appCtx := &Context{
+		Config: &config.Config{},
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) {
+			return (&policy.WriteGuard{Policies: set}).Wrap(mock, "", ""), nil
+		},
+	}
+
+	cmd := &PutCmd{Ref: "ps:/app/prod/DB_PASSWORD", Value: "x"}
+	err = cmd.Run(appCtx)
+	want := "put command failed: policy violation: ps:/app/prod/DB_PASSWORD: must be SecureString (is String) (policy prod)"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Run() error = %v, want %q", err, want)
+	}
+	if len(mock.PutCalls) != 0 {
+		t.Errorf("Put called %d times, want 0", len(mock.PutCalls))
+	}
+
+	cmd = &PutCmd{Ref: "ps:/app/prod/DB_PASSWORD", Value: "x", Secure: true}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+}
+
+func TestPutCmd_Schema(t *testing.T) {
+	mock := backend.NewMockBackend()
+	if err := mock.Put(context.Background(), "ps:/schemas/port", backend.PutOptions{Value: `{"type":"object","required":["port"]}`, StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatal(err)
+	}
+	// 設定がなくてもライトガードなしで --schema を検証する
+	appCtx := &Context{
+		Config: &config.Config{},
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) {
+			return mock, nil
+		},
+	}
+	puts := len(mock.PutCalls)
+
+	cmd := &PutCmd{Ref: "ps:/app/config", Value: `{"host":"db"}`, Schema: "ps:/schemas/port"}
+	if err := cmd.Run(appCtx); err == nil || !strings.Contains(err.Error(), "/: missing required properties: port") {
+		t.Fatalf("Run() error = %v, want a schema error", err)
+	}
+	cmd = &PutCmd{Ref: "ps:/app/config", Value: `{"port":5432}`, Schema: "schemas/port.json"}
+	if err := cmd.Run(appCtx); err == nil || !strings.Contains(err.Error(), "--schema must be a ps: or sm: ref") {
+		t.Fatalf("Run() with a file path: error = %v", err)
+	}
+	if len(mock.PutCalls) != puts {
+		t.Fatalf("Put called %d times, want 0", len(mock.PutCalls)-puts)
+	}
+
+	cmd = &PutCmd{Ref: "ps:/app/config", Value: `{"port":5432}`, Schema: "ps:/schemas/port"}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := mock.PutCalls[puts].Opts.Tags[tags.TagValueSchema]; got != "ps:/schemas/port" {
+		t.Errorf("%s tag = %q, want ps:/schemas/port", tags.TagValueSchema, got)
+	}
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/backup"
+	"github.com/youyo/bundr/internal/envelope"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// Restore conflict strategies.
+const (
+	ConflictFail      = "fail"      // abort before writing anything when a target exists
+	ConflictSkip      = "skip"      // keep existing targets
+	ConflictOverwrite = "overwrite" // replace existing targets
+)
+
+// RestoreCmd represents the "restore" subcommand.
+type RestoreCmd struct {
+	File           string   `arg:"" type:"existingfile" help:"Backup file written by bundr backup"`
+	To             string   `name:"to" predictor:"prefix" help:"Restore under this prefix (or ref, for a single-entry backup) instead of the original one"`
+	DryRun         bool     `name:"dry-run" help:"Print what would be restored without writing"`
+	OnConflict     string   `name:"on-conflict" default:"fail" enum:"fail,skip,overwrite" help:"What to do when a target already exists (fail, skip, overwrite)"`
+	Identity       []string `name:"identity" placeholder:"FILE" help:"age identity file to decrypt with (repeatable). Without --passphrase-file or $BUNDR_BACKUP_PASSPHRASE no passphrase is asked"`
+	PassphraseFile string   `name:"passphrase-file" placeholder:"FILE" help:"Read the passphrase from this file (default: $BUNDR_BACKUP_PASSPHRASE, or a prompt on the terminal)"`
+	Concurrency    int      `name:"concurrency" default:"4" help:"Number of entries written in parallel"`
+
+	out        io.Writer                          // for testing; nil means os.Stdout
+	passphrase func(confirm bool) (string, error) // for testing; nil means a terminal prompt
+}
+
+// restoreItem is the plan and outcome for one archived entry.
+type restoreItem struct {
+	entry    backup.Entry
+	target   string
+	action   string // create, overwrite or skip
+	err      error
+	verified bool
+}
+
+// Run executes the restore command.
+func (c *RestoreCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	a, err := c.open()
+	if err != nil {
+		return fmt.Errorf("restore command failed: %w", err)
+	}
+	dest, err := c.destination(a)
+	if err != nil {
+		return fmt.Errorf("restore command failed: %w", err)
+	}
+	b, err := appCtx.BackendFactory(dest.Type)
+	if err != nil {
+		return fmt.Errorf("restore command failed: create backend: %w", err)
+	}
+
+	ctx := context.Background()
+	items, err := c.plan(ctx, b, a, dest)
+	if err != nil {
+		return fmt.Errorf("restore command failed: %w", err)
+	}
+	if c.DryRun {
+		return c.report(appCtx.Output, items)
+	}
+
+	errs := forEachConcurrent(len(items), c.Concurrency, func(i int) error {
+		if items[i].action == "skip" {
+			return nil
+		}
+		return b.Put(ctx, items[i].target, restorePutOptions(items[i].entry))
+	})
+	for i, err := range errs {
+		items[i].err = err
+	}
+	c.verify(ctx, b, items)
+
+	if err := c.report(appCtx.Output, items); err != nil {
+		return fmt.Errorf("restore command failed: %w", err)
+	}
+	failed := 0
+	for _, it := range items {
+		if it.err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("restore command failed: %d of %d entries failed", failed, len(items))
+	}
+	return nil
+}
+
+// open decrypts the backup file with the given identities and/or passphrase.
+func (c *RestoreCmd) open() (*backup.Archive, error) {
+	var opts envelope.OpenOptions
+	for _, name := range c.Identity {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("--identity: %w", err)
+		}
+		ids, err := envelope.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("--identity %s: %w", name, err)
+		}
+		opts.Identities = append(opts.Identities, ids...)
+	}
+	if len(c.Identity) == 0 || c.PassphraseFile != "" || os.Getenv(passphraseEnv) != "" {
+		p, err := readPassphrase(c.PassphraseFile, c.passphrase, false)
+		if err != nil {
+			return nil, err
+		}
+		opts.Passphrase = p
+	}
+
+	f, err := os.Open(c.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return backup.Open(f, opts)
+}
+
+// destination returns the ref entries are restored under: --to, or the backed-up prefix.
+func (c *RestoreCmd) destination(a *backup.Archive) (backend.Ref, error) {
+	source, _, err := parseTagTarget(a.Source)
+	if err != nil {
+		return backend.Ref{}, fmt.Errorf("archive source %q: %w", a.Source, err)
+	}
+	if c.To == "" {
+		return source, nil
+	}
+	to, _, err := parseTagTarget(c.To)
+	if err != nil {
+		return backend.Ref{}, fmt.Errorf("--to: %w", err)
+	}
+	if to.Type != source.Type {
+		return backend.Ref{}, fmt.Errorf("--to: the backup holds %s: entries; restore them to a %s: target", source.Type, source.Type)
+	}
+	return to, nil
+}
+
+// targetRef maps an archived ref to the ref it is restored to.
+func (c *RestoreCmd) targetRef(a *backup.Archive, dest backend.Ref, entryRef string) (string, error) {
+	if c.To == "" {
+		return entryRef, nil
+	}
+	source, prefix, err := parseTagTarget(a.Source)
+	if err != nil {
+		return "", err
+	}
+	entry, err := backend.ParseRef(entryRef)
+	if err != nil {
+		return "", err
+	}
+	if !prefix {
+		if strings.HasSuffix(dest.Path, "/") {
+			return string(dest.Type) + ":" + dest.Path + path.Base(entry.Path), nil
+		}
+		return string(dest.Type) + ":" + dest.Path, nil
+	}
+	if !strings.HasSuffix(dest.Path, "/") && dest.Path != "" {
+		return "", fmt.Errorf("--to must be a prefix (trailing /) when restoring %s", a.Source)
+	}
+	return string(dest.Type) + ":" + dest.Path + strings.TrimPrefix(entry.Path, source.Path), nil
+}
+
+// plan maps every entry to its target and decides the action from the targets that
+// already exist. With --on-conflict=fail, any existing target aborts the restore.
+func (c *RestoreCmd) plan(ctx context.Context, b backend.Backend, a *backup.Archive, dest backend.Ref) ([]restoreItem, error) {
+	existing := map[string]bool{}
+	listPrefix := dest.Path
+	if dest.Type == backend.BackendTypePS && !strings.HasSuffix(listPrefix, "/") {
+		listPrefix = strings.TrimSuffix(path.Dir(listPrefix), "/") + "/"
+	}
+	err := backend.List(ctx, b, listPrefix, backend.ListOptions{Recursive: true}, func(e backend.ListEntry) error {
+		existing[string(dest.Type)+":"+e.Path] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]restoreItem, 0, len(a.Entries))
+	var conflicts []string
+	for _, e := range a.Entries {
+		target, err := c.targetRef(a, dest, e.Ref)
+		if err != nil {
+			return nil, err
+		}
+		it := restoreItem{entry: e, target: target, action: "create"}
+		if existing[target] {
+			switch c.OnConflict {
+			case ConflictSkip:
+				it.action = "skip"
+			case ConflictOverwrite:
+				it.action = "overwrite"
+			default:
+				conflicts = append(conflicts, target)
+			}
+		}
+		items = append(items, it)
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%d targets already exist (use --on-conflict skip or overwrite): %s", len(conflicts), strings.Join(conflicts, ", "))
+	}
+	return items, nil
+}
+
+// restorePutOptions recreates an archived entry exactly: type, tier, key, description,
+// policies and its own tags, without adding bundr's managed tags to unmanaged entries.
+func restorePutOptions(e backup.Entry) backend.PutOptions {
+	opts := backend.PutOptions{
+		Value:       e.Value,
+		StoreMode:   e.StoreMode,
+		KMSKeyID:    e.KMSKeyID,
+		Description: e.Description,
+		Policies:    e.Policies,
+		Tags:        e.Tags,
+		ExactTags:   true,
+	}
+	if opts.StoreMode == "" {
+		opts.StoreMode = tags.StoreModeRaw
+	}
+	switch e.Type {
+	case "SecureString":
+		opts.ValueType = backend.ValueTypeSecure
+	case "StringList":
+		opts.ValueType = backend.ValueTypeStringList
+	}
+	switch e.Tier {
+	case "Advanced":
+		opts.AdvancedTier, opts.TierExplicit = true, true
+	case "Standard":
+		opts.TierExplicit = true
+	}
+	return opts
+}
+
+// verify reads the restored entries back and compares their values (by checksum) and
+// tags with the archive. Mismatches are recorded as errors.
+func (c *RestoreCmd) verify(ctx context.Context, b backend.Backend, items []restoreItem) {
+	var refs []string
+	for _, it := range items {
+		if it.action != "skip" && it.err == nil {
+			refs = append(refs, it.target)
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+	values, _, err := backend.GetMany(ctx, b, refs, backend.GetOptions{ForceRaw: true})
+	tagger, tagErr := backend.AsTagger(b)
+	for i := range items {
+		it := &items[i]
+		if it.action == "skip" || it.err != nil {
+			continue
+		}
+		if err != nil {
+			it.err = fmt.Errorf("verify: %w", err)
+			continue
+		}
+		v, ok := values[it.target]
+		if !ok || backup.Checksum(v) != it.entry.SHA256 {
+			it.err = fmt.Errorf("verify: value does not match the backup")
+			continue
+		}
+		if tagErr == nil {
+			got, err := tagger.Tags(ctx, it.target)
+			if err != nil {
+				it.err = fmt.Errorf("verify: %w", err)
+				continue
+			}
+			for k, want := range it.entry.Tags {
+				if got[k] != want {
+					it.err = fmt.Errorf("verify: tag %s is %q, want %q", k, got[k], want)
+					break
+				}
+			}
+			if it.err != nil {
+				continue
+			}
+		}
+		it.verified = true
+	}
+}
+
+// report prints one line per entry and a summary, or one record per entry with --output.
+func (c *RestoreCmd) report(format string, items []restoreItem) error {
+	if format != "" {
+		rw := newRecordWriter(c.out, format, "ref", "source", "action", "verified", "error")
+		for _, it := range items {
+			rec := record{"ref": it.target, "source": it.entry.Ref, "action": it.action, "verified": it.verified, "error": ""}
+			if it.err != nil {
+				rec["error"] = it.err.Error()
+			}
+			if err := rw.Write(rec); err != nil {
+				return err
+			}
+		}
+		return rw.Close()
+	}
+
+	counts := map[string]int{}
+	verified, failed := 0, 0
+	for _, it := range items {
+		line := it.action + " " + it.target
+		if it.target != it.entry.Ref {
+			line += " (from " + it.entry.Ref + ")"
+		}
+		switch {
+		case c.DryRun:
+			line = "dry-run: " + line
+		case it.err != nil:
+			line = "failed " + it.target + ": " + it.err.Error()
+			failed++
+		case it.verified:
+			line += ": verified"
+			verified++
+		}
+		if it.entry.RotationEnabled && it.action != "skip" {
+			line += " (rotation is not restored)"
+		}
+		counts[it.action]++
+		fmt.Fprintln(c.out, line)
+	}
+	if c.DryRun {
+		_, err := fmt.Fprintf(c.out, "dry-run: %d to create, %d to overwrite, %d to skip\n", counts["create"], counts["overwrite"], counts["skip"])
+		return err
+	}
+	_, err := fmt.Fprintf(c.out, "restored %d entries (%d created, %d overwritten), %d skipped, %d failed; %d verified\n",
+		counts["create"]+counts["overwrite"]-failed, counts["create"], counts["overwrite"], counts["skip"], failed, verified)
+	return err
+}
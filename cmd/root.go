@@ -13,9 +13,11 @@ import (
 
 // CLI is the Kong root command structure.
 type CLI struct {
-	Region   string `help:"AWS region (overrides all other region settings)" optional:"" name:"region"`
-	Profile  string `help:"AWS profile (overrides all other profile settings)" optional:"" name:"profile"`
-	KMSKeyID string `help:"KMS key ID or ARN for encryption" env:"BUNDR_KMS_KEY_ID" optional:"" name:"kms-key-id"`
+	Region       string `help:"AWS region (overrides all other region settings)" optional:"" name:"region"`
+	Profile      string `help:"AWS profile (overrides all other profile settings)" optional:"" name:"profile"`
+	KMSKeyID     string `help:"KMS key ID or ARN for encryption" env:"BUNDR_KMS_KEY_ID" optional:"" name:"kms-key-id"`
+	Output       string `short:"o" help:"Output format for get and ls (json|jsonl|yaml|table|env|tsv). Omit for each command's default." enum:"json,jsonl,yaml,table,env,tsv," default:""`
+	YesIMeanProd bool   `name:"yes-i-mean-prod" help:"Confirm writes to refs protected by [[protect]] confirm rules without the interactive prompt"`
 
 	Put        PutCmd        `cmd:"" help:"Store a value to AWS Parameter Store or Secrets Manager."`
 	Get        GetCmd        `cmd:"" help:"Get a value from a backend."`
@@ -24,6 +26,16 @@ type CLI struct {
 	Completion CompletionCmd `cmd:"" help:"Output shell completion script."`
 	Cache      CacheCmd      `cmd:"" help:"Manage local completion cache."`
 	Sync       SyncCmd       `cmd:"" help:"Sync parameters between .env, ps:, and sm:"`
+	Find       FindCmd       `cmd:"" help:"Search parameter and secret names (and optionally values)."`
+	Tag        TagCmd        `cmd:"" help:"List, set and remove tags."`
+	Adopt      AdoptCmd      `cmd:"" help:"Add bundr's managed tags to existing unmanaged parameters."`
+	Audit      AuditCmd      `cmd:"" help:"Report secret hygiene problems (plaintext secrets, stale values, missing rotation, ...)."`
+	Policy     PolicyCmd     `cmd:"" help:"Check entries against the [[policy]] rules in the configuration."`
+	Validate   ValidateCmd   `cmd:"" help:"Validate values against their JSON Schema ([[schema]] rules or the cli-value-schema tag)."`
+	Check      CheckCmd      `cmd:"" help:"Check that sources provide every key of a manifest such as .env.example."`
+	Skeleton   SkeletonCmd   `cmd:"" help:"Print the keys exec would produce, without their values (e.g. for .env.example)."`
+	Backup     BackupCmd     `cmd:"" help:"Write an encrypted backup of a prefix."`
+	Restore    RestoreCmd    `cmd:"" help:"Restore an encrypted backup written by bundr backup."`
 }
 
 // BackendFactory は BackendType からバックエンドを生成する関数型。
@@ -65,4 +77,9 @@ type Context struct {
 	CacheStore cache.Store
 	// BGLauncher はバックグラウンド更新プロセスの起動（テスト時は MockBGLauncher を差し替え）。
 	BGLauncher BGLauncher
+	// Output は --output で指定された出力形式（"" = コマンド既定の出力）。
+	Output string
+	// RegionContext は指定リージョン用の Context（BackendFactory / CacheStore）を返す。
+	// nil の場合は複数リージョンの横断検索を利用できない。
+	RegionContext func(region string) (*Context, error)
 }
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/youyo/bundr/internal/backend"
+)
+
+// Skeleton value modes.
+const (
+	SkeletonValuesEmpty       = "empty"       // KEY=
+	SkeletonValuesPlaceholder = "placeholder" // KEY=<KEY>
+	SkeletonValuesType        = "type"        // KEY=integer
+)
+
+// SkeletonCmd represents the "skeleton" subcommand.
+type SkeletonCmd struct {
+	From           []string `arg:"" predictor:"prefix" help:"Source prefixes, as for exec --from (e.g. ps:/app/prod/ or APP_=ps:/app/prod/)"`
+	Format         string   `default:"dotenv" enum:"dotenv,json,yaml" help:"Output format (dotenv, json, yaml)"`
+	Values         string   `default:"empty" enum:"empty,placeholder,type" help:"What to write instead of the real values: empty, a <NAME> placeholder, or a type hint (string, integer, number, boolean, json)"`
+	Descriptions   bool     `name:"descriptions" help:"Include parameter descriptions (as comments in dotenv and yaml)"`
+	NoFlatten      bool     `name:"no-flatten" help:"Disable JSON flattening"`
+	ArrayMode      string   `default:"join" enum:"join,index,json" help:"Array handling mode"`
+	ArrayJoinDelim string   `default:"," help:"Delimiter for array join mode"`
+	FlattenDelim   string   `default:"_" help:"Delimiter for flattened keys"`
+	Upper          bool     `default:"true" negatable:"" help:"Uppercase variable names"`
+	Sanitize       string   `name:"sanitize" default:"keep" enum:"replace,drop,error,keep" help:"Handling of invalid environment variable names (replace, drop, error, keep)"`
+
+	out    io.Writer // for testing; nil means os.Stdout
+	errOut io.Writer // for testing; nil means os.Stderr
+}
+
+// skeletonKey is one generated variable with the value to write in its place.
+type skeletonKey struct {
+	name        string
+	value       string
+	description string
+}
+
+// Run executes the skeleton command.
+func (c *SkeletonCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	if c.errOut == nil {
+		c.errOut = os.Stderr
+	}
+	ctx := context.Background()
+	opts := VarsBuildOptions{
+		FlattenDelim:   c.FlattenDelim,
+		ArrayMode:      c.ArrayMode,
+		ArrayJoinDelim: c.ArrayJoinDelim,
+		Upper:          c.Upper,
+		NoFlatten:      c.NoFlatten,
+	}
+	set, err := collectVars(ctx, appCtx, c.From, opts, c.Sanitize, c.errOut)
+	if err != nil {
+		return fmt.Errorf("skeleton command failed: %w", err)
+	}
+
+	var descriptions map[string]string
+	if c.Descriptions {
+		if descriptions, err = c.loadDescriptions(ctx, appCtx); err != nil {
+			return fmt.Errorf("skeleton command failed: %w", err)
+		}
+	}
+
+	names := make([]string, 0, len(set.values))
+	for name := range set.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	keys := make([]skeletonKey, 0, len(names))
+	for _, name := range names {
+		k := skeletonKey{name: name}
+		switch c.Values {
+		case SkeletonValuesPlaceholder:
+			k.value = "<" + name + ">"
+		case SkeletonValuesType:
+			k.value = valueTypeHint(set.values[name])
+		}
+		// 展開されたキーの説明は元のパラメータの説明を使う
+		source, _, _ := strings.Cut(set.sources[name], "#")
+		k.description = descriptions[source]
+		keys = append(keys, k)
+	}
+
+	if err := c.write(keys); err != nil {
+		return fmt.Errorf("skeleton command failed: %w", err)
+	}
+	return nil
+}
+
+// loadDescriptions lists the parameters of every --from source and returns their
+// descriptions keyed by ref. Single refs are looked up in their parent path.
+func (c *SkeletonCmd) loadDescriptions(ctx context.Context, appCtx *Context) (map[string]string, error) {
+	descriptions := map[string]string{}
+	for _, spec := range c.From {
+		_, from := parseFromSpec(spec)
+		ref, err := backend.ParseRef(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ref: %w", err)
+		}
+		b, err := appCtx.BackendFactory(ref.Type)
+		if err != nil {
+			return nil, fmt.Errorf("create backend: %w", err)
+		}
+		prefix, recursive := ref.Path, true
+		if !strings.HasSuffix(prefix, "/") {
+			prefix, recursive = strings.TrimSuffix(path.Dir(ref.Path), "/")+"/", false
+		}
+		err = backend.List(ctx, b, prefix, backend.ListOptions{Recursive: recursive}, func(e backend.ListEntry) error {
+			if e.Description != "" {
+				descriptions[string(ref.Type)+":"+e.Path] = e.Description
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return descriptions, nil
+}
+
+// write renders keys in the selected format.
+func (c *SkeletonCmd) write(keys []skeletonKey) error {
+	switch c.Format {
+	case "json":
+		out := make(map[string]any, len(keys))
+		for _, k := range keys {
+			if c.Descriptions {
+				out[k.name] = map[string]string{"value": k.value, "description": k.description}
+			} else {
+				out[k.name] = k.value
+			}
+		}
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(out)
+	case "yaml":
+		doc := &yaml.Node{Kind: yaml.MappingNode}
+		for _, k := range keys {
+			name := &yaml.Node{Kind: yaml.ScalarNode, Value: k.name, HeadComment: k.description}
+			doc.Content = append(doc.Content, name, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.value})
+		}
+		enc := yaml.NewEncoder(c.out)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		for _, k := range keys {
+			if k.description != "" {
+				for _, line := range strings.Split(k.description, "\n") {
+					fmt.Fprintf(c.out, "# %s\n", line)
+				}
+			}
+			if _, err := fmt.Fprintf(c.out, "%s=%s\n", k.name, k.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// valueTypeHint describes what kind of value v is without revealing it.
+func valueTypeHint(v string) string {
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return "number"
+	}
+	if v == "true" || v == "false" {
+		return "boolean"
+	}
+	if t := strings.TrimSpace(v); (strings.HasPrefix(t, "{") || strings.HasPrefix(t, "[")) && json.Valid([]byte(t)) {
+		return "json"
+	}
+	return "string"
+}
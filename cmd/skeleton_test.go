@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func newSkeletonTestContext(t *testing.T) *Context {
+	t.Helper()
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	puts := map[string]backend.PutOptions{
+		"ps:/app/prod/db_host":  {Value: "db.internal", StoreMode: tags.StoreModeRaw},
+		"ps:/app/prod/db_port":  {Value: "5432", StoreMode: tags.StoreModeRaw},
+		"ps:/app/prod/debug":    {Value: "false", StoreMode: tags.StoreModeRaw},
+		"ps:/app/prod/password": {Value: "s3cr3t", StoreMode: tags.StoreModeRaw, ValueType: backend.ValueTypeSecure},
+		"ps:/app/prod/smtp":     {Value: `{"host":"mail","ratio":0.5}`, StoreMode: tags.StoreModeJSON},
+	}
+	for ref, opts := range puts {
+		if err := mb.Put(ctx, ref, opts); err != nil {
+			t.Fatalf("Put(%s): %v", ref, err)
+		}
+	}
+	mb.SetDescription("ps:/app/prod/db_host", "Primary database host")
+	mb.SetDescription("ps:/app/prod/smtp", "Mail relay\nused by the notifier")
+	return appCtx
+}
+
+func TestSkeletonCmd(t *testing.T) {
+	tests := []struct {
+		name         string
+		from         []string
+		format       string
+		values       string
+		descriptions bool
+		want         string
+	}{
+		{
+			name: "dotenv, empty values",
+			from: []string{"ps:/app/prod/"}, format: "dotenv", values: SkeletonValuesEmpty,
+			want: "DB_HOST=\nDB_PORT=\nDEBUG=\nPASSWORD=\nSMTP_HOST=\nSMTP_RATIO=\n",
+		},
+		{
+			name: "dotenv, type hints and descriptions",
+			from: []string{"ps:/app/prod/"}, format: "dotenv", values: SkeletonValuesType, descriptions: true,
+			want: "# Primary database host\nDB_HOST=string\nDB_PORT=integer\nDEBUG=boolean\nPASSWORD=string\n" +
+				"# Mail relay\n# used by the notifier\nSMTP_HOST=string\n# Mail relay\n# used by the notifier\nSMTP_RATIO=number\n",
+		},
+		{
+			name: "json, placeholders",
+			from: []string{"APP_=ps:/app/prod/db_host", "ps:/app/prod/db_port"}, format: "json", values: SkeletonValuesPlaceholder,
+			want: "{\n  \"APP_DB_HOST\": \"<APP_DB_HOST>\",\n  \"DB_PORT\": \"<DB_PORT>\"\n}\n",
+		},
+		{
+			name: "json with descriptions",
+			from: []string{"ps:/app/prod/db_host"}, format: "json", values: SkeletonValuesEmpty, descriptions: true,
+			want: "{\n  \"DB_HOST\": {\n    \"description\": \"Primary database host\",\n    \"value\": \"\"\n  }\n}\n",
+		},
+		{
+			name: "yaml with descriptions",
+			from: []string{"ps:/app/prod/db_host", "ps:/app/prod/db_port"}, format: "yaml", values: SkeletonValuesType, descriptions: true,
+			want: "# Primary database host\nDB_HOST: string\nDB_PORT: integer\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := &SkeletonCmd{
+				From:           tc.from,
+				Format:         tc.format,
+				Values:         tc.values,
+				Descriptions:   tc.descriptions,
+				ArrayMode:      "join",
+				ArrayJoinDelim: ",",
+				FlattenDelim:   "_",
+				Upper:          true,
+				Sanitize:       SanitizeReplace,
+				out:            &out,
+				errOut:         &bytes.Buffer{},
+			}
+			if err := cmd.Run(newSkeletonTestContext(t)); err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output =\n%s\nwant\n%s", out.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestValueTypeHint(t *testing.T) {
+	tests := map[string]string{
+		"42":          "integer",
+		"-1.5":        "number",
+		"true":        "boolean",
+		`{"a":1}`:     "json",
+		"[1,2]":       "json",
+		"[not json":   "string",
+		"db.internal": "string",
+		"":            "string",
+	}
+	for v, want := range tests {
+		if got := valueTypeHint(v); got != want {
+			t.Errorf("valueTypeHint(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path"
 	"sort"
 	"strings"
 
@@ -17,7 +16,7 @@ import (
 
 // SyncCmd represents the "sync" subcommand.
 type SyncCmd struct {
-	From   string `required:"" short:"f" help:"Source: file path, -, ps:/path, ps:/prefix/, sm:id"`
+	From   string `required:"" short:"f" help:"Source: file path, -, ps:/path, ps:/prefix/, sm:id, env:PREFIX_, dotenv:FILE:"`
 	To     string `required:"" short:"t" help:"Destination: file path, -, ps:/path, ps:/prefix/, sm:id"`
 	Raw    bool   `help:"Output raw value without expanding JSON (only for file/stdout destination)"`
 	Format string `default:"dotenv" enum:"dotenv,export" help:"Output format for file/stdout destination (dotenv or export)"`
@@ -37,15 +36,6 @@ func (c *SyncCmd) Run(appCtx *Context) error {
 	return nil
 }
 
-func isBackendRef(s string) bool {
-	return strings.HasPrefix(s, "ps:") || strings.HasPrefix(s, "sm:") ||
-		strings.HasPrefix(s, "parameterstore:") || strings.HasPrefix(s, "secretsmanager:")
-}
-
-func isPrefix(s string) bool {
-	return strings.HasSuffix(s, "/")
-}
-
 func isStdio(s string) bool {
 	return s == "-"
 }
@@ -55,7 +45,7 @@ func (c *SyncCmd) readEntries(appCtx *Context) ([]dotenv.Entry, error) {
 	ctx := context.Background()
 
 	// File or stdin
-	if !isBackendRef(c.From) {
+	if !backend.IsRef(c.From) {
 		var r io.Reader
 		if isStdio(c.From) {
 			r = os.Stdin
@@ -81,16 +71,19 @@ func (c *SyncCmd) readEntries(appCtx *Context) ([]dotenv.Entry, error) {
 		return nil, fmt.Errorf("create backend: %w", err)
 	}
 
-	// PS or SM prefix (ends with /)
-	if isPrefix(c.From) {
+	if ref.Field != "" && ref.IsPrefix() {
+		return nil, fmt.Errorf("invalid source ref: a #field selector requires a single ref, not a prefix")
+	}
+
+	// Prefix (ps:/app/, env:APP_, dotenv:.env:, ...)
+	if ref.IsPrefix() {
 		results, err := b.GetByPrefix(ctx, ref.Path, backend.GetByPrefixOptions{Recursive: true})
 		if err != nil {
 			return nil, err
 		}
 		var entries []dotenv.Entry
-		normalizedPrefix := strings.TrimRight(ref.Path, "/") + "/"
 		for _, e := range results {
-			relPath := strings.TrimPrefix(e.Path, normalizedPrefix)
+			relPath := strings.TrimPrefix(e.Path, ref.Path)
 			if relPath == "" || relPath == e.Path {
 				continue
 			}
@@ -104,14 +97,14 @@ func (c *SyncCmd) readEntries(appCtx *Context) ([]dotenv.Entry, error) {
 	}
 
 	// Single ref (ps:/path or sm:id)
-	val, err := b.Get(ctx, c.From, backend.GetOptions{ForceRaw: true})
+	val, err := backend.GetValue(ctx, b, c.From, backend.GetOptions{ForceRaw: true})
 	if err != nil {
 		return nil, err
 	}
 
 	// Raw mode: skip JSON expansion, return as single entry
 	if c.Raw {
-		keyName := path.Base(ref.Path)
+		keyName := refKeyName(ref)
 		return []dotenv.Entry{{Key: keyName, Value: val}}, nil
 	}
 
@@ -130,7 +123,7 @@ func (c *SyncCmd) readEntries(appCtx *Context) ([]dotenv.Entry, error) {
 	}
 
 	// Scalar value
-	keyName := path.Base(ref.Path)
+	keyName := refKeyName(ref)
 	return []dotenv.Entry{{Key: keyName, Value: val}}, nil
 }
 
@@ -139,7 +132,7 @@ func (c *SyncCmd) writeEntries(appCtx *Context, entries []dotenv.Entry) error {
 	ctx := context.Background()
 
 	// File or stdout
-	if !isBackendRef(c.To) {
+	if !backend.IsRef(c.To) {
 		var w io.Writer
 		if isStdio(c.To) {
 			w = os.Stdout
@@ -166,6 +159,9 @@ func (c *SyncCmd) writeEntries(appCtx *Context, entries []dotenv.Entry) error {
 	if err != nil {
 		return fmt.Errorf("invalid destination ref: %w", err)
 	}
+	if ref.Field != "" {
+		return fmt.Errorf("invalid destination ref: a #field selector cannot be written to")
+	}
 
 	b, err := appCtx.BackendFactory(ref.Type)
 	if err != nil {
@@ -173,7 +169,7 @@ func (c *SyncCmd) writeEntries(appCtx *Context, entries []dotenv.Entry) error {
 	}
 
 	// PS prefix (ends with /): write each entry as individual parameter
-	if ref.Type == backend.BackendTypePS && isPrefix(c.To) {
+	if ref.Type == backend.BackendTypePS && ref.IsPrefix() {
 		basePath := strings.TrimRight(ref.Path, "/")
 		for _, e := range entries {
 			key := strings.ToLower(e.Key)
@@ -195,10 +191,15 @@ func (c *SyncCmd) writeEntries(appCtx *Context, entries []dotenv.Entry) error {
 		return fmt.Errorf("marshal entries: %w", err)
 	}
 
-	return b.Put(ctx, c.To, backend.PutOptions{
+	opts := backend.PutOptions{
 		Value:     jsonVal,
 		StoreMode: tags.StoreModeJSON,
-	})
+	}
+	// シークレットは設定の kms_key_id で暗号化する
+	if ref.Type == backend.BackendTypeSM && appCtx.Config != nil {
+		opts.KMSKeyID = appCtx.Config.AWS.KMSKeyID
+	}
+	return b.Put(ctx, c.To, opts)
 }
 
 // entriesToJSON converts entries to a JSON object string.
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -449,6 +452,154 @@ func TestSyncCmd_PS_Prefix_JSON_Value_NotExpanded(t *testing.T) {
 	}
 }
 
+func TestSyncCmd_FieldRef_ToStdout(t *testing.T) {
+	// ps:/app/config#db → only the "db" object is expanded
+	mb, appCtx := newSyncTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/config", backend.PutOptions{
+		Value:     `{"db":{"HOST":"localhost"},"cache":{"HOST":"redis"}}`,
+		StoreMode: tags.StoreModeRaw,
+	})
+
+	r, w, _ := os.Pipe()
+	oldStdout := os.Stdout
+	os.Stdout = w
+
+	cmd := &SyncCmd{From: "ps:/app/config#db", To: "-"}
+	err := cmd.Run(appCtx)
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ := io.ReadAll(r)
+	if string(out) != "HOST=localhost\n" {
+		t.Errorf("output = %q, want %q", string(out), "HOST=localhost\n")
+	}
+
+	// A field selector cannot be a destination
+	cmd = &SyncCmd{From: "ps:/app/config", To: "ps:/app/other#db"}
+	if err := cmd.Run(appCtx); err == nil {
+		t.Error("expected error for #field destination, got nil")
+	}
+}
+
+func TestSyncCmd_LocalSources(t *testing.T) {
+	t.Setenv("BUNDR_TEST_DB_HOST", "env-host")
+	t.Setenv("BUNDR_TEST_DB_PORT", "5432")
+	envFile := writeTempEnv(t, "API_KEY=secret\nAPI_URL=https://example.com\n")
+
+	tests := []struct {
+		name string
+		from string
+		want string
+	}{
+		{name: "env prefix", from: "env:BUNDR_TEST_", want: "DB_HOST=env-host\nDB_PORT=5432\n"},
+		{name: "env single", from: "env:BUNDR_TEST_DB_HOST", want: "BUNDR_TEST_DB_HOST=env-host\n"},
+		{name: "dotenv file", from: "dotenv:" + envFile + ":", want: "API_KEY=secret\nAPI_URL=https://example.com\n"},
+		{name: "dotenv key prefix", from: "dotenv:" + envFile + ":API_", want: "KEY=secret\nURL=https://example.com\n"},
+		{name: "dotenv single", from: "dotenv:" + envFile + ":API_KEY", want: "API_KEY=secret\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mb := backend.NewMockBackend()
+			appCtx := &Context{Config: &config.Config{}, BackendFactory: localTestFactory(mb)}
+			out := filepath.Join(t.TempDir(), "out.env")
+			cmd := &SyncCmd{From: tt.from, To: out, Format: "dotenv"}
+			if err := cmd.Run(appCtx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncCmd_LocalDestinationReadOnly(t *testing.T) {
+	mb := backend.NewMockBackend()
+	appCtx := &Context{Config: &config.Config{}, BackendFactory: localTestFactory(mb)}
+	cmd := &SyncCmd{From: writeTempEnv(t, "A=1\n"), To: "env:APP_CONFIG"}
+	err := cmd.Run(appCtx)
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("error = %v, want read-only error", err)
+	}
+}
+
+// newVaultStub serves secrets (by key, mount "secret") read-only over the Vault KV v2 API.
+func newVaultStub(t *testing.T, secrets map[string]map[string]any) *backend.VaultBackend {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+		switch {
+		case r.Method == "LIST" && strings.HasPrefix(path, "metadata/"):
+			dir := strings.TrimPrefix(path, "metadata/")
+			var keys []string
+			for k := range secrets {
+				if rest, ok := strings.CutPrefix(k, dir); ok && !strings.Contains(rest, "/") {
+					keys = append(keys, rest)
+				}
+			}
+			if len(keys) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"keys": keys}})
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "data/"):
+			d, ok := secrets[strings.TrimPrefix(path, "data/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": d, "metadata": map[string]any{"version": 1}}})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return backend.NewVaultBackend(backend.VaultOptions{Address: srv.URL, Token: "test", HTTPClient: srv.Client()})
+}
+
+func TestSyncCmd_VaultToPS(t *testing.T) {
+	// Vault のシークレット（複数フィールド）→ PS flat
+	vb := newVaultStub(t, map[string]map[string]any{
+		"app/prod": {"db_host": "db.internal", "db_password": "s3cret"},
+	})
+	mb := backend.NewMockBackend()
+	appCtx := &Context{
+		Config: &config.Config{},
+		BackendFactory: func(bt backend.BackendType) (backend.Backend, error) {
+			if bt == backend.BackendTypeVault {
+				return vb, nil
+			}
+			return mb, nil
+		},
+	}
+
+	cmd := &SyncCmd{From: "vault:secret/data/app/prod", To: "ps:/app/prod/"}
+	if err := cmd.Run(appCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := map[string]string{}
+	for _, c := range mb.PutCalls {
+		got[c.Ref] = c.Opts.Value
+	}
+	want := map[string]string{"ps:/app/prod/db_host": "db.internal", "ps:/app/prod/db_password": "s3cret"}
+	if len(got) != len(want) {
+		t.Fatalf("puts = %v, want %v", got, want)
+	}
+	for ref, v := range want {
+		if got[ref] != v {
+			t.Errorf("%s = %q, want %q", ref, got[ref], v)
+		}
+	}
+}
+
 func TestSyncCmd_Helpers(t *testing.T) {
 	tests := []struct {
 		name string
@@ -456,12 +607,6 @@ func TestSyncCmd_Helpers(t *testing.T) {
 		in   string
 		want bool
 	}{
-		{"isBackendRef ps:", isBackendRef, "ps:/app/key", true},
-		{"isBackendRef sm:", isBackendRef, "sm:secret", true},
-		{"isBackendRef file", isBackendRef, "/tmp/file.env", false},
-		{"isBackendRef stdin", isBackendRef, "-", false},
-		{"isPrefix yes", isPrefix, "ps:/app/", true},
-		{"isPrefix no", isPrefix, "ps:/app/key", false},
 		{"isStdio yes", isStdio, "-", true},
 		{"isStdio no", isStdio, "file.env", false},
 	}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// TagCmd groups the tag subcommands.
+type TagCmd struct {
+	Ls  TagLsCmd  `cmd:"" help:"List the tags of a ref, or of every entry under a prefix."`
+	Set TagSetCmd `cmd:"" help:"Add or overwrite tags (KEY=VALUE ...) on a ref or every entry under a prefix."`
+	Rm  TagRmCmd  `cmd:"" help:"Remove tags by key from a ref or every entry under a prefix."`
+}
+
+// TagLsCmd represents the "tag ls" subcommand.
+type TagLsCmd struct {
+	Target string `arg:"" predictor:"ref" help:"Ref or prefix (trailing /) to list tags for"`
+
+	out io.Writer // for testing; nil means os.Stdout
+}
+
+// TagSetCmd represents the "tag set" subcommand.
+type TagSetCmd struct {
+	Target      string   `arg:"" predictor:"ref" help:"Ref or prefix (trailing /); a prefix applies to every entry under it recursively"`
+	Tags        []string `arg:"" placeholder:"KEY=VALUE" help:"Tags to add or overwrite"`
+	Force       bool     `name:"force" help:"Allow changing bundr's reserved cli and cli-* tags"`
+	DryRun      bool     `name:"dry-run" help:"Print the changes without applying them"`
+	Concurrency int      `name:"concurrency" default:"4" help:"Number of entries tagged in parallel"`
+
+	out io.Writer // for testing; nil means os.Stdout
+}
+
+// TagRmCmd represents the "tag rm" subcommand.
+type TagRmCmd struct {
+	Target      string   `arg:"" predictor:"ref" help:"Ref or prefix (trailing /); a prefix applies to every entry under it recursively"`
+	Keys        []string `arg:"" placeholder:"KEY" help:"Tag keys to remove"`
+	Force       bool     `name:"force" help:"Allow removing bundr's reserved cli and cli-* tags"`
+	DryRun      bool     `name:"dry-run" help:"Print the changes without applying them"`
+	Concurrency int      `name:"concurrency" default:"4" help:"Number of entries updated in parallel"`
+
+	out io.Writer // for testing; nil means os.Stdout
+}
+
+// Run executes the tag ls command.
+func (c *TagLsCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	ref, prefix, err := parseTagTarget(c.Target)
+	if err != nil {
+		return fmt.Errorf("tag ls failed: %w", err)
+	}
+	b, err := appCtx.BackendFactory(ref.Type)
+	if err != nil {
+		return fmt.Errorf("tag ls failed: create backend: %w", err)
+	}
+	tagger, err := backend.AsTagger(b)
+	if err != nil {
+		return fmt.Errorf("tag ls failed: %w", err)
+	}
+	ctx := context.Background()
+
+	rw := newRecordWriter(c.out, appCtx.Output, "ref", "key", "value")
+	write := func(r string, tagMap map[string]string) error {
+		for _, k := range sortedKeys(tagMap) {
+			var err error
+			switch {
+			case appCtx.Output != "":
+				err = rw.Write(record{"ref": r, "key": k, "value": tagMap[k]})
+			case prefix:
+				_, err = fmt.Fprintf(c.out, "%s\t%s=%s\n", r, k, tagMap[k])
+			default:
+				_, err = fmt.Fprintf(c.out, "%s=%s\n", k, tagMap[k])
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !prefix {
+		tagMap, err := tagger.Tags(ctx, c.Target)
+		if err != nil {
+			return fmt.Errorf("tag ls failed: %w", err)
+		}
+		if err := write(c.Target, tagMap); err != nil {
+			return fmt.Errorf("tag ls failed: %w", err)
+		}
+		return rw.Close()
+	}
+
+	// prefix: 一覧取得のタグ（SM は ListSecrets に含まれる）をそのまま使う
+	err = backend.WalkPrefix(ctx, b, ref.Path, backend.GetByPrefixOptions{Recursive: true}, func(e backend.ParameterEntry) error {
+		r := string(ref.Type) + ":" + e.Path
+		tagMap := e.Tags
+		if tagMap == nil {
+			var err error
+			if tagMap, err = tagger.Tags(ctx, r); err != nil {
+				return err
+			}
+		}
+		return write(r, tagMap)
+	})
+	if err != nil {
+		return fmt.Errorf("tag ls failed: %w", err)
+	}
+	return rw.Close()
+}
+
+// Run executes the tag set command.
+func (c *TagSetCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	tagMap := make(map[string]string, len(c.Tags))
+	for _, t := range c.Tags {
+		k, v, ok := strings.Cut(t, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("tag set failed: invalid tag %q: expected KEY=VALUE", t)
+		}
+		if tags.IsReserved(k) && !c.Force {
+			return fmt.Errorf("tag set failed: %q is reserved for bundr (use --force to change it)", k)
+		}
+		tagMap[k] = v
+	}
+
+	desc := "set"
+	for _, k := range sortedKeys(tagMap) {
+		desc += " " + k + "=" + tagMap[k]
+	}
+	err := applyTagChange(appCtx, c.out, c.Target, desc, c.DryRun, c.Concurrency, func(ctx context.Context, t backend.Tagger, ref string) error {
+		return t.AddTags(ctx, ref, tagMap)
+	})
+	if err != nil {
+		return fmt.Errorf("tag set failed: %w", err)
+	}
+	return nil
+}
+
+// Run executes the tag rm command.
+func (c *TagRmCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	for _, k := range c.Keys {
+		if tags.IsReserved(k) && !c.Force {
+			return fmt.Errorf("tag rm failed: %q is reserved for bundr (use --force to remove it)", k)
+		}
+	}
+
+	err := applyTagChange(appCtx, c.out, c.Target, "rm "+strings.Join(c.Keys, " "), c.DryRun, c.Concurrency, func(ctx context.Context, t backend.Tagger, ref string) error {
+		return t.RemoveTags(ctx, ref, c.Keys)
+	})
+	if err != nil {
+		return fmt.Errorf("tag rm failed: %w", err)
+	}
+	return nil
+}
+
+// parseTagTarget parses a tag command target. prefix is true for "ps:/path/" and "sm:".
+func parseTagTarget(target string) (backend.Ref, bool, error) {
+	if target == "sm:" || target == "secretsmanager:" {
+		return backend.Ref{Type: backend.BackendTypeSM}, true, nil
+	}
+	ref, err := backend.ParseRef(target)
+	if err != nil {
+		return backend.Ref{}, false, fmt.Errorf("invalid ref: %w", err)
+	}
+	if ref.Field != "" {
+		return backend.Ref{}, false, fmt.Errorf("a #field selector cannot be used with tags")
+	}
+	return ref, strings.HasSuffix(ref.Path, "/"), nil
+}
+
+// applyTagChange applies fn to the target ref, or to every entry under a prefix target.
+// Entries are updated by up to concurrency workers; one line per entry is printed
+// (prefixed with "dry-run: " when nothing is changed) and failures are reported together.
+func applyTagChange(appCtx *Context, out io.Writer, target, desc string, dryRun bool, concurrency int, fn func(context.Context, backend.Tagger, string) error) error {
+	ref, prefix, err := parseTagTarget(target)
+	if err != nil {
+		return err
+	}
+	b, err := appCtx.BackendFactory(ref.Type)
+	if err != nil {
+		return fmt.Errorf("create backend: %w", err)
+	}
+	tagger, err := backend.AsTagger(b)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	refs := []string{target}
+	if prefix {
+		refs = nil
+		err := backend.WalkPrefix(ctx, b, ref.Path, backend.GetByPrefixOptions{Recursive: true, SkipTagFetch: true}, func(e backend.ParameterEntry) error {
+			refs = append(refs, string(ref.Type)+":"+e.Path)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		sort.Strings(refs)
+	}
+
+	if dryRun {
+		for _, r := range refs {
+			fmt.Fprintf(out, "dry-run: %s: %s\n", r, desc)
+		}
+		return nil
+	}
+
+	errs := forEachConcurrent(len(refs), concurrency, func(i int) error {
+		return fn(ctx, tagger, refs[i])
+	})
+
+	var failed []string
+	for i, r := range refs {
+		if errs[i] != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", r, errs[i]))
+			continue
+		}
+		fmt.Fprintf(out, "%s: %s\n", r, desc)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d entries failed: %s", len(failed), len(refs), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// forEachConcurrent calls fn(i) for i in [0, n) on up to concurrency goroutines and
+// returns the error of each call by index.
+func forEachConcurrent(n, concurrency int, fn func(i int) error) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	errs := make([]error, n)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func newTagTestContext(t *testing.T) (*backend.MockBackend, *Context) {
+	t.Helper()
+	mb := backend.NewMockBackend()
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/a", backend.PutOptions{Value: "1", StoreMode: tags.StoreModeRaw, Tags: map[string]string{"team": "core"}})
+	_ = mb.Put(ctx, "ps:/app/sub/b", backend.PutOptions{Value: "2", StoreMode: tags.StoreModeRaw})
+	return mb, &Context{
+		BackendFactory: func(_ backend.BackendType) (backend.Backend, error) {
+			return mb, nil
+		},
+	}
+}
+
+func TestTagLsCmd(t *testing.T) {
+	tests := []struct {
+		id     string
+		target string
+		output string
+		want   string
+	}{
+		{id: "ref", target: "ps:/app/a", want: "cli=bundr\ncli-schema=v1\ncli-store-mode=raw\nteam=core\n"},
+		{id: "prefix", target: "ps:/app/sub/", want: "ps:/app/sub/b\tcli=bundr\nps:/app/sub/b\tcli-schema=v1\nps:/app/sub/b\tcli-store-mode=raw\n"},
+		{id: "output-tsv", target: "ps:/app/a", output: OutputTSV, want: "ref\tkey\tvalue\nps:/app/a\tcli\tbundr\nps:/app/a\tcli-schema\tv1\nps:/app/a\tcli-store-mode\traw\nps:/app/a\tteam\tcore\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			_, appCtx := newTagTestContext(t)
+			appCtx.Output = tc.output
+			var out bytes.Buffer
+			cmd := &TagLsCmd{Target: tc.target, out: &out}
+			if err := cmd.Run(appCtx); err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output = %q, want %q", out.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestTagSetCmd(t *testing.T) {
+	tests := []struct {
+		id       string
+		cmd      TagSetCmd
+		want     string
+		wantErr  string
+		wantTags map[string]map[string]string // ref → expected subset of tags
+	}{
+		{
+			id:       "ref",
+			cmd:      TagSetCmd{Target: "ps:/app/a", Tags: []string{"owner=alice", "team=web"}},
+			want:     "ps:/app/a: set owner=alice team=web\n",
+			wantTags: map[string]map[string]string{"ps:/app/a": {"owner": "alice", "team": "web"}},
+		},
+		{
+			id:   "prefix-recursive",
+			cmd:  TagSetCmd{Target: "ps:/app/", Tags: []string{"env=prod"}, Concurrency: 2},
+			want: "ps:/app/a: set env=prod\nps:/app/sub/b: set env=prod\n",
+			wantTags: map[string]map[string]string{
+				"ps:/app/a":     {"env": "prod"},
+				"ps:/app/sub/b": {"env": "prod"},
+			},
+		},
+		{
+			id:       "dry-run",
+			cmd:      TagSetCmd{Target: "ps:/app/", Tags: []string{"env=prod"}, DryRun: true},
+			want:     "dry-run: ps:/app/a: set env=prod\ndry-run: ps:/app/sub/b: set env=prod\n",
+			wantTags: map[string]map[string]string{"ps:/app/a": {"env": ""}},
+		},
+		{id: "reserved", cmd: TagSetCmd{Target: "ps:/app/a", Tags: []string{"cli-store-mode=json"}}, wantErr: "reserved for bundr"},
+		{
+			id:       "reserved-force",
+			cmd:      TagSetCmd{Target: "ps:/app/a", Tags: []string{"cli-store-mode=json"}, Force: true},
+			want:     "ps:/app/a: set cli-store-mode=json\n",
+			wantTags: map[string]map[string]string{"ps:/app/a": {tags.TagStoreMode: tags.StoreModeJSON}},
+		},
+		{id: "invalid", cmd: TagSetCmd{Target: "ps:/app/a", Tags: []string{"owner"}}, wantErr: "expected KEY=VALUE"},
+		{id: "missing-ref", cmd: TagSetCmd{Target: "ps:/app/nope", Tags: []string{"a=b"}}, wantErr: "1 of 1 entries failed"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			mb, appCtx := newTagTestContext(t)
+			var out bytes.Buffer
+			cmd := tc.cmd
+			cmd.out = &out
+			err := cmd.Run(appCtx)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Run() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output = %q, want %q", out.String(), tc.want)
+			}
+			for ref, want := range tc.wantTags {
+				got, _ := mb.Tags(context.Background(), ref)
+				for k, v := range want {
+					if got[k] != v {
+						t.Errorf("%s tag %s = %q, want %q", ref, k, got[k], v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTagRmCmd(t *testing.T) {
+	tests := []struct {
+		id      string
+		cmd     TagRmCmd
+		want    string
+		wantErr string
+	}{
+		{id: "ref", cmd: TagRmCmd{Target: "ps:/app/a", Keys: []string{"team"}}, want: "ps:/app/a: rm team\n"},
+		{id: "reserved", cmd: TagRmCmd{Target: "ps:/app/", Keys: []string{"cli"}}, wantErr: "reserved for bundr"},
+		{id: "reserved-force", cmd: TagRmCmd{Target: "ps:/app/a", Keys: []string{"cli"}, Force: true}, want: "ps:/app/a: rm cli\n"},
+		{id: "field", cmd: TagRmCmd{Target: "ps:/app/a#x", Keys: []string{"team"}}, wantErr: "#field selector"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			mb, appCtx := newTagTestContext(t)
+			var out bytes.Buffer
+			cmd := tc.cmd
+			cmd.out = &out
+			err := cmd.Run(appCtx)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Run() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output = %q, want %q", out.String(), tc.want)
+			}
+			got, _ := mb.Tags(context.Background(), "ps:/app/a")
+			for _, k := range tc.cmd.Keys {
+				if _, ok := got[k]; ok {
+					t.Errorf("tag %s still present: %v", k, got)
+				}
+			}
+		})
+	}
+}
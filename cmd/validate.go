@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/policy"
+)
+
+// validateInvalidExitCode is the exit status of validate when a value does not match its schema (errors exit with 1).
+const validateInvalidExitCode = 2
+
+// ValidateCmd represents the "validate" subcommand.
+type ValidateCmd struct {
+	Target string `arg:"" predictor:"prefix" help:"Ref or prefix (trailing /, or sm: for all secrets) whose values to validate"`
+
+	out io.Writer // for testing; nil means os.Stdout
+}
+
+// validateResult is the outcome for one entry that has a schema.
+type validateResult struct {
+	ref    string
+	schema string
+	err    error // nil = valid
+}
+
+// Run executes the validate command.
+func (c *ValidateCmd) Run(appCtx *Context) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	var rules []config.SchemaRule
+	if appCtx.Config != nil {
+		rules = appCtx.Config.Schemas
+	}
+	schemas, err := policy.CompileSchemas(rules, policy.SchemaLoader(appCtx.BackendFactory))
+	if err != nil {
+		return fmt.Errorf("validate command failed: %w", err)
+	}
+	ref, prefix, err := parseTagTarget(c.Target)
+	if err != nil {
+		return fmt.Errorf("validate command failed: %w", err)
+	}
+	b, err := appCtx.BackendFactory(ref.Type)
+	if err != nil {
+		return fmt.Errorf("validate command failed: create backend: %w", err)
+	}
+
+	results, total, err := c.validate(context.Background(), b, schemas, ref, prefix)
+	if err != nil {
+		return fmt.Errorf("validate command failed: %w", err)
+	}
+	invalid := 0
+	for _, r := range results {
+		if r.err != nil {
+			invalid++
+		}
+	}
+	if err := c.report(appCtx.Output, results, total, invalid); err != nil {
+		return fmt.Errorf("validate command failed: %w", err)
+	}
+	if invalid > 0 {
+		return &ExitCodeError{Code: validateInvalidExitCode}
+	}
+	return nil
+}
+
+// validate checks the value of every entry of the target that has a schema and returns
+// the results together with the number of entries read. A schema that cannot be loaded
+// is reported as the result of the entries using it.
+func (c *ValidateCmd) validate(ctx context.Context, b backend.Backend, schemas *policy.SchemaSet, ref backend.Ref, prefix bool) ([]validateResult, int, error) {
+	check := func(target, value string, tagMap map[string]string) *validateResult {
+		src := schemas.Source(target, tagMap)
+		if src == "" {
+			return nil
+		}
+		r := validateResult{ref: target, schema: src}
+		if err := schemas.Validate(target, src, value); err != nil {
+			var se *policy.SchemaError
+			if errors.As(err, &se) {
+				err = se.Err
+			}
+			r.err = err
+		}
+		return &r
+	}
+
+	if !prefix {
+		target := string(ref.Type) + ":" + ref.Path
+		value, err := b.Get(ctx, target, backend.GetOptions{ForceRaw: true})
+		if err != nil {
+			return nil, 0, err
+		}
+		var tagMap map[string]string
+		if t, err := backend.AsTagger(b); err == nil {
+			if tagMap, err = t.Tags(ctx, target); err != nil {
+				return nil, 0, err
+			}
+		}
+		if r := check(target, value, tagMap); r != nil {
+			return []validateResult{*r}, 1, nil
+		}
+		return nil, 1, nil
+	}
+
+	var results []validateResult
+	total := 0
+	err := backend.WalkPrefix(ctx, b, ref.Path, backend.GetByPrefixOptions{Recursive: true}, func(e backend.ParameterEntry) error {
+		total++
+		if r := check(string(ref.Type)+":"+e.Path, e.Value, e.Tags); r != nil {
+			results = append(results, *r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// report prints one line per invalid value and a summary, or one record per validated
+// entry with --output.
+func (c *ValidateCmd) report(format string, results []validateResult, total, invalid int) error {
+	if format != "" {
+		rw := newRecordWriter(c.out, format, "ref", "schema", "valid", "error")
+		for _, r := range results {
+			rec := record{"ref": r.ref, "schema": r.schema, "valid": r.err == nil, "error": ""}
+			if r.err != nil {
+				rec["error"] = r.err.Error()
+			}
+			if err := rw.Write(rec); err != nil {
+				return err
+			}
+		}
+		return rw.Close()
+	}
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(c.out, "%s: %v (schema %s)\n", r.ref, r.err, r.schema)
+		}
+	}
+	_, err := fmt.Fprintf(c.out, "validated %d entries (%d without a schema): %d invalid\n", len(results), total-len(results), invalid)
+	return err
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// newValidateTestContext stores config values under /app/, a schema parameter and a
+// secret tagged with that schema, with a [[schema]] rule for the app configs.
+func newValidateTestContext(t *testing.T) *Context {
+	t.Helper()
+	ctx := context.Background()
+	ps, sm := backend.NewMockBackend(), backend.NewMockBackend()
+	puts := []struct {
+		b          *backend.MockBackend
+		ref, value string
+	}{
+		{ps, "ps:/schemas/db", `{"type":"object","required":["host","port"],"properties":{"port":{"type":"integer"}}}`},
+		{ps, "ps:/app/prod/db", `{"host":"db","port":5432}`},
+		{ps, "ps:/app/dev/db", `{"host":"localhost","port":"5432"}`},
+		{ps, "ps:/app/dev/name", `web`},
+		{sm, "sm:app-db", `{"host":"db"}`},
+	}
+	for _, p := range puts {
+		if err := p.b.Put(ctx, p.ref, backend.PutOptions{Value: p.value, StoreMode: tags.StoreModeRaw}); err != nil {
+			t.Fatalf("Put(%s): %v", p.ref, err)
+		}
+	}
+	sm.SetTags("sm:app-db", map[string]string{tags.TagValueSchema: "ps:/schemas/db"})
+
+	return &Context{
+		Config: &config.Config{Schemas: []config.SchemaRule{{Match: "ps:/app/*/db", Schema: "ps:/schemas/db"}}},
+		BackendFactory: func(bt backend.BackendType) (backend.Backend, error) {
+			if bt == backend.BackendTypeSM {
+				return sm, nil
+			}
+			return ps, nil
+		},
+	}
+}
+
+func TestValidateCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		want     string
+		wantCode int
+		wantErr  string
+	}{
+		{
+			name:     "prefix",
+			target:   "ps:/app/",
+			want:     "ps:/app/dev/db: /port: expected integer, got string (schema ps:/schemas/db)\nvalidated 2 entries (1 without a schema): 1 invalid\n",
+			wantCode: validateInvalidExitCode,
+		},
+		{name: "valid ref", target: "ps:/app/prod/db", want: "validated 1 entries (0 without a schema): 0 invalid\n"},
+		{name: "ref without schema", target: "ps:/app/dev/name", want: "validated 0 entries (1 without a schema): 0 invalid\n"},
+		{
+			name:     "schema from tag",
+			target:   "sm:",
+			want:     "sm:app-db: /: missing required properties: port (schema ps:/schemas/db)\nvalidated 1 entries (0 without a schema): 1 invalid\n",
+			wantCode: validateInvalidExitCode,
+		},
+		{name: "missing ref", target: "ps:/app/prod/nope", wantErr: "key not found"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := ValidateCmd{Target: tc.target, out: &out}
+			err := cmd.Run(newValidateTestContext(t))
+			switch {
+			case tc.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Run() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			case tc.wantCode != 0:
+				var exitErr *ExitCodeError
+				if !isExitCodeError(err, &exitErr) || exitErr.Code != tc.wantCode {
+					t.Fatalf("err = %v, want ExitCodeError{%d}", err, tc.wantCode)
+				}
+			case err != nil:
+				t.Fatalf("Run() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output =\n%s\nwant\n%s", out.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateCmd_Output(t *testing.T) {
+	var out bytes.Buffer
+	appCtx := newValidateTestContext(t)
+	appCtx.Output = OutputJSON
+	cmd := ValidateCmd{Target: "ps:/app/", out: &out}
+	if err := cmd.Run(appCtx); err == nil {
+		t.Fatal("expected ExitCodeError")
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(got) != 2 || got[0]["ref"] != "ps:/app/dev/db" || got[0]["valid"] != false || got[1]["valid"] != true {
+		t.Errorf("records = %v", got)
+	}
+}
@@ -3,11 +3,15 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"path"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/youyo/bundr/internal/backend"
 	"github.com/youyo/bundr/internal/flatten"
+	"github.com/youyo/bundr/internal/jsonquery"
 	"github.com/youyo/bundr/internal/tags"
 )
 
@@ -19,11 +23,21 @@ type VarsBuildOptions struct {
 	ArrayJoinDelim string
 	Upper          bool
 	NoFlatten      bool
+	Warn           io.Writer // receives warnings such as malformed cli-flatten tags (nil discards them)
 }
 
-// buildVars fetches parameters from the given prefix and returns a key→value map.
+// VarEntry is a single generated variable together with the ref it came from.
+type VarEntry struct {
+	Name   string
+	Value  string
+	Source string // ref, plus "#json.path" for keys flattened out of a JSON value
+}
+
+// buildVars fetches parameters from the given prefix and returns the generated variables.
+// Entries are ordered deterministically (by parameter path, then JSON key); when two
+// entries produce the same name, the later one wins.
 // Used by ExecCmd.
-func buildVars(ctx context.Context, appCtx *Context, opts VarsBuildOptions) (map[string]string, error) {
+func buildVars(ctx context.Context, appCtx *Context, opts VarsBuildOptions) ([]VarEntry, error) {
 	ref, err := backend.ParseRef(opts.From)
 	if err != nil {
 		return nil, fmt.Errorf("invalid ref: %w", err)
@@ -38,13 +52,18 @@ func buildVars(ctx context.Context, appCtx *Context, opts VarsBuildOptions) (map
 		return nil, fmt.Errorf("create backend: %w", err)
 	}
 
-	entries, err := b.GetByPrefix(ctx, ref.Path, backend.GetByPrefixOptions{Recursive: true})
-	if err != nil {
-		return nil, err
-	}
+	var entries []backend.ParameterEntry
+	if ref.Field == "" {
+		entries, err = b.GetByPrefix(ctx, ref.Path, backend.GetByPrefixOptions{Recursive: true})
+		if err != nil {
+			return nil, err
+		}
 
-	if appCtx.CacheStore != nil {
-		_ = appCtx.CacheStore.Write(string(ref.Type), toCacheEntries(entries))
+		if appCtx.CacheStore != nil {
+			_ = appCtx.CacheStore.Write(string(ref.Type), ref.Path, toCacheEntries(entries))
+		}
+	} else if ref.IsPrefix() {
+		return nil, fmt.Errorf("%s: a #field selector requires a single ref, not a prefix", opts.From)
 	}
 
 	flatOpts := flatten.Options{
@@ -55,44 +74,211 @@ func buildVars(ctx context.Context, appCtx *Context, opts VarsBuildOptions) (map
 		NoFlatten:      opts.NoFlatten,
 	}
 
-	vars := make(map[string]string)
-
-	if len(entries) == 0 && !strings.HasSuffix(ref.Path, "/") {
-		val, err := b.Get(ctx, opts.From, backend.GetOptions{})
+	if len(entries) == 0 && !ref.IsPrefix() {
+		val, err := backend.GetValue(ctx, b, opts.From, backend.GetOptions{})
 		if err != nil {
 			return nil, err
 		}
-		keyName := path.Base(ref.Path)
+		keyName := refKeyName(ref)
 		normalizedKey := flatten.ApplyCasing(keyName, flatOpts)
 		normalizedKey = strings.ReplaceAll(normalizedKey, ".", opts.FlattenDelim)
-		vars[normalizedKey] = val
-		return vars, nil
+		return []VarEntry{{Name: normalizedKey, Value: val, Source: opts.From}}, nil
 	}
 
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	var vars []VarEntry
 	for _, entry := range entries {
-		keyPrefix := pathToKey(entry.Path, ref.Path, opts.FlattenDelim)
+		keyPrefix := pathToKey(entry.Path, ref, opts.FlattenDelim)
+		source := string(ref.Type) + ":" + entry.Path
 
-		if entry.StoreMode == tags.StoreModeJSON && !opts.NoFlatten {
-			kvs, err := flatten.Flatten(keyPrefix, entry.Value, flatOpts)
+		// A cli-flatten policy overrides the command-line options for this entry.
+		// Any policy other than "no" also opts a raw parameter holding JSON into flattening.
+		// A malformed policy is reported and the command-line options are used instead.
+		policy, err := flatten.ParsePolicy(entry.Flatten)
+		if err != nil && opts.Warn != nil {
+			fmt.Fprintf(opts.Warn, "bundr: warning: %s: ignoring invalid %s tag: %v\n", source, tags.TagFlatten, err)
+		}
+		entryOpts := flatOpts
+		doFlatten := entry.StoreMode == tags.StoreModeJSON && !opts.NoFlatten
+		if entry.Flatten != "" && err == nil {
+			entryOpts = policy.Apply(flatOpts)
+			entryOpts.NoFlatten = policy.NoFlatten
+			doFlatten = !policy.NoFlatten
+		}
+
+		if doFlatten {
+			kvs, err := flatten.FlattenEntries(keyPrefix, entry.Value, entryOpts)
 			if err != nil {
 				return nil, fmt.Errorf("flatten %s: %w", entry.Path, err)
 			}
-			for k, v := range kvs {
-				k = strings.ReplaceAll(k, ".", opts.FlattenDelim)
-				vars[k] = v
+			for _, kv := range kvs {
+				src := source
+				if kv.Path != "" {
+					src += "#" + kv.Path
+				}
+				vars = append(vars, VarEntry{
+					Name:   strings.ReplaceAll(kv.Key, ".", entryOpts.Delimiter),
+					Value:  kv.Value,
+					Source: src,
+				})
 			}
 		} else {
 			normalizedKey := flatten.ApplyCasing(keyPrefix, flatOpts)
 			normalizedKey = strings.ReplaceAll(normalizedKey, ".", opts.FlattenDelim)
-			vars[normalizedKey] = entry.Value
+			vars = append(vars, VarEntry{Name: normalizedKey, Value: entry.Value, Source: source})
 		}
 	}
 
 	return vars, nil
 }
 
-// pathToKey converts an SSM path to a key name by trimming the from prefix.
-func pathToKey(paramPath, fromPath, delim string) string {
-	trimmed := strings.TrimPrefix(paramPath, strings.TrimRight(fromPath, "/")+"/")
+// collectVars builds the variables of every --from entry in order (later entries take
+// precedence), applying an optional "PREFIX_=" of the entry and the sanitize mode.
+// Dropped names are reported to warn. opts.From is set per entry.
+func collectVars(ctx context.Context, appCtx *Context, from []string, opts VarsBuildOptions, sanitize string, warn io.Writer) (*varSet, error) {
+	set := newVarSet()
+	opts.Warn = warn
+	for i, spec := range from {
+		varPrefix, ref := parseFromSpec(spec)
+		opts.From = ref
+		result, err := buildVars(ctx, appCtx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range result {
+			name, ok, err := sanitizeEnvName(varPrefix+e.Name, sanitize)
+			if err != nil {
+				return nil, fmt.Errorf("%w (from %s)", err, e.Source)
+			}
+			if !ok {
+				fmt.Fprintf(warn, "bundr: warning: dropped invalid variable name %q (from %s)\n", varPrefix+e.Name, e.Source)
+				continue
+			}
+			e.Name = name
+			set.add(e, i)
+		}
+	}
+	return set, nil
+}
+
+// manifestKeyNormalizer returns the function that maps a manifest key to the variable
+// name buildVars would generate for it with opts: the same casing, delimiter and
+// sanitizing, so that "db-host" in a manifest matches DB_HOST.
+func manifestKeyNormalizer(opts VarsBuildOptions, sanitize string) func(string) string {
+	flatOpts := flatten.Options{Upper: opts.Upper}
+	return func(key string) string {
+		name := flatten.ApplyCasing(key, flatOpts)
+		name = strings.ReplaceAll(name, ".", opts.FlattenDelim)
+		if sanitized, ok, err := sanitizeEnvName(name, sanitize); err == nil && ok {
+			return sanitized
+		}
+		return name
+	}
+}
+
+// refKeyName returns the variable name base for a single ref: the last key of its
+// #field selector when there is one, otherwise the last path segment.
+func refKeyName(ref backend.Ref) string {
+	if ref.Field != "" {
+		if q, err := jsonquery.Parse(ref.Field); err == nil && q.Name() != "" {
+			return q.Name()
+		}
+	}
+	if ref.Type == backend.BackendTypeDotenv {
+		_, key, _ := backend.SplitDotenvPath(ref.Path)
+		return key
+	}
+	return path.Base(ref.Path)
+}
+
+// pathToKey converts an entry path to a key name by trimming the from prefix.
+// A ref that is not itself a prefix (ps:/app) is read as the prefix under it (ps:/app/).
+func pathToKey(paramPath string, from backend.Ref, delim string) string {
+	prefix := from.Path
+	if !from.IsPrefix() {
+		prefix = strings.TrimRight(prefix, "/") + "/"
+	}
+	trimmed := strings.TrimPrefix(paramPath, prefix)
 	return strings.ReplaceAll(trimmed, "/", delim)
 }
+
+// Sanitize modes for invalid environment variable names.
+const (
+	SanitizeReplace = "replace" // replace invalid characters with "_" (and prefix a leading digit)
+	SanitizeDrop    = "drop"    // skip the variable
+	SanitizeError   = "error"   // fail
+	SanitizeKeep    = "keep"    // pass the name through unchanged
+)
+
+// envNameRe matches a POSIX-portable environment variable name.
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// invalidEnvCharRe matches characters that are not allowed in an environment variable name.
+var invalidEnvCharRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// sanitizeEnvName applies the sanitize mode to name. An empty mode keeps the name.
+// It returns the name to use and false when the variable should be dropped.
+func sanitizeEnvName(name, mode string) (string, bool, error) {
+	if mode == SanitizeKeep || mode == "" || envNameRe.MatchString(name) {
+		return name, true, nil
+	}
+	switch mode {
+	case SanitizeDrop:
+		return "", false, nil
+	case SanitizeError:
+		return "", false, fmt.Errorf("invalid environment variable name %q", name)
+	default:
+		sanitized := invalidEnvCharRe.ReplaceAllString(name, "_")
+		if sanitized == "" || (sanitized[0] >= '0' && sanitized[0] <= '9') {
+			sanitized = "_" + sanitized
+		}
+		return sanitized, true, nil
+	}
+}
+
+// Collision records two sources that produced the same variable name.
+type Collision struct {
+	Name        string
+	Winner      string // source whose value is used
+	Loser       string // source whose value was overwritten
+	CrossSource bool   // true when winner and loser come from different --from entries
+}
+
+// String formats the collision for reports, e.g. "DB_HOST: ps:/app/db_host overrides ps:/app/db/host".
+func (c Collision) String() string {
+	return fmt.Sprintf("%s: %s overrides %s", c.Name, c.Winner, c.Loser)
+}
+
+// varSet accumulates variables from several --from entries and records collisions.
+type varSet struct {
+	values     map[string]string
+	sources    map[string]string
+	origins    map[string]int
+	collisions []Collision
+}
+
+func newVarSet() *varSet {
+	return &varSet{
+		values:  make(map[string]string),
+		sources: make(map[string]string),
+		origins: make(map[string]int),
+	}
+}
+
+// add stores a variable produced by the --from entry at index origin. Later calls win.
+func (s *varSet) add(e VarEntry, origin int) {
+	if prev, ok := s.sources[e.Name]; ok {
+		s.collisions = append(s.collisions, Collision{
+			Name:        e.Name,
+			Winner:      e.Source,
+			Loser:       prev,
+			CrossSource: s.origins[e.Name] != origin,
+		})
+	}
+	s.values[e.Name] = e.Value
+	s.sources[e.Name] = e.Source
+	s.origins[e.Name] = origin
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func TestSanitizeEnvName(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		want     string
+		wantKeep bool
+		wantErr  bool
+	}{
+		{name: "DB_HOST", mode: SanitizeReplace, want: "DB_HOST", wantKeep: true},
+		{name: "DB@HOST", mode: SanitizeReplace, want: "DB_HOST", wantKeep: true},
+		{name: "1PASSWORD", mode: SanitizeReplace, want: "_1PASSWORD", wantKeep: true},
+		{name: "DB@HOST", mode: SanitizeDrop, wantKeep: false},
+		{name: "DB@HOST", mode: SanitizeError, wantErr: true},
+		{name: "DB@HOST", mode: SanitizeKeep, want: "DB@HOST", wantKeep: true},
+		{name: "DB@HOST", mode: "", want: "DB@HOST", wantKeep: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name+"/"+tc.mode, func(t *testing.T) {
+			got, keep, err := sanitizeEnvName(tc.name, tc.mode)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want || keep != tc.wantKeep {
+				t.Errorf("sanitizeEnvName(%q, %q) = (%q, %v), want (%q, %v)", tc.name, tc.mode, got, keep, tc.want, tc.wantKeep)
+			}
+		})
+	}
+}
+
+func TestBuildVars_Deterministic(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/db/host", backend.PutOptions{Value: "nested", StoreMode: tags.StoreModeRaw})
+	_ = mb.Put(ctx, "ps:/app/db_host", backend.PutOptions{Value: "flat", StoreMode: tags.StoreModeRaw})
+
+	vars, err := buildVars(ctx, appCtx, VarsBuildOptions{From: "ps:/app/", FlattenDelim: "_", ArrayMode: "join", ArrayJoinDelim: ",", Upper: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vars) != 2 {
+		t.Fatalf("got %d vars, want 2: %v", len(vars), vars)
+	}
+	// Sorted by path: "/app/db/host" < "/app/db_host"
+	if vars[0].Source != "ps:/app/db/host" || vars[1].Source != "ps:/app/db_host" {
+		t.Errorf("sources = %q, %q", vars[0].Source, vars[1].Source)
+	}
+}
+
+func TestExecCmd_Collisions(t *testing.T) {
+	setup := func(t *testing.T) (*Context, *MockRunner) {
+		mb, appCtx := newExecTestContext(t)
+		ctx := context.Background()
+		_ = mb.Put(ctx, "ps:/app/db/host", backend.PutOptions{Value: "nested", StoreMode: tags.StoreModeRaw})
+		_ = mb.Put(ctx, "ps:/app/db_host", backend.PutOptions{Value: "flat", StoreMode: tags.StoreModeRaw})
+		_ = mb.Put(ctx, "ps:/shared/PORT", backend.PutOptions{Value: "1", StoreMode: tags.StoreModeRaw})
+		_ = mb.Put(ctx, "ps:/app/PORT", backend.PutOptions{Value: "2", StoreMode: tags.StoreModeRaw})
+		return appCtx, &MockRunner{}
+	}
+
+	t.Run("warn-within-source", func(t *testing.T) {
+		appCtx, mr := setup(t)
+		var errOut bytes.Buffer
+		cmd := setupExecCmd([]string{"ps:/shared/", "ps:/app/"}, []string{"env"}, func(c *ExecCmd) {
+			c.runner = mr
+			c.errOut = &errOut
+		})
+		if err := cmd.Run(appCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out := errOut.String()
+		if !strings.Contains(out, "DB_HOST: ps:/app/db_host overrides ps:/app/db/host") {
+			t.Errorf("stderr = %q, want within-source collision", out)
+		}
+		if strings.Contains(out, "PORT") {
+			t.Errorf("cross-source override should not be reported by default: %q", out)
+		}
+		if got := envMap(mr.LastEnv())["DB_HOST"]; got != "flat" {
+			t.Errorf("DB_HOST = %q, want %q", got, "flat")
+		}
+	})
+
+	t.Run("report-collisions", func(t *testing.T) {
+		appCtx, mr := setup(t)
+		var errOut bytes.Buffer
+		cmd := setupExecCmd([]string{"ps:/shared/", "ps:/app/"}, []string{"env"}, func(c *ExecCmd) {
+			c.runner = mr
+			c.errOut = &errOut
+			c.ReportCollisions = true
+		})
+		if err := cmd.Run(appCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(errOut.String(), "PORT: ps:/app/PORT overrides ps:/shared/PORT") {
+			t.Errorf("stderr = %q, want cross-source collision", errOut.String())
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		appCtx, mr := setup(t)
+		cmd := setupExecCmd([]string{"ps:/shared/", "ps:/app/"}, []string{"env"}, func(c *ExecCmd) {
+			c.runner = mr
+			c.Strict = true
+		})
+		err := cmd.Run(appCtx)
+		if err == nil || !strings.Contains(err.Error(), "--strict") {
+			t.Fatalf("err = %v, want strict collision error", err)
+		}
+		if mr.Called() {
+			t.Error("runner must not be called with --strict collisions")
+		}
+	})
+}
+
+func TestExecCmd_SanitizeError(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	_ = mb.Put(context.Background(), "ps:/app/1st@key", backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw})
+
+	cmd := setupExecCmd([]string{"ps:/app/"}, []string{"env"}, func(c *ExecCmd) {
+		c.runner = &MockRunner{}
+		c.Sanitize = SanitizeError
+	})
+	err := cmd.Run(appCtx)
+	if err == nil || !strings.Contains(err.Error(), `invalid environment variable name "1ST@KEY"`) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestBuildVars_FlattenPolicy(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	const doc = `{"db":{"host":"h","port":5432},"hosts":["a","b"]}`
+	_ = mb.Put(ctx, "ps:/app/default", backend.PutOptions{Value: doc, StoreMode: tags.StoreModeJSON})
+	_ = mb.Put(ctx, "ps:/app/index", backend.PutOptions{Value: doc, StoreMode: tags.StoreModeJSON, Tags: map[string]string{tags.TagFlatten: "array=index"}})
+	_ = mb.Put(ctx, "ps:/app/depth", backend.PutOptions{Value: doc, StoreMode: tags.StoreModeJSON, Tags: map[string]string{tags.TagFlatten: "depth=1 delim=__"}})
+	_ = mb.Put(ctx, "ps:/app/whole", backend.PutOptions{Value: doc, StoreMode: tags.StoreModeJSON, Tags: map[string]string{tags.TagFlatten: "no"}})
+	// A policy opts a raw parameter holding JSON into flattening.
+	_ = mb.Put(ctx, "ps:/app/raw", backend.PutOptions{Value: `{"k":"v"}`, StoreMode: tags.StoreModeRaw, Tags: map[string]string{tags.TagFlatten: "array=join"}})
+
+	vars, err := buildVars(ctx, appCtx, VarsBuildOptions{From: "ps:/app/", FlattenDelim: "_", ArrayMode: "join", ArrayJoinDelim: ",", Upper: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[string]string)
+	for _, v := range vars {
+		got[v.Name] = v.Value
+	}
+	want := map[string]string{
+		"DEFAULT_DB_HOST": "h",
+		"DEFAULT_DB_PORT": "5432",
+		"DEFAULT_HOSTS":   "a,b",
+		"INDEX_DB_HOST":   "h",
+		"INDEX_DB_PORT":   "5432",
+		"INDEX_HOSTS_0":   "a",
+		"INDEX_HOSTS_1":   "b",
+		"DEPTH__DB":       `{"host":"h","port":5432}`,
+		"DEPTH__HOSTS":    `["a","b"]`,
+		"WHOLE":           doc,
+		"RAW_K":           "v",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d vars, want %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestBuildVars_InvalidFlattenPolicy(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/cfg", backend.PutOptions{Value: `{}`, StoreMode: tags.StoreModeJSON, Tags: map[string]string{tags.TagFlatten: "array=zip"}})
+
+	_ = mb.Put(ctx, "ps:/app/host", backend.PutOptions{Value: "h", StoreMode: tags.StoreModeRaw})
+
+	// 壊れたタグは警告し、コマンドラインの設定で展開を続ける
+	var warn bytes.Buffer
+	vars, err := buildVars(ctx, appCtx, VarsBuildOptions{From: "ps:/app/", FlattenDelim: "_", ArrayMode: "join", ArrayJoinDelim: ",", Upper: true, Warn: &warn})
+	if err != nil {
+		t.Fatalf("buildVars() error: %v", err)
+	}
+	if len(vars) != 1 || vars[0].Name != "HOST" {
+		t.Errorf("vars = %+v, want only HOST", vars)
+	}
+	if !strings.Contains(warn.String(), "ps:/app/cfg: ignoring invalid cli-flatten tag") {
+		t.Errorf("warnings = %q, want invalid cli-flatten tag warning", warn.String())
+	}
+}
+
+func TestBuildVars_FieldRef(t *testing.T) {
+	mb, appCtx := newExecTestContext(t)
+	ctx := context.Background()
+	_ = mb.Put(ctx, "ps:/app/db", backend.PutOptions{Value: `{"password":"s3cr3t","replicas":[{"host":"r1"}]}`, StoreMode: tags.StoreModeRaw})
+
+	tests := []struct {
+		from     string
+		wantName string
+		want     string
+		wantErr  bool
+	}{
+		{from: "ps:/app/db#password", wantName: "PASSWORD", want: "s3cr3t"},
+		{from: "ps:/app/db#replicas[0].host", wantName: "HOST", want: "r1"},
+		{from: "ps:/app/db#replicas[0]", wantName: "DB", want: `{"host":"r1"}`},
+		{from: "ps:/app/#password", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.from, func(t *testing.T) {
+			vars, err := buildVars(ctx, appCtx, VarsBuildOptions{From: tc.from, FlattenDelim: "_", ArrayMode: "join", ArrayJoinDelim: ",", Upper: true})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if len(vars) != 1 || vars[0].Name != tc.wantName || vars[0].Value != tc.want {
+				t.Errorf("vars = %+v, want %s=%s", vars, tc.wantName, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Package audit inspects parameters and secrets for hygiene problems: plaintext secrets,
+// default encryption keys, stale values, missing rotation, duplicated secret values and
+// missing bundr tags. It only reports; it never changes anything.
+package audit
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+)
+
+// Severity ranks findings. The zero value is below every real severity.
+type Severity int
+
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+)
+
+// String returns the lower-case name of s.
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	}
+	return "none"
+}
+
+// ParseSeverity parses "low", "medium" or "high". "none" parses to 0, which no finding reaches.
+func ParseSeverity(s string) (Severity, error) {
+	switch s {
+	case "low":
+		return SeverityLow, nil
+	case "medium":
+		return SeverityMedium, nil
+	case "high":
+		return SeverityHigh, nil
+	case "none":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unknown severity %q (want low, medium, high or none)", s)
+}
+
+// Check names reported in Finding.Check.
+const (
+	CheckPlaintextSecret = "plaintext-secret"
+	CheckDefaultKMSKey   = "default-kms-key"
+	CheckStale           = "stale"
+	CheckNoRotation      = "no-rotation"
+	CheckDuplicateValue  = "duplicate-value"
+	CheckUnmanaged       = "unmanaged"
+)
+
+// Finding is one problem found on one ref.
+type Finding struct {
+	Ref      string
+	Check    string
+	Severity Severity
+	Message  string
+}
+
+// Item is one parameter or secret to inspect.
+type Item struct {
+	Ref   string
+	Type  backend.BackendType
+	Entry backend.ListEntry // listed with ResolveManaged
+	// Value is the raw value; HasValue is false when it was not read.
+	Value    string
+	HasValue bool
+}
+
+// Options controls the checks.
+type Options struct {
+	// KMSKeyID is the configured custom key. When empty, entries on the AWS managed key are not reported.
+	KMSKeyID string
+	// StaleAfter reports entries not modified for this long. Zero disables the check.
+	StaleAfter time.Duration
+	Now        time.Time
+}
+
+// awsManagedKeys are the aliases of the default keys; an empty key id means the same.
+var awsManagedKeys = map[string]bool{
+	"":                         true,
+	"alias/aws/ssm":            true,
+	"alias/aws/secretsmanager": true,
+}
+
+// Run inspects items and returns the findings, ordered by severity (highest first), then ref and check.
+func Run(items []Item, opts Options) []Finding {
+	var findings []Finding
+	add := func(ref, check string, sev Severity, format string, args ...any) {
+		findings = append(findings, Finding{Ref: ref, Check: check, Severity: sev, Message: fmt.Sprintf(format, args...)})
+	}
+
+	byHash := map[[sha256.Size]byte][]string{}
+	for _, it := range items {
+		e := it.Entry
+		secret := it.Type == backend.BackendTypeSM || e.Type == "SecureString"
+
+		if it.Type == backend.BackendTypePS && e.Type == "String" {
+			if LooksLikeSecretName(e.Path) {
+				add(it.Ref, CheckPlaintextSecret, SeverityHigh, "name looks like a secret but the type is String; use SecureString")
+			} else if it.HasValue {
+				if reason := SecretValueReason(it.Value); reason != "" {
+					add(it.Ref, CheckPlaintextSecret, SeverityHigh, "value looks like a secret (%s) but the type is String; use SecureString", reason)
+				}
+			}
+		}
+
+		if opts.KMSKeyID != "" && secret && awsManagedKeys[e.KMSKeyID] {
+			add(it.Ref, CheckDefaultKMSKey, SeverityMedium, "encrypted with the AWS managed key while kms_key_id is %s", opts.KMSKeyID)
+		}
+
+		if opts.StaleAfter > 0 && e.LastModified != nil && opts.Now.Sub(*e.LastModified) > opts.StaleAfter {
+			days := int(opts.Now.Sub(*e.LastModified).Hours() / 24)
+			add(it.Ref, CheckStale, SeverityLow, "unchanged for %d days", days)
+		}
+
+		if e.RotationEnabled != nil && !*e.RotationEnabled {
+			add(it.Ref, CheckNoRotation, SeverityMedium, "rotation is not enabled")
+		}
+
+		if !e.Managed {
+			add(it.Ref, CheckUnmanaged, SeverityLow, "missing bundr managed tags (see bundr adopt)")
+		}
+
+		// 重複チェックは秘匿値のみ対象（"true" や "80" のような共通値は除外）
+		if it.HasValue && it.Value != "" && (secret || SecretValueReason(it.Value) != "") {
+			h := sha256.Sum256([]byte(it.Value))
+			byHash[h] = append(byHash[h], it.Ref)
+		}
+	}
+
+	for _, refs := range byHash {
+		if len(refs) < 2 {
+			continue
+		}
+		sort.Strings(refs)
+		for i, ref := range refs {
+			others := make([]string, 0, len(refs)-1)
+			others = append(others, refs[:i]...)
+			others = append(others, refs[i+1:]...)
+			add(ref, CheckDuplicateValue, SeverityMedium, "same value as %s", strings.Join(others, ", "))
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.Ref != b.Ref {
+			return a.Ref < b.Ref
+		}
+		return a.Check < b.Check
+	})
+	return findings
+}
+
+var (
+	secretNamePattern = regexp.MustCompile(`(?i)(passw(or)?d|pwd|secret|token|credential|api[_-]?key|private[_-]?key|access[_-]?key|[_./-]key$)`)
+
+	secretValuePatterns = []struct {
+		re     *regexp.Regexp
+		reason string
+	}{
+		{regexp.MustCompile(`^(AKIA|ASIA)[0-9A-Z]{16}$`), "AWS access key id"},
+		{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`), "private key"},
+		{regexp.MustCompile(`^gh[pousr]_[0-9A-Za-z]{36,}$`), "GitHub token"},
+		{regexp.MustCompile(`^xox[abprs]-[0-9A-Za-z-]{10,}$`), "Slack token"},
+		{regexp.MustCompile(`^sk_live_[0-9A-Za-z]{16,}$`), "Stripe key"},
+		{regexp.MustCompile(`^eyJ[0-9A-Za-z_-]+\.eyJ[0-9A-Za-z_-]+\.[0-9A-Za-z_-]+$`), "JWT"},
+	}
+)
+
+// LooksLikeSecretName reports whether a parameter name suggests a secret
+// (password, token, api key, ... or a name ending in "key").
+func LooksLikeSecretName(name string) bool {
+	return secretNamePattern.MatchString(name)
+}
+
+// Entropy thresholds for SecretValueReason.
+const (
+	minEntropyLen = 16
+	minEntropy    = 4.0 // bits per character
+)
+
+// SecretValueReason returns why value looks like a secret (a known token format or
+// high entropy), or "" when it does not.
+func SecretValueReason(value string) string {
+	v := strings.TrimSpace(value)
+	for _, p := range secretValuePatterns {
+		if p.re.MatchString(v) {
+			return p.reason
+		}
+	}
+	if len(v) >= minEntropyLen && !strings.ContainsAny(v, " \t\n") && !strings.Contains(v, "://") && Entropy(v) >= minEntropy {
+		return "high entropy"
+	}
+	return ""
+}
+
+// Entropy returns the Shannon entropy of s in bits per byte.
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is wrapped by Get errors of backends that can tell a missing ref apart
+// from other failures. GetMany's fallback uses it to report missing refs.
+var ErrNotFound = errors.New("key not found")
+
+// BatchGetter is implemented by backends that can fetch several refs with fewer API
+// calls than one Get per ref. Use GetMany to call it.
+type BatchGetter interface {
+	GetMany(ctx context.Context, refs []string, opts GetOptions) (map[string]string, []string, error)
+}
+
+// GetMany fetches every ref (without "#field" selectors) from b. It returns the values
+// keyed by ref and the refs that do not exist.
+// Backends implementing BatchGetter batch the requests; others fall back to one Get
+// per ref, where errors wrapping ErrNotFound count as missing and any other error
+// stops the batch.
+func GetMany(ctx context.Context, b Backend, refs []string, opts GetOptions) (map[string]string, []string, error) {
+	if g, ok := b.(BatchGetter); ok {
+		return g.GetMany(ctx, refs, opts)
+	}
+	values := make(map[string]string, len(refs))
+	var missing []string
+	seen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		val, err := b.Get(ctx, ref, opts)
+		if errors.Is(err, ErrNotFound) {
+			missing = append(missing, ref)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		values[ref] = val
+	}
+	return values, missing, nil
+}
@@ -0,0 +1,38 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func TestGetMany_FallsBackToGet(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockBackend()
+	_ = mock.Put(ctx, "ps:/app/a", PutOptions{Value: "1", StoreMode: tags.StoreModeRaw})
+	_ = mock.Put(ctx, "ps:/app/b", PutOptions{Value: "2", StoreMode: tags.StoreModeRaw})
+
+	values, missing, err := GetMany(ctx, mock, []string{"ps:/app/a", "ps:/app/x", "ps:/app/b", "ps:/app/a"}, GetOptions{})
+	if err != nil {
+		t.Fatalf("GetMany() error: %v", err)
+	}
+	if len(values) != 2 || values["ps:/app/a"] != "1" || values["ps:/app/b"] != "2" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if len(missing) != 1 || missing[0] != "ps:/app/x" {
+		t.Errorf("missing = %v, want [ps:/app/x]", missing)
+	}
+	// duplicate refs are fetched once
+	if len(mock.GetCalls) != 3 {
+		t.Errorf("expected 3 Get calls, got %d", len(mock.GetCalls))
+	}
+}
+
+func TestMockBackend_GetNotFoundWrapsErrNotFound(t *testing.T) {
+	_, err := NewMockBackend().Get(context.Background(), "ps:/nope", GetOptions{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
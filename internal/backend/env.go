@@ -0,0 +1,195 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/youyo/bundr/internal/dotenv"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// ErrReadOnly is returned by writes to read-only backends (env:, dotenv:).
+var ErrReadOnly = errors.New("backend is read-only")
+
+// EnvBackend reads variables of the process environment, addressed as "env:NAME".
+// A name ending in "_" is a prefix ("env:APP_"). Values are raw strings without tags.
+type EnvBackend struct {
+	environ func() []string // for testing; os.Environ
+}
+
+// NewEnvBackend creates an EnvBackend reading the process environment.
+func NewEnvBackend() *EnvBackend {
+	return &EnvBackend{environ: os.Environ}
+}
+
+// vars returns the environment as a map.
+func (b *EnvBackend) vars() map[string]string {
+	vars := map[string]string{}
+	for _, kv := range b.environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && k != "" {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// Put always fails: the environment is read-only.
+func (b *EnvBackend) Put(_ context.Context, ref string, _ PutOptions) error {
+	return fmt.Errorf("%w: cannot write %s", ErrReadOnly, ref)
+}
+
+// Get returns the value of the variable.
+func (b *EnvBackend) Get(_ context.Context, ref string, opts GetOptions) (string, error) {
+	parsed, err := parseTypedRef(ref, BackendTypeEnv)
+	if err != nil {
+		return "", err
+	}
+	v, ok := b.vars()[parsed.Path]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	return localValue(v, opts)
+}
+
+// GetByPrefix returns the variables whose names start with prefix, sorted by name.
+// Only names ending in "_" are prefixes; any other prefix matches nothing.
+func (b *EnvBackend) GetByPrefix(_ context.Context, prefix string, opts GetByPrefixOptions) ([]ParameterEntry, error) {
+	return localEntries(b.vars(), "", prefix, opts), nil
+}
+
+// Describe returns the name and value of the variable.
+func (b *EnvBackend) Describe(ctx context.Context, ref string) (map[string]any, error) {
+	v, err := b.Get(ctx, ref, GetOptions{ForceRaw: true})
+	if err != nil {
+		return nil, err
+	}
+	parsed, _ := ParseRef(ref)
+	return map[string]any{"Name": parsed.Path, "Value": v, "Tags": map[string]string{}}, nil
+}
+
+// DotenvBackend reads .env files, addressed as "dotenv:PATH:KEY". "dotenv:PATH:" is
+// the whole file and a key ending in "_" is a prefix. Files are read on every call.
+type DotenvBackend struct{}
+
+// NewDotenvBackend creates a DotenvBackend.
+func NewDotenvBackend() *DotenvBackend {
+	return &DotenvBackend{}
+}
+
+// read parses file into a map; later duplicates win, as when the file is sourced.
+func (b *DotenvBackend) read(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := dotenv.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	vars := make(map[string]string, len(entries))
+	for _, e := range entries {
+		vars[e.Key] = e.Value
+	}
+	return vars, nil
+}
+
+// Put always fails: .env files are read-only.
+func (b *DotenvBackend) Put(_ context.Context, ref string, _ PutOptions) error {
+	return fmt.Errorf("%w: cannot write %s", ErrReadOnly, ref)
+}
+
+// Get returns the value of KEY in the file.
+func (b *DotenvBackend) Get(_ context.Context, ref string, opts GetOptions) (string, error) {
+	parsed, err := parseTypedRef(ref, BackendTypeDotenv)
+	if err != nil {
+		return "", err
+	}
+	file, key, _ := SplitDotenvPath(parsed.Path)
+	if key == "" {
+		return "", fmt.Errorf("%s: a key is required (dotenv:PATH:KEY)", ref)
+	}
+	vars, err := b.read(file)
+	if err != nil {
+		return "", err
+	}
+	v, ok := vars[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	return localValue(v, opts)
+}
+
+// GetByPrefix returns the variables of the file under prefix ("PATH:" or "PATH:APP_"),
+// sorted by name. Entry paths are "PATH:KEY".
+func (b *DotenvBackend) GetByPrefix(_ context.Context, prefix string, opts GetByPrefixOptions) ([]ParameterEntry, error) {
+	file, key, err := SplitDotenvPath(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prefix %q: %w", prefix, err)
+	}
+	vars, err := b.read(file)
+	if err != nil {
+		return nil, err
+	}
+	return localEntries(vars, file+":", key, opts), nil
+}
+
+// Describe returns the file, name and value of KEY.
+func (b *DotenvBackend) Describe(ctx context.Context, ref string) (map[string]any, error) {
+	v, err := b.Get(ctx, ref, GetOptions{ForceRaw: true})
+	if err != nil {
+		return nil, err
+	}
+	parsed, _ := ParseRef(ref)
+	file, key, _ := SplitDotenvPath(parsed.Path)
+	return map[string]any{"Name": key, "File": file, "Value": v, "Tags": map[string]string{}}, nil
+}
+
+// parseTypedRef parses ref and checks that it belongs to backend type bt.
+func parseTypedRef(ref string, bt BackendType) (Ref, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return Ref{}, err
+	}
+	if parsed.Type != bt {
+		return Ref{}, fmt.Errorf("%s backend cannot handle %s: refs", bt, parsed.Type)
+	}
+	return parsed, nil
+}
+
+// localValue applies GetOptions to an untagged (raw) value.
+func localValue(v string, opts GetOptions) (string, error) {
+	if opts.ForceJSON {
+		return decodeJSON(v)
+	}
+	return v, nil
+}
+
+// localEntries returns the variables whose names start with prefix as raw entries,
+// sorted by name, with pathPrefix prepended to their paths. A prefix that does not
+// end in "_" only matches when it is empty.
+func localEntries(vars map[string]string, pathPrefix, prefix string, opts GetByPrefixOptions) []ParameterEntry {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		return nil
+	}
+	var names []string
+	for name := range vars {
+		if strings.HasPrefix(name, prefix) && name != prefix {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	entries := make([]ParameterEntry, 0, len(names))
+	for _, name := range names {
+		e := ParameterEntry{Path: pathPrefix + name, Value: vars[name], StoreMode: tags.StoreModeRaw}
+		if opts.IncludeMetadata {
+			e.Metadata = map[string]any{"Name": name}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
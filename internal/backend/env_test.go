@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestEnvBackend(vars ...string) *EnvBackend {
+	return &EnvBackend{environ: func() []string { return vars }}
+}
+
+func TestEnvBackend_Get(t *testing.T) {
+	b := newTestEnvBackend("DB_HOST=localhost", "APP_CONFIG={\"port\":8080}", "EMPTY=")
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		ref     string
+		opts    GetOptions
+		want    string
+		wantErr error
+	}{
+		{name: "value", ref: "env:DB_HOST", want: "localhost"},
+		{name: "empty value", ref: "env:EMPTY", want: ""},
+		{name: "json kept raw", ref: "env:APP_CONFIG", want: `{"port":8080}`},
+		{name: "missing", ref: "env:NOPE", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.Get(ctx, tt.ref, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Get(%q) error = %v, want %v", tt.ref, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Get(%q) = %q, %v; want %q", tt.ref, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvBackend_GetByPrefix(t *testing.T) {
+	b := newTestEnvBackend("APP_PORT=8080", "APP_HOST=localhost", "APPLE=1", "OTHER=x")
+	ctx := context.Background()
+
+	entries, err := b.GetByPrefix(ctx, "APP_", GetByPrefixOptions{Recursive: true})
+	if err != nil {
+		t.Fatalf("GetByPrefix: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != "APP_HOST" || entries[1].Path != "APP_PORT" || entries[1].Value != "8080" {
+		t.Fatalf("GetByPrefix(APP_) = %+v", entries)
+	}
+
+	// 末尾が "_" でないものは prefix として扱わない
+	entries, err = b.GetByPrefix(ctx, "APP", GetByPrefixOptions{Recursive: true})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("GetByPrefix(APP) = %+v, %v; want none", entries, err)
+	}
+}
+
+func TestLocalBackends_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		b    Backend
+		ref  string
+	}{
+		{name: "env", b: newTestEnvBackend(), ref: "env:DB_HOST"},
+		{name: "dotenv", b: NewDotenvBackend(), ref: "dotenv:.env:DB_HOST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.b.Put(ctx, tt.ref, PutOptions{Value: "x"})
+			if !errors.Is(err, ErrReadOnly) {
+				t.Fatalf("Put error = %v, want ErrReadOnly", err)
+			}
+		})
+	}
+}
+
+func TestDotenvBackend(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	content := "DB_HOST=localhost\nAPP_PORT=8080\nAPP_NAME=\"demo app\"\n# comment\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b := NewDotenvBackend()
+	ctx := context.Background()
+
+	t.Run("get", func(t *testing.T) {
+		got, err := b.Get(ctx, "dotenv:"+file+":APP_NAME", GetOptions{})
+		if err != nil || got != "demo app" {
+			t.Fatalf("Get = %q, %v; want %q", got, err, "demo app")
+		}
+	})
+	t.Run("missing key", func(t *testing.T) {
+		_, err := b.Get(ctx, "dotenv:"+file+":NOPE", GetOptions{})
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Get error = %v, want ErrNotFound", err)
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		_, err := b.Get(ctx, "dotenv:"+file+".missing:DB_HOST", GetOptions{})
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("Get error = %v, want os.ErrNotExist", err)
+		}
+	})
+	t.Run("whole file", func(t *testing.T) {
+		entries, err := b.GetByPrefix(ctx, file+":", GetByPrefixOptions{Recursive: true})
+		if err != nil {
+			t.Fatalf("GetByPrefix: %v", err)
+		}
+		var paths []string
+		for _, e := range entries {
+			paths = append(paths, e.Path)
+		}
+		want := []string{file + ":APP_NAME", file + ":APP_PORT", file + ":DB_HOST"}
+		if len(paths) != len(want) {
+			t.Fatalf("GetByPrefix paths = %v, want %v", paths, want)
+		}
+		for i := range want {
+			if paths[i] != want[i] {
+				t.Fatalf("GetByPrefix paths = %v, want %v", paths, want)
+			}
+		}
+	})
+	t.Run("key prefix", func(t *testing.T) {
+		entries, err := b.GetByPrefix(ctx, file+":APP_", GetByPrefixOptions{Recursive: true})
+		if err != nil || len(entries) != 2 || entries[1].Value != "8080" {
+			t.Fatalf("GetByPrefix(APP_) = %+v, %v", entries, err)
+		}
+	})
+}
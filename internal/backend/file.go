@@ -0,0 +1,454 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+
+	"github.com/youyo/bundr/internal/envelope"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// fileFormat is the format line of a file: store (see package envelope).
+const fileFormat = "bundr-file/1"
+
+// FileKeys are the keys a FileBackend opens and writes files with.
+type FileKeys struct {
+	Passphrase string
+	Identities []age.Identity
+	// Recipients are the age public keys (age1...) new files are encrypted to.
+	// Existing files keep the recipients they were created with.
+	Recipients []string
+	ScryptLogN int // 0 = envelope.DefaultScryptLogN
+}
+
+// fileStore is the decrypted content of a file: store.
+type fileStore struct {
+	Version int `json:"version"`
+	// Passphrase and Recipients record how the file is sealed, so that writes keep it.
+	Passphrase bool                  `json:"passphrase,omitempty"`
+	Recipients []string              `json:"recipients,omitempty"`
+	Entries    map[string]*fileEntry `json:"entries"` // by key path ("/app/key")
+}
+
+type fileEntry struct {
+	Value        string            `json:"value"`
+	ValueType    string            `json:"value_type,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	Version      int64             `json:"version"`
+	LastModified time.Time         `json:"last_modified"`
+}
+
+// openFile is a loaded store and the state of the file it was read from.
+type openFile struct {
+	store   *fileStore
+	modTime time.Time
+	size    int64
+}
+
+// FileBackend stores entries in encrypted local files, addressed as
+// "file:PATH:/key" (e.g. file:./secrets.bundr:/app/db_host). One file holds a whole
+// key space; it is decrypted once and rewritten (atomically) on every change.
+// Values, store modes, descriptions and tags behave as on Parameter Store.
+type FileBackend struct {
+	keys  FileKeys
+	mu    sync.Mutex
+	files map[string]*openFile // by file name as written in refs
+}
+
+// NewFileBackend creates a FileBackend using keys to open and write files.
+func NewFileBackend(keys FileKeys) *FileBackend {
+	return &FileBackend{keys: keys, files: map[string]*openFile{}}
+}
+
+// splitFileRef parses a file: ref into the file name and key path.
+func splitFileRef(ref string) (string, string, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return "", "", err
+	}
+	if parsed.Type != BackendTypeFile {
+		return "", "", fmt.Errorf("file backend cannot handle %s: refs", parsed.Type)
+	}
+	return SplitFilePath(parsed.Path)
+}
+
+// load returns the store of file, reading it again when it changed on disk.
+// A missing file is an empty store. b.mu must be held.
+func (b *FileBackend) load(file string) (*fileStore, error) {
+	info, err := os.Stat(file)
+	if errors.Is(err, os.ErrNotExist) {
+		of := &openFile{store: &fileStore{Version: 1, Entries: map[string]*fileEntry{}}}
+		b.files[file] = of
+		return of.store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if of, ok := b.files[file]; ok && of.modTime.Equal(info.ModTime()) && of.size == info.Size() {
+		return of.store, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	payload, err := envelope.Open(f, fileFormat, envelope.OpenOptions{Passphrase: b.keys.Passphrase, Identities: b.keys.Identities})
+	if errors.Is(err, envelope.ErrFormat) {
+		return nil, fmt.Errorf("%s is not a bundr file store", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", file, err)
+	}
+	var store fileStore
+	if err := json.Unmarshal(payload, &store); err != nil {
+		return nil, fmt.Errorf("open %s: invalid content: %w", file, err)
+	}
+	if store.Entries == nil {
+		store.Entries = map[string]*fileEntry{}
+	}
+	b.files[file] = &openFile{store: &store, modTime: info.ModTime(), size: info.Size()}
+	return &store, nil
+}
+
+// save seals store and replaces file with it. New files are sealed with the configured
+// passphrase and recipients; existing files keep theirs. b.mu must be held.
+func (b *FileBackend) save(file string, store *fileStore) error {
+	if len(store.Entries) > 0 && !store.Passphrase && len(store.Recipients) == 0 {
+		// 新規ファイル: 設定された鍵で暗号化する
+		store.Passphrase = b.keys.Passphrase != ""
+		store.Recipients = b.keys.Recipients
+	}
+	opts := envelope.SealOptions{ScryptLogN: b.keys.ScryptLogN}
+	if store.Passphrase {
+		if b.keys.Passphrase == "" {
+			return fmt.Errorf("%s is encrypted with a passphrase; a passphrase is needed to write it", file)
+		}
+		opts.Passphrase = b.keys.Passphrase
+	}
+	if len(store.Recipients) > 0 {
+		recipients, err := envelope.ParseRecipients(store.Recipients...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		opts.Recipients = recipients
+	}
+	if opts.Passphrase == "" && len(opts.Recipients) == 0 {
+		return fmt.Errorf("no passphrase or age recipient to encrypt %s with", file)
+	}
+
+	payload, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := envelope.Seal(tmp, fileFormat, payload, opts); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	b.files[file] = &openFile{store: store, modTime: info.ModTime(), size: info.Size()}
+	return nil
+}
+
+// update loads the store of ref, applies fn to it and saves it.
+func (b *FileBackend) update(ref string, fn func(store *fileStore, key string) error) error {
+	file, key, err := splitFileRef(ref)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	store, err := b.load(file)
+	if err != nil {
+		return err
+	}
+	if err := fn(store, key); err != nil {
+		return err
+	}
+	if err := b.save(file, store); err != nil {
+		delete(b.files, file) // 変更を捨てて次回読み直す
+		return err
+	}
+	return nil
+}
+
+// lookup returns the entry of ref, or ErrNotFound.
+func (b *FileBackend) lookup(ref string) (fileEntry, error) {
+	file, key, err := splitFileRef(ref)
+	if err != nil {
+		return fileEntry{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	store, err := b.load(file)
+	if err != nil {
+		return fileEntry{}, err
+	}
+	e, ok := store.Entries[key]
+	if !ok {
+		return fileEntry{}, fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	return *e, nil
+}
+
+// Put stores a value. Like Parameter Store, scalars are JSON-encoded in json store mode,
+// and overwriting an entry keeps its other tags and, unless opts.Description is set, its
+// description.
+func (b *FileBackend) Put(_ context.Context, ref string, opts PutOptions) error {
+	value := opts.Value
+	if opts.StoreMode == tags.StoreModeJSON && !json.Valid([]byte(opts.Value)) {
+		encoded, err := json.Marshal(opts.Value)
+		if err != nil {
+			return fmt.Errorf("json encode: %w", err)
+		}
+		value = string(encoded)
+	}
+	return b.update(ref, func(store *fileStore, key string) error {
+		if strings.HasSuffix(key, "/") {
+			return fmt.Errorf("%s: a key cannot end with /", ref)
+		}
+		// 上書き時は Parameter Store と同様に既存のタグと説明を引き継ぐ
+		entry := &fileEntry{Tags: map[string]string{}}
+		if old, ok := store.Entries[key]; ok {
+			entry.Version = old.Version
+			entry.Description = old.Description
+			for k, v := range old.Tags {
+				entry.Tags[k] = v
+			}
+		}
+		for k, v := range putTags(opts) {
+			entry.Tags[k] = v
+		}
+		if opts.Description != "" {
+			entry.Description = opts.Description
+		}
+		entry.Value = value
+		entry.ValueType = opts.ValueType
+		entry.Version++
+		entry.LastModified = time.Now().UTC()
+		store.Entries[key] = entry
+		return nil
+	})
+}
+
+// Get returns the value of ref, decoded according to its store mode.
+func (b *FileBackend) Get(_ context.Context, ref string, opts GetOptions) (string, error) {
+	e, err := b.lookup(ref)
+	if err != nil {
+		return "", err
+	}
+	if opts.ForceRaw {
+		return e.Value, nil
+	}
+	if opts.ForceJSON || storeModeOf(e.Tags) == tags.StoreModeJSON {
+		return decodeJSON(e.Value)
+	}
+	return e.Value, nil
+}
+
+// GetByPrefix returns the entries under prefix ("FILE:/app/"), sorted by path.
+// Values are returned raw, as on Parameter Store.
+func (b *FileBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPrefixOptions) ([]ParameterEntry, error) {
+	var entries []ParameterEntry
+	err := b.WalkPrefix(ctx, prefix, opts, func(e ParameterEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WalkPrefix calls fn for each entry under prefix, sorted by path.
+func (b *FileBackend) WalkPrefix(_ context.Context, prefix string, opts GetByPrefixOptions, fn func(ParameterEntry) error) error {
+	file, keys, store, err := b.match(prefix, opts.Recursive)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		e := store[key]
+		var metadata map[string]any
+		if opts.IncludeMetadata {
+			metadata = map[string]any{
+				"Name":             key,
+				"Type":             fileParamType(e.ValueType),
+				"Version":          e.Version,
+				"LastModifiedDate": e.LastModified,
+			}
+		}
+		if err := fn(ParameterEntry{
+			Path:      file + ":" + key,
+			Value:     e.Value,
+			StoreMode: storeModeOf(e.Tags),
+			Flatten:   e.Tags[tags.TagFlatten],
+			Tags:      copyTags(e.Tags),
+			Metadata:  metadata,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns the sorted keys under prefix with a snapshot of their entries.
+func (b *FileBackend) match(prefix string, recursive bool) (string, []string, map[string]fileEntry, error) {
+	file, keyPrefix, err := SplitFilePath(prefix)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("invalid prefix %q: %w", prefix, err)
+	}
+	keyPrefix = strings.TrimSuffix(keyPrefix, "/") + "/"
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	store, err := b.load(file)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var keys []string
+	snapshot := map[string]fileEntry{}
+	for key, e := range store.Entries {
+		if !strings.HasPrefix(key, keyPrefix) {
+			continue
+		}
+		if !recursive && strings.Contains(strings.TrimPrefix(key, keyPrefix), "/") {
+			continue
+		}
+		keys = append(keys, key)
+		snapshot[key] = *e
+	}
+	sort.Strings(keys)
+	return file, keys, snapshot, nil
+}
+
+// List calls fn for each entry under prefix matching opts, in path order.
+func (b *FileBackend) List(_ context.Context, prefix string, opts ListOptions, fn func(ListEntry) error) error {
+	file, keys, store, err := b.match(prefix, opts.Recursive)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		e := store[key]
+		matched := true
+		for k, v := range opts.Tags {
+			if e.Tags[k] != v {
+				matched = false
+			}
+		}
+		paramType := fileParamType(e.ValueType)
+		if !matched || (opts.Type != "" && paramType != opts.Type) {
+			continue
+		}
+		modified := e.LastModified
+		le := ListEntry{
+			Path:         file + ":" + key,
+			Type:         paramType,
+			Version:      e.Version,
+			LastModified: &modified,
+			Description:  e.Description,
+		}
+		if e.Tags[tags.TagCLI] == tags.TagCLIValue {
+			le.Managed = true
+			le.StoreMode = e.Tags[tags.TagStoreMode]
+		}
+		if err := fn(le); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Describe returns the metadata, value and tags of ref.
+func (b *FileBackend) Describe(_ context.Context, ref string) (map[string]any, error) {
+	e, err := b.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	_, key, _ := splitFileRef(ref)
+	return map[string]any{
+		"Name":             key,
+		"Type":             fileParamType(e.ValueType),
+		"Value":            e.Value,
+		"Version":          e.Version,
+		"Description":      e.Description,
+		"LastModifiedDate": e.LastModified,
+		"Tags":             copyTags(e.Tags),
+	}, nil
+}
+
+// Tags returns all tags of ref.
+func (b *FileBackend) Tags(_ context.Context, ref string) (map[string]string, error) {
+	e, err := b.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	return copyTags(e.Tags), nil
+}
+
+// AddTags adds or overwrites tags of ref.
+func (b *FileBackend) AddTags(_ context.Context, ref string, tagMap map[string]string) error {
+	return b.update(ref, func(store *fileStore, key string) error {
+		e, ok := store.Entries[key]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+		e.Tags = copyTags(e.Tags)
+		for k, v := range tagMap {
+			e.Tags[k] = v
+		}
+		return nil
+	})
+}
+
+// RemoveTags removes tag keys from ref. Missing keys are ignored.
+func (b *FileBackend) RemoveTags(_ context.Context, ref string, keys []string) error {
+	return b.update(ref, func(store *fileStore, key string) error {
+		e, ok := store.Entries[key]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+		e.Tags = copyTags(e.Tags)
+		for _, k := range keys {
+			delete(e.Tags, k)
+		}
+		return nil
+	})
+}
+
+// fileParamType reports a value type with Parameter Store's type names.
+func fileParamType(valueType string) string {
+	switch valueType {
+	case ValueTypeSecure:
+		return "SecureString"
+	case ValueTypeStringList:
+		return "StringList"
+	default:
+		return "String"
+	}
+}
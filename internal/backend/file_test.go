@@ -0,0 +1,320 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+
+	"github.com/youyo/bundr/internal/envelope"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func newTestFileBackend(t *testing.T) (*FileBackend, string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "secrets.bundr")
+	return NewFileBackend(FileKeys{Passphrase: "pw", ScryptLogN: 10}), file
+}
+
+func TestFileBackend_PutAndGet(t *testing.T) {
+	ctx := context.Background()
+	b, file := newTestFileBackend(t)
+	ref := "file:" + file + ":/app/db"
+
+	tests := []struct {
+		name string
+		put  PutOptions
+		get  GetOptions
+		want string
+	}{
+		{name: "raw", put: PutOptions{Value: "plain", StoreMode: tags.StoreModeRaw}, want: "plain"},
+		{name: "json scalar", put: PutOptions{Value: "hello", StoreMode: tags.StoreModeJSON}, want: "hello"},
+		{name: "json scalar raw", put: PutOptions{Value: "hello", StoreMode: tags.StoreModeJSON}, get: GetOptions{ForceRaw: true}, want: `"hello"`},
+		{name: "json object", put: PutOptions{Value: `{"a":1}`, StoreMode: tags.StoreModeJSON}, want: `{"a":1}`},
+		{name: "force json", put: PutOptions{Value: `"quoted"`, StoreMode: tags.StoreModeRaw}, get: GetOptions{ForceJSON: true}, want: "quoted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := b.Put(ctx, ref, tt.put); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			got, err := b.Get(ctx, ref, tt.get)
+			if err != nil || got != tt.want {
+				t.Fatalf("Get = %q, %v; want %q", got, err, tt.want)
+			}
+
+			// 別のインスタンス（= 別プロセス）からも同じ値が読める
+			other := NewFileBackend(FileKeys{Passphrase: "pw"})
+			got, err = other.Get(ctx, ref, tt.get)
+			if err != nil || got != tt.want {
+				t.Fatalf("reopened Get = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "bundr-file/1\n") || strings.Contains(string(data), "quoted") {
+		t.Errorf("file is not sealed: %q", data[:40])
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestFileBackend_GetNotFound(t *testing.T) {
+	ctx := context.Background()
+	b, file := newTestFileBackend(t)
+
+	// ファイルがない場合もキーがない場合も ErrNotFound
+	if _, err := b.Get(ctx, "file:"+file+":/app/missing", GetOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing file: err = %v, want ErrNotFound", err)
+	}
+	if err := b.Put(ctx, "file:"+file+":/app/key", PutOptions{Value: "v", StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Get(ctx, "file:"+file+":/app/missing", GetOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing key: err = %v, want ErrNotFound", err)
+	}
+	if entries, err := b.GetByPrefix(ctx, filepath.Join(t.TempDir(), "none.bundr")+":/", GetByPrefixOptions{Recursive: true}); err != nil || len(entries) != 0 {
+		t.Fatalf("GetByPrefix on a missing file = %v, %v", entries, err)
+	}
+}
+
+func TestFileBackend_GetByPrefix(t *testing.T) {
+	ctx := context.Background()
+	b, file := newTestFileBackend(t)
+	for key, opts := range map[string]PutOptions{
+		"/app/db_host":       {Value: "db.internal", StoreMode: tags.StoreModeRaw},
+		"/app/config":        {Value: `{"port":5432}`, StoreMode: tags.StoreModeJSON, Tags: map[string]string{tags.TagFlatten: "no"}},
+		"/app/sub/nested":    {Value: "n", StoreMode: tags.StoreModeRaw},
+		"/application/other": {Value: "o", StoreMode: tags.StoreModeRaw},
+	} {
+		if err := b.Put(ctx, "file:"+file+":"+key, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		prefix    string
+		recursive bool
+		want      []string
+	}{
+		{name: "recursive", prefix: "/app/", recursive: true, want: []string{"/app/config", "/app/db_host", "/app/sub/nested"}},
+		{name: "one level", prefix: "/app/", want: []string{"/app/config", "/app/db_host"}},
+		{name: "without trailing slash", prefix: "/app", recursive: true, want: []string{"/app/config", "/app/db_host", "/app/sub/nested"}},
+		{name: "root", prefix: "/", recursive: true, want: []string{"/app/config", "/app/db_host", "/app/sub/nested", "/application/other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := b.GetByPrefix(ctx, file+":"+tt.prefix, GetByPrefixOptions{Recursive: tt.recursive})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, strings.TrimPrefix(e.Path, file+":"))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	entries, err := b.GetByPrefix(ctx, file+":/app/", GetByPrefixOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := entries[0]
+	if cfg.Path != file+":/app/config" || cfg.StoreMode != tags.StoreModeJSON || cfg.Flatten != "no" || cfg.Value != `{"port":5432}` {
+		t.Errorf("config entry = %+v", cfg)
+	}
+}
+
+func TestFileBackend_Overwrite(t *testing.T) {
+	ctx := context.Background()
+	b, file := newTestFileBackend(t)
+	ref := "file:" + file + ":/app/config"
+
+	first := PutOptions{Value: `{"a":1}`, StoreMode: tags.StoreModeJSON, Description: "app config", Tags: map[string]string{"team": "core", tags.TagValueSchema: "schema.json"}}
+	if err := b.Put(ctx, ref, first); err != nil {
+		t.Fatal(err)
+	}
+	// 上書きは既存のタグと説明を残し、指定したタグだけを更新する
+	if err := b.Put(ctx, ref, PutOptions{Value: `{"a":2}`, StoreMode: tags.StoreModeJSON, Tags: map[string]string{"team": "platform"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Tags(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["team"] != "platform" || got[tags.TagValueSchema] != "schema.json" || got[tags.TagStoreMode] != tags.StoreModeJSON {
+		t.Errorf("Tags after overwrite = %v", got)
+	}
+	desc, err := b.Describe(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc["Description"] != "app config" || desc["Value"] != `{"a":2}` || desc["Version"] != int64(2) {
+		t.Errorf("Describe after overwrite = %v", desc)
+	}
+
+	if err := b.Put(ctx, ref, PutOptions{Value: `{"a":3}`, StoreMode: tags.StoreModeJSON, Description: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if desc, _ := b.Describe(ctx, ref); desc["Description"] != "new" {
+		t.Errorf("Description = %v, want new", desc["Description"])
+	}
+}
+
+func TestFileBackend_TagsListDescribe(t *testing.T) {
+	ctx := context.Background()
+	b, file := newTestFileBackend(t)
+	ref := "file:" + file + ":/app/password"
+	if err := b.Put(ctx, ref, PutOptions{Value: "s3cr3t", StoreMode: tags.StoreModeRaw, ValueType: ValueTypeSecure, Description: "DB password"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Put(ctx, "file:"+file+":/app/unmanaged", PutOptions{Value: "u", ExactTags: true, Tags: map[string]string{"team": "core"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.AddTags(ctx, ref, map[string]string{"team": "core"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RemoveTags(ctx, ref, []string{tags.TagFlatten, "missing"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Tags(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["team"] != "core" || got[tags.TagCLI] != tags.TagCLIValue || got[tags.TagStoreMode] != tags.StoreModeRaw {
+		t.Errorf("Tags = %v", got)
+	}
+
+	var listed []ListEntry
+	err = b.List(ctx, file+":/app/", ListOptions{Recursive: true, Tags: map[string]string{"team": "core"}}, func(e ListEntry) error {
+		listed = append(listed, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 {
+		t.Fatalf("List = %+v, want 2 entries", listed)
+	}
+	pw := listed[0]
+	if pw.Path != file+":/app/password" || pw.Type != "SecureString" || pw.Description != "DB password" || !pw.Managed || pw.Version != 1 || pw.LastModified == nil {
+		t.Errorf("password entry = %+v", pw)
+	}
+	if listed[1].Managed {
+		t.Errorf("unmanaged entry reported as managed: %+v", listed[1])
+	}
+
+	desc, err := b.Describe(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc["Name"] != "/app/password" || desc["Type"] != "SecureString" || desc["Value"] != "s3cr3t" || desc["Description"] != "DB password" {
+		t.Errorf("Describe = %v", desc)
+	}
+	if err := b.AddTags(ctx, "file:"+file+":/app/missing", map[string]string{"a": "b"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AddTags on a missing key: err = %v", err)
+	}
+}
+
+func TestFileBackend_Keys(t *testing.T) {
+	ctx := context.Background()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	t.Run("age recipient", func(t *testing.T) {
+		file := filepath.Join(dir, "age.bundr")
+		writer := NewFileBackend(FileKeys{Recipients: []string{id.Recipient().String()}})
+		if err := writer.Put(ctx, "file:"+file+":/k", PutOptions{Value: "v"}); err != nil {
+			t.Fatal(err)
+		}
+		// identity だけで読み書きでき、書き込み後も同じ受信者で暗号化される
+		reader := NewFileBackend(FileKeys{Identities: []age.Identity{id}})
+		if err := reader.Put(ctx, "file:"+file+":/k2", PutOptions{Value: "v2"}); err != nil {
+			t.Fatalf("Put with identity only: %v", err)
+		}
+		again := NewFileBackend(FileKeys{Identities: []age.Identity{id}})
+		if got, err := again.Get(ctx, "file:"+file+":/k", GetOptions{}); err != nil || got != "v" {
+			t.Fatalf("Get = %q, %v", got, err)
+		}
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		file := filepath.Join(dir, "pw.bundr")
+		if err := NewFileBackend(FileKeys{Passphrase: "pw", ScryptLogN: 10}).Put(ctx, "file:"+file+":/k", PutOptions{Value: "v"}); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewFileBackend(FileKeys{Passphrase: "nope"}).Get(ctx, "file:"+file+":/k", GetOptions{})
+		if !errors.Is(err, envelope.ErrNoKey) {
+			t.Fatalf("err = %v, want ErrNoKey", err)
+		}
+		// identity では開けないし、パスフレーズなしでは書き戻せない
+		err = NewFileBackend(FileKeys{Identities: []age.Identity{id}}).Put(ctx, "file:"+file+":/k", PutOptions{Value: "x"})
+		if err == nil {
+			t.Fatal("Put without the passphrase succeeded")
+		}
+	})
+
+	t.Run("no key for a new file", func(t *testing.T) {
+		file := filepath.Join(dir, "new.bundr")
+		err := NewFileBackend(FileKeys{}).Put(ctx, "file:"+file+":/k", PutOptions{Value: "v"})
+		if err == nil || !strings.Contains(err.Error(), "no passphrase or age recipient") {
+			t.Fatalf("err = %v", err)
+		}
+		if _, statErr := os.Stat(file); !errors.Is(statErr, os.ErrNotExist) {
+			t.Errorf("file was created: %v", statErr)
+		}
+	})
+
+	t.Run("not a store", func(t *testing.T) {
+		file := filepath.Join(dir, "plain.env")
+		if err := os.WriteFile(file, []byte("A=1\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewFileBackend(FileKeys{Passphrase: "pw"}).Get(ctx, "file:"+file+":/A", GetOptions{})
+		if err == nil || !strings.Contains(err.Error(), "not a bundr file store") {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestFileBackend_ReloadsChangedFile(t *testing.T) {
+	ctx := context.Background()
+	b, file := newTestFileBackend(t)
+	other := NewFileBackend(FileKeys{Passphrase: "pw", ScryptLogN: 10})
+
+	if err := b.Put(ctx, "file:"+file+":/a", PutOptions{Value: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Get(ctx, "file:"+file+":/a", GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Put(ctx, "file:"+file+":/b", PutOptions{Value: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	// other は古い内容をキャッシュしているが、書き込み前に読み直すので /b を失わない
+	if err := other.Put(ctx, "file:"+file+":/c", PutOptions{Value: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"/a", "/b", "/c"} {
+		if _, err := b.Get(ctx, "file:"+file+":"+key, GetOptions{}); err != nil {
+			t.Errorf("Get(%s): %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/youyo/bundr/internal/jsonquery"
+)
+
+// GetValue fetches ref with b.Get and, when ref has a "#field" selector, extracts
+// that field from the JSON value (strings unquoted, other values as compact JSON).
+func GetValue(ctx context.Context, b Backend, ref string, opts GetOptions) (string, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	base, _ := SplitField(ref)
+
+	val, err := b.Get(ctx, base, opts)
+	if err != nil || parsed.Field == "" {
+		return val, err
+	}
+
+	q, err := jsonquery.Parse(parsed.Field)
+	if err != nil {
+		return "", err
+	}
+	out, err := jsonquery.Extract(val, q)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	return out, nil
+}
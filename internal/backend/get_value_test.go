@@ -0,0 +1,40 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func TestGetValue(t *testing.T) {
+	ctx := context.Background()
+	mb := NewMockBackend()
+	_ = mb.Put(ctx, "sm:prod/db", PutOptions{Value: `{"password":"s3cr3t","replicas":[{"host":"r1"}]}`, StoreMode: tags.StoreModeRaw})
+	_ = mb.Put(ctx, "ps:/app/plain", PutOptions{Value: "not json", StoreMode: tags.StoreModeRaw})
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "sm:prod/db#password", want: "s3cr3t"},
+		{ref: "sm:prod/db#.replicas[0].host", want: "r1"},
+		{ref: "sm:prod/db#replicas[0]", want: `{"host":"r1"}`},
+		{ref: "ps:/app/plain", want: "not json"},
+		{ref: "sm:prod/db#missing", wantErr: true},
+		{ref: "ps:/app/plain#x", wantErr: true},
+		{ref: "sm:prod/db#a..b", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.ref, func(t *testing.T) {
+			got, err := GetValue(ctx, mb, tc.ref, GetOptions{})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("GetValue(%q) = %q, want %q", tc.ref, got, tc.want)
+			}
+		})
+	}
+}
@@ -1,16 +1,39 @@
 package backend
 
-import "context"
+import (
+	"context"
+
+	"github.com/youyo/bundr/internal/tags"
+)
 
 // PutOptions contains options for the Put operation.
 type PutOptions struct {
 	Value        string
 	StoreMode    string // "raw" or "json"
-	ValueType    string // "string" or "secure"
+	ValueType    string // "string", "secure" or "stringlist"
 	KMSKeyID     string
 	Tags         map[string]string
 	AdvancedTier bool // true to force Advanced tier (equivalent to psa: prefix)
 	TierExplicit bool // true when --tier flag was explicitly specified (skips auto-detect)
+	Description  string
+	Policies     string // Parameter Store parameter policies (JSON array); requires the Advanced tier
+	// ExactTags writes Tags as given, without adding bundr's managed tags
+	// (used by restore to reproduce unmanaged entries). Updates of an existing
+	// secret then also switch it to KMSKeyID.
+	ExactTags bool
+}
+
+// putTags returns the tags a Put with opts writes: bundr's managed tags plus opts.Tags,
+// or only opts.Tags with ExactTags.
+func putTags(opts PutOptions) map[string]string {
+	result := map[string]string{}
+	if !opts.ExactTags {
+		result = tags.ManagedTags(opts.StoreMode)
+	}
+	for k, v := range opts.Tags {
+		result[k] = v
+	}
+	return result
 }
 
 // GetOptions contains options for the Get operation.
@@ -31,7 +54,34 @@ type ParameterEntry struct {
 	Path      string
 	Value     string
 	StoreMode string
-	Metadata  map[string]any // nil = 未取得（IncludeMetadata=false 時）
+	Flatten   string            // cli-flatten タグの値（未設定・SkipTagFetch 時は ""）
+	Tags      map[string]string // 全タグ（取得していない場合は nil）
+	Metadata  map[string]any    // nil = 未取得（IncludeMetadata=false 時）
+}
+
+// PrefixWalker is implemented by backends that can stream GetByPrefix results page
+// by page instead of collecting them first. Use WalkPrefix to call it.
+type PrefixWalker interface {
+	WalkPrefix(ctx context.Context, prefix string, opts GetByPrefixOptions, fn func(ParameterEntry) error) error
+}
+
+// WalkPrefix calls fn for every entry under prefix. Backends implementing PrefixWalker
+// stream entries as pages arrive; others fall back to GetByPrefix.
+// An error returned by fn stops the walk and is returned as is.
+func WalkPrefix(ctx context.Context, b Backend, prefix string, opts GetByPrefixOptions, fn func(ParameterEntry) error) error {
+	if w, ok := b.(PrefixWalker); ok {
+		return w.WalkPrefix(ctx, prefix, opts, fn)
+	}
+	entries, err := b.GetByPrefix(ctx, prefix, opts)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Backend is the interface for interacting with AWS parameter/secret backends.
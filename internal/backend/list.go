@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ListOptions selects the entries returned by List.
+type ListOptions struct {
+	Recursive bool
+	// Tags keeps entries carrying every key=value pair. Pushed down to the API where possible.
+	Tags map[string]string
+	// Type keeps Parameter Store entries of this type (String, StringList, SecureString).
+	Type string
+	// ResolveManaged fills ListEntry.Managed and ListEntry.StoreMode.
+	// On Parameter Store this costs two extra filtered listings.
+	ResolveManaged bool
+}
+
+// ListEntry is the metadata of one parameter or secret returned by List (no value).
+// Fields a backend does not have (e.g. Tier on Secrets Manager) are left empty.
+type ListEntry struct {
+	Path         string
+	Type         string
+	Tier         string
+	Version      int64
+	LastModified *time.Time
+	KMSKeyID     string // KMS key of SecureStrings and secrets ("" = AWS managed key or unencrypted)
+	Description  string
+	Policies     string // Parameter Store parameter policies as a JSON array ("" = none)
+	// RotationEnabled is set for Secrets Manager secrets only.
+	RotationEnabled *bool
+	Managed         bool   // cli=bundr tag present (only with ResolveManaged)
+	StoreMode       string // cli-store-mode of managed entries (only with ResolveManaged)
+}
+
+// Lister is implemented by backends that can list entries with server-side filters.
+// Use List to call it.
+type Lister interface {
+	List(ctx context.Context, prefix string, opts ListOptions, fn func(ListEntry) error) error
+}
+
+// List calls fn for every entry under prefix that matches opts.
+// An error returned by fn stops the listing and is returned as is.
+func List(ctx context.Context, b Backend, prefix string, opts ListOptions, fn func(ListEntry) error) error {
+	l, ok := b.(Lister)
+	if !ok {
+		return fmt.Errorf("filtered listing is not supported by this backend")
+	}
+	return l.List(ctx, prefix, opts, fn)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/youyo/bundr/internal/tags"
 )
@@ -34,9 +36,16 @@ type DescribeCall struct {
 }
 
 type mockEntry struct {
-	Value     string
-	StoreMode string
-	Tags      map[string]string
+	Value        string
+	StoreMode    string
+	ValueType    string
+	KMSKeyID     string
+	Description  string
+	Policies     string
+	Advanced     bool
+	Version      int64
+	LastModified time.Time
+	Tags         map[string]string
 }
 
 // MockBackend is an in-memory Backend implementation for testing.
@@ -47,6 +56,7 @@ type MockBackend struct {
 	GetCalls         []GetCall
 	GetByPrefixCalls []GetByPrefixCall
 	DescribeCalls    []DescribeCall
+	TagsCalls        []string
 }
 
 // NewMockBackend creates a new MockBackend.
@@ -78,15 +88,19 @@ func (m *MockBackend) Put(_ context.Context, ref string, opts PutOptions) error
 		// Valid JSON (objects, arrays, already-encoded strings) stored as-is
 	}
 
-	entryTags := tags.ManagedTags(opts.StoreMode)
-	for k, v := range opts.Tags {
-		entryTags[k] = v
-	}
+	entryTags := putTags(opts)
 
 	m.store[ref] = mockEntry{
-		Value:     storedValue,
-		StoreMode: opts.StoreMode,
-		Tags:      entryTags,
+		Value:        storedValue,
+		StoreMode:    opts.StoreMode,
+		ValueType:    opts.ValueType,
+		KMSKeyID:     opts.KMSKeyID,
+		Description:  opts.Description,
+		Policies:     opts.Policies,
+		Advanced:     opts.AdvancedTier,
+		Version:      m.store[ref].Version + 1,
+		LastModified: time.Now(),
+		Tags:         entryTags,
 	}
 
 	return nil
@@ -101,7 +115,7 @@ func (m *MockBackend) Get(_ context.Context, ref string, opts GetOptions) (strin
 
 	entry, ok := m.store[ref]
 	if !ok {
-		return "", fmt.Errorf("key not found: %s", ref)
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
 	}
 
 	// ForceRaw: return stored value as-is
@@ -143,6 +157,8 @@ func (m *MockBackend) GetByPrefix(_ context.Context, prefix string, opts GetByPr
 				Path:      parsed.Path,
 				Value:     entry.Value,
 				StoreMode: entry.StoreMode,
+				Flatten:   entry.Tags[tags.TagFlatten],
+				Tags:      copyTags(entry.Tags),
 				Metadata:  metadata,
 			})
 		}
@@ -179,14 +195,170 @@ func (m *MockBackend) GetByPrefix(_ context.Context, prefix string, opts GetByPr
 			Path:      parsed.Path,
 			Value:     entry.Value,
 			StoreMode: entry.StoreMode,
+			Flatten:   entry.Tags[tags.TagFlatten],
+			Tags:      copyTags(entry.Tags),
 			Metadata:  metadata,
 		})
 	}
 	return result, nil
 }
 
+// List calls fn for every entry under prefix matching opts, in path order.
+// Entries stored with ValueType "secure" have type SecureString; sm: entries have no type
+// and report rotation as disabled.
+func (m *MockBackend) List(_ context.Context, prefix string, opts ListOptions, fn func(ListEntry) error) error {
+	m.mu.Lock()
+	var entries []ListEntry
+	for ref, entry := range m.store {
+		parsed, err := ParseRef(ref)
+		if err != nil {
+			continue
+		}
+		if prefix != "" {
+			normalizedPrefix := strings.TrimSuffix(prefix, "/") + "/"
+			if !strings.HasPrefix(parsed.Path, normalizedPrefix) {
+				continue
+			}
+			if !opts.Recursive && strings.Contains(strings.TrimPrefix(parsed.Path, normalizedPrefix), "/") {
+				continue
+			}
+		}
+		matched := true
+		for k, v := range opts.Tags {
+			if entry.Tags[k] != v {
+				matched = false
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		le := ListEntry{Path: parsed.Path, Version: entry.Version, KMSKeyID: entry.KMSKeyID, Description: entry.Description, Policies: entry.Policies}
+		if !entry.LastModified.IsZero() {
+			modified := entry.LastModified
+			le.LastModified = &modified
+		}
+		if parsed.Type == BackendTypePS {
+			le.Type = "String"
+			switch entry.ValueType {
+			case ValueTypeSecure:
+				le.Type = "SecureString"
+			case ValueTypeStringList:
+				le.Type = "StringList"
+			}
+			le.Tier = "Standard"
+			if entry.Advanced {
+				le.Tier = "Advanced"
+			}
+		} else {
+			rotation := false
+			le.RotationEnabled = &rotation
+		}
+		if opts.Type != "" && le.Type != opts.Type {
+			continue
+		}
+		if entry.Tags[tags.TagCLI] == tags.TagCLIValue {
+			le.Managed = true
+			le.StoreMode = entry.Tags[tags.TagStoreMode]
+		}
+		entries = append(entries, le)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	for _, e := range entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tags returns a copy of the tags of a stored entry.
+func (m *MockBackend) Tags(_ context.Context, ref string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.TagsCalls = append(m.TagsCalls, ref)
+	entry, ok := m.store[ref]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	return copyTags(entry.Tags), nil
+}
+
+// AddTags adds or overwrites tags of a stored entry. The store mode follows cli-store-mode.
+func (m *MockBackend) AddTags(_ context.Context, ref string, tagMap map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.store[ref]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	entry.Tags = copyTags(entry.Tags)
+	for k, v := range tagMap {
+		entry.Tags[k] = v
+	}
+	entry.StoreMode = entry.Tags[tags.TagStoreMode]
+	m.store[ref] = entry
+	return nil
+}
+
+// RemoveTags removes tag keys from a stored entry. The store mode follows cli-store-mode.
+func (m *MockBackend) RemoveTags(_ context.Context, ref string, keys []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.store[ref]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	entry.Tags = copyTags(entry.Tags)
+	for _, k := range keys {
+		delete(entry.Tags, k)
+	}
+	entry.StoreMode = entry.Tags[tags.TagStoreMode]
+	m.store[ref] = entry
+	return nil
+}
+
+// copyTags returns a copy of tagMap (never nil).
+func copyTags(tagMap map[string]string) map[string]string {
+	out := make(map[string]string, len(tagMap))
+	for k, v := range tagMap {
+		out[k] = v
+	}
+	return out
+}
+
+// SetTags replaces the tags of a stored entry (e.g. to simulate unmanaged parameters).
+func (m *MockBackend) SetTags(ref string, tagMap map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.store[ref]
+	entry.Tags = tagMap
+	m.store[ref] = entry
+}
+
+// SetLastModified overrides the last-modified time of a stored entry.
+func (m *MockBackend) SetLastModified(ref string, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.store[ref]
+	entry.LastModified = t
+	m.store[ref] = entry
+}
+
+// SetDescription sets the description of a stored entry.
+func (m *MockBackend) SetDescription(ref, description string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.store[ref]
+	entry.Description = description
+	m.store[ref] = entry
+}
+
 // Describe returns mock metadata for the given ref.
-// Tags are returned as the metadata map, plus the Value field.
+// Tags are returned as the metadata map, plus the Value field and a Tags map
+// mirroring the real backends.
 func (m *MockBackend) Describe(_ context.Context, ref string) (map[string]any, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -195,11 +367,16 @@ func (m *MockBackend) Describe(_ context.Context, ref string) (map[string]any, e
 
 	entry, ok := m.store[ref]
 	if !ok {
-		return nil, fmt.Errorf("key not found: %s", ref)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
 	}
 
 	result := tagsToMetadata(entry.Tags)
 	result["Value"] = entry.Value
+	tagCopy := make(map[string]string, len(entry.Tags))
+	for k, v := range entry.Tags {
+		tagCopy[k] = v
+	}
+	result["Tags"] = tagCopy
 	return result, nil
 }
 
@@ -147,3 +147,87 @@ func TestMockBackend_PutJSONObject(t *testing.T) {
 		t.Errorf("Get() = %q, want %q", val, `{"key":"value"}`)
 	}
 }
+
+func TestWalkPrefix_FallsBackToGetByPrefix(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockBackend()
+	_ = mock.Put(ctx, "ps:/app/a", PutOptions{Value: "1", StoreMode: tags.StoreModeRaw})
+	_ = mock.Put(ctx, "ps:/app/b", PutOptions{Value: "2", StoreMode: tags.StoreModeRaw})
+
+	count := 0
+	err := WalkPrefix(ctx, mock, "/app/", GetByPrefixOptions{Recursive: true}, func(ParameterEntry) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkPrefix() error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("fn called %d times, want 2", count)
+	}
+	if len(mock.GetByPrefixCalls) != 1 {
+		t.Errorf("GetByPrefix called %d times, want 1", len(mock.GetByPrefixCalls))
+	}
+}
+
+func TestMockBackend_List(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockBackend()
+	_ = mock.Put(ctx, "ps:/app/a", PutOptions{Value: "1", StoreMode: tags.StoreModeRaw, ValueType: "secure", Tags: map[string]string{"team": "core"}})
+	_ = mock.Put(ctx, "ps:/app/b", PutOptions{Value: "2", StoreMode: tags.StoreModeJSON})
+	_ = mock.Put(ctx, "ps:/app/sub/c", PutOptions{Value: "3", StoreMode: tags.StoreModeRaw})
+	mock.SetTags("ps:/app/b", map[string]string{})
+
+	list := func(opts ListOptions) []ListEntry {
+		var got []ListEntry
+		if err := List(ctx, mock, "/app/", opts, func(e ListEntry) error {
+			got = append(got, e)
+			return nil
+		}); err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		return got
+	}
+
+	if got := list(ListOptions{}); len(got) != 2 || got[0].Path != "/app/a" || got[0].Type != "SecureString" || !got[0].Managed || got[1].Managed {
+		t.Errorf("unexpected one-level entries: %+v", got)
+	}
+	if got := list(ListOptions{Recursive: true}); len(got) != 3 {
+		t.Errorf("expected 3 recursive entries, got %+v", got)
+	}
+	if got := list(ListOptions{Recursive: true, Tags: map[string]string{"team": "core"}}); len(got) != 1 || got[0].Path != "/app/a" {
+		t.Errorf("unexpected tag-filtered entries: %+v", got)
+	}
+	if got := list(ListOptions{Recursive: true, Type: "String"}); len(got) != 2 {
+		t.Errorf("expected 2 String entries, got %+v", got)
+	}
+}
+
+func TestMockBackend_Tagger(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockBackend()
+	_ = mock.Put(ctx, "ps:/app/a", PutOptions{Value: "1", StoreMode: tags.StoreModeRaw})
+
+	tagger, err := AsTagger(mock)
+	if err != nil {
+		t.Fatalf("AsTagger() error: %v", err)
+	}
+	if err := tagger.AddTags(ctx, "ps:/app/a", map[string]string{"team": "core", tags.TagStoreMode: tags.StoreModeJSON}); err != nil {
+		t.Fatalf("AddTags() error: %v", err)
+	}
+	if err := tagger.RemoveTags(ctx, "ps:/app/a", []string{tags.TagSchema}); err != nil {
+		t.Fatalf("RemoveTags() error: %v", err)
+	}
+	got, _ := tagger.Tags(ctx, "ps:/app/a")
+	if got["team"] != "core" || got[tags.TagStoreMode] != tags.StoreModeJSON || got[tags.TagSchema] != "" {
+		t.Errorf("unexpected tags: %v", got)
+	}
+	// the store mode follows the cli-store-mode tag
+	entries, _ := mock.GetByPrefix(ctx, "/app/", GetByPrefixOptions{})
+	if len(entries) != 1 || entries[0].StoreMode != tags.StoreModeJSON || entries[0].Tags["team"] != "core" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	if err := tagger.AddTags(ctx, "ps:/nope", map[string]string{"a": "b"}); err == nil {
+		t.Error("expected error for missing ref")
+	}
+}
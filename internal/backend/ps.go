@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
@@ -15,8 +17,10 @@ import (
 type SSMClient interface {
 	PutParameter(ctx context.Context, input *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
 	GetParameter(ctx context.Context, input *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParameters(ctx context.Context, input *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
 	GetParametersByPath(ctx context.Context, input *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
 	AddTagsToResource(ctx context.Context, input *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error)
+	RemoveTagsFromResource(ctx context.Context, input *ssm.RemoveTagsFromResourceInput, optFns ...func(*ssm.Options)) (*ssm.RemoveTagsFromResourceOutput, error)
 	ListTagsForResource(ctx context.Context, input *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error)
 	DescribeParameters(ctx context.Context, input *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error)
 }
@@ -53,15 +57,15 @@ func (b *PSBackend) Put(ctx context.Context, ref string, opts PutOptions) error
 
 	// Determine parameter type
 	paramType := ssmtypes.ParameterTypeString
-	if opts.ValueType == ValueTypeSecure {
+	switch opts.ValueType {
+	case ValueTypeSecure:
 		paramType = ssmtypes.ParameterTypeSecureString
+	case ValueTypeStringList:
+		paramType = ssmtypes.ParameterTypeStringList
 	}
 
 	// Build managed tags
-	managedTags := tags.ManagedTags(opts.StoreMode)
-	for k, v := range opts.Tags {
-		managedTags[k] = v
-	}
+	managedTags := putTags(opts)
 
 	ssmTags := make([]ssmtypes.Tag, 0, len(managedTags))
 	for k, v := range managedTags {
@@ -88,10 +92,19 @@ func (b *PSBackend) Put(ctx context.Context, ref string, opts PutOptions) error
 	if opts.KMSKeyID != "" {
 		input.KeyId = aws.String(opts.KMSKeyID)
 	}
+	if opts.Description != "" {
+		input.Description = aws.String(opts.Description)
+	}
+	if opts.Policies != "" {
+		input.Policies = aws.String(opts.Policies)
+	}
 
 	if _, err = b.client.PutParameter(ctx, input); err != nil {
 		return fmt.Errorf("ssm PutParameter: %w", err)
 	}
+	if len(ssmTags) == 0 {
+		return nil
+	}
 
 	// Step 2: AddTagsToResource to set managed tags separately
 	if _, err = b.client.AddTagsToResource(ctx, &ssm.AddTagsToResourceInput{
@@ -121,8 +134,83 @@ func (b *PSBackend) Get(ctx context.Context, ref string, opts GetOptions) (strin
 		return "", fmt.Errorf("ssm GetParameter: %w", err)
 	}
 
-	rawValue := aws.ToString(getOutput.Parameter.Value)
+	return b.decodeValue(ctx, parsed.Path, aws.ToString(getOutput.Parameter.Value), opts)
+}
+
+// psBatchSize is the maximum number of names accepted by a single GetParameters call.
+const psBatchSize = 10
+
+// GetMany fetches refs with GetParameters, psBatchSize names per call. Refs that do not
+// exist are returned as missing. Unless opts.ForceRaw or opts.ForceJSON makes it
+// unnecessary, the store modes of each batch are resolved with one DescribeParameters
+// call filtered on the cli-store-mode tag instead of a tag lookup per parameter.
+func (b *PSBackend) GetMany(ctx context.Context, refs []string, opts GetOptions) (map[string]string, []string, error) {
+	// 重複した ref は 1 回だけ取得する
+	byPath := make(map[string][]string)
+	var paths []string
+	for _, ref := range refs {
+		parsed, err := ParseRef(ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := byPath[parsed.Path]; !ok {
+			paths = append(paths, parsed.Path)
+		}
+		byPath[parsed.Path] = append(byPath[parsed.Path], ref)
+	}
+
+	values := make(map[string]string, len(refs))
+	var missing []string
+	for start := 0; start < len(paths); start += psBatchSize {
+		chunk := paths[start:min(start+psBatchSize, len(paths))]
+		out, err := b.client.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          chunk,
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("ssm GetParameters: %w", err)
+		}
+		jsonMode, err := b.jsonModeNames(ctx, out.Parameters, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, param := range out.Parameters {
+			path := aws.ToString(param.Name)
+			value := aws.ToString(param.Value)
+			if !opts.ForceRaw && (opts.ForceJSON || jsonMode[path]) {
+				if value, err = decodeJSON(value); err != nil {
+					return nil, nil, fmt.Errorf("%s: %w", path, err)
+				}
+			}
+			for _, ref := range byPath[path] {
+				values[ref] = value
+			}
+		}
+		for _, name := range out.InvalidParameters {
+			missing = append(missing, byPath[name]...)
+		}
+	}
+	return values, missing, nil
+}
+
+// jsonModeNames returns the names among params stored in json mode, or nil when opts
+// decide the decoding by themselves.
+func (b *PSBackend) jsonModeNames(ctx context.Context, params []ssmtypes.Parameter, opts GetOptions) (map[string]bool, error) {
+	if opts.ForceRaw || opts.ForceJSON || len(params) == 0 {
+		return nil, nil
+	}
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = aws.ToString(p.Name)
+	}
+	return b.describeNames(ctx, []ssmtypes.ParameterStringFilter{
+		{Key: aws.String("Name"), Option: aws.String("Equals"), Values: names},
+		psTagFilter(tags.TagStoreMode, tags.StoreModeJSON),
+	})
+}
 
+// decodeValue applies opts and the cli-store-mode tag of path to a raw parameter value.
+func (b *PSBackend) decodeValue(ctx context.Context, path, rawValue string, opts GetOptions) (string, error) {
 	// ForceRaw: return as-is
 	if opts.ForceRaw {
 		return rawValue, nil
@@ -135,7 +223,7 @@ func (b *PSBackend) Get(ctx context.Context, ref string, opts GetOptions) (strin
 
 	// Check tags to determine store mode
 	tagsOutput, err := b.client.ListTagsForResource(ctx, &ssm.ListTagsForResourceInput{
-		ResourceId:   aws.String(parsed.Path),
+		ResourceId:   aws.String(path),
 		ResourceType: ssmtypes.ResourceTypeForTaggingParameter,
 	})
 	if err != nil {
@@ -160,6 +248,18 @@ func (b *PSBackend) Get(ctx context.Context, ref string, opts GetOptions) (strin
 // GetByPrefix retrieves all parameters under the given SSM path prefix.
 func (b *PSBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPrefixOptions) ([]ParameterEntry, error) {
 	var entries []ParameterEntry
+	err := b.WalkPrefix(ctx, prefix, opts, func(e ParameterEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WalkPrefix calls fn for each parameter under the given SSM path prefix as pages arrive.
+func (b *PSBackend) WalkPrefix(ctx context.Context, prefix string, opts GetByPrefixOptions, fn func(ParameterEntry) error) error {
 	var nextToken *string
 
 	for {
@@ -174,7 +274,7 @@ func (b *PSBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPr
 
 		out, err := b.client.GetParametersByPath(ctx, input)
 		if err != nil {
-			return nil, fmt.Errorf("ssm GetParametersByPath: %w", err)
+			return fmt.Errorf("ssm GetParametersByPath: %w", err)
 		}
 
 		for _, param := range out.Parameters {
@@ -182,12 +282,14 @@ func (b *PSBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPr
 			value := aws.ToString(param.Value)
 
 			var storeMode string
+			var tagMap map[string]string
 			if !opts.SkipTagFetch {
-				var smErr error
-				storeMode, smErr = b.getStoreMode(ctx, path)
-				if smErr != nil {
-					return nil, fmt.Errorf("get store mode for %s: %w", path, smErr)
+				var tagErr error
+				tagMap, tagErr = b.listTags(ctx, path)
+				if tagErr != nil {
+					return fmt.Errorf("get store mode for %s: %w", path, tagErr)
 				}
+				storeMode = storeModeOf(tagMap)
 			}
 
 			var metadata map[string]any
@@ -202,12 +304,16 @@ func (b *PSBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPr
 				}
 			}
 
-			entries = append(entries, ParameterEntry{
+			if err := fn(ParameterEntry{
 				Path:      path,
 				Value:     value,
 				StoreMode: storeMode,
+				Flatten:   tagMap[tags.TagFlatten],
+				Tags:      tagMap,
 				Metadata:  metadata,
-			})
+			}); err != nil {
+				return err
+			}
 		}
 
 		if out.NextToken == nil {
@@ -216,29 +322,213 @@ func (b *PSBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPr
 		nextToken = out.NextToken
 	}
 
-	return entries, nil
+	return nil
+}
+
+// List calls fn for each parameter under prefix using DescribeParameters, with the path,
+// type and tag filters pushed down as ParameterFilters. Values are not fetched.
+func (b *PSBackend) List(ctx context.Context, prefix string, opts ListOptions, fn func(ListEntry) error) error {
+	filters := psListFilters(prefix, opts)
+
+	// managed / store mode はタグ条件付きの一覧を 2 回追加で取得して判定する
+	// （パラメータごとの ListTagsForResource を避ける）
+	var managed, jsonMode map[string]bool
+	if opts.ResolveManaged {
+		var err error
+		managed, err = b.describeNames(ctx, append(filters[:len(filters):len(filters)], psTagFilter(tags.TagCLI, tags.TagCLIValue)))
+		if err != nil {
+			return err
+		}
+		jsonMode, err = b.describeNames(ctx, append(filters[:len(filters):len(filters)], psTagFilter(tags.TagStoreMode, tags.StoreModeJSON)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.describeParameters(ctx, filters, func(p ssmtypes.ParameterMetadata) error {
+		name := aws.ToString(p.Name)
+		entry := ListEntry{
+			Path:         name,
+			Type:         string(p.Type),
+			Tier:         string(p.Tier),
+			Version:      p.Version,
+			LastModified: p.LastModifiedDate,
+			KMSKeyID:     aws.ToString(p.KeyId),
+			Description:  aws.ToString(p.Description),
+			Policies:     psPolicies(p.Policies),
+		}
+		if managed[name] {
+			entry.Managed = true
+			entry.StoreMode = tags.StoreModeRaw
+			if jsonMode[name] {
+				entry.StoreMode = tags.StoreModeJSON
+			}
+		}
+		return fn(entry)
+	})
+}
+
+// psPolicies joins the policy documents of a parameter into one JSON array, the form
+// PutParameter accepts.
+func psPolicies(policies []ssmtypes.ParameterInlinePolicy) string {
+	if len(policies) == 0 {
+		return ""
+	}
+	docs := make([]string, 0, len(policies))
+	for _, p := range policies {
+		docs = append(docs, aws.ToString(p.PolicyText))
+	}
+	return "[" + strings.Join(docs, ",") + "]"
+}
+
+// psListFilters builds the DescribeParameters filters for List.
+func psListFilters(prefix string, opts ListOptions) []ssmtypes.ParameterStringFilter {
+	option := "OneLevel"
+	if opts.Recursive {
+		option = "Recursive"
+	}
+	path := prefix
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	filters := []ssmtypes.ParameterStringFilter{
+		{Key: aws.String("Path"), Option: aws.String(option), Values: []string{path}},
+	}
+	if opts.Type != "" {
+		filters = append(filters, ssmtypes.ParameterStringFilter{
+			Key: aws.String("Type"), Option: aws.String("Equals"), Values: []string{opts.Type},
+		})
+	}
+	keys := make([]string, 0, len(opts.Tags))
+	for k := range opts.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		filters = append(filters, psTagFilter(k, opts.Tags[k]))
+	}
+	return filters
+}
+
+// psTagFilter returns a DescribeParameters filter matching parameters tagged key=value.
+func psTagFilter(key, value string) ssmtypes.ParameterStringFilter {
+	return ssmtypes.ParameterStringFilter{Key: aws.String("tag:" + key), Values: []string{value}}
+}
+
+// describeParameters calls fn for every parameter matching filters, page by page.
+func (b *PSBackend) describeParameters(ctx context.Context, filters []ssmtypes.ParameterStringFilter, fn func(ssmtypes.ParameterMetadata) error) error {
+	input := &ssm.DescribeParametersInput{
+		ParameterFilters: filters,
+		MaxResults:       aws.Int32(50),
+	}
+	for {
+		out, err := b.client.DescribeParameters(ctx, input)
+		if err != nil {
+			return fmt.Errorf("ssm DescribeParameters: %w", err)
+		}
+		for _, p := range out.Parameters {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+		if out.NextToken == nil {
+			return nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// describeNames returns the set of parameter names matching filters.
+func (b *PSBackend) describeNames(ctx context.Context, filters []ssmtypes.ParameterStringFilter) (map[string]bool, error) {
+	names := make(map[string]bool)
+	err := b.describeParameters(ctx, filters, func(p ssmtypes.ParameterMetadata) error {
+		names[aws.ToString(p.Name)] = true
+		return nil
+	})
+	return names, err
+}
+
+// storeModeOf returns the cli-store-mode tag value, defaulting to raw.
+func storeModeOf(tagMap map[string]string) string {
+	if storeMode, ok := tagMap[tags.TagStoreMode]; ok {
+		return storeMode
+	}
+	return tags.StoreModeRaw
 }
 
-// getStoreMode retrieves the cli-store-mode tag for the given SSM parameter path.
-func (b *PSBackend) getStoreMode(ctx context.Context, path string) (string, error) {
+// listTags returns all tags of the given SSM parameter path as a map.
+func (b *PSBackend) listTags(ctx context.Context, path string) (map[string]string, error) {
 	tagsOut, err := b.client.ListTagsForResource(ctx, &ssm.ListTagsForResourceInput{
 		ResourceId:   aws.String(path),
 		ResourceType: ssmtypes.ResourceTypeForTaggingParameter,
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	tagMap := make(map[string]string, len(tagsOut.TagList))
 	for _, tag := range tagsOut.TagList {
-		if aws.ToString(tag.Key) == tags.TagStoreMode {
-			return aws.ToString(tag.Value), nil
-		}
+		tagMap[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tagMap, nil
+}
+
+// Tags returns all tags of the parameter.
+func (b *PSBackend) Tags(ctx context.Context, ref string) (map[string]string, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	tagMap, err := b.listTags(ctx, parsed.Path)
+	if err != nil {
+		return nil, fmt.Errorf("ssm ListTagsForResource: %w", err)
+	}
+	return tagMap, nil
+}
+
+// AddTags adds or overwrites tags on the parameter in a single AddTagsToResource call.
+func (b *PSBackend) AddTags(ctx context.Context, ref string, tagMap map[string]string) error {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(tagMap))
+	for k := range tagMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ssmTags := make([]ssmtypes.Tag, 0, len(keys))
+	for _, k := range keys {
+		ssmTags = append(ssmTags, ssmtypes.Tag{Key: aws.String(k), Value: aws.String(tagMap[k])})
+	}
+	if _, err := b.client.AddTagsToResource(ctx, &ssm.AddTagsToResourceInput{
+		ResourceId:   aws.String(parsed.Path),
+		ResourceType: ssmtypes.ResourceTypeForTaggingParameter,
+		Tags:         ssmTags,
+	}); err != nil {
+		return fmt.Errorf("ssm AddTagsToResource: %w", err)
+	}
+	return nil
+}
+
+// RemoveTags removes tag keys from the parameter in a single RemoveTagsFromResource call.
+func (b *PSBackend) RemoveTags(ctx context.Context, ref string, keys []string) error {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return err
 	}
-	return tags.StoreModeRaw, nil
+	if _, err := b.client.RemoveTagsFromResource(ctx, &ssm.RemoveTagsFromResourceInput{
+		ResourceId:   aws.String(parsed.Path),
+		ResourceType: ssmtypes.ResourceTypeForTaggingParameter,
+		TagKeys:      keys,
+	}); err != nil {
+		return fmt.Errorf("ssm RemoveTagsFromResource: %w", err)
+	}
+	return nil
 }
 
 // Describe returns metadata for the given SSM parameter ref as a map.
-// Fields: Name, Type, Value, Version, ARN, LastModifiedDate, DataType.
+// Fields: Name, Type, Value, Version, ARN, LastModifiedDate, DataType, Tags.
 func (b *PSBackend) Describe(ctx context.Context, ref string) (map[string]any, error) {
 	parsed, err := ParseRef(ref)
 	if err != nil {
@@ -263,6 +553,12 @@ func (b *PSBackend) Describe(ctx context.Context, ref string) (map[string]any, e
 		"DataType":         aws.ToString(p.DataType),
 		"LastModifiedDate": p.LastModifiedDate,
 	}
+
+	tagMap, err := b.listTags(ctx, parsed.Path)
+	if err != nil {
+		return nil, fmt.Errorf("ssm ListTagsForResource: %w", err)
+	}
+	result["Tags"] = tagMap
 	return result, nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
@@ -14,12 +15,14 @@ import (
 
 // mockSSMClient is a test double for the SSM API.
 type mockSSMClient struct {
-	putParameterFn          func(ctx context.Context, input *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
-	getParameterFn          func(ctx context.Context, input *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
-	getParametersByPathFn   func(ctx context.Context, input *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
-	addTagsToResourceFn     func(ctx context.Context, input *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error)
-	listTagsForResourceFn   func(ctx context.Context, input *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error)
-	describeParametersFn    func(ctx context.Context, input *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error)
+	putParameterFn           func(ctx context.Context, input *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	getParameterFn           func(ctx context.Context, input *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	getParametersFn          func(ctx context.Context, input *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+	getParametersByPathFn    func(ctx context.Context, input *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+	addTagsToResourceFn      func(ctx context.Context, input *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error)
+	listTagsForResourceFn    func(ctx context.Context, input *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error)
+	describeParametersFn     func(ctx context.Context, input *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error)
+	removeTagsFromResourceFn func(ctx context.Context, input *ssm.RemoveTagsFromResourceInput, optFns ...func(*ssm.Options)) (*ssm.RemoveTagsFromResourceOutput, error)
 
 	// Call recording fields for verifying call sequences
 	putParameterCalls      []*ssm.PutParameterInput
@@ -38,6 +41,10 @@ func (m *mockSSMClient) GetParameter(ctx context.Context, input *ssm.GetParamete
 	return m.getParameterFn(ctx, input, optFns...)
 }
 
+func (m *mockSSMClient) GetParameters(ctx context.Context, input *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	return m.getParametersFn(ctx, input, optFns...)
+}
+
 func (m *mockSSMClient) GetParametersByPath(ctx context.Context, input *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
 	if m.getParametersByPathFn == nil {
 		return &ssm.GetParametersByPathOutput{}, nil
@@ -50,6 +57,10 @@ func (m *mockSSMClient) AddTagsToResource(ctx context.Context, input *ssm.AddTag
 	return m.addTagsToResourceFn(ctx, input, optFns...)
 }
 
+func (m *mockSSMClient) RemoveTagsFromResource(ctx context.Context, input *ssm.RemoveTagsFromResourceInput, optFns ...func(*ssm.Options)) (*ssm.RemoveTagsFromResourceOutput, error) {
+	return m.removeTagsFromResourceFn(ctx, input, optFns...)
+}
+
 func (m *mockSSMClient) ListTagsForResource(ctx context.Context, input *ssm.ListTagsForResourceInput, optFns ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
 	return m.listTagsForResourceFn(ctx, input, optFns...)
 }
@@ -190,6 +201,48 @@ func TestPSBackend_PutSecureString(t *testing.T) {
 	}
 }
 
+// TestPSBackend_PutRestoreOptions verifies the options restore uses: StringList,
+// description, policies and ExactTags without any tags (no tagging call).
+func TestPSBackend_PutRestoreOptions(t *testing.T) {
+	ctx := context.Background()
+	var capturedInput *ssm.PutParameterInput
+	tagged := false
+
+	client := &mockSSMClient{
+		putParameterFn: func(_ context.Context, input *ssm.PutParameterInput, _ ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+			capturedInput = input
+			return &ssm.PutParameterOutput{}, nil
+		},
+		addTagsToResourceFn: func(_ context.Context, _ *ssm.AddTagsToResourceInput, _ ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+			tagged = true
+			return &ssm.AddTagsToResourceOutput{}, nil
+		},
+	}
+
+	err := NewPSBackend(client).Put(ctx, "ps:/app/hosts", PutOptions{
+		Value:        "a,b",
+		StoreMode:    tags.StoreModeRaw,
+		ValueType:    ValueTypeStringList,
+		Description:  "hosts",
+		Policies:     `[{"Type":"Expiration","Version":"1.0","Attributes":{"Timestamp":"2030-01-01T00:00:00Z"}}]`,
+		AdvancedTier: true,
+		TierExplicit: true,
+		ExactTags:    true,
+	})
+	if err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if capturedInput.Type != ssmtypes.ParameterTypeStringList {
+		t.Errorf("Type = %v, want StringList", capturedInput.Type)
+	}
+	if aws.ToString(capturedInput.Description) != "hosts" || !strings.HasPrefix(aws.ToString(capturedInput.Policies), `[{"Type":"Expiration"`) {
+		t.Errorf("Description = %q, Policies = %q", aws.ToString(capturedInput.Description), aws.ToString(capturedInput.Policies))
+	}
+	if tagged {
+		t.Error("AddTagsToResource called for a Put without tags")
+	}
+}
+
 // TestPSBackend_PutAdvancedTier_OptsFlag verifies that PutOptions.AdvancedTier=true sets Advanced tier.
 func TestPSBackend_PutAdvancedTier_OptsFlag(t *testing.T) {
 	ctx := context.Background()
@@ -651,6 +704,7 @@ func TestPSBackend_GetByPrefix_StoreModeFromTags(t *testing.T) {
 					{Key: aws.String(tags.TagCLI), Value: aws.String(tags.TagCLIValue)},
 					{Key: aws.String(tags.TagStoreMode), Value: aws.String(tags.StoreModeJSON)},
 					{Key: aws.String(tags.TagSchema), Value: aws.String(tags.TagSchemaValue)},
+					{Key: aws.String(tags.TagFlatten), Value: aws.String("array=index depth=1")},
 				},
 			}, nil
 		},
@@ -667,11 +721,43 @@ func TestPSBackend_GetByPrefix_StoreModeFromTags(t *testing.T) {
 	if entries[0].StoreMode != tags.StoreModeJSON {
 		t.Errorf("StoreMode = %q, want %q", entries[0].StoreMode, tags.StoreModeJSON)
 	}
+	if entries[0].Flatten != "array=index depth=1" {
+		t.Errorf("Flatten = %q, want %q", entries[0].Flatten, "array=index depth=1")
+	}
 	if entries[0].Value != `{"key":"value"}` {
 		t.Errorf("Value = %q, want %q", entries[0].Value, `{"key":"value"}`)
 	}
 }
 
+// PS-GB-W01: WalkPrefix streams page by page and stops when fn returns an error
+func TestPSBackend_WalkPrefix_StopsOnError(t *testing.T) {
+	ctx := context.Background()
+	callCount := 0
+
+	client := &mockSSMClient{
+		getParametersByPathFn: func(_ context.Context, _ *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+			callCount++
+			return &ssm.GetParametersByPathOutput{
+				Parameters: []ssmtypes.Parameter{{Name: aws.String("/app/KEY"), Value: aws.String("v")}},
+				NextToken:  aws.String("tok"),
+			}, nil
+		},
+	}
+
+	stop := fmt.Errorf("stop")
+	var seen []string
+	err := NewPSBackend(client).WalkPrefix(ctx, "/app/", GetByPrefixOptions{Recursive: true, SkipTagFetch: true}, func(e ParameterEntry) error {
+		seen = append(seen, e.Path)
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("WalkPrefix() error = %v, want %v", err, stop)
+	}
+	if len(seen) != 1 || callCount != 1 {
+		t.Errorf("seen = %v, calls = %d; want 1 entry from 1 page", seen, callCount)
+	}
+}
+
 // PS-GB-05: API error from GetParametersByPath
 func TestPSBackend_GetByPrefix_APIError(t *testing.T) {
 	ctx := context.Background()
@@ -866,6 +952,14 @@ func TestPSBackendDescribe_SecureString(t *testing.T) {
 				},
 			}, nil
 		},
+		listTagsForResourceFn: func(_ context.Context, _ *ssm.ListTagsForResourceInput, _ ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
+			return &ssm.ListTagsForResourceOutput{
+				TagList: []ssmtypes.Tag{
+					{Key: aws.String(tags.TagStoreMode), Value: aws.String(tags.StoreModeRaw)},
+					{Key: aws.String(tags.TagFlatten), Value: aws.String("array=index")},
+				},
+			}, nil
+		},
 	}
 
 	b := NewPSBackend(client)
@@ -896,6 +990,10 @@ func TestPSBackendDescribe_SecureString(t *testing.T) {
 	if _, ok := result["LastModifiedDate"]; !ok {
 		t.Error("LastModifiedDate key missing from result")
 	}
+	tagMap, ok := result["Tags"].(map[string]string)
+	if !ok || tagMap[tags.TagFlatten] != "array=index" {
+		t.Errorf("Tags = %v, want cli-flatten=array=index", result["Tags"])
+	}
 }
 
 // PS-D-ERR-01: Describe with nonexistent ref returns error
@@ -1013,3 +1111,186 @@ func TestPSBackend_GetByPrefix_DefaultStoreModeRaw(t *testing.T) {
 		t.Errorf("StoreMode = %q, want %q", entries[0].StoreMode, tags.StoreModeRaw)
 	}
 }
+
+func TestPSBackend_GetMany(t *testing.T) {
+	ctx := context.Background()
+	stored := map[string]string{}
+	var refs []string
+	for i := 0; i < 23; i++ {
+		path := fmt.Sprintf("/app/p%02d", i)
+		stored[path] = fmt.Sprintf("v%d", i)
+		refs = append(refs, "ps:"+path)
+	}
+	refs = append(refs, "ps:/app/missing", "ps:/app/p00")
+
+	var batchSizes []int
+	client := &mockSSMClient{
+		getParametersFn: func(_ context.Context, input *ssm.GetParametersInput, _ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+			batchSizes = append(batchSizes, len(input.Names))
+			out := &ssm.GetParametersOutput{}
+			for _, name := range input.Names {
+				if v, ok := stored[name]; ok {
+					out.Parameters = append(out.Parameters, ssmtypes.Parameter{Name: aws.String(name), Value: aws.String(v)})
+				} else {
+					out.InvalidParameters = append(out.InvalidParameters, name)
+				}
+			}
+			return out, nil
+		},
+	}
+
+	b := NewPSBackend(client)
+	values, missing, err := b.GetMany(ctx, refs, GetOptions{ForceRaw: true})
+	if err != nil {
+		t.Fatalf("GetMany() error: %v", err)
+	}
+	// 24 distinct paths (duplicates fetched once) → 10 + 10 + 4
+	if fmt.Sprint(batchSizes) != "[10 10 4]" {
+		t.Errorf("GetParameters batch sizes = %v, want [10 10 4]", batchSizes)
+	}
+	if len(values) != 23 || values["ps:/app/p22"] != "v22" || values["ps:/app/p00"] != "v0" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if len(missing) != 1 || missing[0] != "ps:/app/missing" {
+		t.Errorf("missing = %v, want [ps:/app/missing]", missing)
+	}
+}
+
+func TestPSBackend_GetMany_StoreMode(t *testing.T) {
+	ctx := context.Background()
+	var describeCalls int
+	client := &mockSSMClient{
+		getParametersFn: func(_ context.Context, _ *ssm.GetParametersInput, _ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+			return &ssm.GetParametersOutput{Parameters: []ssmtypes.Parameter{
+				{Name: aws.String("/app/json"), Value: aws.String(`"hello"`)},
+				{Name: aws.String("/app/raw"), Value: aws.String(`"quoted"`)},
+			}}, nil
+		},
+		describeParametersFn: func(_ context.Context, input *ssm.DescribeParametersInput, _ ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+			describeCalls++
+			f := input.ParameterFilters
+			if len(f) != 2 || aws.ToString(f[0].Key) != "Name" || strings.Join(f[0].Values, ",") != "/app/json,/app/raw" || aws.ToString(f[1].Key) != "tag:"+tags.TagStoreMode {
+				t.Errorf("unexpected filters: %+v", f)
+			}
+			return &ssm.DescribeParametersOutput{Parameters: []ssmtypes.ParameterMetadata{{Name: aws.String("/app/json")}}}, nil
+		},
+		listTagsForResourceFn: func(_ context.Context, input *ssm.ListTagsForResourceInput, _ ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
+			t.Errorf("ListTagsForResource(%s) called; store modes must be resolved per batch", aws.ToString(input.ResourceId))
+			return &ssm.ListTagsForResourceOutput{}, nil
+		},
+	}
+
+	values, _, err := NewPSBackend(client).GetMany(ctx, []string{"ps:/app/json", "ps:/app/raw"}, GetOptions{})
+	if err != nil {
+		t.Fatalf("GetMany() error: %v", err)
+	}
+	if values["ps:/app/json"] != "hello" || values["ps:/app/raw"] != `"quoted"` {
+		t.Errorf("values = %v", values)
+	}
+	if describeCalls != 1 {
+		t.Errorf("DescribeParameters calls = %d, want 1", describeCalls)
+	}
+}
+
+func TestPSBackend_List(t *testing.T) {
+	ctx := context.Background()
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var calls [][]ssmtypes.ParameterStringFilter
+
+	client := &mockSSMClient{
+		describeParametersFn: func(_ context.Context, input *ssm.DescribeParametersInput, _ ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+			calls = append(calls, input.ParameterFilters)
+			last := aws.ToString(input.ParameterFilters[len(input.ParameterFilters)-1].Key)
+			switch {
+			case last == "tag:cli-store-mode":
+				return &ssm.DescribeParametersOutput{Parameters: []ssmtypes.ParameterMetadata{{Name: aws.String("/app/json")}}}, nil
+			case last == "tag:cli":
+				return &ssm.DescribeParametersOutput{Parameters: []ssmtypes.ParameterMetadata{{Name: aws.String("/app/json")}, {Name: aws.String("/app/raw")}}}, nil
+			case input.NextToken == nil:
+				return &ssm.DescribeParametersOutput{
+					Parameters: []ssmtypes.ParameterMetadata{
+						{Name: aws.String("/app/json"), Type: ssmtypes.ParameterTypeSecureString, Tier: ssmtypes.ParameterTierStandard, Version: 3, LastModifiedDate: &modified, Description: aws.String("app config")},
+						{Name: aws.String("/app/raw"), Type: ssmtypes.ParameterTypeSecureString},
+					},
+					NextToken: aws.String("page2"),
+				}, nil
+			default:
+				return &ssm.DescribeParametersOutput{Parameters: []ssmtypes.ParameterMetadata{{Name: aws.String("/app/unmanaged"), Type: ssmtypes.ParameterTypeSecureString}}}, nil
+			}
+		},
+	}
+
+	var got []ListEntry
+	err := NewPSBackend(client).List(ctx, "/app/", ListOptions{
+		Recursive:      true,
+		Type:           "SecureString",
+		Tags:           map[string]string{"team": "core"},
+		ResolveManaged: true,
+	}, func(e ListEntry) error {
+		got = append(got, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+
+	// filters pushed down: path, type, tag
+	base := calls[len(calls)-1]
+	if len(base) != 3 ||
+		aws.ToString(base[0].Key) != "Path" || aws.ToString(base[0].Option) != "Recursive" || base[0].Values[0] != "/app" ||
+		aws.ToString(base[1].Key) != "Type" || base[1].Values[0] != "SecureString" ||
+		aws.ToString(base[2].Key) != "tag:team" || base[2].Values[0] != "core" {
+		t.Errorf("unexpected filters: %+v", base)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(got), got)
+	}
+	if got[0].Path != "/app/json" || !got[0].Managed || got[0].StoreMode != tags.StoreModeJSON || got[0].Version != 3 || !got[0].LastModified.Equal(modified) || got[0].Description != "app config" {
+		t.Errorf("unexpected first entry: %+v", got[0])
+	}
+	if !got[1].Managed || got[1].StoreMode != tags.StoreModeRaw {
+		t.Errorf("unexpected second entry: %+v", got[1])
+	}
+	if got[2].Path != "/app/unmanaged" || got[2].Managed || got[2].StoreMode != "" {
+		t.Errorf("unexpected third entry: %+v", got[2])
+	}
+}
+
+func TestPSBackend_Tags(t *testing.T) {
+	ctx := context.Background()
+	var added *ssm.AddTagsToResourceInput
+	var removed *ssm.RemoveTagsFromResourceInput
+	client := &mockSSMClient{
+		listTagsForResourceFn: func(_ context.Context, input *ssm.ListTagsForResourceInput, _ ...func(*ssm.Options)) (*ssm.ListTagsForResourceOutput, error) {
+			return &ssm.ListTagsForResourceOutput{TagList: []ssmtypes.Tag{{Key: aws.String("team"), Value: aws.String("core")}}}, nil
+		},
+		addTagsToResourceFn: func(_ context.Context, input *ssm.AddTagsToResourceInput, _ ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+			added = input
+			return &ssm.AddTagsToResourceOutput{}, nil
+		},
+		removeTagsFromResourceFn: func(_ context.Context, input *ssm.RemoveTagsFromResourceInput, _ ...func(*ssm.Options)) (*ssm.RemoveTagsFromResourceOutput, error) {
+			removed = input
+			return &ssm.RemoveTagsFromResourceOutput{}, nil
+		},
+	}
+	b := NewPSBackend(client)
+
+	got, err := b.Tags(ctx, "ps:/app/key")
+	if err != nil || got["team"] != "core" {
+		t.Fatalf("Tags() = %v, %v", got, err)
+	}
+	if err := b.AddTags(ctx, "ps:/app/key", map[string]string{"b": "2", "a": "1"}); err != nil {
+		t.Fatalf("AddTags() error: %v", err)
+	}
+	// all tags in one call, sorted by key
+	if aws.ToString(added.ResourceId) != "/app/key" || len(added.Tags) != 2 || aws.ToString(added.Tags[0].Key) != "a" {
+		t.Errorf("unexpected AddTagsToResource input: %+v", added)
+	}
+	if err := b.RemoveTags(ctx, "ps:/app/key", []string{"a", "b"}); err != nil {
+		t.Fatalf("RemoveTags() error: %v", err)
+	}
+	if aws.ToString(removed.ResourceId) != "/app/key" || len(removed.TagKeys) != 2 {
+		t.Errorf("unexpected RemoveTagsFromResource input: %+v", removed)
+	}
+}
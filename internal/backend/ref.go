@@ -2,38 +2,124 @@ package backend
 
 import (
 	"fmt"
+	"os/exec"
+	"regexp"
 	"strings"
 )
 
-// BackendType represents the type of AWS backend.
+// BackendType represents the type of backend.
 type BackendType string
 
 const (
 	BackendTypePS BackendType = "ps"
 	BackendTypeSM BackendType = "sm"
+	// BackendTypeFile is an encrypted local file; its Ref.Path is "FILE:/key".
+	BackendTypeFile BackendType = "file"
+	// BackendTypeEnv reads the process environment (read-only); its Ref.Path is a name.
+	BackendTypeEnv BackendType = "env"
+	// BackendTypeDotenv reads a .env file (read-only); its Ref.Path is "FILE:KEY".
+	BackendTypeDotenv BackendType = "dotenv"
+	// BackendTypeVault is a HashiCorp Vault KV v2 engine; its Ref.Path is
+	// "MOUNT/data/KEY", or a key relative to the configured mount.
+	BackendTypeVault BackendType = "vault"
 )
 
 // ValueType constants for PutOptions.ValueType.
 const (
 	ValueTypeString = "string"
 	ValueTypeSecure = "secure"
+	// ValueTypeStringList stores a Parameter Store StringList (comma-separated values).
+	ValueTypeStringList = "stringlist"
 )
 
 // Ref represents a parsed backend reference.
 type Ref struct {
-	Type BackendType
-	Path string
+	Type  BackendType
+	Path  string
+	Field string // JSON field selector after "#" (e.g. "password", "replicas[0].host"); "" = whole value
 }
 
-// ParseRef parses a ref string (e.g. "ps:/app/key", "sm:secret-name") into a Ref.
+// IsPrefix reports whether r addresses a prefix rather than a single entry: a path
+// ending in "/", or for env: and dotenv: a name ending in "_" (or, for dotenv:, no
+// name, meaning the whole file).
+func (r Ref) IsPrefix() bool {
+	switch r.Type {
+	case BackendTypeEnv:
+		return strings.HasSuffix(r.Path, "_")
+	case BackendTypeDotenv:
+		_, key, _ := SplitDotenvPath(r.Path)
+		return key == "" || strings.HasSuffix(key, "_")
+	default:
+		return strings.HasSuffix(r.Path, "/")
+	}
+}
+
+// refPrefixes are the backend prefixes accepted by ParseRef.
+var refPrefixes = map[string]bool{
+	"ps": true, "parameterstore": true, "psa": true, "sm": true, "secretsmanager": true,
+	"file": true, "env": true, "dotenv": true, "vault": true,
+}
+
+// pluginPrefixRe matches the prefixes that name external backend plugins. Single
+// letters are left out so that Windows drive letters ("C:") stay file paths.
+var pluginPrefixRe = regexp.MustCompile(`^[a-z][a-z0-9-]+$`)
+
+// PluginExecutable returns the executable that implements the backend of an unknown
+// ref prefix ("foo" -> "bundr-backend-foo"), looked up on PATH.
+func PluginExecutable(prefix string) string {
+	return "bundr-backend-" + prefix
+}
+
+// IsRef reports whether s starts with a backend prefix (ps:, sm:, file:, env:, ...,
+// or the prefix of a plugin installed on PATH), as opposed to a local file path or "-".
+func IsRef(s string) bool {
+	prefix, _, ok := strings.Cut(s, ":")
+	if !ok {
+		return false
+	}
+	if refPrefixes[prefix] {
+		return true
+	}
+	if !pluginPrefixRe.MatchString(prefix) {
+		return false
+	}
+	_, err := exec.LookPath(PluginExecutable(prefix))
+	return err == nil
+}
+
+// SplitField splits "ps:/app/db#password" into "ps:/app/db" and "password".
+// "#" cannot appear in SSM parameter or Secrets Manager secret names, so the first
+// "#" always starts the field selector.
+func SplitField(raw string) (string, string) {
+	base, field, _ := strings.Cut(raw, "#")
+	return base, field
+}
+
+// ParseRef parses a ref string (e.g. "ps:/app/key", "sm:secret-name",
+// "file:./secrets.bundr:/app/key") into a Ref.
+// An optional "#field" suffix is returned in Ref.Field.
 func ParseRef(raw string) (Ref, error) {
 	if raw == "" {
 		return Ref{}, fmt.Errorf("empty ref")
 	}
 
+	base, field, hasField := strings.Cut(raw, "#")
+	if hasField && field == "" {
+		return Ref{}, fmt.Errorf("invalid ref %q: field after # is empty", raw)
+	}
+	ref, err := parseBaseRef(base)
+	if err != nil {
+		return Ref{}, err
+	}
+	ref.Field = field
+	return ref, nil
+}
+
+// parseBaseRef parses a ref without a field selector.
+func parseBaseRef(raw string) (Ref, error) {
 	idx := strings.Index(raw, ":")
 	if idx < 0 {
-		return Ref{}, fmt.Errorf("invalid ref %q: missing prefix (expected ps: or sm:)", raw)
+		return Ref{}, fmt.Errorf("invalid ref %q: missing prefix (expected ps:, sm:, file:, env:, dotenv: or vault:)", raw)
 	}
 
 	prefix := raw[:idx]
@@ -52,7 +138,55 @@ func ParseRef(raw string) (Ref, error) {
 			return Ref{}, fmt.Errorf("invalid ref %q: path is empty", raw)
 		}
 		return Ref{Type: BackendTypeSM, Path: path}, nil
+	case "file":
+		if _, _, err := SplitFilePath(path); err != nil {
+			return Ref{}, fmt.Errorf("invalid ref %q: %w", raw, err)
+		}
+		return Ref{Type: BackendTypeFile, Path: path}, nil
+	case "env":
+		if path == "" {
+			return Ref{}, fmt.Errorf("invalid ref %q: variable name is empty", raw)
+		}
+		return Ref{Type: BackendTypeEnv, Path: path}, nil
+	case "dotenv":
+		if _, _, err := SplitDotenvPath(path); err != nil {
+			return Ref{}, fmt.Errorf("invalid ref %q: %w", raw, err)
+		}
+		return Ref{Type: BackendTypeDotenv, Path: path}, nil
+	case "vault":
+		if path == "" || strings.HasPrefix(path, "/") {
+			return Ref{}, fmt.Errorf("invalid ref %q: expected vault:MOUNT/data/KEY", raw)
+		}
+		return Ref{Type: BackendTypeVault, Path: path}, nil
 	default:
-		return Ref{}, fmt.Errorf("unknown backend prefix %q in ref %q", prefix, raw)
+		// 組み込みでない prefix はプラグイン（bundr-backend-PREFIX）が処理する
+		if !pluginPrefixRe.MatchString(prefix) {
+			return Ref{}, fmt.Errorf("unknown backend prefix %q in ref %q", prefix, raw)
+		}
+		if path == "" {
+			return Ref{}, fmt.Errorf("invalid ref %q: path is empty", raw)
+		}
+		return Ref{Type: BackendType(prefix), Path: path}, nil
+	}
+}
+
+// SplitFilePath splits the path of a file: ref ("./secrets.bundr:/app/key") into the
+// file name and the key path. Key paths cannot contain ":", so the last ":" separates
+// them and file names may contain ":" themselves.
+func SplitFilePath(p string) (file, key string, err error) {
+	idx := strings.LastIndex(p, ":")
+	if idx <= 0 || !strings.HasPrefix(p[idx+1:], "/") {
+		return "", "", fmt.Errorf("expected file:PATH:/key")
+	}
+	return p[:idx], p[idx+1:], nil
+}
+
+// SplitDotenvPath splits the path of a dotenv: ref ("config/.env:DB_HOST") into the
+// file name and the variable name, which is empty for the whole file.
+func SplitDotenvPath(p string) (file, key string, err error) {
+	idx := strings.LastIndex(p, ":")
+	if idx <= 0 {
+		return "", "", fmt.Errorf("expected dotenv:PATH:KEY")
 	}
+	return p[:idx], p[idx+1:], nil
 }
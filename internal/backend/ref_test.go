@@ -6,11 +6,12 @@ import (
 
 func TestParseRef(t *testing.T) {
 	tests := []struct {
-		name     string
-		input    string
-		wantType BackendType
-		wantPath string
-		wantErr  bool
+		name      string
+		input     string
+		wantType  BackendType
+		wantPath  string
+		wantField string
+		wantErr   bool
 	}{
 		{
 			name:     "ps prefix",
@@ -47,8 +48,19 @@ func TestParseRef(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "unknown prefix",
-			input:   "xyz:/app/key",
+			name:     "plugin prefix",
+			input:    "xyz:/app/key",
+			wantType: BackendType("xyz"),
+			wantPath: "/app/key",
+		},
+		{
+			name:    "invalid plugin prefix",
+			input:   "X_Y:/app/key",
+			wantErr: true,
+		},
+		{
+			name:    "plugin prefix with no path",
+			input:   "xyz:",
 			wantErr: true,
 		},
 		{
@@ -83,6 +95,88 @@ func TestParseRef(t *testing.T) {
 			input:   "secretsmanager:",
 			wantErr: true,
 		},
+		{
+			name:      "sm with field",
+			input:     "sm:prod/db#password",
+			wantType:  BackendTypeSM,
+			wantPath:  "prod/db",
+			wantField: "password",
+		},
+		{
+			name:      "ps with query field",
+			input:     "ps:/app/config#replicas[0].host",
+			wantType:  BackendTypePS,
+			wantPath:  "/app/config",
+			wantField: "replicas[0].host",
+		},
+		{
+			name:    "empty field",
+			input:   "sm:prod/db#",
+			wantErr: true,
+		},
+		{
+			name:     "file ref",
+			input:    "file:./secrets.bundr:/app/key",
+			wantType: BackendTypeFile,
+			wantPath: "./secrets.bundr:/app/key",
+		},
+		{
+			name:      "file ref with field",
+			input:     "file:/tmp/dev.bundr:/app/db#password",
+			wantType:  BackendTypeFile,
+			wantPath:  "/tmp/dev.bundr:/app/db",
+			wantField: "password",
+		},
+		{
+			name:    "file ref without key path",
+			input:   "file:./secrets.bundr",
+			wantErr: true,
+		},
+		{
+			name:    "file ref without file",
+			input:   "file::/app/key",
+			wantErr: true,
+		},
+		{
+			name:     "env ref",
+			input:    "env:DB_HOST",
+			wantType: BackendTypeEnv,
+			wantPath: "DB_HOST",
+		},
+		{
+			name:    "env ref without name",
+			input:   "env:",
+			wantErr: true,
+		},
+		{
+			name:     "dotenv ref",
+			input:    "dotenv:config/.env:DB_HOST",
+			wantType: BackendTypeDotenv,
+			wantPath: "config/.env:DB_HOST",
+		},
+		{
+			name:     "dotenv whole file",
+			input:    "dotenv:.env:",
+			wantType: BackendTypeDotenv,
+			wantPath: ".env:",
+		},
+		{
+			name:    "dotenv ref without key separator",
+			input:   "dotenv:.env",
+			wantErr: true,
+		},
+		{
+			name:      "vault ref",
+			input:     "vault:secret/data/app/prod#password",
+			wantType:  BackendTypeVault,
+			wantPath:  "secret/data/app/prod",
+			wantField: "password",
+		},
+		{
+			name:    "vault ref with leading slash",
+			input:   "vault:/secret/data/app",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -103,6 +197,86 @@ func TestParseRef(t *testing.T) {
 			if ref.Path != tt.wantPath {
 				t.Errorf("ParseRef(%q).Path = %q, want %q", tt.input, ref.Path, tt.wantPath)
 			}
+			if ref.Field != tt.wantField {
+				t.Errorf("ParseRef(%q).Field = %q, want %q", tt.input, ref.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestSplitFilePath(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantFile string
+		wantKey  string
+		wantErr  bool
+	}{
+		{input: "./secrets.bundr:/app/key", wantFile: "./secrets.bundr", wantKey: "/app/key"},
+		{input: "dev.bundr:/", wantFile: "dev.bundr", wantKey: "/"},
+		{input: "C:/work/dev.bundr:/app/", wantFile: "C:/work/dev.bundr", wantKey: "/app/"},
+		{input: "dev.bundr:app/key", wantErr: true},
+		{input: "/app/key", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			file, key, err := SplitFilePath(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("SplitFilePath(%q) expected error", tt.input)
+				}
+				return
+			}
+			if err != nil || file != tt.wantFile || key != tt.wantKey {
+				t.Errorf("SplitFilePath(%q) = %q, %q, %v; want %q, %q", tt.input, file, key, err, tt.wantFile, tt.wantKey)
+			}
 		})
 	}
 }
+
+func TestRef_IsPrefix(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "ps:/app/", want: true},
+		{input: "ps:/app/key", want: false},
+		{input: "file:dev.bundr:/app/", want: true},
+		{input: "env:APP_", want: true},
+		{input: "env:APP", want: false},
+		{input: "dotenv:.env:", want: true},
+		{input: "dotenv:.env:APP_", want: true},
+		{input: "dotenv:.env:APP_HOST", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ref, err := ParseRef(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ref.IsPrefix(); got != tt.want {
+				t.Errorf("ParseRef(%q).IsPrefix() = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRef(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "ps:/app/key", want: true},
+		{input: "sm:secret", want: true},
+		{input: "env:HOME", want: true},
+		{input: "dotenv:.env:KEY", want: true},
+		{input: "vault:secret/data/app", want: true},
+		{input: "/tmp/file.env", want: false},
+		{input: "C:/work/.env", want: false},
+		{input: "-", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsRef(tt.input); got != tt.want {
+			t.Errorf("IsRef(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
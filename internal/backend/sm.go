@@ -17,9 +17,12 @@ import (
 type smClient interface {
 	CreateSecret(ctx context.Context, input *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error)
 	PutSecretValue(ctx context.Context, input *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error)
+	UpdateSecret(ctx context.Context, input *secretsmanager.UpdateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error)
+	BatchGetSecretValue(ctx context.Context, input *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error)
 	GetSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
 	DescribeSecret(ctx context.Context, input *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error)
 	TagResource(ctx context.Context, input *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error)
+	UntagResource(ctx context.Context, input *secretsmanager.UntagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UntagResourceOutput, error)
 	ListSecrets(ctx context.Context, input *secretsmanager.ListSecretsInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error)
 }
 
@@ -33,7 +36,9 @@ func NewSMBackend(client smClient) *SMBackend {
 	return &SMBackend{client: client}
 }
 
-// Put creates or updates a secret in AWS Secrets Manager.
+// Put creates or updates a secret in AWS Secrets Manager. An update keeps the secret's
+// KMS key unless opts.ExactTags asks for the entry to be reproduced exactly (restore);
+// a non-empty opts.Description is applied to updates as well.
 func (b *SMBackend) Put(ctx context.Context, ref string, opts PutOptions) error {
 	parsed, err := ParseRef(ref)
 	if err != nil {
@@ -54,15 +59,21 @@ func (b *SMBackend) Put(ctx context.Context, ref string, opts PutOptions) error
 		}
 	}
 
-	managedTags := tags.ManagedTags(opts.StoreMode)
-	smTags := mapToSMTags(managedTags)
+	smTags := mapToSMTags(putTags(opts))
 
 	// Try to create the secret first
-	_, createErr := b.client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+	createInput := &secretsmanager.CreateSecretInput{
 		Name:         aws.String(secretName),
 		SecretString: aws.String(value),
 		Tags:         smTags,
-	})
+	}
+	if opts.KMSKeyID != "" {
+		createInput.KmsKeyId = aws.String(opts.KMSKeyID)
+	}
+	if opts.Description != "" {
+		createInput.Description = aws.String(opts.Description)
+	}
+	_, createErr := b.client.CreateSecret(ctx, createInput)
 	if createErr != nil {
 		// If the secret already exists, update it
 		var existsErr *smtypes.ResourceExistsException
@@ -70,16 +81,35 @@ func (b *SMBackend) Put(ctx context.Context, ref string, opts PutOptions) error
 			return fmt.Errorf("create secret: %w", createErr)
 		}
 
-		// Update the secret value
-		_, err = b.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		// 説明や KMS キーも変える場合は UpdateSecret で値と一緒に書き込む
+		update := &secretsmanager.UpdateSecretInput{
 			SecretId:     aws.String(secretName),
 			SecretString: aws.String(value),
-		})
-		if err != nil {
-			return fmt.Errorf("put secret value: %w", err)
+		}
+		if opts.Description != "" {
+			update.Description = aws.String(opts.Description)
+		}
+		if opts.ExactTags && opts.KMSKeyID != "" {
+			update.KmsKeyId = aws.String(opts.KMSKeyID)
+		}
+		if update.Description != nil || update.KmsKeyId != nil {
+			if _, err := b.client.UpdateSecret(ctx, update); err != nil {
+				return fmt.Errorf("update secret: %w", err)
+			}
+		} else {
+			_, err = b.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+				SecretId:     aws.String(secretName),
+				SecretString: aws.String(value),
+			})
+			if err != nil {
+				return fmt.Errorf("put secret value: %w", err)
+			}
 		}
 
 		// Update tags
+		if len(smTags) == 0 {
+			return nil
+		}
 		_, err = b.client.TagResource(ctx, &secretsmanager.TagResourceInput{
 			SecretId: aws.String(secretName),
 			Tags:     smTags,
@@ -108,8 +138,141 @@ func (b *SMBackend) Get(ctx context.Context, ref string, opts GetOptions) (strin
 		return "", fmt.Errorf("get secret value: %w", err)
 	}
 
-	value := aws.ToString(result.SecretString)
+	return b.decodeValue(ctx, secretName, aws.ToString(result.SecretString), opts)
+}
+
+// smBatchSize is the maximum number of secret IDs accepted by a single BatchGetSecretValue call.
+const smBatchSize = 20
+
+// GetMany fetches refs with BatchGetSecretValue, smBatchSize secrets per call. Secrets that
+// do not exist are returned as missing; other per-secret errors are reported together.
+// Refs may name secrets by name, ARN or partial ARN. Unless opts.ForceRaw or
+// opts.ForceJSON makes it unnecessary, the store modes of each batch are resolved with
+// ListSecrets filtered on the cli-store-mode tag instead of a DescribeSecret per secret.
+func (b *SMBackend) GetMany(ctx context.Context, refs []string, opts GetOptions) (map[string]string, []string, error) {
+	byID := make(map[string][]string)
+	var ids []string
+	for _, ref := range refs {
+		parsed, err := ParseRef(ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := byID[parsed.Path]; !ok {
+			ids = append(ids, parsed.Path)
+		}
+		byID[parsed.Path] = append(byID[parsed.Path], ref)
+	}
+
+	values := make(map[string]string, len(refs))
+	var missing, failed []string
+	for start := 0; start < len(ids); start += smBatchSize {
+		input := &secretsmanager.BatchGetSecretValueInput{
+			SecretIdList: ids[start:min(start+smBatchSize, len(ids))],
+		}
+		for {
+			out, err := b.client.BatchGetSecretValue(ctx, input)
+			if err != nil {
+				return nil, nil, fmt.Errorf("batch get secret value: %w", err)
+			}
+			jsonMode, err := b.jsonModeNames(ctx, out.SecretValues, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, sv := range out.SecretValues {
+				name := aws.ToString(sv.Name)
+				value := aws.ToString(sv.SecretString)
+				if !opts.ForceRaw && (opts.ForceJSON || jsonMode[name]) {
+					if value, err = decodeJSON(value); err != nil {
+						return nil, nil, fmt.Errorf("%s: %w", name, err)
+					}
+				}
+				for _, id := range smSecretIDs(sv) {
+					for _, ref := range byID[id] {
+						values[ref] = value
+					}
+				}
+			}
+			for _, apiErr := range out.Errors {
+				id := aws.ToString(apiErr.SecretId)
+				if aws.ToString(apiErr.ErrorCode) == "ResourceNotFoundException" {
+					missing = append(missing, byID[id]...)
+					continue
+				}
+				failed = append(failed, fmt.Sprintf("%s: %s", id, aws.ToString(apiErr.Message)))
+			}
+			if out.NextToken == nil {
+				break
+			}
+			input.NextToken = out.NextToken
+		}
+	}
+	if len(failed) > 0 {
+		return nil, nil, fmt.Errorf("batch get secret value: %s", strings.Join(failed, "; "))
+	}
+	return values, missing, nil
+}
+
+// smSecretIDs returns the ids a ref may use for a fetched secret: its name, its ARN and
+// its partial ARN (the ARN without the "-xxxxxx" suffix Secrets Manager appends).
+func smSecretIDs(sv smtypes.SecretValueEntry) []string {
+	ids := []string{aws.ToString(sv.Name)}
+	if arn := aws.ToString(sv.ARN); arn != "" {
+		ids = append(ids, arn)
+		if i := strings.LastIndex(arn, "-"); i > 0 && len(arn)-i == 7 {
+			ids = append(ids, arn[:i])
+		}
+	}
+	return ids
+}
+
+// smListFilterValues is the maximum number of values of one ListSecrets filter.
+const smListFilterValues = 10
+
+// jsonModeNames returns the names among secrets stored in json mode, or nil when opts
+// decide the decoding by themselves. The name filter of ListSecrets matches prefixes,
+// so the listed names are checked exactly.
+func (b *SMBackend) jsonModeNames(ctx context.Context, secrets []smtypes.SecretValueEntry, opts GetOptions) (map[string]bool, error) {
+	if opts.ForceRaw || opts.ForceJSON || len(secrets) == 0 {
+		return nil, nil
+	}
+	wanted := make(map[string]bool, len(secrets))
+	names := make([]string, 0, len(secrets))
+	for _, sv := range secrets {
+		name := aws.ToString(sv.Name)
+		wanted[name] = true
+		names = append(names, name)
+	}
+
+	jsonMode := make(map[string]bool)
+	for start := 0; start < len(names); start += smListFilterValues {
+		input := &secretsmanager.ListSecretsInput{
+			Filters: []smtypes.Filter{
+				{Key: smtypes.FilterNameStringTypeName, Values: names[start:min(start+smListFilterValues, len(names))]},
+				{Key: smtypes.FilterNameStringTypeTagKey, Values: []string{tags.TagStoreMode}},
+			},
+		}
+		for {
+			out, err := b.client.ListSecrets(ctx, input)
+			if err != nil {
+				return nil, fmt.Errorf("list secrets: %w", err)
+			}
+			for _, e := range out.SecretList {
+				name := aws.ToString(e.Name)
+				if wanted[name] && getTagValue(e.Tags, tags.TagStoreMode) == tags.StoreModeJSON {
+					jsonMode[name] = true
+				}
+			}
+			if out.NextToken == nil {
+				break
+			}
+			input.NextToken = out.NextToken
+		}
+	}
+	return jsonMode, nil
+}
 
+// decodeValue applies opts and the cli-store-mode tag of the secret to its value.
+func (b *SMBackend) decodeValue(ctx context.Context, secretName, value string, opts GetOptions) (string, error) {
 	// ForceRaw: return as-is
 	if opts.ForceRaw {
 		return value, nil
@@ -149,6 +312,18 @@ func mapToSMTags(m map[string]string) []smtypes.Tag {
 // An empty prefix returns all secrets.
 func (b *SMBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPrefixOptions) ([]ParameterEntry, error) {
 	var entries []ParameterEntry
+	err := b.WalkPrefix(ctx, prefix, opts, func(e ParameterEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WalkPrefix calls fn for each secret with the given name prefix as ListSecrets pages arrive.
+func (b *SMBackend) WalkPrefix(ctx context.Context, prefix string, opts GetByPrefixOptions, fn func(ParameterEntry) error) error {
 	var nextToken *string
 
 	for {
@@ -164,7 +339,7 @@ func (b *SMBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPr
 
 		out, err := b.client.ListSecrets(ctx, input)
 		if err != nil {
-			return nil, fmt.Errorf("list secrets: %w", err)
+			return fmt.Errorf("list secrets: %w", err)
 		}
 
 		for _, secret := range out.SecretList {
@@ -192,12 +367,16 @@ func (b *SMBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPr
 				}
 			}
 
-			entries = append(entries, ParameterEntry{
+			if err := fn(ParameterEntry{
 				Path:      name,
 				Value:     "",
 				StoreMode: storeMode,
+				Flatten:   getTagValue(secret.Tags, tags.TagFlatten),
+				Tags:      smTagMap(secret.Tags),
 				Metadata:  metadata,
-			})
+			}); err != nil {
+				return err
+			}
 		}
 
 		nextToken = out.NextToken
@@ -206,7 +385,83 @@ func (b *SMBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPr
 		}
 	}
 
-	return entries, nil
+	return nil
+}
+
+// List calls fn for each secret with the given name prefix. Tag keys and values are pushed
+// down as ListSecrets filters; because those match keys and values independently, the
+// exact key=value pairs are checked again on the returned tags.
+func (b *SMBackend) List(ctx context.Context, prefix string, opts ListOptions, fn func(ListEntry) error) error {
+	if opts.Type != "" {
+		return fmt.Errorf("filtering by type is not supported by Secrets Manager")
+	}
+
+	var filters []smtypes.Filter
+	if prefix != "" {
+		filters = append(filters, smtypes.Filter{Key: smtypes.FilterNameStringTypeName, Values: []string{prefix}})
+	}
+	for k, v := range opts.Tags {
+		filters = append(filters,
+			smtypes.Filter{Key: smtypes.FilterNameStringTypeTagKey, Values: []string{k}},
+			smtypes.Filter{Key: smtypes.FilterNameStringTypeTagValue, Values: []string{v}},
+		)
+	}
+
+	input := &secretsmanager.ListSecretsInput{Filters: filters}
+	for {
+		out, err := b.client.ListSecrets(ctx, input)
+		if err != nil {
+			return fmt.Errorf("list secrets: %w", err)
+		}
+
+		for _, secret := range out.SecretList {
+			name := aws.ToString(secret.Name)
+			if !opts.Recursive && prefix != "" && strings.Contains(strings.TrimPrefix(name, prefix), "/") {
+				continue
+			}
+			if !hasSMTags(secret.Tags, opts.Tags) {
+				continue
+			}
+
+			rotation := aws.ToBool(secret.RotationEnabled)
+			entry := ListEntry{
+				Path:            name,
+				LastModified:    secret.LastChangedDate,
+				KMSKeyID:        aws.ToString(secret.KmsKeyId),
+				Description:     aws.ToString(secret.Description),
+				RotationEnabled: &rotation,
+			}
+			if getTagValue(secret.Tags, tags.TagCLI) == tags.TagCLIValue {
+				entry.Managed = true
+				entry.StoreMode = getTagValue(secret.Tags, tags.TagStoreMode)
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if out.NextToken == nil {
+			return nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// hasSMTags reports whether tagSlice contains every key=value pair of want.
+func hasSMTags(tagSlice []smtypes.Tag, want map[string]string) bool {
+	for k, v := range want {
+		found := false
+		for _, t := range tagSlice {
+			if aws.ToString(t.Key) == k && aws.ToString(t.Value) == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
 }
 
 // Describe returns metadata for the given Secrets Manager ref as a map.
@@ -245,9 +500,65 @@ func (b *SMBackend) Describe(ctx context.Context, ref string) (map[string]any, e
 	result["LastAccessedDate"] = dsOut.LastAccessedDate
 	result["LastRotatedDate"] = dsOut.LastRotatedDate
 
+	result["Tags"] = smTagMap(dsOut.Tags)
+
 	return result, nil
 }
 
+// Tags returns all tags of the secret.
+func (b *SMBackend) Tags(ctx context.Context, ref string) (map[string]string, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := b.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(parsed.Path),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe secret: %w", err)
+	}
+	return smTagMap(desc.Tags), nil
+}
+
+// AddTags adds or overwrites tags on the secret in a single TagResource call.
+func (b *SMBackend) AddTags(ctx context.Context, ref string, tagMap map[string]string) error {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return err
+	}
+	if _, err := b.client.TagResource(ctx, &secretsmanager.TagResourceInput{
+		SecretId: aws.String(parsed.Path),
+		Tags:     mapToSMTags(tagMap),
+	}); err != nil {
+		return fmt.Errorf("tag resource: %w", err)
+	}
+	return nil
+}
+
+// RemoveTags removes tag keys from the secret in a single UntagResource call.
+func (b *SMBackend) RemoveTags(ctx context.Context, ref string, keys []string) error {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return err
+	}
+	if _, err := b.client.UntagResource(ctx, &secretsmanager.UntagResourceInput{
+		SecretId: aws.String(parsed.Path),
+		TagKeys:  keys,
+	}); err != nil {
+		return fmt.Errorf("untag resource: %w", err)
+	}
+	return nil
+}
+
+// smTagMap converts a Secrets Manager Tag slice to a map.
+func smTagMap(tagSlice []smtypes.Tag) map[string]string {
+	m := make(map[string]string, len(tagSlice))
+	for _, t := range tagSlice {
+		m[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return m
+}
+
 // getTagValue finds a tag value by key from a slice of SM tags.
 func getTagValue(tagSlice []smtypes.Tag, key string) string {
 	for _, t := range tagSlice {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
@@ -15,14 +16,18 @@ import (
 
 // mockSMClient is a mock implementation of the smClient interface for testing.
 type mockSMClient struct {
-	secrets map[string]*mockSecret
+	secrets       map[string]*mockSecret
+	batchGetCalls []*secretsmanager.BatchGetSecretValueInput
+	describeCalls int
 }
 
 type mockSecret struct {
-	value     string
-	tags      []smtypes.Tag
-	arn       string
-	versionId string
+	value       string
+	tags        []smtypes.Tag
+	kmsKeyID    string
+	description string
+	arn         string
+	versionId   string
 }
 
 func newMockSMClient() *mockSMClient {
@@ -37,8 +42,10 @@ func (m *mockSMClient) CreateSecret(ctx context.Context, input *secretsmanager.C
 		return nil, &smtypes.ResourceExistsException{Message: aws.String("already exists")}
 	}
 	m.secrets[name] = &mockSecret{
-		value: aws.ToString(input.SecretString),
-		tags:  input.Tags,
+		value:       aws.ToString(input.SecretString),
+		tags:        input.Tags,
+		kmsKeyID:    aws.ToString(input.KmsKeyId),
+		description: aws.ToString(input.Description),
 	}
 	return &secretsmanager.CreateSecretOutput{
 		Name: input.Name,
@@ -55,6 +62,24 @@ func (m *mockSMClient) PutSecretValue(ctx context.Context, input *secretsmanager
 	return &secretsmanager.PutSecretValueOutput{}, nil
 }
 
+func (m *mockSMClient) UpdateSecret(ctx context.Context, input *secretsmanager.UpdateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error) {
+	name := aws.ToString(input.SecretId)
+	secret, exists := m.secrets[name]
+	if !exists {
+		return nil, fmt.Errorf("secret not found: %s", name)
+	}
+	if input.SecretString != nil {
+		secret.value = aws.ToString(input.SecretString)
+	}
+	if input.Description != nil {
+		secret.description = aws.ToString(input.Description)
+	}
+	if input.KmsKeyId != nil {
+		secret.kmsKeyID = aws.ToString(input.KmsKeyId)
+	}
+	return &secretsmanager.UpdateSecretOutput{Name: input.SecretId}, nil
+}
+
 func (m *mockSMClient) GetSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
 	name := aws.ToString(input.SecretId)
 	secret, exists := m.secrets[name]
@@ -69,7 +94,43 @@ func (m *mockSMClient) GetSecretValue(ctx context.Context, input *secretsmanager
 	}, nil
 }
 
+func (m *mockSMClient) BatchGetSecretValue(ctx context.Context, input *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	m.batchGetCalls = append(m.batchGetCalls, input)
+	out := &secretsmanager.BatchGetSecretValueOutput{}
+	for _, id := range input.SecretIdList {
+		name, secret, exists := m.lookup(id)
+		if !exists {
+			out.Errors = append(out.Errors, smtypes.APIErrorType{
+				SecretId:  aws.String(id),
+				ErrorCode: aws.String("ResourceNotFoundException"),
+				Message:   aws.String("secret not found"),
+			})
+			continue
+		}
+		out.SecretValues = append(out.SecretValues, smtypes.SecretValueEntry{
+			Name:         aws.String(name),
+			ARN:          aws.String(secret.arn),
+			SecretString: aws.String(secret.value),
+		})
+	}
+	return out, nil
+}
+
+// lookup finds a secret by name, ARN or partial ARN, as SecretId does.
+func (m *mockSMClient) lookup(id string) (string, *mockSecret, bool) {
+	if secret, ok := m.secrets[id]; ok {
+		return id, secret, true
+	}
+	for name, secret := range m.secrets {
+		if secret.arn != "" && (secret.arn == id || strings.HasPrefix(secret.arn, id+"-")) {
+			return name, secret, true
+		}
+	}
+	return "", nil, false
+}
+
 func (m *mockSMClient) DescribeSecret(ctx context.Context, input *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	m.describeCalls++
 	name := aws.ToString(input.SecretId)
 	secret, exists := m.secrets[name]
 	if !exists {
@@ -110,6 +171,22 @@ func (m *mockSMClient) ListSecrets(ctx context.Context, input *secretsmanager.Li
 	return &secretsmanager.ListSecretsOutput{SecretList: list}, nil
 }
 
+func (m *mockSMClient) UntagResource(ctx context.Context, input *secretsmanager.UntagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UntagResourceOutput, error) {
+	name := aws.ToString(input.SecretId)
+	secret, exists := m.secrets[name]
+	if !exists {
+		return nil, fmt.Errorf("secret not found: %s", name)
+	}
+	var kept []smtypes.Tag
+	for _, t := range secret.tags {
+		if !slices.Contains(input.TagKeys, aws.ToString(t.Key)) {
+			kept = append(kept, t)
+		}
+	}
+	secret.tags = kept
+	return &secretsmanager.UntagResourceOutput{}, nil
+}
+
 func (m *mockSMClient) TagResource(ctx context.Context, input *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error) {
 	name := aws.ToString(input.SecretId)
 	secret, exists := m.secrets[name]
@@ -168,6 +245,69 @@ func TestSMBackend_PutCreateSecret(t *testing.T) {
 	}
 }
 
+func TestSMBackend_PutKMSKey(t *testing.T) {
+	ctx := context.Background()
+	client := newMockSMClient()
+	b := NewSMBackend(client)
+
+	if err := b.Put(ctx, "sm:my-secret", PutOptions{Value: "hello", StoreMode: tags.StoreModeRaw, KMSKeyID: "alias/app"}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if got := client.secrets["my-secret"].kmsKeyID; got != "alias/app" {
+		t.Errorf("kms key = %q, want %q", got, "alias/app")
+	}
+	if err := b.Put(ctx, "sm:plain", PutOptions{Value: "hello", StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if got := client.secrets["plain"].kmsKeyID; got != "" {
+		t.Errorf("kms key = %q, want AWS managed key", got)
+	}
+}
+
+func TestSMBackend_PutExactTagsAndDescription(t *testing.T) {
+	ctx := context.Background()
+	client := newMockSMClient()
+	b := NewSMBackend(client)
+
+	opts := PutOptions{Value: "hello", StoreMode: tags.StoreModeRaw, Description: "db password", Tags: map[string]string{"team": "core"}, ExactTags: true}
+	if err := b.Put(ctx, "sm:my-secret", opts); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	secret := client.secrets["my-secret"]
+	if secret.description != "db password" {
+		t.Errorf("description = %q, want %q", secret.description, "db password")
+	}
+	if got := tagsToMap(secret.tags); len(got) != 1 || got["team"] != "core" {
+		t.Errorf("tags = %v, want only team=core", got)
+	}
+}
+
+// an overwrite applies the description, and the KMS key only when reproducing an entry (restore)
+func TestSMBackend_PutUpdateMetadata(t *testing.T) {
+	ctx := context.Background()
+	client := newMockSMClient()
+	b := NewSMBackend(client)
+	if err := b.Put(ctx, "sm:my-secret", PutOptions{Value: "v1", StoreMode: tags.StoreModeRaw, KMSKeyID: "alias/old"}); err != nil {
+		t.Fatalf("first Put() error: %v", err)
+	}
+
+	if err := b.Put(ctx, "sm:my-secret", PutOptions{Value: "v2", StoreMode: tags.StoreModeRaw, KMSKeyID: "alias/config"}); err != nil {
+		t.Fatalf("second Put() error: %v", err)
+	}
+	if secret := client.secrets["my-secret"]; secret.value != "v2" || secret.kmsKeyID != "alias/old" {
+		t.Errorf("after put: value = %q, kms key = %q; want v2 with alias/old kept", secret.value, secret.kmsKeyID)
+	}
+
+	opts := PutOptions{Value: "v3", StoreMode: tags.StoreModeRaw, KMSKeyID: "alias/new", Description: "restored", ExactTags: true}
+	if err := b.Put(ctx, "sm:my-secret", opts); err != nil {
+		t.Fatalf("exact Put() error: %v", err)
+	}
+	secret := client.secrets["my-secret"]
+	if secret.value != "v3" || secret.kmsKeyID != "alias/new" || secret.description != "restored" {
+		t.Errorf("after exact put: value = %q, kms key = %q, description = %q", secret.value, secret.kmsKeyID, secret.description)
+	}
+}
+
 func TestSMBackend_PutJSONScalar(t *testing.T) {
 	ctx := context.Background()
 	client := newMockSMClient()
@@ -523,6 +663,7 @@ func TestSMBackendDescribe(t *testing.T) {
 			tags.TagCLI:       tags.TagCLIValue,
 			tags.TagStoreMode: tags.StoreModeRaw,
 			tags.TagSchema:    tags.TagSchemaValue,
+			tags.TagFlatten:   "no",
 		}),
 	}
 
@@ -549,6 +690,10 @@ func TestSMBackendDescribe(t *testing.T) {
 			t.Errorf("%s key missing from result", key)
 		}
 	}
+	tagMap, ok := result["Tags"].(map[string]string)
+	if !ok || tagMap[tags.TagFlatten] != "no" {
+		t.Errorf("Tags = %v, want cli-flatten=no", result["Tags"])
+	}
 }
 
 // SM-D-ERR-01: Describe with nonexistent secret returns error
@@ -626,3 +771,128 @@ func tagsToMap(tagSlice []smtypes.Tag) map[string]string {
 	}
 	return m
 }
+
+// SM-P-TAGS-01: Put merges opts.Tags into the managed tags (create and update)
+func TestSMBackend_Put_CustomTags(t *testing.T) {
+	ctx := context.Background()
+	client := newMockSMClient()
+	b := NewSMBackend(client)
+
+	opts := PutOptions{Value: `{"a":1}`, StoreMode: tags.StoreModeJSON, Tags: map[string]string{tags.TagFlatten: "no"}}
+	if err := b.Put(ctx, "sm:myapp/cfg", opts); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if got := getTagValue(client.secrets["myapp/cfg"].tags, tags.TagFlatten); got != "no" {
+		t.Errorf("cli-flatten after create = %q, want %q", got, "no")
+	}
+
+	opts.Tags = map[string]string{tags.TagFlatten: "depth=1"}
+	if err := b.Put(ctx, "sm:myapp/cfg", opts); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if got := getTagValue(client.secrets["myapp/cfg"].tags, tags.TagFlatten); got != "depth=1" {
+		t.Errorf("cli-flatten after update = %q, want %q", got, "depth=1")
+	}
+
+	entries, err := b.GetByPrefix(ctx, "myapp/", GetByPrefixOptions{Recursive: true})
+	if err != nil {
+		t.Fatalf("GetByPrefix() error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Flatten != "depth=1" {
+		t.Errorf("GetByPrefix() = %+v, want Flatten=depth=1", entries)
+	}
+}
+
+func TestSMBackend_GetMany(t *testing.T) {
+	ctx := context.Background()
+	client := newMockSMClient()
+	var refs []string
+	for i := 0; i < 25; i++ {
+		name := fmt.Sprintf("app/s%02d", i)
+		client.secrets[name] = &mockSecret{value: fmt.Sprintf("v%d", i)}
+		refs = append(refs, "sm:"+name)
+	}
+	const arn = "arn:aws:secretsmanager:us-east-1:123456789012:secret:app/json-AbCdEf"
+	client.secrets["app/json"] = &mockSecret{
+		value: `"hello"`,
+		arn:   arn,
+		tags:  []smtypes.Tag{{Key: aws.String(tags.TagStoreMode), Value: aws.String(tags.StoreModeJSON)}},
+	}
+	refs = append(refs, "sm:app/json", "sm:app/missing", "sm:"+arn, "sm:"+strings.TrimSuffix(arn, "-AbCdEf"))
+
+	b := NewSMBackend(client)
+	values, missing, err := b.GetMany(ctx, refs, GetOptions{})
+	if err != nil {
+		t.Fatalf("GetMany() error: %v", err)
+	}
+	if len(client.batchGetCalls) != 2 || len(client.batchGetCalls[0].SecretIdList) != 20 {
+		t.Errorf("expected 2 BatchGetSecretValue calls (20 + 9 ids), got %d", len(client.batchGetCalls))
+	}
+	if len(values) != 28 || values["sm:app/s24"] != "v24" || values["sm:app/json"] != "hello" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	// ARN や部分 ARN で指定した ref も名前で返る結果に対応付ける
+	if values["sm:"+arn] != "hello" || values["sm:"+strings.TrimSuffix(arn, "-AbCdEf")] != "hello" {
+		t.Errorf("values by ARN = %q, %q; want hello", values["sm:"+arn], values["sm:"+strings.TrimSuffix(arn, "-AbCdEf")])
+	}
+	if client.describeCalls != 0 {
+		t.Errorf("DescribeSecret called %d times; store modes must be resolved per batch", client.describeCalls)
+	}
+	if len(missing) != 1 || missing[0] != "sm:app/missing" {
+		t.Errorf("missing = %v, want [sm:app/missing]", missing)
+	}
+}
+
+func TestSMBackend_List(t *testing.T) {
+	ctx := context.Background()
+	client := newMockSMClient()
+	client.secrets["app/a"] = &mockSecret{tags: []smtypes.Tag{
+		{Key: aws.String(tags.TagCLI), Value: aws.String(tags.TagCLIValue)},
+		{Key: aws.String(tags.TagStoreMode), Value: aws.String(tags.StoreModeRaw)},
+		{Key: aws.String("team"), Value: aws.String("core")},
+	}}
+	// ListSecrets matches tag keys and values independently; the pair must be rechecked
+	client.secrets["app/b"] = &mockSecret{tags: []smtypes.Tag{
+		{Key: aws.String("team"), Value: aws.String("web")},
+		{Key: aws.String("owner"), Value: aws.String("core")},
+	}}
+	client.secrets["app/nested/c"] = &mockSecret{tags: []smtypes.Tag{{Key: aws.String("team"), Value: aws.String("core")}}}
+
+	b := NewSMBackend(client)
+	var got []string
+	err := b.List(ctx, "app/", ListOptions{Tags: map[string]string{"team": "core"}}, func(e ListEntry) error {
+		got = append(got, fmt.Sprintf("%s managed=%v mode=%s", e.Path, e.Managed, e.StoreMode))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "app/a managed=true mode=raw" {
+		t.Errorf("unexpected entries: %v", got)
+	}
+
+	if err := b.List(ctx, "app/", ListOptions{Type: "SecureString"}, func(ListEntry) error { return nil }); err == nil {
+		t.Error("expected error for --type on Secrets Manager")
+	}
+}
+
+func TestSMBackend_Tags(t *testing.T) {
+	ctx := context.Background()
+	client := newMockSMClient()
+	client.secrets["app/key"] = &mockSecret{tags: []smtypes.Tag{{Key: aws.String("team"), Value: aws.String("core")}}}
+	b := NewSMBackend(client)
+
+	if err := b.AddTags(ctx, "sm:app/key", map[string]string{"owner": "alice", "team": "web"}); err != nil {
+		t.Fatalf("AddTags() error: %v", err)
+	}
+	if err := b.RemoveTags(ctx, "sm:app/key", []string{"owner"}); err != nil {
+		t.Fatalf("RemoveTags() error: %v", err)
+	}
+	got, err := b.Tags(ctx, "sm:app/key")
+	if err != nil {
+		t.Fatalf("Tags() error: %v", err)
+	}
+	if len(got) != 1 || got["team"] != "web" {
+		t.Errorf("Tags() = %v, want map[team:web]", got)
+	}
+}
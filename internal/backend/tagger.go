@@ -0,0 +1,26 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tagger is implemented by backends whose entries carry tags (SSM Parameter Store,
+// Secrets Manager). Use AsTagger to get it from a Backend.
+type Tagger interface {
+	// Tags returns all tags of ref.
+	Tags(ctx context.Context, ref string) (map[string]string, error)
+	// AddTags adds or overwrites the given tags on ref.
+	AddTags(ctx context.Context, ref string, tagMap map[string]string) error
+	// RemoveTags removes the given tag keys from ref. Missing keys are ignored.
+	RemoveTags(ctx context.Context, ref string, keys []string) error
+}
+
+// AsTagger returns b as a Tagger, or an error when the backend has no tags.
+func AsTagger(b Backend) (Tagger, error) {
+	t, ok := b.(Tagger)
+	if !ok {
+		return nil, fmt.Errorf("tags are not supported by this backend")
+	}
+	return t, nil
+}
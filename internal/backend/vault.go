@@ -0,0 +1,512 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// DefaultVaultMount is the KV v2 mount of refs that do not name one.
+const DefaultVaultMount = "secret"
+
+// vaultTimeout bounds each request when VaultOptions.HTTPClient is not set.
+const vaultTimeout = 30 * time.Second
+
+// VaultOptions configure a VaultBackend.
+type VaultOptions struct {
+	Address    string // e.g. https://vault.example.com:8200
+	Token      string
+	Namespace  string       // Vault Enterprise namespace ("" = root)
+	Mount      string       // KV v2 mount of refs without "/data/" ("" = DefaultVaultMount)
+	HTTPClient *http.Client // nil = a client with a vaultTimeout timeout
+}
+
+// VaultBackend stores entries in a HashiCorp Vault KV v2 secrets engine, addressed as
+// "vault:MOUNT/data/KEY" (e.g. vault:secret/data/app/prod) or "vault:KEY" under the
+// configured mount. bundr keeps the value in the secret's "value" field and its tags
+// (cli-store-mode etc.) in the KV custom_metadata. Secrets written by other tools are
+// read as a JSON object of their fields.
+type VaultBackend struct {
+	opts   VaultOptions
+	client *http.Client
+}
+
+// NewVaultBackend creates a VaultBackend talking to the server at opts.Address.
+func NewVaultBackend(opts VaultOptions) *VaultBackend {
+	if opts.Mount == "" {
+		opts.Mount = DefaultVaultMount
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: vaultTimeout}
+	}
+	return &VaultBackend{opts: opts, client: client}
+}
+
+// vaultSecret is the "data" of a KV v2 read.
+type vaultSecret struct {
+	Data     map[string]any `json:"data"`
+	Metadata struct {
+		CreatedTime    time.Time         `json:"created_time"`
+		Version        int64             `json:"version"`
+		CustomMetadata map[string]string `json:"custom_metadata"`
+	} `json:"metadata"`
+}
+
+// vaultMetadata is the "data" of a KV v2 metadata read.
+type vaultMetadata struct {
+	CurrentVersion int64             `json:"current_version"`
+	CreatedTime    time.Time         `json:"created_time"`
+	UpdatedTime    time.Time         `json:"updated_time"`
+	CustomMetadata map[string]string `json:"custom_metadata"`
+}
+
+// split resolves the path of a vault: ref into the mount and the key.
+func (b *VaultBackend) split(p string) (mount, key string) {
+	if i := strings.Index(p, "/data/"); i > 0 {
+		return p[:i], p[i+len("/data/"):]
+	}
+	return b.opts.Mount, p
+}
+
+// splitRef parses a vault: ref into the mount and the key of a single secret.
+func (b *VaultBackend) splitRef(ref string) (string, string, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return "", "", err
+	}
+	if parsed.Type != BackendTypeVault {
+		return "", "", fmt.Errorf("vault backend cannot handle %s: refs", parsed.Type)
+	}
+	mount, key := b.split(parsed.Path)
+	if key == "" || strings.HasSuffix(key, "/") {
+		return "", "", fmt.Errorf("%s: expected a secret, not a folder", ref)
+	}
+	return mount, key, nil
+}
+
+// do sends a request to the Vault HTTP API and decodes the JSON response into out.
+// A 404 is returned as ErrNotFound.
+func (b *VaultBackend) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(b.opts.Address, "/")+"/v1/"+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", b.opts.Token)
+	if b.opts.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.opts.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		msg := strings.Join(e.Errors, "; ")
+		if msg == "" {
+			msg = resp.Status
+		}
+		return fmt.Errorf("vault %s %s: %s", method, path, msg)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("vault %s %s: decode response: %w", method, path, err)
+	}
+	return nil
+}
+
+// read returns the current version of a secret.
+func (b *VaultBackend) read(ctx context.Context, mount, key string) (vaultSecret, error) {
+	var resp struct {
+		Data vaultSecret `json:"data"`
+	}
+	if err := b.do(ctx, http.MethodGet, mount+"/data/"+key, nil, &resp); err != nil {
+		return vaultSecret{}, err
+	}
+	return resp.Data, nil
+}
+
+// metadata returns the metadata of a secret.
+func (b *VaultBackend) metadata(ctx context.Context, mount, key string) (vaultMetadata, error) {
+	var resp struct {
+		Data vaultMetadata `json:"data"`
+	}
+	if err := b.do(ctx, http.MethodGet, mount+"/metadata/"+key, nil, &resp); err != nil {
+		return vaultMetadata{}, err
+	}
+	return resp.Data, nil
+}
+
+// writeMetadata replaces the custom_metadata of a secret.
+func (b *VaultBackend) writeMetadata(ctx context.Context, mount, key string, tagMap map[string]string) error {
+	if tagMap == nil {
+		tagMap = map[string]string{}
+	}
+	return b.do(ctx, http.MethodPost, mount+"/metadata/"+key, map[string]any{"custom_metadata": tagMap}, nil)
+}
+
+// list returns the secrets under dir ("" or ending in "/") relative to it, sorted,
+// descending into subfolders when recursive. A missing folder has no secrets.
+func (b *VaultBackend) list(ctx context.Context, mount, dir string, recursive bool) ([]string, error) {
+	var resp struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	err := b.do(ctx, "LIST", mount+"/metadata/"+dir, nil, &resp)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, k := range resp.Data.Keys {
+		if !strings.HasSuffix(k, "/") {
+			keys = append(keys, k)
+			continue
+		}
+		if !recursive {
+			continue
+		}
+		sub, err := b.list(ctx, mount, dir+k, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sub {
+			keys = append(keys, k+s)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// vaultValue returns the value and store mode of a secret. Secrets written by bundr
+// keep the value in the "value" field. Other secrets are a JSON object of their fields,
+// except a lone string "value" field, which is read as a raw value.
+func vaultValue(s vaultSecret) (string, string, error) {
+	tagMap := s.Metadata.CustomMetadata
+	v, isString := s.Data["value"].(string)
+	if tagMap[tags.TagCLI] == tags.TagCLIValue && isString {
+		return v, storeModeOf(tagMap), nil
+	}
+	if isString && len(s.Data) == 1 {
+		return v, tags.StoreModeRaw, nil
+	}
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), tags.StoreModeJSON, nil
+}
+
+// Put writes a new version of the secret and merges the tags into its custom_metadata
+// (with opts.ExactTags the custom_metadata is replaced by opts.Tags). Like Parameter
+// Store, scalars are JSON-encoded in json store mode. Value types, tiers, KMS keys,
+// descriptions and policies do not apply to Vault and are ignored.
+//
+// A secret with fields other than "value" was written by another tool; Put refuses to
+// overwrite it, since the new version would drop those fields.
+func (b *VaultBackend) Put(ctx context.Context, ref string, opts PutOptions) error {
+	mount, key, err := b.splitRef(ref)
+	if err != nil {
+		return err
+	}
+	value := opts.Value
+	if opts.StoreMode == tags.StoreModeJSON && !json.Valid([]byte(opts.Value)) {
+		encoded, err := json.Marshal(opts.Value)
+		if err != nil {
+			return fmt.Errorf("json encode: %w", err)
+		}
+		value = string(encoded)
+	}
+
+	// 既存のフィールドと custom_metadata を失わないよう、書き込む前に読む
+	var current map[string]string
+	s, err := b.read(ctx, mount, key)
+	switch {
+	case err == nil:
+		if fields := foreignFields(s.Data); len(fields) > 0 {
+			return fmt.Errorf("%s was not written by bundr: refusing to replace its fields %s", ref, strings.Join(fields, ", "))
+		}
+		current = s.Metadata.CustomMetadata
+	case errors.Is(err, ErrNotFound):
+		// 最新バージョンが削除済みでもメタデータは残っている
+		m, err := b.metadata(ctx, mount, key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("read metadata: %w", err)
+		}
+		current = m.CustomMetadata
+	default:
+		return fmt.Errorf("read secret: %w", err)
+	}
+
+	tagMap := putTags(opts)
+	if !opts.ExactTags {
+		merged := copyTags(current)
+		for k, v := range tagMap {
+			merged[k] = v
+		}
+		tagMap = merged
+	}
+
+	if err := b.do(ctx, http.MethodPost, mount+"/data/"+key, map[string]any{"data": map[string]string{"value": value}}, nil); err != nil {
+		return fmt.Errorf("write secret: %w", err)
+	}
+	if err := b.writeMetadata(ctx, mount, key, tagMap); err != nil {
+		return fmt.Errorf("write metadata: %w", err)
+	}
+	return nil
+}
+
+// foreignFields returns the sorted data fields of a secret that bundr does not write:
+// every field except a string "value".
+func foreignFields(data map[string]any) []string {
+	var fields []string
+	for k, v := range data {
+		if _, isString := v.(string); k == "value" && isString {
+			continue
+		}
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Get returns the value of ref, decoded according to its store mode.
+func (b *VaultBackend) Get(ctx context.Context, ref string, opts GetOptions) (string, error) {
+	mount, key, err := b.splitRef(ref)
+	if err != nil {
+		return "", err
+	}
+	s, err := b.read(ctx, mount, key)
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	if err != nil {
+		return "", err
+	}
+	value, storeMode, err := vaultValue(s)
+	if err != nil {
+		return "", err
+	}
+	if opts.ForceRaw {
+		return value, nil
+	}
+	if opts.ForceJSON || storeMode == tags.StoreModeJSON {
+		return decodeJSON(value)
+	}
+	return value, nil
+}
+
+// GetByPrefix returns the secrets under prefix ("secret/data/app/"), sorted by path.
+// Values are returned raw, as on Parameter Store.
+func (b *VaultBackend) GetByPrefix(ctx context.Context, prefix string, opts GetByPrefixOptions) ([]ParameterEntry, error) {
+	var entries []ParameterEntry
+	err := b.WalkPrefix(ctx, prefix, opts, func(e ParameterEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WalkPrefix calls fn for each secret under prefix, sorted by path. Each secret is read
+// with its own request; with SkipTagFetch only the paths are listed.
+func (b *VaultBackend) WalkPrefix(ctx context.Context, prefix string, opts GetByPrefixOptions, fn func(ParameterEntry) error) error {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	mount, dir := b.split(prefix)
+	keys, err := b.list(ctx, mount, dir, opts.Recursive)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if opts.SkipTagFetch {
+			if err := fn(ParameterEntry{Path: prefix + k}); err != nil {
+				return err
+			}
+			continue
+		}
+		s, err := b.read(ctx, mount, dir+k)
+		if errors.Is(err, ErrNotFound) {
+			continue // 最新バージョンが削除済み
+		}
+		if err != nil {
+			return err
+		}
+		value, storeMode, err := vaultValue(s)
+		if err != nil {
+			return err
+		}
+		var metadata map[string]any
+		if opts.IncludeMetadata {
+			metadata = map[string]any{
+				"Name":             mount + "/data/" + dir + k,
+				"Version":          s.Metadata.Version,
+				"LastModifiedDate": s.Metadata.CreatedTime,
+			}
+		}
+		if err := fn(ParameterEntry{
+			Path:      prefix + k,
+			Value:     value,
+			StoreMode: storeMode,
+			Flatten:   s.Metadata.CustomMetadata[tags.TagFlatten],
+			Tags:      copyTags(s.Metadata.CustomMetadata),
+			Metadata:  metadata,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List calls fn for each secret under prefix matching opts, in path order.
+// Tags are matched against the custom_metadata of each secret.
+func (b *VaultBackend) List(ctx context.Context, prefix string, opts ListOptions, fn func(ListEntry) error) error {
+	if opts.Type != "" {
+		return fmt.Errorf("filtering by type is not supported by Vault")
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	mount, dir := b.split(prefix)
+	keys, err := b.list(ctx, mount, dir, opts.Recursive)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		md, err := b.metadata(ctx, mount, dir+k)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		matched := true
+		for tk, tv := range opts.Tags {
+			if md.CustomMetadata[tk] != tv {
+				matched = false
+			}
+		}
+		if !matched {
+			continue
+		}
+		modified := md.UpdatedTime
+		le := ListEntry{
+			Path:         prefix + k,
+			Version:      md.CurrentVersion,
+			LastModified: &modified,
+		}
+		if md.CustomMetadata[tags.TagCLI] == tags.TagCLIValue {
+			le.Managed = true
+			le.StoreMode = md.CustomMetadata[tags.TagStoreMode]
+		}
+		if err := fn(le); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Describe returns the metadata, value and tags of ref.
+func (b *VaultBackend) Describe(ctx context.Context, ref string) (map[string]any, error) {
+	mount, key, err := b.splitRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	md, err := b.metadata(ctx, mount, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, err := b.Get(ctx, ref, GetOptions{ForceRaw: true})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"Name":             key,
+		"Mount":            mount,
+		"Value":            value,
+		"Version":          md.CurrentVersion,
+		"CreatedDate":      md.CreatedTime,
+		"LastModifiedDate": md.UpdatedTime,
+		"Tags":             copyTags(md.CustomMetadata),
+	}, nil
+}
+
+// Tags returns the custom_metadata of ref.
+func (b *VaultBackend) Tags(ctx context.Context, ref string) (map[string]string, error) {
+	mount, key, err := b.splitRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	md, err := b.metadata(ctx, mount, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return copyTags(md.CustomMetadata), nil
+}
+
+// AddTags adds or overwrites custom_metadata keys of ref.
+func (b *VaultBackend) AddTags(ctx context.Context, ref string, tagMap map[string]string) error {
+	return b.updateTags(ctx, ref, func(m map[string]string) {
+		for k, v := range tagMap {
+			m[k] = v
+		}
+	})
+}
+
+// RemoveTags removes custom_metadata keys from ref. Missing keys are ignored.
+func (b *VaultBackend) RemoveTags(ctx context.Context, ref string, keys []string) error {
+	return b.updateTags(ctx, ref, func(m map[string]string) {
+		for _, k := range keys {
+			delete(m, k)
+		}
+	})
+}
+
+// updateTags rewrites the custom_metadata of ref, which Vault replaces as a whole.
+func (b *VaultBackend) updateTags(ctx context.Context, ref string, fn func(map[string]string)) error {
+	current, err := b.Tags(ctx, ref)
+	if err != nil {
+		return err
+	}
+	fn(current)
+	mount, key, _ := b.splitRef(ref)
+	return b.writeMetadata(ctx, mount, key, current)
+}
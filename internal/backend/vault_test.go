@@ -0,0 +1,281 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// fakeVault is an in-memory KV v2 engine mounted at "secret".
+type fakeVault struct {
+	mu       sync.Mutex
+	data     map[string]map[string]any    // by key
+	meta     map[string]map[string]string // custom_metadata by key
+	versions map[string]int64
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *VaultBackend) {
+	t.Helper()
+	fv := &fakeVault{data: map[string]map[string]any{}, meta: map[string]map[string]string{}, versions: map[string]int64{}}
+	srv := httptest.NewServer(fv)
+	t.Cleanup(srv.Close)
+	return fv, NewVaultBackend(VaultOptions{Address: srv.URL, Token: "test-token", HTTPClient: srv.Client()})
+}
+
+func (fv *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	if r.Header.Get("X-Vault-Token") != "test-token" {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	var body map[string]json.RawMessage
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	reply := func(v any) { _ = json.NewEncoder(w).Encode(map[string]any{"data": v}) }
+
+	switch {
+	case strings.HasPrefix(path, "secret/data/"):
+		key := strings.TrimPrefix(path, "secret/data/")
+		switch r.Method {
+		case http.MethodGet:
+			d, ok := fv.data[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			reply(map[string]any{"data": d, "metadata": map[string]any{
+				"version": fv.versions[key], "created_time": time.Unix(0, 0).UTC(), "custom_metadata": fv.meta[key],
+			}})
+		case http.MethodPost:
+			var d map[string]any
+			_ = json.Unmarshal(body["data"], &d)
+			fv.data[key] = d
+			fv.versions[key]++
+			reply(map[string]any{"version": fv.versions[key]})
+		}
+	case strings.HasPrefix(path, "secret/metadata/"):
+		key := strings.TrimPrefix(path, "secret/metadata/")
+		switch r.Method {
+		case "LIST":
+			seen := map[string]bool{}
+			for k := range fv.data {
+				if !strings.HasPrefix(k, key) {
+					continue
+				}
+				rest := strings.TrimPrefix(k, key)
+				if i := strings.Index(rest, "/"); i >= 0 {
+					rest = rest[:i+1]
+				}
+				seen[rest] = true
+			}
+			if len(seen) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var keys []string
+			for k := range seen {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			reply(map[string]any{"keys": keys})
+		case http.MethodGet:
+			if _, ok := fv.data[key]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			reply(map[string]any{"current_version": fv.versions[key], "custom_metadata": fv.meta[key]})
+		case http.MethodPost:
+			var m map[string]string
+			_ = json.Unmarshal(body["custom_metadata"], &m)
+			fv.meta[key] = m
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVaultBackend_PutGet(t *testing.T) {
+	fv, b := newFakeVault(t)
+	ctx := context.Background()
+
+	if err := b.Put(ctx, "vault:secret/data/app/db_host", PutOptions{Value: "localhost", StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatalf("Put raw: %v", err)
+	}
+	if err := b.Put(ctx, "vault:app/config", PutOptions{Value: `{"port":5432}`, StoreMode: tags.StoreModeJSON}); err != nil {
+		t.Fatalf("Put json: %v", err)
+	}
+	if got := fv.meta["app/config"][tags.TagStoreMode]; got != tags.StoreModeJSON {
+		t.Errorf("custom_metadata cli-store-mode = %q, want json", got)
+	}
+	// 他のツールが書いた複数フィールドのシークレット
+	fv.data["app/native"] = map[string]any{"user": "admin", "password": "s3cret"}
+	fv.data["app/plain"] = map[string]any{"value": "hello"}
+
+	tests := []struct {
+		name    string
+		ref     string
+		opts    GetOptions
+		want    string
+		wantErr error
+	}{
+		{name: "raw", ref: "vault:secret/data/app/db_host", want: "localhost"},
+		{name: "relative to mount", ref: "vault:app/db_host", want: "localhost"},
+		{name: "json", ref: "vault:secret/data/app/config", want: `{"port":5432}`},
+		{name: "unmanaged fields", ref: "vault:secret/data/app/native", want: `{"password":"s3cret","user":"admin"}`},
+		{name: "unmanaged value field", ref: "vault:secret/data/app/plain", want: "hello"},
+		{name: "missing", ref: "vault:secret/data/app/none", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.Get(ctx, tt.ref, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Get(%q) error = %v, want %v", tt.ref, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Get(%q) = %q, %v; want %q", tt.ref, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// Put keeps other custom_metadata and refuses to drop fields written by other tools
+func TestVaultBackend_PutExisting(t *testing.T) {
+	fv, b := newFakeVault(t)
+	ctx := context.Background()
+
+	fv.data["app/plain"] = map[string]any{"value": "old"}
+	fv.meta["app/plain"] = map[string]string{"owner": "team-a"}
+	if err := b.Put(ctx, "vault:app/plain", PutOptions{Value: "new", StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := fv.meta["app/plain"]; got["owner"] != "team-a" || got[tags.TagCLI] != tags.TagCLIValue {
+		t.Errorf("custom_metadata = %v, want owner kept and cli=bundr added", got)
+	}
+	if got := fv.data["app/plain"]["value"]; got != "new" {
+		t.Errorf("value = %v, want new", got)
+	}
+
+	if err := b.Put(ctx, "vault:app/plain", PutOptions{Value: "v", StoreMode: tags.StoreModeRaw, Tags: map[string]string{"team": "core"}, ExactTags: true}); err != nil {
+		t.Fatalf("Put exact: %v", err)
+	}
+	if got := fv.meta["app/plain"]; len(got) != 1 || got["team"] != "core" {
+		t.Errorf("custom_metadata = %v, want only team=core with ExactTags", got)
+	}
+
+	fv.data["app/native"] = map[string]any{"user": "admin", "password": "s3cret"}
+	err := b.Put(ctx, "vault:app/native", PutOptions{Value: "x", StoreMode: tags.StoreModeRaw})
+	if err == nil || !strings.Contains(err.Error(), "password, user") {
+		t.Errorf("Put over foreign fields: error = %v, want refusal naming password, user", err)
+	}
+	if got := fv.data["app/native"]; len(got) != 2 {
+		t.Errorf("data = %v, want the foreign fields untouched", got)
+	}
+}
+
+func TestVaultBackend_GetByPrefix(t *testing.T) {
+	_, b := newFakeVault(t)
+	ctx := context.Background()
+	for ref, v := range map[string]string{
+		"vault:secret/data/app/prod/db_host":   "db",
+		"vault:secret/data/app/prod/api/token": "tok",
+		"vault:secret/data/app/dev/db_host":    "dev-db",
+	} {
+		if err := b.Put(ctx, ref, PutOptions{Value: v, StoreMode: tags.StoreModeRaw}); err != nil {
+			t.Fatalf("Put(%s): %v", ref, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		prefix    string
+		recursive bool
+		want      []string
+	}{
+		{name: "recursive", prefix: "secret/data/app/prod/", recursive: true, want: []string{"secret/data/app/prod/api/token", "secret/data/app/prod/db_host"}},
+		{name: "one level", prefix: "secret/data/app/prod/", want: []string{"secret/data/app/prod/db_host"}},
+		{name: "relative", prefix: "app/dev/", recursive: true, want: []string{"app/dev/db_host"}},
+		{name: "missing folder", prefix: "secret/data/none/", recursive: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := b.GetByPrefix(ctx, tt.prefix, GetByPrefixOptions{Recursive: tt.recursive})
+			if err != nil {
+				t.Fatalf("GetByPrefix: %v", err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Path)
+				if e.StoreMode != tags.StoreModeRaw || e.Value == "" {
+					t.Errorf("entry %s = %+v", e.Path, e)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultBackend_TagsAndList(t *testing.T) {
+	_, b := newFakeVault(t)
+	ctx := context.Background()
+	ref := "vault:secret/data/app/key"
+	if err := b.Put(ctx, ref, PutOptions{Value: "v", StoreMode: tags.StoreModeRaw, Tags: map[string]string{"team": "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddTags(ctx, ref, map[string]string{"env": "prod"}); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	if err := b.RemoveTags(ctx, ref, []string{"team"}); err != nil {
+		t.Fatalf("RemoveTags: %v", err)
+	}
+	got, err := b.Tags(ctx, ref)
+	if err != nil {
+		t.Fatalf("Tags: %v", err)
+	}
+	if got["env"] != "prod" || got["team"] != "" || got[tags.TagCLI] != tags.TagCLIValue {
+		t.Errorf("Tags = %v", got)
+	}
+
+	var listed []ListEntry
+	err = b.List(ctx, "secret/data/app/", ListOptions{Recursive: true, Tags: map[string]string{"env": "prod"}}, func(e ListEntry) error {
+		listed = append(listed, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(listed) != 1 || listed[0].Path != "secret/data/app/key" || !listed[0].Managed || listed[0].Version != 1 {
+		t.Errorf("List = %+v", listed)
+	}
+}
+
+func TestVaultBackend_Errors(t *testing.T) {
+	_, b := newFakeVault(t)
+	ctx := context.Background()
+
+	denied := NewVaultBackend(VaultOptions{Address: b.opts.Address, Token: "wrong", HTTPClient: b.client})
+	if _, err := denied.Get(ctx, "vault:secret/data/app/key", GetOptions{}); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Get with a bad token: error = %v, want permission denied", err)
+	}
+	if err := b.Put(ctx, "vault:secret/data/app/", PutOptions{Value: "v"}); err == nil {
+		t.Error("Put to a folder: expected error")
+	}
+}
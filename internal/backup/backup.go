@@ -0,0 +1,80 @@
+// Package backup defines bundr's backup archive. A backup file is the JSON archive
+// sealed by package envelope under the format line "bundr-backup/1".
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/youyo/bundr/internal/envelope"
+)
+
+// FormatVersion is the version of the archive layout.
+const FormatVersion = 1
+
+const format = "bundr-backup/1"
+
+// Archive is the decrypted content of a backup file.
+type Archive struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	// Source is the prefix that was backed up (e.g. "ps:/app/" or "sm:").
+	Source  string  `json:"source"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is one parameter or secret with everything needed to recreate it.
+type Entry struct {
+	Ref         string            `json:"ref"`
+	Type        string            `json:"type,omitempty"` // String, SecureString or StringList (Parameter Store)
+	Tier        string            `json:"tier,omitempty"`
+	KMSKeyID    string            `json:"kms_key_id,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Policies    string            `json:"policies,omitempty"`
+	StoreMode   string            `json:"store_mode,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Value       string            `json:"value"`
+	// SHA256 is the hex SHA-256 of Value, used to verify restores.
+	SHA256 string `json:"sha256"`
+	// RotationEnabled records whether a secret had rotation; restore cannot recreate it.
+	RotationEnabled bool `json:"rotation_enabled,omitempty"`
+}
+
+// Checksum returns the hex SHA-256 of value, as stored in Entry.SHA256.
+func Checksum(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// Seal writes a as an encrypted backup file to w.
+func Seal(w io.Writer, a *Archive, opts envelope.SealOptions) error {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return envelope.Seal(w, format, payload, opts)
+}
+
+// Open reads and decrypts a backup file written by Seal.
+func Open(r io.Reader, opts envelope.OpenOptions) (*Archive, error) {
+	payload, err := envelope.Open(r, format, opts)
+	if errors.Is(err, envelope.ErrFormat) {
+		return nil, fmt.Errorf("not a bundr backup file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var a Archive
+	if err := json.Unmarshal(payload, &a); err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	if a.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported archive version %d", a.Version)
+	}
+	return &a, nil
+}
@@ -0,0 +1,174 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+
+	"github.com/youyo/bundr/internal/envelope"
+)
+
+func testArchive() *Archive {
+	return &Archive{
+		Version: FormatVersion,
+		Created: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Source:  "ps:/app/",
+		Entries: []Entry{
+			{Ref: "ps:/app/db_host", Type: "String", Tier: "Standard", Value: "db.internal", SHA256: Checksum("db.internal")},
+			{Ref: "ps:/app/password", Type: "SecureString", Tier: "Advanced", KMSKeyID: "alias/app", Tags: map[string]string{"team": "core"}, Value: "s3cr3t", SHA256: Checksum("s3cr3t")},
+		},
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		seal    envelope.SealOptions
+		open    envelope.OpenOptions
+		wantErr error
+	}{
+		{
+			name: "passphrase",
+			seal: envelope.SealOptions{Passphrase: "correct horse", ScryptLogN: 10},
+			open: envelope.OpenOptions{Passphrase: "correct horse"},
+		},
+		{
+			name:    "wrong passphrase",
+			seal:    envelope.SealOptions{Passphrase: "correct horse", ScryptLogN: 10},
+			open:    envelope.OpenOptions{Passphrase: "battery staple"},
+			wantErr: envelope.ErrNoKey,
+		},
+		{
+			name: "age recipient",
+			seal: envelope.SealOptions{Recipients: []age.Recipient{id.Recipient()}},
+			open: envelope.OpenOptions{Identities: []age.Identity{id}},
+		},
+		{
+			name:    "wrong identity",
+			seal:    envelope.SealOptions{Recipients: []age.Recipient{id.Recipient()}},
+			open:    envelope.OpenOptions{Identities: []age.Identity{other}},
+			wantErr: envelope.ErrNoKey,
+		},
+		{
+			name: "passphrase and recipient, opened with identity",
+			seal: envelope.SealOptions{Passphrase: "pw", Recipients: []age.Recipient{id.Recipient()}, ScryptLogN: 10},
+			open: envelope.OpenOptions{Identities: []age.Identity{id}},
+		},
+		{
+			name: "passphrase and recipient, opened with passphrase",
+			seal: envelope.SealOptions{Passphrase: "pw", Recipients: []age.Recipient{id.Recipient()}, ScryptLogN: 10},
+			open: envelope.OpenOptions{Passphrase: "pw"},
+		},
+		{
+			name:    "passphrase-only file opened with identity",
+			seal:    envelope.SealOptions{Passphrase: "pw", ScryptLogN: 10},
+			open:    envelope.OpenOptions{Identities: []age.Identity{id}},
+			wantErr: envelope.ErrNoKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Seal(&buf, testArchive(), tt.seal); err != nil {
+				t.Fatalf("Seal: %v", err)
+			}
+			if bytes.Contains(buf.Bytes(), []byte("s3cr3t")) {
+				t.Fatal("sealed file contains a plaintext value")
+			}
+
+			got, err := Open(&buf, tt.open)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Open error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			want := testArchive()
+			if got.Source != want.Source || !got.Created.Equal(want.Created) || len(got.Entries) != len(want.Entries) {
+				t.Fatalf("Open = %+v, want %+v", got, want)
+			}
+			e := got.Entries[1]
+			if e.Value != "s3cr3t" || e.Tier != "Advanced" || e.KMSKeyID != "alias/app" || e.Tags["team"] != "core" || e.SHA256 != Checksum("s3cr3t") {
+				t.Errorf("entry = %+v", e)
+			}
+		})
+	}
+}
+
+func TestSeal_RequiresKey(t *testing.T) {
+	if err := Seal(&bytes.Buffer{}, testArchive(), envelope.SealOptions{}); err == nil {
+		t.Fatal("Seal without passphrase or recipients succeeded")
+	}
+}
+
+func TestOpen_Tampered(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Seal(&buf, testArchive(), envelope.SealOptions{Passphrase: "pw", ScryptLogN: 10}); err != nil {
+		t.Fatal(err)
+	}
+	sealed := buf.Bytes()
+	headerEnd := bytes.IndexByte(sealed[len(format)+1:], '\n') + len(format) + 1
+
+	tests := []struct {
+		name    string
+		mutate  func([]byte) []byte
+		wantErr string
+	}{
+		{
+			name:    "not a backup",
+			mutate:  func([]byte) []byte { return []byte("KEY=value\n") },
+			wantErr: "not a bundr backup file",
+		},
+		{
+			name: "ciphertext flipped",
+			mutate: func(b []byte) []byte {
+				b[len(b)-1] ^= 1
+				return b
+			},
+			wantErr: "corrupted or was modified",
+		},
+		{
+			name: "header extended",
+			mutate: func(b []byte) []byte {
+				// 有効な JSON のままヘッダーを書き換えても認証で弾かれる
+				out := append([]byte{}, b[:headerEnd]...)
+				out = append(out, ' ')
+				return append(out, b[headerEnd:]...)
+			},
+			wantErr: "corrupted or was modified",
+		},
+		{
+			name: "excessive work factor",
+			mutate: func(b []byte) []byte {
+				return bytes.Replace(b, []byte(`"log_n":10`), []byte(`"log_n":30`), 1)
+			},
+			wantErr: "invalid scrypt work factor",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.mutate(append([]byte{}, sealed...))
+			_, err := Open(bytes.NewReader(data), envelope.OpenOptions{Passphrase: "pw"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Open error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
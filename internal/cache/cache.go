@@ -45,11 +45,14 @@ func CacheIdentifier(profile string) string {
 
 // CacheFile はキャッシュファイル全体を表す。
 type CacheFile struct {
-	SchemaVersion   string       `json:"schema_version"`
-	BackendType     string       `json:"backend_type"`
-	UpdatedAt       time.Time    `json:"updated_at"`
-	LastRefreshedAt time.Time    `json:"last_refreshed_at"`
-	Entries         []CacheEntry `json:"entries"`
+	SchemaVersion   string    `json:"schema_version"`
+	BackendType     string    `json:"backend_type"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	LastRefreshedAt time.Time `json:"last_refreshed_at"`
+	// Scope は entries が網羅するプレフィックス（"/" や "" はバックエンド全体）。
+	// スコープ記録前のファイルでは nil（網羅範囲は不明）。
+	Scope   *string      `json:"scope,omitempty"`
+	Entries []CacheEntry `json:"entries"`
 }
 
 // CacheEntry はキャッシュ内の 1 エントリ（パスとメタデータ）。
@@ -62,7 +65,11 @@ type CacheEntry struct {
 // Store はキャッシュの読み書きインターフェース（テスト容易性のため）。
 type Store interface {
 	Read(backendType string) ([]CacheEntry, error)
-	Write(backendType string, entries []CacheEntry) error
+	// Write は scope 配下を再帰的に列挙した entries でキャッシュ全体を置き換える。
+	Write(backendType, scope string, entries []CacheEntry) error
+	// Scope は最後の Write が網羅したプレフィックスを返す。
+	// キャッシュが存在しないかスコープが不明な場合は ok=false を返す。
+	Scope(backendType string) (scope string, ok bool)
 	// LastRefreshedAt は指定バックエンドの最終 BG 更新時刻を返す。
 	// キャッシュが存在しない場合は zero time を返す。
 	LastRefreshedAt(backendType string) time.Time
@@ -130,7 +137,7 @@ func (s *FileStore) Read(backendType string) ([]CacheEntry, error) {
 
 // Write はエントリをキャッシュファイルにアトミックに書き込む。
 // ファイルロックを取得してから書き込み、ロック解放する。
-func (s *FileStore) Write(backendType string, entries []CacheEntry) error {
+func (s *FileStore) Write(backendType, scope string, entries []CacheEntry) error {
 	if err := os.MkdirAll(s.baseDir, 0o700); err != nil {
 		return fmt.Errorf("create cache dir: %w", err)
 	}
@@ -151,6 +158,7 @@ func (s *FileStore) Write(backendType string, entries []CacheEntry) error {
 			BackendType:     backendType,
 			UpdatedAt:       now,
 			LastRefreshedAt: now,
+			Scope:           &scope,
 			Entries:         entries,
 		}
 		if err := json.NewEncoder(tmp).Encode(cf); err != nil {
@@ -177,6 +185,15 @@ func (s *FileStore) LastRefreshedAt(backendType string) time.Time {
 	return cf.LastRefreshedAt
 }
 
+// Scope は指定バックエンドのキャッシュが網羅するプレフィックスを返す。
+func (s *FileStore) Scope(backendType string) (string, bool) {
+	cf, err := s.readFile(backendType)
+	if err != nil || cf.Scope == nil {
+		return "", false
+	}
+	return *cf.Scope, true
+}
+
 // Clear はキャッシュディレクトリ内のすべての JSON ファイルを削除する。
 // 旧形式のキャッシュファイルも含めて全削除する。
 func (s *FileStore) Clear() error {
@@ -205,7 +222,10 @@ func NewNoopStore() *NoopStore { return &NoopStore{} }
 func (n *NoopStore) Read(_ string) ([]CacheEntry, error) { return nil, ErrCacheNotFound }
 
 // Write は何もせずに成功を返す。
-func (n *NoopStore) Write(_ string, _ []CacheEntry) error { return nil }
+func (n *NoopStore) Write(_, _ string, _ []CacheEntry) error { return nil }
+
+// Scope は常に ok=false を返す。
+func (n *NoopStore) Scope(_ string) (string, bool) { return "", false }
 
 // LastRefreshedAt は常に zero time を返す。
 func (n *NoopStore) LastRefreshedAt(_ string) time.Time { return time.Time{} }
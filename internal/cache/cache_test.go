@@ -20,7 +20,7 @@ func TestFileStore_WriteRead(t *testing.T) {
 		{Path: "/app/prod/DB_PORT", StoreMode: "raw"},
 	}
 
-	if err := store.Write("ps", entries); err != nil {
+	if err := store.Write("ps", "/", entries); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 
@@ -44,7 +44,7 @@ func TestFileStore_Write_JSONSchema(t *testing.T) {
 	store := NewFileStoreWithDir(dir)
 	entries := []CacheEntry{{Path: "/app/config", StoreMode: "json"}}
 
-	if err := store.Write("ps", entries); err != nil {
+	if err := store.Write("ps", "/", entries); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 
@@ -89,7 +89,7 @@ func TestFileStore_Write_Filename(t *testing.T) {
 	dir := t.TempDir()
 	store := NewFileStoreWithDir(dir)
 
-	if err := store.Write("psa", []CacheEntry{{Path: "/app/x", StoreMode: "raw"}}); err != nil {
+	if err := store.Write("psa", "/", []CacheEntry{{Path: "/app/x", StoreMode: "raw"}}); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 
@@ -104,7 +104,7 @@ func TestFileStore_Write_AutoCreateDir(t *testing.T) {
 	dir := filepath.Join(base, "subdir", "bundr")
 	store := NewFileStoreWithDir(dir)
 
-	err := store.Write("ps", []CacheEntry{{Path: "/app/x", StoreMode: "raw"}})
+	err := store.Write("ps", "/", []CacheEntry{{Path: "/app/x", StoreMode: "raw"}})
 	if err != nil {
 		t.Fatalf("Write: %v", err)
 	}
@@ -117,7 +117,7 @@ func TestFileStore_Write_AutoCreateDir(t *testing.T) {
 func TestFileStore_Write_EmptyEntries(t *testing.T) {
 	store := NewFileStoreWithDir(t.TempDir())
 
-	if err := store.Write("ps", []CacheEntry{}); err != nil {
+	if err := store.Write("ps", "/", []CacheEntry{}); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 	got, err := store.Read("ps")
@@ -134,7 +134,7 @@ func TestFileStore_LastRefreshedAt_AfterWrite(t *testing.T) {
 	store := NewFileStoreWithDir(t.TempDir())
 	before := time.Now().Add(-time.Second)
 
-	if err := store.Write("ps", []CacheEntry{}); err != nil {
+	if err := store.Write("ps", "/", []CacheEntry{}); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 
@@ -147,6 +147,30 @@ func TestFileStore_LastRefreshedAt_AfterWrite(t *testing.T) {
 	}
 }
 
+// cache-008: Write → Scope が記録され、スコープなしの旧ファイルは不明扱い
+func TestFileStore_Scope(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStoreWithDir(dir)
+
+	if _, ok := store.Scope("ps"); ok {
+		t.Error("Scope of a missing cache: ok = true, want false")
+	}
+	if err := store.Write("ps", "/app/", []CacheEntry{{Path: "/app/x", StoreMode: "raw"}}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if scope, ok := store.Scope("ps"); !ok || scope != "/app/" {
+		t.Errorf("Scope = %q, %v; want /app/", scope, ok)
+	}
+
+	legacy := `{"schema_version":"v1","backend_type":"sm","entries":[]}`
+	if err := os.WriteFile(store.cacheFilePath("sm"), []byte(legacy), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, ok := store.Scope("sm"); ok {
+		t.Error("Scope of a cache without a scope: ok = true, want false")
+	}
+}
+
 // ---- 異常系テスト ----
 
 // cache-010: 破損 JSON ファイルを Read → エラーを返す（panic しない、ErrCacheNotFound とは区別）
@@ -181,7 +205,7 @@ func TestFileStore_Write_NoPermission(t *testing.T) {
 	defer os.Chmod(dir, 0o755) //nolint:errcheck
 
 	store := NewFileStoreWithDir(dir)
-	err := store.Write("ps", []CacheEntry{})
+	err := store.Write("ps", "/", []CacheEntry{})
 	if err == nil {
 		t.Fatal("expected error for read-only directory, got nil")
 	}
@@ -243,7 +267,7 @@ func TestFileStore_Write_Concurrent(t *testing.T) {
 			entries := []CacheEntry{
 				{Path: strings.Repeat("/app/path", i+1), StoreMode: "raw"},
 			}
-			if err := store.Write("ps", entries); err != nil {
+			if err := store.Write("ps", "/", entries); err != nil {
 				t.Errorf("worker %d: Write: %v", i, err)
 			}
 		}()
@@ -267,7 +291,7 @@ func TestFileStore_Write_LongPath(t *testing.T) {
 	longPath := "/" + strings.Repeat("a", 1020)
 	entries := []CacheEntry{{Path: longPath, StoreMode: "raw"}}
 
-	if err := store.Write("ps", entries); err != nil {
+	if err := store.Write("ps", "/", entries); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 	got, err := store.Read("ps")
@@ -287,7 +311,7 @@ func TestFileStore_Write_ManyEntries(t *testing.T) {
 		entries[i] = CacheEntry{Path: strings.Repeat("/app/", 1) + string(rune('a'+i%26)), StoreMode: "raw"}
 	}
 
-	if err := store.Write("ps", entries); err != nil {
+	if err := store.Write("ps", "/", entries); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 	got, err := store.Read("ps")
@@ -305,7 +329,7 @@ func TestFileStore_Write_ManyEntries(t *testing.T) {
 func TestFileStore_LastRefreshedAt_Within10Seconds(t *testing.T) {
 	store := NewFileStoreWithDir(t.TempDir())
 
-	if err := store.Write("ps", []CacheEntry{}); err != nil {
+	if err := store.Write("ps", "/", []CacheEntry{}); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 
@@ -430,7 +454,7 @@ func TestFileStore_Write_DoesNotStoreSecretValues(t *testing.T) {
 		{Path: "/app/prod/API_SECRET", StoreMode: "raw"},
 	}
 
-	if err := store.Write("ps", entries); err != nil {
+	if err := store.Write("ps", "/", entries); err != nil {
 		t.Fatalf("Write: %v", err)
 	}
 
@@ -492,7 +516,7 @@ func TestNoopStore_Read(t *testing.T) {
 // NoopStore: Write は常に nil を返す
 func TestNoopStore_Write(t *testing.T) {
 	s := NewNoopStore()
-	err := s.Write("ps", []CacheEntry{{Path: "/app/key", StoreMode: "raw"}})
+	err := s.Write("ps", "/", []CacheEntry{{Path: "/app/key", StoreMode: "raw"}})
 	if err != nil {
 		t.Errorf("expected nil, got %v", err)
 	}
@@ -526,10 +550,10 @@ func TestFileStore_Clear_DeletesFiles(t *testing.T) {
 	store := NewFileStoreWithDir(dir)
 
 	// 複数バックエンドのファイルを作成
-	if err := store.Write("ps", []CacheEntry{{Path: "/app/key", StoreMode: "raw"}}); err != nil {
+	if err := store.Write("ps", "/", []CacheEntry{{Path: "/app/key", StoreMode: "raw"}}); err != nil {
 		t.Fatalf("Write ps: %v", err)
 	}
-	if err := store.Write("sm", []CacheEntry{{Path: "my-secret", StoreMode: "raw"}}); err != nil {
+	if err := store.Write("sm", "", []CacheEntry{{Path: "my-secret", StoreMode: "raw"}}); err != nil {
 		t.Fatalf("Write sm: %v", err)
 	}
 
@@ -611,13 +635,13 @@ func TestFileStore_Write_IsAtomic(t *testing.T) {
 
 	// 1回目の書き込み
 	entries1 := []CacheEntry{{Path: "/app/v1/key", StoreMode: "raw"}}
-	if err := store.Write("ps", entries1); err != nil {
+	if err := store.Write("ps", "/", entries1); err != nil {
 		t.Fatalf("Write 1: %v", err)
 	}
 
 	// 2回目の書き込み（上書き）
 	entries2 := []CacheEntry{{Path: "/app/v2/key", StoreMode: "json"}}
-	if err := store.Write("ps", entries2); err != nil {
+	if err := store.Write("ps", "/", entries2); err != nil {
 		t.Fatalf("Write 2: %v", err)
 	}
 
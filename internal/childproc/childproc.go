@@ -0,0 +1,85 @@
+// Package childproc keeps track of the child processes bundr waits for itself (the exec
+// child, backend plugins), so that the PID 1 reaper only collects the orphans it
+// inherits and never takes the exit status another Wait is waiting for.
+package childproc
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+var (
+	mu      sync.Mutex
+	tracked = map[int]bool{}
+)
+
+// Start starts cmd and tracks its pid until Untrack. The reaper does not run in
+// between, so even a child that exits at once is left to its owner.
+func Start(cmd *exec.Cmd) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	tracked[cmd.Process.Pid] = true
+	return nil
+}
+
+// Untrack forgets pid once its owner has waited for it.
+func Untrack(pid int) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(tracked, pid)
+}
+
+// ReapOrphans reaps, without blocking, every exited child of bundr that is not tracked.
+// Each zombie is found in /proc and reaped by pid; without /proc it does nothing.
+func ReapOrphans() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	stats, _ := filepath.Glob("/proc/[0-9]*/stat")
+	self := os.Getpid()
+	for _, path := range stats {
+		pid, ppid, zombie, ok := readStat(path)
+		if !ok || !zombie || ppid != self || tracked[pid] {
+			continue
+		}
+		var ws syscall.WaitStatus
+		for {
+			_, err := syscall.Wait4(pid, &ws, syscall.WNOHANG, nil)
+			if err != syscall.EINTR {
+				break
+			}
+		}
+	}
+}
+
+// readStat parses the pid, parent pid and state of a /proc/<pid>/stat file.
+func readStat(path string) (pid, ppid int, zombie, ok bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, false, false
+	}
+	// "pid (comm) state ppid ..."; comm may contain spaces and parentheses
+	s := string(data)
+	open, end := strings.IndexByte(s, '('), strings.LastIndexByte(s, ')')
+	if open < 0 || end < open {
+		return 0, 0, false, false
+	}
+	fields := strings.Fields(s[end+1:])
+	if len(fields) < 2 {
+		return 0, 0, false, false
+	}
+	pid, err1 := strconv.Atoi(strings.TrimSpace(s[:open]))
+	ppid, err2 := strconv.Atoi(fields[1])
+	if err1 != nil || err2 != nil {
+		return 0, 0, false, false
+	}
+	return pid, ppid, fields[0] == "Z", true
+}
@@ -0,0 +1,56 @@
+package childproc
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitZombie polls until pid has exited without being reaped.
+func waitZombie(t *testing.T, pid int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, _, zombie, ok := readStat("/proc/" + strconv.Itoa(pid) + "/stat"); ok && zombie {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("process %d did not exit", pid)
+}
+
+func TestReapOrphans(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("/proc not available")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	// 追跡中の子は終了しても残し、持ち主の Wait に任せる
+	owned := exec.Command("sh", "-c", "exit 3")
+	if err := Start(owned); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	orphan := exec.Command("sh", "-c", "exit 0")
+	if err := orphan.Start(); err != nil {
+		t.Fatalf("start orphan: %v", err)
+	}
+	waitZombie(t, owned.Process.Pid)
+	waitZombie(t, orphan.Process.Pid)
+
+	ReapOrphans()
+
+	err := owned.Wait()
+	Untrack(owned.Process.Pid)
+	if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 3 {
+		t.Errorf("owned Wait() = %v, want exit status 3", err)
+	}
+	var ws syscall.WaitStatus
+	if _, err := syscall.Wait4(orphan.Process.Pid, &ws, syscall.WNOHANG, nil); err != syscall.ECHILD {
+		t.Errorf("orphan Wait4() error = %v, want ECHILD (already reaped)", err)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -10,6 +11,31 @@ import (
 // Config はアプリケーション全体の設定を保持する。
 type Config struct {
 	AWS AWSConfig `mapstructure:"aws"`
+	// Policies は [[policy]] で宣言された書き込みルール。グローバル設定のルールにプロジェクト設定のルールが追加される。
+	Policies []PolicyRule `mapstructure:"policy"`
+	// Protect は [[protect]] で宣言された書き込み保護。グローバル設定とプロジェクト設定の両方が適用される。
+	Protect []ProtectRule `mapstructure:"protect"`
+	// Schemas は [[schema]] で宣言された値の JSON Schema。グローバル設定とプロジェクト設定の両方が適用される。
+	Schemas []SchemaRule `mapstructure:"schema"`
+	// File は file: バックエンドの鍵の設定。
+	File FileConfig `mapstructure:"file"`
+	// Vault は vault: バックエンドの接続設定。
+	Vault VaultConfig `mapstructure:"vault"`
+}
+
+// VaultConfig は vault: バックエンドの接続設定。トークンは設定ファイルには書かず、
+// VAULT_TOKEN（または ~/.vault-token）で渡す。
+type VaultConfig struct {
+	Address   string `mapstructure:"address"`   // Vault のアドレス（例: https://vault.example.com:8200）
+	Namespace string `mapstructure:"namespace"` // Vault Enterprise の namespace
+	Mount     string `mapstructure:"mount"`     // "/data/" を含まない ref の KV v2 マウント（既定: secret）
+}
+
+// FileConfig は file: バックエンドの鍵の設定。パスフレーズは設定ファイルには書かず、
+// BUNDR_FILE_PASSPHRASE で渡す。
+type FileConfig struct {
+	Identity   string   `mapstructure:"identity"`   // 復号に使う age identity ファイルのパス
+	Recipients []string `mapstructure:"recipients"` // 新しいファイルを暗号化する age 受信者（age1...）
 }
 
 // AWSConfig は AWS 関連の設定を保持する。
@@ -17,6 +43,38 @@ type AWSConfig struct {
 	Region   string `mapstructure:"region"`
 	Profile  string `mapstructure:"profile"`
 	KMSKeyID string `mapstructure:"kms_key_id"`
+	// Regions は複数リージョンを横断するコマンド（find など）の検索対象リージョン。
+	Regions []string `mapstructure:"regions"`
+}
+
+// PolicyRule は Match に一致する ref への書き込みに適用されるルール。
+// 空のフィールドはチェックしない。
+type PolicyRule struct {
+	Name          string   `mapstructure:"name"`
+	Match         string   `mapstructure:"match"`          // ref の glob（例: "ps:/app/prod/**"）
+	RequireSecure bool     `mapstructure:"require_secure"` // Parameter Store では SecureString のみ許可
+	KMSKeyID      string   `mapstructure:"kms_key_id"`     // 暗号化に必須の KMS キー
+	Tiers         []string `mapstructure:"tiers"`          // 許可する tier（Standard, Advanced, Intelligent-Tiering）
+	NamePattern   string   `mapstructure:"name_pattern"`   // パスが一致すべき正規表現
+	MaxSize       int      `mapstructure:"max_size"`       // 値の最大バイト数
+	RequiredTags  []string `mapstructure:"required_tags"`  // 必須タグ（"KEY" または "KEY=VALUE"）
+}
+
+// ProtectRule は書き込みを禁止または確認必須にする保護ルール。
+// Match・Profile・Region のうち指定したものがすべて一致したときに適用される。
+type ProtectRule struct {
+	Name     string `mapstructure:"name"`
+	Match    string `mapstructure:"match"`     // ref の glob（例: "ps:/app/prod/**"）
+	Profile  string `mapstructure:"profile"`   // AWS プロファイル（未設定時は "default"）
+	Region   string `mapstructure:"region"`    // AWS リージョン
+	ReadOnly bool   `mapstructure:"read_only"` // すべての書き込みを拒否する
+	Confirm  bool   `mapstructure:"confirm"`   // 対話的な確認または --yes-i-mean-prod を必須にする
+}
+
+// SchemaRule は Match に一致する ref の値を検証する JSON Schema を指定する。
+type SchemaRule struct {
+	Match  string `mapstructure:"match"`  // ref の glob（例: "ps:/app/*/config"）
+	Schema string `mapstructure:"schema"` // スキーマファイルのパス、またはスキーマを格納した ref（ps:/sm:）
 }
 
 // Load はカレントディレクトリとデフォルトのグローバル設定を読み込む。
@@ -87,6 +145,27 @@ func loadTOML(dir, name string, cfg *Config) error {
 	if fileCfg.AWS.KMSKeyID != "" {
 		cfg.AWS.KMSKeyID = fileCfg.AWS.KMSKeyID
 	}
+	if len(fileCfg.AWS.Regions) > 0 {
+		cfg.AWS.Regions = fileCfg.AWS.Regions
+	}
+	if fileCfg.File.Identity != "" {
+		cfg.File.Identity = fileCfg.File.Identity
+	}
+	if len(fileCfg.File.Recipients) > 0 {
+		cfg.File.Recipients = fileCfg.File.Recipients
+	}
+	if fileCfg.Vault.Address != "" {
+		cfg.Vault.Address = fileCfg.Vault.Address
+	}
+	if fileCfg.Vault.Namespace != "" {
+		cfg.Vault.Namespace = fileCfg.Vault.Namespace
+	}
+	if fileCfg.Vault.Mount != "" {
+		cfg.Vault.Mount = fileCfg.Vault.Mount
+	}
+	cfg.Policies = append(cfg.Policies, fileCfg.Policies...)
+	cfg.Protect = append(cfg.Protect, fileCfg.Protect...)
+	cfg.Schemas = append(cfg.Schemas, fileCfg.Schemas...)
 
 	return nil
 }
@@ -106,7 +185,7 @@ func ApplyCLIOverrides(cfg *Config, region, profile, kmsKeyID string) {
 }
 
 // applyEnvOverrides は環境変数の値で設定をオーバーライドする。
-// 優先順位: BUNDR_* > AWS_*（標準 AWS 環境変数はフォールバック）
+// 優先順位: BUNDR_* > AWS_*（標準 AWS 環境変数はフォールバック）。VAULT_* は設定ファイルより優先。
 func applyEnvOverrides(cfg *Config) {
 	// 1. 標準 AWS 環境変数（フォールバック）
 	if v := os.Getenv("AWS_REGION"); v != "" {
@@ -115,6 +194,12 @@ func applyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("AWS_PROFILE"); v != "" {
 		cfg.AWS.Profile = v
 	}
+	if v := os.Getenv("VAULT_ADDR"); v != "" {
+		cfg.Vault.Address = v
+	}
+	if v := os.Getenv("VAULT_NAMESPACE"); v != "" {
+		cfg.Vault.Namespace = v
+	}
 	// 2. BUNDR_* 環境変数（AWS_* より優先）
 	if v := os.Getenv("BUNDR_AWS_REGION"); v != "" {
 		cfg.AWS.Region = v
@@ -125,4 +210,24 @@ func applyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("BUNDR_AWS_KMS_KEY_ID"); v != "" {
 		cfg.AWS.KMSKeyID = v
 	}
+	if v := os.Getenv("BUNDR_AWS_REGIONS"); v != "" {
+		cfg.AWS.Regions = splitList(v)
+	}
+	if v := os.Getenv("BUNDR_FILE_IDENTITY"); v != "" {
+		cfg.File.Identity = v
+	}
+	if v := os.Getenv("BUNDR_FILE_RECIPIENTS"); v != "" {
+		cfg.File.Recipients = splitList(v)
+	}
+}
+
+// splitList はカンマ区切りの文字列を空要素を除いたスライスに変換する。
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
 }
@@ -371,3 +371,208 @@ func TestAWSStandardEnvVars(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadRegions(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := []byte(`[aws]
+regions = ["us-east-1", "eu-west-1"]
+`)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".bundr.toml"), configContent, 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	t.Setenv("BUNDR_AWS_REGIONS", "")
+
+	cfg, err := LoadFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() returned error: %v", err)
+	}
+	if len(cfg.AWS.Regions) != 2 || cfg.AWS.Regions[0] != "us-east-1" || cfg.AWS.Regions[1] != "eu-west-1" {
+		t.Errorf("unexpected regions: %v", cfg.AWS.Regions)
+	}
+
+	// BUNDR_AWS_REGIONS (カンマ区切り) が設定ファイルより優先
+	t.Setenv("BUNDR_AWS_REGIONS", "ap-northeast-1, us-west-2,")
+	cfg, err = LoadFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() returned error: %v", err)
+	}
+	if len(cfg.AWS.Regions) != 2 || cfg.AWS.Regions[0] != "ap-northeast-1" || cfg.AWS.Regions[1] != "us-west-2" {
+		t.Errorf("unexpected regions from env: %v", cfg.AWS.Regions)
+	}
+}
+
+func TestLoadFileKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := []byte(`[file]
+identity = "keys/dev.txt"
+recipients = ["age1aaa", "age1bbb"]
+`)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".bundr.toml"), configContent, 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	t.Setenv("BUNDR_FILE_IDENTITY", "")
+	t.Setenv("BUNDR_FILE_RECIPIENTS", "")
+
+	cfg, err := LoadFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() returned error: %v", err)
+	}
+	if cfg.File.Identity != "keys/dev.txt" || len(cfg.File.Recipients) != 2 || cfg.File.Recipients[1] != "age1bbb" {
+		t.Errorf("unexpected file keys: %+v", cfg.File)
+	}
+
+	// 環境変数が設定ファイルより優先
+	t.Setenv("BUNDR_FILE_IDENTITY", "/ci/key.txt")
+	t.Setenv("BUNDR_FILE_RECIPIENTS", "age1ccc")
+	cfg, err = LoadFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() returned error: %v", err)
+	}
+	if cfg.File.Identity != "/ci/key.txt" || len(cfg.File.Recipients) != 1 || cfg.File.Recipients[0] != "age1ccc" {
+		t.Errorf("unexpected file keys from env: %+v", cfg.File)
+	}
+}
+
+func TestLoadVault(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := []byte(`[vault]
+address = "https://vault.example.com:8200"
+namespace = "team-a"
+mount = "kv"
+`)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".bundr.toml"), configContent, 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_NAMESPACE", "")
+
+	cfg, err := LoadFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() returned error: %v", err)
+	}
+	want := VaultConfig{Address: "https://vault.example.com:8200", Namespace: "team-a", Mount: "kv"}
+	if cfg.Vault != want {
+		t.Errorf("Vault = %+v, want %+v", cfg.Vault, want)
+	}
+
+	// 環境変数が設定ファイルより優先
+	t.Setenv("VAULT_ADDR", "http://127.0.0.1:8200")
+	t.Setenv("VAULT_NAMESPACE", "team-b")
+	cfg, err = LoadFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() returned error: %v", err)
+	}
+	want = VaultConfig{Address: "http://127.0.0.1:8200", Namespace: "team-b", Mount: "kv"}
+	if cfg.Vault != want {
+		t.Errorf("Vault from env = %+v, want %+v", cfg.Vault, want)
+	}
+}
+
+func TestLoadPolicies(t *testing.T) {
+	globalDir := t.TempDir()
+	projectDir := t.TempDir()
+	globalContent := []byte(`[[policy]]
+match = "ps:/**"
+name_pattern = "^/[a-z0-9_/-]+$"
+`)
+	projectContent := []byte(`[[policy]]
+name = "prod-secrets"
+match = "ps:/app/prod/**"
+require_secure = true
+kms_key_id = "alias/app"
+tiers = ["Standard"]
+max_size = 1024
+required_tags = ["team", "env=prod"]
+`)
+	if err := os.WriteFile(filepath.Join(globalDir, "config.toml"), globalContent, 0644); err != nil {
+		t.Fatalf("failed to write global config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, ".bundr.toml"), projectContent, 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+
+	cfg, err := LoadWithGlobalDir(projectDir, globalDir)
+	if err != nil {
+		t.Fatalf("LoadWithGlobalDir() returned error: %v", err)
+	}
+	// グローバル設定のルールにプロジェクト設定のルールが追加される
+	if len(cfg.Policies) != 2 {
+		t.Fatalf("expected 2 policies, got %d: %+v", len(cfg.Policies), cfg.Policies)
+	}
+	if cfg.Policies[0].Match != "ps:/**" || cfg.Policies[0].NamePattern != "^/[a-z0-9_/-]+$" {
+		t.Errorf("unexpected global policy: %+v", cfg.Policies[0])
+	}
+	p := cfg.Policies[1]
+	if p.Name != "prod-secrets" || !p.RequireSecure || p.KMSKeyID != "alias/app" || p.MaxSize != 1024 {
+		t.Errorf("unexpected project policy: %+v", p)
+	}
+	if len(p.Tiers) != 1 || p.Tiers[0] != "Standard" {
+		t.Errorf("unexpected tiers: %v", p.Tiers)
+	}
+	if len(p.RequiredTags) != 2 || p.RequiredTags[1] != "env=prod" {
+		t.Errorf("unexpected required tags: %v", p.RequiredTags)
+	}
+}
+
+func TestLoadProtect(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := []byte(`[[protect]]
+name = "prod"
+match = "ps:/app/prod/**"
+confirm = true
+
+[[protect]]
+profile = "prod-admin"
+region = "us-west-2"
+read_only = true
+`)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".bundr.toml"), configContent, 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() returned error: %v", err)
+	}
+	if len(cfg.Protect) != 2 {
+		t.Fatalf("expected 2 protect rules, got %d: %+v", len(cfg.Protect), cfg.Protect)
+	}
+	if p := cfg.Protect[0]; p.Name != "prod" || p.Match != "ps:/app/prod/**" || !p.Confirm || p.ReadOnly {
+		t.Errorf("unexpected first rule: %+v", p)
+	}
+	if p := cfg.Protect[1]; p.Profile != "prod-admin" || p.Region != "us-west-2" || !p.ReadOnly {
+		t.Errorf("unexpected second rule: %+v", p)
+	}
+}
+
+func TestLoadSchemas(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := []byte(`[[schema]]
+match = "ps:/app/*/config"
+schema = "schemas/app-config.json"
+
+[[schema]]
+match = "sm:app-*"
+schema = "ps:/schemas/secret"
+`)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".bundr.toml"), configContent, 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() returned error: %v", err)
+	}
+	want := []SchemaRule{
+		{Match: "ps:/app/*/config", Schema: "schemas/app-config.json"},
+		{Match: "sm:app-*", Schema: "ps:/schemas/secret"},
+	}
+	if len(cfg.Schemas) != len(want) {
+		t.Fatalf("expected %d schema rules, got %+v", len(want), cfg.Schemas)
+	}
+	for i := range want {
+		if cfg.Schemas[i] != want[i] {
+			t.Errorf("schema rule %d = %+v, want %+v", i, cfg.Schemas[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package envelope encrypts bundr's local files (backups, file: stores) with a
+// passphrase, age recipients, or both.
+//
+// A sealed file starts with a format line (e.g. "bundr-backup/1"), followed by a
+// one-line JSON header and the encrypted payload:
+//
+//	bundr-backup/1
+//	{"stanzas":[{"type":"scrypt",...},{"type":"age",...}]}
+//	<24-byte nonce><XChaCha20-Poly1305 ciphertext of the gzipped payload>
+//
+// The payload is encrypted with a random file key. Each stanza wraps that key for one
+// way of opening the file: a passphrase (scrypt) or a set of age recipients. The first
+// two lines are authenticated as associated data, so the header cannot be altered.
+package envelope
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// DefaultScryptLogN is the scrypt work factor (N = 2^18) used for passphrases.
+const DefaultScryptLogN = 18
+
+// maxScryptLogN bounds the work factor accepted from a file, so that a crafted header
+// cannot make Open run for minutes.
+const maxScryptLogN = 22
+
+var (
+	// ErrFormat is returned by Open when the file does not start with the expected format line.
+	ErrFormat = errors.New("unrecognized file format")
+	// ErrNoKey is returned by Open when none of the given keys opens the file.
+	ErrNoKey = errors.New("no matching passphrase or identity")
+)
+
+// SealOptions selects who can open a sealed file. At least one of Passphrase and
+// Recipients must be set.
+type SealOptions struct {
+	Passphrase string
+	Recipients []age.Recipient
+	ScryptLogN int // 0 = DefaultScryptLogN
+}
+
+// OpenOptions holds the keys tried by Open.
+type OpenOptions struct {
+	Passphrase string
+	Identities []age.Identity
+}
+
+type header struct {
+	Stanzas []stanza `json:"stanzas"`
+}
+
+// stanza wraps the file key. For "scrypt" Key is the XChaCha20-Poly1305 encryption of
+// the file key under the scrypt-derived key; for "age" it is an age file holding it.
+type stanza struct {
+	Type  string `json:"type"`
+	Salt  []byte `json:"salt,omitempty"`
+	LogN  int    `json:"log_n,omitempty"`
+	Nonce []byte `json:"nonce,omitempty"`
+	Key   []byte `json:"key"`
+}
+
+// Seal writes payload to w as a sealed file of the given format.
+func Seal(w io.Writer, format string, payload []byte, opts SealOptions) error {
+	if opts.Passphrase == "" && len(opts.Recipients) == 0 {
+		return fmt.Errorf("a passphrase or at least one recipient is required")
+	}
+	fileKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return err
+	}
+
+	var h header
+	if opts.Passphrase != "" {
+		s, err := scryptStanza(fileKey, opts.Passphrase, opts.ScryptLogN)
+		if err != nil {
+			return err
+		}
+		h.Stanzas = append(h.Stanzas, s)
+	}
+	if len(opts.Recipients) > 0 {
+		var buf bytes.Buffer
+		aw, err := age.Encrypt(&buf, opts.Recipients...)
+		if err != nil {
+			return fmt.Errorf("age: %w", err)
+		}
+		if _, err := aw.Write(fileKey); err != nil {
+			return err
+		}
+		if err := aw.Close(); err != nil {
+			return err
+		}
+		h.Stanzas = append(h.Stanzas, stanza{Type: "age", Key: buf.Bytes()})
+	}
+
+	var plain bytes.Buffer
+	zw := gzip.NewWriter(&plain)
+	if _, err := zw.Write(payload); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	preamble := format + "\n" + string(headerJSON) + "\n"
+	sealed, err := encrypt(fileKey, plain.Bytes(), []byte(preamble))
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, preamble); err != nil {
+		return err
+	}
+	_, err = w.Write(sealed)
+	return err
+}
+
+// Open reads a sealed file of the given format and returns its payload.
+func Open(r io.Reader, format string, opts OpenOptions) ([]byte, error) {
+	br := bufio.NewReader(r)
+	line, err := br.ReadString('\n')
+	if err != nil || strings.TrimSuffix(line, "\n") != format {
+		return nil, ErrFormat
+	}
+	headerLine, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	var h header
+	if err := json.Unmarshal([]byte(headerLine), &h); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	sealed, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+
+	fileKey, err := unwrap(h, opts)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decrypt(fileKey, sealed, []byte(line+headerLine))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: file is corrupted or was modified")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+// unwrap returns the file key from the first stanza one of the keys opens.
+func unwrap(h header, opts OpenOptions) ([]byte, error) {
+	for _, s := range h.Stanzas {
+		switch {
+		case s.Type == "scrypt" && opts.Passphrase != "":
+			if s.LogN <= 0 || s.LogN > maxScryptLogN {
+				return nil, fmt.Errorf("invalid scrypt work factor %d", s.LogN)
+			}
+			kek, err := scrypt.Key([]byte(opts.Passphrase), s.Salt, 1<<s.LogN, 8, 1, chacha20poly1305.KeySize)
+			if err != nil {
+				return nil, err
+			}
+			aead, err := chacha20poly1305.NewX(kek)
+			if err != nil {
+				return nil, err
+			}
+			if key, err := aead.Open(nil, s.Nonce, s.Key, nil); err == nil {
+				return key, nil
+			}
+		case s.Type == "age" && len(opts.Identities) > 0:
+			ar, err := age.Decrypt(bytes.NewReader(s.Key), opts.Identities...)
+			if err != nil {
+				continue
+			}
+			if key, err := io.ReadAll(ar); err == nil {
+				return key, nil
+			}
+		}
+	}
+	return nil, ErrNoKey
+}
+
+func scryptStanza(fileKey []byte, passphrase string, logN int) (stanza, error) {
+	if logN == 0 {
+		logN = DefaultScryptLogN
+	}
+	s := stanza{Type: "scrypt", LogN: logN, Salt: make([]byte, 16)}
+	if _, err := rand.Read(s.Salt); err != nil {
+		return s, err
+	}
+	kek, err := scrypt.Key([]byte(passphrase), s.Salt, 1<<logN, 8, 1, chacha20poly1305.KeySize)
+	if err != nil {
+		return s, err
+	}
+	sealed, err := encrypt(kek, fileKey, nil)
+	if err != nil {
+		return s, err
+	}
+	s.Nonce, s.Key = sealed[:chacha20poly1305.NonceSizeX], sealed[chacha20poly1305.NonceSizeX:]
+	return s, nil
+}
+
+// encrypt seals plain with XChaCha20-Poly1305 under key and returns nonce||ciphertext.
+func encrypt(key, plain, ad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, ad), nil
+}
+
+// decrypt opens nonce||ciphertext produced by encrypt.
+func decrypt(key, sealed, ad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], ad)
+}
+
+// ParseRecipients parses age recipients (age1... public keys), one per line or
+// argument; blank lines and # comments are ignored.
+func ParseRecipients(lines ...string) ([]age.Recipient, error) {
+	return age.ParseRecipients(strings.NewReader(strings.Join(lines, "\n")))
+}
+
+// ParseIdentities parses an age identity file (AGE-SECRET-KEY-1... lines).
+func ParseIdentities(r io.Reader) ([]age.Identity, error) {
+	return age.ParseIdentities(r)
+}
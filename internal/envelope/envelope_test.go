@@ -0,0 +1,49 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestSealOpen_Format(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	opts := SealOptions{Passphrase: "pw", Recipients: []age.Recipient{id.Recipient()}, ScryptLogN: 10}
+	if err := Seal(&buf, "test-format/1", []byte(`{"k":"v"}`), opts); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	sealed := buf.Bytes()
+
+	tests := []struct {
+		name    string
+		format  string
+		open    OpenOptions
+		want    string
+		wantErr error
+	}{
+		{name: "passphrase", format: "test-format/1", open: OpenOptions{Passphrase: "pw"}, want: `{"k":"v"}`},
+		{name: "identity", format: "test-format/1", open: OpenOptions{Identities: []age.Identity{id}}, want: `{"k":"v"}`},
+		{name: "other format", format: "bundr-backup/1", open: OpenOptions{Passphrase: "pw"}, wantErr: ErrFormat},
+		{name: "no key", format: "test-format/1", open: OpenOptions{}, wantErr: ErrNoKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Open(bytes.NewReader(sealed), tt.format, tt.open)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Open error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Fatalf("Open = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package flatten
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -14,6 +15,7 @@ type Options struct {
 	ArrayJoinDelim string // Delimiter for array join mode (default ",")
 	Upper          bool   // Uppercase key names
 	NoFlatten      bool   // Disable JSON flattening
+	MaxDepth       int    // Objects and arrays nested deeper than this are kept as JSON (0 = unlimited)
 }
 
 // DefaultOptions returns the default flatten options.
@@ -27,24 +29,47 @@ func DefaultOptions() Options {
 	}
 }
 
+// Entry is a single flattened key-value pair.
+type Entry struct {
+	Key   string // flattened, cased key
+	Value string
+	Path  string // JSON path of the value within the document (e.g. "db.host", "hosts[0]"); "" for the root
+}
+
 // Flatten takes a prefix and raw value string and returns a flattened key-value map.
 // If rawValue is not valid JSON, it is treated as a raw string.
+// When two JSON paths produce the same key, the later one in FlattenEntries order wins.
 func Flatten(prefix, rawValue string, opts Options) (map[string]string, error) {
-	result := make(map[string]string)
+	entries, err := FlattenEntries(prefix, rawValue, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(entries))
+	for _, e := range entries {
+		result[e.Key] = e.Value
+	}
+	return result, nil
+}
+
+// FlattenEntries is like Flatten but returns every generated pair in a deterministic
+// order (object keys sorted, array elements in order), including pairs whose keys
+// collide. Callers use it to detect and report collisions.
+func FlattenEntries(prefix, rawValue string, opts Options) ([]Entry, error) {
+	var result []Entry
 
 	if opts.NoFlatten {
-		setKey(result, prefix, rawValue, opts)
+		setKey(&result, prefix, "", rawValue, opts)
 		return result, nil
 	}
 
 	var v interface{}
 	if err := json.Unmarshal([]byte(rawValue), &v); err != nil {
 		// Not valid JSON: treat as raw string
-		setKey(result, prefix, rawValue, opts)
+		setKey(&result, prefix, "", rawValue, opts)
 		return result, nil
 	}
 
-	flattenAny(prefix, v, opts, result)
+	flattenAny(prefix, "", v, 0, opts, &result)
 	return result, nil
 }
 
@@ -58,12 +83,21 @@ func ApplyCasing(key string, opts Options) string {
 }
 
 // flattenAny dispatches to the appropriate handler based on value type.
-func flattenAny(key string, v interface{}, opts Options, result map[string]string) {
+// depth is the nesting level of v (the document root is 0).
+func flattenAny(key, jsonPath string, v interface{}, depth int, opts Options, result *[]Entry) {
 	switch val := v.(type) {
 	case map[string]interface{}:
-		flattenObject(key, val, opts, result)
+		if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+			setKeyJSON(result, key, jsonPath, val, opts)
+			return
+		}
+		flattenObject(key, jsonPath, val, depth, opts, result)
 	case []interface{}:
-		flattenArray(key, val, opts, result)
+		if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+			setKeyJSON(result, key, jsonPath, val, opts)
+			return
+		}
+		flattenArray(key, jsonPath, val, depth, opts, result)
 	case string:
 		// If the string is valid JSON (e.g. a nested array/object encoded as a string),
 		// try to parse and recursively flatten it.
@@ -71,30 +105,35 @@ func flattenAny(key string, v interface{}, opts Options, result map[string]strin
 		if json.Unmarshal([]byte(val), &nested) == nil {
 			// Only recurse if it parsed into a non-string type (object, array, etc.)
 			if _, isStr := nested.(string); !isStr {
-				flattenAny(key, nested, opts, result)
+				flattenAny(key, jsonPath, nested, depth, opts, result)
 				return
 			}
 		}
-		setKey(result, key, val, opts)
+		setKey(result, key, jsonPath, val, opts)
 	case float64:
-		setKey(result, key, formatNumber(val), opts)
+		setKey(result, key, jsonPath, formatNumber(val), opts)
 	case bool:
-		setKey(result, key, strconv.FormatBool(val), opts)
+		setKey(result, key, jsonPath, strconv.FormatBool(val), opts)
 	case nil:
-		setKey(result, key, "", opts)
+		setKey(result, key, jsonPath, "", opts)
 	}
 }
 
-// flattenObject handles JSON object values.
-func flattenObject(key string, obj map[string]interface{}, opts Options, result map[string]string) {
-	for k, v := range obj {
+// flattenObject handles JSON object values. Keys are visited in sorted order.
+func flattenObject(key, jsonPath string, obj map[string]interface{}, depth int, opts Options, result *[]Entry) {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
 		childKey := joinKey(key, k, opts.Delimiter)
-		flattenAny(childKey, v, opts, result)
+		flattenAny(childKey, joinKey(jsonPath, k, "."), obj[k], depth+1, opts, result)
 	}
 }
 
 // flattenArray handles JSON array values.
-func flattenArray(key string, arr []interface{}, opts Options, result map[string]string) {
+func flattenArray(key, jsonPath string, arr []interface{}, depth int, opts Options, result *[]Entry) {
 	if len(arr) == 0 {
 		return
 	}
@@ -104,30 +143,30 @@ func flattenArray(key string, arr []interface{}, opts Options, result map[string
 		raw, err := json.Marshal(arr)
 		if err != nil {
 			// Fallback to index mode on marshal error (should not happen)
-			flattenArrayByIndex(key, arr, opts, result)
+			flattenArrayByIndex(key, jsonPath, arr, depth, opts, result)
 			return
 		}
-		setKey(result, key, string(raw), opts)
+		setKey(result, key, jsonPath, string(raw), opts)
 	case "join":
 		strs, ok := tryJoinStrings(arr)
 		if ok {
-			setKey(result, key, strings.Join(strs, opts.ArrayJoinDelim), opts)
+			setKey(result, key, jsonPath, strings.Join(strs, opts.ArrayJoinDelim), opts)
 		} else {
 			// Fallback to index mode
-			flattenArrayByIndex(key, arr, opts, result)
+			flattenArrayByIndex(key, jsonPath, arr, depth, opts, result)
 		}
 	case "index":
-		flattenArrayByIndex(key, arr, opts, result)
+		flattenArrayByIndex(key, jsonPath, arr, depth, opts, result)
 	default:
-		flattenArrayByIndex(key, arr, opts, result)
+		flattenArrayByIndex(key, jsonPath, arr, depth, opts, result)
 	}
 }
 
 // flattenArrayByIndex expands each array element with an index suffix.
-func flattenArrayByIndex(key string, arr []interface{}, opts Options, result map[string]string) {
+func flattenArrayByIndex(key, jsonPath string, arr []interface{}, depth int, opts Options, result *[]Entry) {
 	for i, elem := range arr {
 		childKey := joinKey(key, strconv.Itoa(i), opts.Delimiter)
-		flattenAny(childKey, elem, opts, result)
+		flattenAny(childKey, jsonPath+"["+strconv.Itoa(i)+"]", elem, depth+1, opts, result)
 	}
 }
 
@@ -158,10 +197,18 @@ func joinKey(prefix, suffix, delimiter string) string {
 	return prefix + delimiter + suffix
 }
 
-// setKey applies casing and stores the key-value pair in the result map.
-func setKey(result map[string]string, key, value string, opts Options) {
-	normalizedKey := ApplyCasing(key, opts)
-	result[normalizedKey] = value
+// setKey applies casing and appends the key-value pair to result.
+func setKey(result *[]Entry, key, jsonPath, value string, opts Options) {
+	*result = append(*result, Entry{Key: ApplyCasing(key, opts), Value: value, Path: jsonPath})
+}
+
+// setKeyJSON stores v re-encoded as compact JSON (used beyond MaxDepth).
+func setKeyJSON(result *[]Entry, key, jsonPath string, v interface{}, opts Options) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	setKey(result, key, jsonPath, string(raw), opts)
 }
 
 // formatNumber converts a float64 to a string, preferring integer format when possible.
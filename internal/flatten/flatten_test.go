@@ -358,6 +358,29 @@ func TestFlatten(t *testing.T) {
 			},
 			want: map[string]string{"A_0": "true", "A_1": "false"},
 		},
+		// --- MaxDepth ---
+		{
+			id:     "F-DEPTH-01",
+			prefix: "APP",
+			raw:    `{"db":{"host":"h","opts":{"ssl":true}},"name":"x"}`,
+			opts: func() flatten.Options {
+				o := flatten.DefaultOptions()
+				o.MaxDepth = 1
+				return o
+			}(),
+			want: map[string]string{"APP_DB": `{"host":"h","opts":{"ssl":true}}`, "APP_NAME": "x"},
+		},
+		{
+			id:     "F-DEPTH-02",
+			prefix: "APP",
+			raw:    `{"db":{"host":"h","opts":{"ssl":true}},"ports":[[1,2]]}`,
+			opts: func() flatten.Options {
+				o := flatten.DefaultOptions()
+				o.MaxDepth = 2
+				return o
+			}(),
+			want: map[string]string{"APP_DB_HOST": "h", "APP_DB_OPTS": `{"ssl":true}`, "APP_PORTS_0": "[1,2]"},
+		},
 	}
 
 	for _, tc := range tests {
@@ -438,3 +461,32 @@ func TestApplyCasing(t *testing.T) {
 		})
 	}
 }
+
+func TestFlattenEntries(t *testing.T) {
+	raw := `{"db":{"host":"h"},"db_host":"x","hosts":["a",{"b":1}]}`
+	got, err := flatten.FlattenEntries("CFG", raw, flatten.DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []flatten.Entry{
+		{Key: "CFG_DB_HOST", Value: "h", Path: "db.host"},
+		{Key: "CFG_DB_HOST", Value: "x", Path: "db_host"},
+		{Key: "CFG_HOSTS_0", Value: "a", Path: "hosts[0]"},
+		{Key: "CFG_HOSTS_1_B", Value: "1", Path: "hosts[1].b"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Flatten keeps the last entry for a colliding key.
+	m, _ := flatten.Flatten("CFG", raw, flatten.DefaultOptions())
+	if m["CFG_DB_HOST"] != "x" {
+		t.Errorf("Flatten CFG_DB_HOST = %q, want %q", m["CFG_DB_HOST"], "x")
+	}
+}
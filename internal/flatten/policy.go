@@ -0,0 +1,102 @@
+package flatten
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PolicyNoFlatten is the policy value that disables flattening for a parameter.
+const PolicyNoFlatten = "no"
+
+// Policy is a per-parameter flatten policy stored in the cli-flatten tag.
+// Zero fields are unset and leave the command-line option unchanged.
+//
+// The tag value is either "no" or space-separated settings, e.g.
+// "array=index depth=1 delim=__". Commas are also accepted as separators
+// on input, but tag values written by bundr use spaces because AWS tag
+// values do not allow commas.
+type Policy struct {
+	NoFlatten bool
+	ArrayMode string // "join", "index", or "json"
+	Delimiter string
+	MaxDepth  int
+}
+
+// ParsePolicy parses a cli-flatten tag value. An empty string yields the zero Policy.
+func ParsePolicy(s string) (Policy, error) {
+	var p Policy
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, f := range fields {
+		if f == PolicyNoFlatten {
+			p.NoFlatten = true
+			continue
+		}
+		key, val, ok := strings.Cut(f, "=")
+		if !ok || val == "" {
+			return Policy{}, fmt.Errorf("invalid flatten policy %q: expected \"no\" or key=value", f)
+		}
+		switch key {
+		case "array":
+			switch val {
+			case "join", "index", "json":
+				p.ArrayMode = val
+			default:
+				return Policy{}, fmt.Errorf("invalid flatten policy %q: array must be join, index or json", f)
+			}
+		case "depth":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return Policy{}, fmt.Errorf("invalid flatten policy %q: depth must be a positive integer", f)
+			}
+			p.MaxDepth = n
+		case "delim":
+			// 区切り文字はそのまま環境変数名に入るため、名前に使える文字だけ許す
+			if strings.Trim(val, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_") != "" {
+				return Policy{}, fmt.Errorf("invalid flatten policy %q: delim may only contain letters, digits and _", f)
+			}
+			p.Delimiter = val
+		default:
+			return Policy{}, fmt.Errorf("invalid flatten policy %q: unknown key %q", f, key)
+		}
+	}
+	if p.NoFlatten && (p.ArrayMode != "" || p.Delimiter != "" || p.MaxDepth != 0) {
+		return Policy{}, fmt.Errorf("invalid flatten policy %q: \"no\" cannot be combined with other settings", s)
+	}
+	return p, nil
+}
+
+// String returns the canonical tag value for the policy ("" for the zero Policy).
+func (p Policy) String() string {
+	if p.NoFlatten {
+		return PolicyNoFlatten
+	}
+	var parts []string
+	if p.ArrayMode != "" {
+		parts = append(parts, "array="+p.ArrayMode)
+	}
+	if p.MaxDepth > 0 {
+		parts = append(parts, "depth="+strconv.Itoa(p.MaxDepth))
+	}
+	if p.Delimiter != "" {
+		parts = append(parts, "delim="+p.Delimiter)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Apply returns opts with the fields set by the policy overridden.
+func (p Policy) Apply(opts Options) Options {
+	if p.NoFlatten {
+		opts.NoFlatten = true
+	}
+	if p.ArrayMode != "" {
+		opts.ArrayMode = p.ArrayMode
+	}
+	if p.Delimiter != "" {
+		opts.Delimiter = p.Delimiter
+	}
+	if p.MaxDepth > 0 {
+		opts.MaxDepth = p.MaxDepth
+	}
+	return opts
+}
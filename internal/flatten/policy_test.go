@@ -0,0 +1,73 @@
+package flatten_test
+
+import (
+	"testing"
+
+	"github.com/youyo/bundr/internal/flatten"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		id      string
+		input   string
+		want    flatten.Policy
+		wantStr string
+		wantErr bool
+	}{
+		{id: "P-01", input: "", want: flatten.Policy{}, wantStr: ""},
+		{id: "P-02", input: "no", want: flatten.Policy{NoFlatten: true}, wantStr: "no"},
+		{id: "P-03", input: "array=index", want: flatten.Policy{ArrayMode: "index"}, wantStr: "array=index"},
+		{id: "P-04", input: "delim=__ depth=1 array=json", want: flatten.Policy{ArrayMode: "json", Delimiter: "__", MaxDepth: 1}, wantStr: "array=json depth=1 delim=__"},
+		{id: "P-05", input: "array=join,depth=2", want: flatten.Policy{ArrayMode: "join", MaxDepth: 2}, wantStr: "array=join depth=2"},
+		// --- Error cases ---
+		{id: "P-ERR-01", input: "array=zip", wantErr: true},
+		{id: "P-ERR-02", input: "depth=0", wantErr: true},
+		{id: "P-ERR-03", input: "depth=abc", wantErr: true},
+		{id: "P-ERR-04", input: "color=red", wantErr: true},
+		{id: "P-ERR-05", input: "no array=index", wantErr: true},
+		{id: "P-ERR-06", input: "delim=;", wantErr: true},
+		{id: "P-ERR-07", input: "yes", wantErr: true},
+		// 区切り文字は環境変数名に使える文字だけ
+		{id: "P-ERR-08", input: "delim==", wantErr: true},
+		{id: "P-ERR-09", input: "delim=/", wantErr: true},
+		{id: "P-ERR-10", input: "delim=.", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			got, err := flatten.ParsePolicy(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("ParsePolicy() = %+v, want %+v", got, tc.want)
+			}
+			if got.String() != tc.wantStr {
+				t.Errorf("String() = %q, want %q", got.String(), tc.wantStr)
+			}
+		})
+	}
+}
+
+func TestPolicyApply(t *testing.T) {
+	base := flatten.DefaultOptions()
+
+	got := flatten.Policy{ArrayMode: "index", MaxDepth: 2}.Apply(base)
+	if got.ArrayMode != "index" || got.MaxDepth != 2 {
+		t.Errorf("Apply() = %+v, want ArrayMode=index MaxDepth=2", got)
+	}
+	// Unset fields keep the command-line value.
+	if got.Delimiter != base.Delimiter || got.ArrayJoinDelim != base.ArrayJoinDelim || got.Upper != base.Upper {
+		t.Errorf("Apply() changed unset fields: %+v", got)
+	}
+
+	if got := (flatten.Policy{NoFlatten: true}).Apply(base); !got.NoFlatten {
+		t.Errorf("Apply(no) NoFlatten = false, want true")
+	}
+}
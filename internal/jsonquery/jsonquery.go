@@ -0,0 +1,290 @@
+// Package jsonquery implements a small jq/JSONPath subset for extracting fields
+// from JSON values.
+//
+// Supported syntax:
+//
+//	.            the whole document
+//	.a.b         object keys (the leading "." or "$." is optional: "a.b")
+//	.["a.b"]     quoted keys (single or double quotes)
+//	.a[0]        array index; negative indexes count from the end
+//	.a[] / .a[*] every element of an array (or every value of an object)
+package jsonquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepIndex
+	stepIterate
+)
+
+type step struct {
+	kind  stepKind
+	key   string
+	index int
+}
+
+// Query is a parsed query expression.
+type Query struct {
+	expr  string
+	steps []step
+}
+
+// Parse parses a query expression.
+func Parse(expr string) (Query, error) {
+	q := Query{expr: expr}
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	if s == "" || s == "." {
+		return q, nil
+	}
+
+	i := 0
+	// A leading bare key ("db.host") is accepted as if written ".db.host".
+	if s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+	for i < len(s) {
+		switch s[i] {
+		case '.':
+			i++
+			if i < len(s) && s[i] == '[' {
+				continue
+			}
+			start := i
+			for i < len(s) && s[i] != '.' && s[i] != '[' {
+				i++
+			}
+			if start == i {
+				return Query{}, fmt.Errorf("invalid query %q: empty key at offset %d", expr, start)
+			}
+			key := s[start:i]
+			if key == "*" {
+				q.steps = append(q.steps, step{kind: stepIterate})
+			} else {
+				q.steps = append(q.steps, step{kind: stepKey, key: key})
+			}
+		case '[':
+			end, st, err := parseBracket(s, i)
+			if err != nil {
+				return Query{}, fmt.Errorf("invalid query %q: %w", expr, err)
+			}
+			q.steps = append(q.steps, st)
+			i = end
+		default:
+			return Query{}, fmt.Errorf("invalid query %q: unexpected %q at offset %d", expr, s[i], i)
+		}
+	}
+	return q, nil
+}
+
+// parseBracket parses the bracket expression starting at s[i] == '[' and returns
+// the offset just past the closing ']'.
+func parseBracket(s string, i int) (int, step, error) {
+	i++ // skip '['
+	if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+		quote := s[i]
+		var key strings.Builder
+		i++
+		for i < len(s) && s[i] != quote {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			key.WriteByte(s[i])
+			i++
+		}
+		if i+1 >= len(s) || s[i+1] != ']' {
+			return 0, step{}, fmt.Errorf("unterminated quoted key")
+		}
+		return i + 2, step{kind: stepKey, key: key.String()}, nil
+	}
+
+	end := strings.IndexByte(s[i:], ']')
+	if end < 0 {
+		return 0, step{}, fmt.Errorf("missing ]")
+	}
+	inner := strings.TrimSpace(s[i : i+end])
+	next := i + end + 1
+	if inner == "" || inner == "*" {
+		return next, step{kind: stepIterate}, nil
+	}
+	n, err := strconv.Atoi(inner)
+	if err != nil {
+		return 0, step{}, fmt.Errorf("invalid index %q", inner)
+	}
+	return next, step{kind: stepIndex, index: n}, nil
+}
+
+// Key returns a query selecting the top-level key name literally (dots and
+// brackets in name are not interpreted).
+func Key(name string) Query {
+	return Query{expr: name, steps: []step{{kind: stepKey, key: name}}}
+}
+
+// String returns the expression the query was parsed from.
+func (q Query) String() string {
+	return q.expr
+}
+
+// Name returns the last key selected by the query, or "" when the query does not
+// end in a key (e.g. ".", ".hosts[0]"). Callers use it to name extracted values.
+func (q Query) Name() string {
+	if len(q.steps) == 0 {
+		return ""
+	}
+	last := q.steps[len(q.steps)-1]
+	if last.kind != stepKey {
+		return ""
+	}
+	return last.key
+}
+
+// Eval applies the query to a decoded JSON document and returns every result.
+// A missing key or an out-of-range index is an error.
+func (q Query) Eval(doc any) ([]any, error) {
+	current := []any{doc}
+	for _, st := range q.steps {
+		var next []any
+		for _, v := range current {
+			results, err := st.apply(v)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, results...)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+func (st step) apply(v any) ([]any, error) {
+	switch st.kind {
+	case stepKey:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot select key %q from %s", st.key, typeName(v))
+		}
+		child, ok := obj[st.key]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", st.key)
+		}
+		return []any{child}, nil
+	case stepIndex:
+		arr, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot index %s with [%d]", typeName(v), st.index)
+		}
+		idx := st.index
+		if idx < 0 {
+			idx += len(arr)
+		}
+		if idx < 0 || idx >= len(arr) {
+			return nil, fmt.Errorf("index [%d] out of range (length %d)", st.index, len(arr))
+		}
+		return []any{arr[idx]}, nil
+	default:
+		switch val := v.(type) {
+		case []any:
+			return val, nil
+		case map[string]any:
+			keys := Keys(val)
+			out := make([]any, 0, len(keys))
+			for _, k := range keys {
+				out = append(out, val[k])
+			}
+			return out, nil
+		default:
+			return nil, fmt.Errorf("cannot iterate over %s", typeName(v))
+		}
+	}
+}
+
+// Decode parses a JSON document, keeping numbers as json.Number so that they are
+// printed exactly as stored.
+func Decode(raw string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("value is not valid JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("value is not valid JSON: trailing data")
+	}
+	return v, nil
+}
+
+// Extract decodes raw as JSON, applies q and formats the results one per line.
+// Strings are printed without quotes (like jq --raw-output); everything else is
+// printed as compact JSON.
+func Extract(raw string, q Query) (string, error) {
+	doc, err := Decode(raw)
+	if err != nil {
+		return "", err
+	}
+	results, err := q.Eval(doc)
+	if err != nil {
+		return "", err
+	}
+	lines := make([]string, 0, len(results))
+	for _, r := range results {
+		s, err := Format(r)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, s)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Format renders a single result with --raw-output semantics.
+func Format(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("json encode: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// Keys returns the keys of a JSON object in sorted order.
+func Keys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
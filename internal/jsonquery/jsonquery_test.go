@@ -0,0 +1,100 @@
+package jsonquery
+
+import (
+	"testing"
+)
+
+const testDoc = `{
+	"password": "p@ss",
+	"port": 5432,
+	"ratio": 0.25,
+	"enabled": true,
+	"db.host": "dotted",
+	"replicas": [{"host": "r1"}, {"host": "r2"}],
+	"nested": {"a": {"b": null}}
+}`
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		id      string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{id: "Q-01", expr: ".password", want: "p@ss"},
+		{id: "Q-02", expr: "password", want: "p@ss"},
+		{id: "Q-03", expr: "$.password", want: "p@ss"},
+		{id: "Q-04", expr: ".port", want: "5432"},
+		{id: "Q-05", expr: ".ratio", want: "0.25"},
+		{id: "Q-06", expr: ".enabled", want: "true"},
+		{id: "Q-07", expr: `.["db.host"]`, want: "dotted"},
+		{id: "Q-08", expr: `.['db.host']`, want: "dotted"},
+		{id: "Q-09", expr: ".replicas[0].host", want: "r1"},
+		{id: "Q-10", expr: "replicas[-1].host", want: "r2"},
+		{id: "Q-11", expr: ".replicas[].host", want: "r1\nr2"},
+		{id: "Q-12", expr: ".replicas[*].host", want: "r1\nr2"},
+		{id: "Q-13", expr: ".replicas[0]", want: `{"host":"r1"}`},
+		{id: "Q-14", expr: ".nested.a.b", want: "null"},
+		{id: "Q-15", expr: ".nested.a", want: `{"b":null}`},
+		{id: "Q-16", expr: ".nested.*", want: `{"b":null}`},
+		// --- Error cases ---
+		{id: "Q-ERR-01", expr: ".missing", wantErr: true},
+		{id: "Q-ERR-02", expr: ".replicas[5]", wantErr: true},
+		{id: "Q-ERR-03", expr: ".password.x", wantErr: true},
+		{id: "Q-ERR-04", expr: ".port[]", wantErr: true},
+		{id: "Q-ERR-05", expr: ".replicas[x]", wantErr: true},
+		{id: "Q-ERR-06", expr: ".a..b", wantErr: true},
+		{id: "Q-ERR-07", expr: `.["open`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			q, err := Parse(tc.expr)
+			if err == nil {
+				var got string
+				got, err = Extract(testDoc, q)
+				if err == nil && got != tc.want {
+					t.Errorf("Extract(%q) = %q, want %q", tc.expr, got, tc.want)
+				}
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtract_NotJSON(t *testing.T) {
+	if _, err := Extract("plain text", Key("a")); err == nil {
+		t.Error("expected error for non-JSON value, got nil")
+	}
+}
+
+func TestKey(t *testing.T) {
+	got, err := Extract(testDoc, Key("db.host"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "dotted" {
+		t.Errorf("Extract(Key) = %q, want %q", got, "dotted")
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	tests := map[string]string{
+		".":                 "",
+		"password":          "password",
+		".replicas[0].host": "host",
+		".replicas[0]":      "",
+		`.["db.host"]`:      "db.host",
+	}
+	for expr, want := range tests {
+		q, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", expr, err)
+		}
+		if got := q.Name(); got != want {
+			t.Errorf("Parse(%q).Name() = %q, want %q", expr, got, want)
+		}
+	}
+}
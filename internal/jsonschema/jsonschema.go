@@ -0,0 +1,569 @@
+// Package jsonschema validates JSON values against a JSON Schema.
+//
+// It implements the validation keywords that describe the shape of configuration
+// values (drafts 7 to 2020-12):
+//
+//	type, enum, const
+//	properties, required, additionalProperties, minProperties, maxProperties
+//	items, minItems, maxItems, uniqueItems
+//	minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf
+//	minLength, maxLength, pattern
+//	allOf, anyOf, oneOf, not
+//	$ref to the root ("#") or to "#/$defs/..." and "#/definitions/..."
+//
+// Compile rejects schemas that use other assertion keywords (patternProperties,
+// if/then/else, contains, ...), so that a value is never accepted because a constraint
+// was silently skipped. Annotations (title, description, default, format, ...) and
+// unknown keywords are ignored, as the specification allows.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRefDepth bounds $ref chains that do not descend into the value (e.g. "a": {"$ref": "#/$defs/a"}).
+const maxRefDepth = 64
+
+// unsupportedKeywords are the assertion and applicator keywords of drafts 7 to 2020-12
+// that Validate does not implement.
+var unsupportedKeywords = []string{
+	"patternProperties", "propertyNames", "dependencies", "dependentRequired", "dependentSchemas",
+	"if", "then", "else", "prefixItems", "additionalItems", "contains", "minContains", "maxContains",
+	"unevaluatedProperties", "unevaluatedItems", "$dynamicRef", "$recursiveRef",
+}
+
+var knownTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// Schema is a compiled schema.
+type Schema struct {
+	root     any // bool or map[string]any
+	patterns map[string]*regexp.Regexp
+}
+
+// Compile parses and checks a schema document.
+func Compile(data []byte) (*Schema, error) {
+	root, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	s := &Schema{root: root, patterns: map[string]*regexp.Regexp{}}
+	if err := s.check(root, "#"); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return s, nil
+}
+
+// Decode parses a JSON document, keeping numbers as json.Number.
+func Decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+// FieldError is one failed keyword. Path is a JSON Pointer to the value ("" = the whole value).
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) String() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// ValidationError lists every keyword a value failed.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks a decoded value (see Decode). It returns a *ValidationError when the
+// value does not match.
+func (s *Schema) Validate(v any) error {
+	var errs []FieldError
+	s.validate(s.root, v, "", 0, &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// check verifies the structure of a schema node so that validation cannot fail on it.
+func (s *Schema) check(node any, loc string) error {
+	if _, ok := node.(bool); ok {
+		return nil
+	}
+	m, ok := node.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: a schema must be an object or a boolean", loc)
+	}
+	for _, kw := range unsupportedKeywords {
+		if _, ok := m[kw]; ok {
+			return fmt.Errorf("%s/%s: keyword is not supported", loc, kw)
+		}
+	}
+
+	for _, kw := range []string{"properties", "$defs", "definitions"} {
+		if raw, ok := m[kw]; ok {
+			props, ok := raw.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s/%s: must be an object", loc, kw)
+			}
+			for name, sub := range props {
+				if err := s.check(sub, loc+"/"+kw+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, kw := range []string{"additionalProperties", "items", "not"} {
+		if sub, ok := m[kw]; ok {
+			if _, isArray := sub.([]any); isArray && kw == "items" {
+				return fmt.Errorf("%s/items: the array (tuple) form is not supported", loc)
+			}
+			if err := s.check(sub, loc+"/"+kw); err != nil {
+				return err
+			}
+		}
+	}
+	for _, kw := range []string{"allOf", "anyOf", "oneOf"} {
+		if raw, ok := m[kw]; ok {
+			list, ok := raw.([]any)
+			if !ok || len(list) == 0 {
+				return fmt.Errorf("%s/%s: must be a non-empty array", loc, kw)
+			}
+			for i, sub := range list {
+				if err := s.check(sub, fmt.Sprintf("%s/%s/%d", loc, kw, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if raw, ok := m["type"]; ok {
+		types, ok := typeList(raw)
+		if !ok {
+			return fmt.Errorf("%s/type: must be a type name or an array of type names", loc)
+		}
+		for _, t := range types {
+			if !knownTypes[t] {
+				return fmt.Errorf("%s/type: unknown type %q", loc, t)
+			}
+		}
+	}
+	if raw, ok := m["required"]; ok {
+		list, ok := raw.([]any)
+		if !ok {
+			return fmt.Errorf("%s/required: must be an array of strings", loc)
+		}
+		for _, r := range list {
+			if _, ok := r.(string); !ok {
+				return fmt.Errorf("%s/required: must be an array of strings", loc)
+			}
+		}
+	}
+	if raw, ok := m["enum"]; ok {
+		if _, ok := raw.([]any); !ok {
+			return fmt.Errorf("%s/enum: must be an array", loc)
+		}
+	}
+	for _, kw := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+		"minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties"} {
+		if raw, ok := m[kw]; ok {
+			if _, ok := number(raw); !ok {
+				return fmt.Errorf("%s/%s: must be a number", loc, kw)
+			}
+		}
+	}
+	if raw, ok := m["pattern"]; ok {
+		p, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("%s/pattern: must be a string", loc)
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("%s/pattern: %w", loc, err)
+		}
+		s.patterns[p] = re
+	}
+	if raw, ok := m["$ref"]; ok {
+		ref, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("%s/$ref: must be a string", loc)
+		}
+		if _, err := s.resolve(ref); err != nil {
+			return fmt.Errorf("%s/$ref: %w", loc, err)
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema node a local $ref points to.
+func (s *Schema) resolve(ref string) (any, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only local references (#/...) are supported: %q", ref)
+	}
+	node := s.root
+	for _, tok := range strings.Split(ref[2:], "/") {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot resolve %q", ref)
+		}
+		if node, ok = m[tok]; !ok {
+			return nil, fmt.Errorf("cannot resolve %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func (s *Schema) validate(node, v any, path string, depth int, errs *[]FieldError) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if b, ok := node.(bool); ok {
+		if !b {
+			fail("no value is allowed here")
+		}
+		return
+	}
+	m := node.(map[string]any)
+
+	if raw, ok := m["$ref"]; ok {
+		if depth >= maxRefDepth {
+			fail("$ref nesting is too deep")
+			return
+		}
+		target, _ := s.resolve(raw.(string))
+		s.validate(target, v, path, depth+1, errs)
+	}
+
+	if raw, ok := m["type"]; ok {
+		types, _ := typeList(raw)
+		matched := false
+		for _, t := range types {
+			if hasType(v, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("expected %s, got %s", strings.Join(types, " or "), typeOf(v))
+			return
+		}
+	}
+	if raw, ok := m["enum"]; ok {
+		found := false
+		for _, e := range raw.([]any) {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %s", canonical(raw))
+		}
+	}
+	if raw, ok := m["const"]; ok && !equal(raw, v) {
+		fail("must be %s", canonical(raw))
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		s.validateObject(m, val, path, depth, errs)
+	case []any:
+		s.validateArray(m, val, path, depth, errs)
+	case string:
+		n := float64(utf8.RuneCountInString(val))
+		if limit, ok := keyword(m, "minLength"); ok && n < limit {
+			fail("must be at least %s characters long", format(limit))
+		}
+		if limit, ok := keyword(m, "maxLength"); ok && n > limit {
+			fail("must be at most %s characters long", format(limit))
+		}
+		if p, ok := m["pattern"].(string); ok && !s.patterns[p].MatchString(val) {
+			fail("must match pattern %s", p)
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if limit, ok := keyword(m, "minimum"); ok && f < limit {
+			fail("must be >= %s", format(limit))
+		}
+		if limit, ok := keyword(m, "maximum"); ok && f > limit {
+			fail("must be <= %s", format(limit))
+		}
+		if limit, ok := keyword(m, "exclusiveMinimum"); ok && f <= limit {
+			fail("must be > %s", format(limit))
+		}
+		if limit, ok := keyword(m, "exclusiveMaximum"); ok && f >= limit {
+			fail("must be < %s", format(limit))
+		}
+		if d, ok := keyword(m, "multipleOf"); ok && d > 0 {
+			if q := f / d; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("must be a multiple of %s", format(d))
+			}
+		}
+	}
+
+	if raw, ok := m["allOf"]; ok {
+		for _, sub := range raw.([]any) {
+			s.validate(sub, v, path, depth, errs)
+		}
+	}
+	if raw, ok := m["anyOf"]; ok {
+		matched := false
+		for _, sub := range raw.([]any) {
+			if s.matches(sub, v, path, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("does not match any of the anyOf schemas")
+		}
+	}
+	if raw, ok := m["oneOf"]; ok {
+		n := 0
+		for _, sub := range raw.([]any) {
+			if s.matches(sub, v, path, depth) {
+				n++
+			}
+		}
+		if n != 1 {
+			fail("must match exactly one of the oneOf schemas (matches %d)", n)
+		}
+	}
+	if sub, ok := m["not"]; ok && s.matches(sub, v, path, depth) {
+		fail("must not match the not schema")
+	}
+}
+
+func (s *Schema) validateObject(m map[string]any, obj map[string]any, path string, depth int, errs *[]FieldError) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if raw, ok := m["required"]; ok {
+		var missing []string
+		for _, r := range raw.([]any) {
+			if _, ok := obj[r.(string)]; !ok {
+				missing = append(missing, r.(string))
+			}
+		}
+		if len(missing) > 0 {
+			fail("missing required properties: %s", strings.Join(missing, ", "))
+		}
+	}
+	n := float64(len(obj))
+	if limit, ok := keyword(m, "minProperties"); ok && n < limit {
+		fail("must have at least %s properties", format(limit))
+	}
+	if limit, ok := keyword(m, "maxProperties"); ok && n > limit {
+		fail("must have at most %s properties", format(limit))
+	}
+
+	props, _ := m["properties"].(map[string]any)
+	additional, hasAdditional := m["additionalProperties"]
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		child := path + "/" + escapePointer(k)
+		if sub, ok := props[k]; ok {
+			s.validate(sub, obj[k], child, depth, errs)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if b, ok := additional.(bool); ok && !b {
+			*errs = append(*errs, FieldError{Path: child, Message: "additional property is not allowed"})
+			continue
+		}
+		s.validate(additional, obj[k], child, depth, errs)
+	}
+}
+
+func (s *Schema) validateArray(m map[string]any, arr []any, path string, depth int, errs *[]FieldError) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	n := float64(len(arr))
+	if limit, ok := keyword(m, "minItems"); ok && n < limit {
+		fail("must have at least %s items", format(limit))
+	}
+	if limit, ok := keyword(m, "maxItems"); ok && n > limit {
+		fail("must have at most %s items", format(limit))
+	}
+	if unique, _ := m["uniqueItems"].(bool); unique {
+		seen := map[string]bool{}
+		for _, item := range arr {
+			c := canonical(item)
+			if seen[c] {
+				fail("items must be unique (%s is repeated)", c)
+				break
+			}
+			seen[c] = true
+		}
+	}
+	if sub, ok := m["items"]; ok {
+		for i, item := range arr {
+			s.validate(sub, item, path+"/"+strconv.Itoa(i), depth, errs)
+		}
+	}
+}
+
+// matches reports whether v is valid against node, discarding the errors.
+func (s *Schema) matches(node, v any, path string, depth int) bool {
+	var errs []FieldError
+	s.validate(node, v, path, depth, &errs)
+	return len(errs) == 0
+}
+
+func typeList(raw any) ([]string, bool) {
+	switch t := raw.(type) {
+	case string:
+		return []string{t}, true
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, len(out) > 0
+	}
+	return nil, false
+}
+
+func hasType(v any, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	}
+	return typeOf(v) == t
+}
+
+func typeOf(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if hasType(val, "integer") {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func keyword(m map[string]any, name string) (float64, bool) {
+	raw, ok := m[name]
+	if !ok {
+		return 0, false
+	}
+	return number(raw)
+}
+
+func number(raw any) (float64, bool) {
+	n, ok := raw.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func format(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// equal compares two decoded values by their canonical JSON.
+func equal(a, b any) bool {
+	return canonical(a) == canonical(b)
+}
+
+// canonical returns compact JSON with sorted keys and normalized numbers.
+func canonical(v any) string {
+	switch val := v.(type) {
+	case json.Number:
+		if f, err := val.Float64(); err == nil {
+			return format(f)
+		}
+		return val.String()
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			kb, _ := json.Marshal(k)
+			parts[i] = string(kb) + ":" + canonical(val[k])
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	case []any:
+		parts := make([]string, len(val))
+		for i, e := range val {
+			parts[i] = canonical(e)
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
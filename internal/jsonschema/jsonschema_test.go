@@ -0,0 +1,124 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+const appSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["db", "replicas"],
+	"additionalProperties": false,
+	"properties": {
+		"db": {"$ref": "#/$defs/db"},
+		"replicas": {"type": "integer", "minimum": 1, "maximum": 10},
+		"env": {"enum": ["dev", "staging", "prod"]},
+		"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "uniqueItems": true, "maxItems": 3},
+		"ratio": {"type": "number", "exclusiveMaximum": 1, "multipleOf": 0.25},
+		"owner": {"type": ["string", "null"], "minLength": 2},
+		"mode": {"oneOf": [{"const": "a"}, {"const": "b"}]},
+		"note": {"not": {"type": "number"}, "format": "anything"}
+	},
+	"$defs": {
+		"db": {
+			"type": "object",
+			"required": ["host", "port"],
+			"properties": {
+				"host": {"type": "string", "minLength": 1},
+				"port": {"type": "integer"}
+			}
+		}
+	}
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := Compile([]byte(appSchema))
+	if err != nil {
+		t.Fatalf("Compile() error: %v", err)
+	}
+	tests := []struct {
+		name  string
+		value string
+		want  string // "" = valid
+	}{
+		{"valid", `{"db":{"host":"db","port":5432},"replicas":2,"env":"prod","tags":["a","b"],"ratio":0.5,"owner":null,"mode":"a","note":"x"}`, ""},
+		{"integer written as float", `{"db":{"host":"db","port":5432.0},"replicas":2}`, ""},
+		{"wrong type", `{"db":{"host":"db","port":"5432"},"replicas":2}`, "/db/port: expected integer, got string"},
+		{"missing required", `{"db":{"host":"db"}}`, "/: missing required properties: replicas; /db: missing required properties: port"},
+		{"additional property", `{"db":{"host":"db","port":1},"replicas":2,"extra":1}`, "/extra: additional property is not allowed"},
+		{"range", `{"db":{"host":"db","port":1},"replicas":11}`, "/replicas: must be <= 10"},
+		{"enum", `{"db":{"host":"db","port":1},"replicas":1,"env":"qa"}`, `/env: must be one of ["dev","staging","prod"]`},
+		{"items", `{"db":{"host":"db","port":1},"replicas":1,"tags":["a","B","a","c"]}`, "/tags: must have at most 3 items; /tags: items must be unique (\"a\" is repeated); /tags/1: must match pattern ^[a-z]+$"},
+		{"number keywords", `{"db":{"host":"db","port":1},"replicas":1,"ratio":1.1}`, "/ratio: must be < 1; /ratio: must be a multiple of 0.25"},
+		{"minLength", `{"db":{"host":"","port":1},"replicas":1,"owner":"x"}`, "/db/host: must be at least 1 characters long; /owner: must be at least 2 characters long"},
+		{"oneOf", `{"db":{"host":"db","port":1},"replicas":1,"mode":"c"}`, "/mode: must match exactly one of the oneOf schemas (matches 0)"},
+		{"not", `{"db":{"host":"db","port":1},"replicas":1,"note":5}`, "/note: must not match the not schema"},
+		{"root type", `"just a string"`, "/: expected object, got string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Decode([]byte(tt.value))
+			if err != nil {
+				t.Fatalf("Decode() error: %v", err)
+			}
+			err = s.Validate(v)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema_BooleanAndAnyOf(t *testing.T) {
+	s, err := Compile([]byte(`{"anyOf":[{"type":"string"},{"type":"integer"}],"additionalProperties":{"type":"boolean"}}`))
+	if err != nil {
+		t.Fatalf("Compile() error: %v", err)
+	}
+	for value, valid := range map[string]bool{`"x"`: true, `3`: true, `3.5`: false, `true`: false} {
+		v, _ := Decode([]byte(value))
+		if err := s.Validate(v); (err == nil) != valid {
+			t.Errorf("Validate(%s) = %v, want valid=%v", value, err, valid)
+		}
+	}
+
+	never, _ := Compile([]byte(`false`))
+	if err := never.Validate("x"); err == nil || err.Error() != "/: no value is allowed here" {
+		t.Errorf("false schema: %v", err)
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{`{`, "invalid schema: unexpected EOF"},
+		{`[1]`, "#: a schema must be an object or a boolean"},
+		{`{"type":"text"}`, `#/type: unknown type "text"`},
+		{`{"properties":{"a":{"pattern":"("}}}`, "#/properties/a/pattern: error parsing regexp"},
+		{`{"$ref":"#/$defs/missing"}`, `#/$ref: cannot resolve "#/$defs/missing"`},
+		{`{"$ref":"https://example.com/s.json"}`, "only local references"},
+		{`{"items":[{"type":"string"}]}`, "the array (tuple) form is not supported"},
+		{`{"minimum":"1"}`, "#/minimum: must be a number"},
+		{`{"anyOf":[]}`, "#/anyOf: must be a non-empty array"},
+		{`{"patternProperties":{"^x":{"type":"string"}}}`, "#/patternProperties: keyword is not supported"},
+		{`{"properties":{"a":{"if":{"type":"number"},"then":{"minimum":2}}}}`, "#/properties/a/if: keyword is not supported"},
+		{`{"items":{"contains":{"const":1}}}`, "#/items/contains: keyword is not supported"},
+		{`{"$defs":{"x":{"unevaluatedProperties":false}}}`, "#/$defs/x/unevaluatedProperties: keyword is not supported"},
+	}
+	for _, tt := range tests {
+		if _, err := Compile([]byte(tt.schema)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%s) error = %v, want %q", tt.schema, err, tt.want)
+		}
+	}
+
+	// 注釈キーワードは無視する
+	if _, err := Compile([]byte(`{"title":"t","description":"d","default":1,"format":"uri","examples":[1],"type":"string"}`)); err != nil {
+		t.Errorf("Compile with annotations: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Package manifest reads the list of variables an application expects and compares it
+// with the variables bundr generates.
+//
+// A manifest is either a dotenv file (e.g. .env.example), where every key is required
+// and the values are ignored, or a TOML file (*.toml):
+//
+//	[required]
+//	keys = ["DB_HOST", "DB_PORT"]
+//
+//	[optional]
+//	keys = ["LOG_LEVEL"]
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/youyo/bundr/internal/dotenv"
+)
+
+// Manifest lists the expected variable names.
+type Manifest struct {
+	Required []string
+	Optional []string // allowed but not required; never reported as extra
+}
+
+// Load reads the manifest at path. Files ending in .toml are read as TOML, anything
+// else as dotenv.
+func Load(path string) (*Manifest, error) {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return loadTOML(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	defer f.Close()
+	entries, err := dotenv.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", path, err)
+	}
+	m := &Manifest{}
+	for _, e := range entries {
+		m.Required = append(m.Required, e.Key)
+	}
+	return m, nil
+}
+
+func loadTOML(path string) (*Manifest, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read manifest %s: %w", path, err)
+	}
+	m := &Manifest{
+		Required: v.GetStringSlice("required.keys"),
+		Optional: v.GetStringSlice("optional.keys"),
+	}
+	if len(m.Required) == 0 && len(m.Optional) == 0 {
+		return nil, fmt.Errorf("manifest %s: no [required] or [optional] keys", path)
+	}
+	return m, nil
+}
+
+// Result is the outcome of Check. Every list is sorted.
+type Result struct {
+	Missing []string // required keys without a variable
+	Empty   []string // required keys whose value is empty
+	Extra   []string // variables the manifest does not list
+}
+
+// OK reports whether every required key has a non-empty value. Extra keys do not count.
+func (r Result) OK() bool {
+	return len(r.Missing) == 0 && len(r.Empty) == 0
+}
+
+// Check compares vars with the manifest. normalize maps a manifest key to the variable
+// name it stands for (e.g. the casing applied by exec); nil keeps keys as written.
+func (m *Manifest) Check(vars map[string]string, normalize func(string) string) Result {
+	if normalize == nil {
+		normalize = func(k string) string { return k }
+	}
+	var r Result
+	listed := map[string]bool{}
+	for _, k := range m.Required {
+		name := normalize(k)
+		if listed[name] {
+			continue
+		}
+		listed[name] = true
+		v, ok := vars[name]
+		switch {
+		case !ok:
+			r.Missing = append(r.Missing, name)
+		case v == "":
+			r.Empty = append(r.Empty, name)
+		}
+	}
+	for _, k := range m.Optional {
+		listed[normalize(k)] = true
+	}
+	for name := range vars {
+		if !listed[name] {
+			r.Extra = append(r.Extra, name)
+		}
+	}
+	sort.Strings(r.Missing)
+	sort.Strings(r.Empty)
+	sort.Strings(r.Extra)
+	return r
+}
@@ -0,0 +1,77 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		want                *Manifest
+		wantErr             string
+	}{
+		{
+			name: "dotenv", file: ".env.example",
+			content: "# database\nDB_HOST=localhost\nDB_PORT=\nAPI_KEY=\"changeme\"\n",
+			want:    &Manifest{Required: []string{"DB_HOST", "DB_PORT", "API_KEY"}},
+		},
+		{
+			name: "toml", file: "manifest.toml",
+			content: "[required]\nkeys = [\"DB_HOST\", \"DB_PORT\"]\n\n[optional]\nkeys = [\"LOG_LEVEL\"]\n",
+			want:    &Manifest{Required: []string{"DB_HOST", "DB_PORT"}, Optional: []string{"LOG_LEVEL"}},
+		},
+		{name: "toml without keys", file: "manifest.toml", content: "[other]\nx = 1\n", wantErr: "no [required] or [optional] keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(writeFile(t, tt.file, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Load(missing file) expected error")
+	}
+}
+
+func TestManifest_Check(t *testing.T) {
+	m := &Manifest{Required: []string{"db-host", "DB_PORT", "API_KEY", "DB_PORT"}, Optional: []string{"LOG_LEVEL"}}
+	vars := map[string]string{"DB_HOST": "db", "API_KEY": "", "LOG_LEVEL": "info", "OLD_FLAG": "1", "ZZZ": "x"}
+
+	got := m.Check(vars, func(k string) string { return strings.ToUpper(strings.ReplaceAll(k, "-", "_")) })
+	want := Result{Missing: []string{"DB_PORT"}, Empty: []string{"API_KEY"}, Extra: []string{"OLD_FLAG", "ZZZ"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %+v, want %+v", got, want)
+	}
+	if got.OK() {
+		t.Error("OK() = true, want false")
+	}
+
+	if r := m.Check(map[string]string{"db-host": "x", "DB_PORT": "1", "API_KEY": "k"}, nil); !r.OK() || len(r.Extra) != 0 {
+		t.Errorf("Check() = %+v, want OK", r)
+	}
+}
@@ -0,0 +1,261 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/childproc"
+)
+
+// DefaultTimeout bounds each request to a plugin, so that a hung plugin cannot hang bundr.
+const DefaultTimeout = 30 * time.Second
+
+// Client is a connection to a running plugin. Requests are sent one at a time.
+type Client struct {
+	prefix  string
+	info    InitializeResult
+	timeout time.Duration
+
+	mu     sync.Mutex
+	w      io.WriteCloser
+	dec    *json.Decoder
+	nextID int64
+	cmd    *exec.Cmd // nil when connected with Connect
+	broken error     // set when a request was abandoned; the connection is unusable
+}
+
+// Start runs cmd as the plugin for ref prefix and performs the handshake. The plugin
+// exits when the client is closed (or when bundr exits and its stdin is closed).
+func Start(prefix string, cmd *exec.Cmd) (*Client, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	if err := childproc.Start(cmd); err != nil {
+		return nil, fmt.Errorf("start %s: %w", cmd.Path, err)
+	}
+	c, err := Connect(prefix, stdout, stdin)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		childproc.Untrack(cmd.Process.Pid)
+		return nil, err
+	}
+	c.cmd = cmd
+	return c, nil
+}
+
+// Connect performs the handshake with a plugin reading requests from w and writing
+// responses to r.
+func Connect(prefix string, r io.Reader, w io.WriteCloser) (*Client, error) {
+	c := &Client{prefix: prefix, timeout: DefaultTimeout, w: w, dec: json.NewDecoder(bufio.NewReader(r))}
+	err := c.call(context.Background(), MethodInitialize, InitializeParams{ProtocolVersion: ProtocolVersion, Prefix: prefix}, &c.info)
+	if err != nil {
+		return nil, fmt.Errorf("%s plugin: initialize: %w", prefix, err)
+	}
+	if c.info.ProtocolVersion != ProtocolVersion {
+		return nil, fmt.Errorf("%s plugin speaks protocol version %d, bundr speaks %d", prefix, c.info.ProtocolVersion, ProtocolVersion)
+	}
+	return c, nil
+}
+
+// Info returns the plugin's answer to the handshake.
+func (c *Client) Info() InitializeResult {
+	return c.info
+}
+
+// Close closes the plugin's stdin and waits for it to exit. A plugin that was killed
+// after a request timed out is only reaped.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.w.Close()
+	if c.cmd != nil {
+		if werr := c.cmd.Wait(); err == nil {
+			err = werr
+		}
+		childproc.Untrack(c.cmd.Process.Pid)
+	}
+	if c.broken != nil {
+		return nil
+	}
+	return err
+}
+
+// call sends one request and decodes its result into result (unless nil).
+// Errors reported by the plugin are returned as *Error. When ctx ends or the request
+// takes longer than the client's timeout, the plugin is killed and every later call
+// fails: its response could still arrive and be taken for the next one.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.broken != nil {
+		return c.broken
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	c.nextID++
+	req := request{JSONRPC: "2.0", ID: c.nextID, Method: method, Params: raw}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	var resp response
+	done := make(chan error, 1)
+	go func() {
+		if _, err := c.w.Write(append(data, '\n')); err != nil {
+			done <- fmt.Errorf("%s plugin: send %s: %w", c.prefix, method, err)
+			return
+		}
+		if err := c.dec.Decode(&resp); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			done <- fmt.Errorf("%s plugin: read %s response: %w", c.prefix, method, err)
+			return
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		c.broken = fmt.Errorf("%s plugin: %s: %w", c.prefix, method, ctx.Err())
+		if c.cmd != nil {
+			_ = c.cmd.Process.Kill()
+		}
+		_ = c.w.Close()
+		return c.broken
+	}
+	if resp.ID != req.ID {
+		return fmt.Errorf("%s plugin: response id %d does not match request id %d", c.prefix, resp.ID, req.ID)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("%s plugin: decode %s result: %w", c.prefix, method, err)
+	}
+	return nil
+}
+
+// Backend returns the plugin as a backend.Backend. It implements backend.Lister and
+// backend.Tagger only when the plugin has the matching capability, so commands see the
+// plugin exactly like a built-in backend with the same features.
+func (c *Client) Backend() backend.Backend {
+	core := coreBackend{c}
+	switch caps := c.info.Capabilities; {
+	case caps.List && caps.Tags:
+		return struct {
+			coreBackend
+			listMethods
+			tagMethods
+		}{core, listMethods{c}, tagMethods{c}}
+	case caps.List:
+		return struct {
+			coreBackend
+			listMethods
+		}{core, listMethods{c}}
+	case caps.Tags:
+		return struct {
+			coreBackend
+			tagMethods
+		}{core, tagMethods{c}}
+	}
+	return core
+}
+
+// coreBackend implements backend.Backend over a Client.
+type coreBackend struct{ c *Client }
+
+func (b coreBackend) Put(ctx context.Context, ref string, opts backend.PutOptions) error {
+	return b.c.call(ctx, MethodPut, PutParams{Ref: ref, Options: toPutOptions(opts)}, nil)
+}
+
+func (b coreBackend) Get(ctx context.Context, ref string, opts backend.GetOptions) (string, error) {
+	var res GetResult
+	if err := b.c.call(ctx, MethodGet, GetParams{Ref: ref, Options: toGetOptions(opts)}, &res); err != nil {
+		return "", err
+	}
+	return res.Value, nil
+}
+
+func (b coreBackend) GetByPrefix(ctx context.Context, prefix string, opts backend.GetByPrefixOptions) ([]backend.ParameterEntry, error) {
+	var res EntriesResult
+	if err := b.c.call(ctx, MethodGetByPrefix, GetByPrefixParams{Prefix: prefix, Options: toGetByPrefixOptions(opts)}, &res); err != nil {
+		return nil, err
+	}
+	return fromEntries(res.Entries), nil
+}
+
+func (b coreBackend) Describe(ctx context.Context, ref string) (map[string]any, error) {
+	var res map[string]any
+	if err := b.c.call(ctx, MethodDescribe, RefParams{Ref: ref}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// listMethods implements backend.Lister over a Client.
+type listMethods struct{ c *Client }
+
+func (b listMethods) List(ctx context.Context, prefix string, opts backend.ListOptions, fn func(backend.ListEntry) error) error {
+	var res ListResult
+	if err := b.c.call(ctx, MethodList, ListParams{Prefix: prefix, Options: toListOptions(opts)}, &res); err != nil {
+		return err
+	}
+	for _, e := range res.Entries {
+		if err := fn(e.toBackend()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagMethods implements backend.Tagger over a Client.
+type tagMethods struct{ c *Client }
+
+func (b tagMethods) Tags(ctx context.Context, ref string) (map[string]string, error) {
+	var res TagsResult
+	if err := b.c.call(ctx, MethodTags, RefParams{Ref: ref}, &res); err != nil {
+		return nil, err
+	}
+	if res.Tags == nil {
+		res.Tags = map[string]string{}
+	}
+	return res.Tags, nil
+}
+
+func (b tagMethods) AddTags(ctx context.Context, ref string, tagMap map[string]string) error {
+	return b.c.call(ctx, MethodAddTags, AddTagsParams{Ref: ref, Tags: tagMap}, nil)
+}
+
+func (b tagMethods) RemoveTags(ctx context.Context, ref string, keys []string) error {
+	return b.c.call(ctx, MethodRemoveTags, RemoveTagsParams{Ref: ref, Keys: keys}, nil)
+}
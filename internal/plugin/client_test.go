@@ -0,0 +1,201 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/plugin/plugintest"
+)
+
+// TestMain lets the test binary act as a plugin: with BUNDR_PLUGIN_TEST set, it serves
+// a backend on stdin/stdout instead of running the tests.
+func TestMain(m *testing.M) {
+	switch os.Getenv("BUNDR_PLUGIN_TEST") {
+	case "mock":
+		if err := Serve(os.Stdin, os.Stdout, "mock", backend.NewMockBackend()); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "env":
+		if err := Serve(os.Stdin, os.Stdout, "env", backend.NewEnvBackend()); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "hang":
+		if err := Serve(os.Stdin, os.Stdout, "hang", hangBackend{backend.NewMockBackend()}); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "exit":
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// hangBackend never answers Get, like a plugin stuck on a network call.
+type hangBackend struct{ *backend.MockBackend }
+
+func (hangBackend) Get(context.Context, string, backend.GetOptions) (string, error) {
+	select {}
+}
+
+// startHelper starts the test binary as a plugin serving kind.
+func startHelper(t *testing.T, prefix, kind string) *Client {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "BUNDR_PLUGIN_TEST="+kind, "BUNDR_PLUGIN_TEST_VAR=from-env")
+	c, err := Start(prefix, cmd)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	})
+	return c
+}
+
+func TestConformance_Process(t *testing.T) {
+	plugintest.Run(t, "mock", func(t *testing.T) backend.Backend {
+		return startHelper(t, "mock", "mock").Backend()
+	})
+}
+
+func TestConformance_Pipe(t *testing.T) {
+	plugintest.Run(t, "mem", func(t *testing.T) backend.Backend {
+		reqR, reqW := io.Pipe()
+		respR, respW := io.Pipe()
+		go func() {
+			_ = Serve(reqR, respW, "mem", backend.NewMockBackend())
+			respW.Close()
+		}()
+		c, err := Connect("mem", respR, reqW)
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		return c.Backend()
+	})
+}
+
+func TestClient_Capabilities(t *testing.T) {
+	tests := []struct {
+		kind     string
+		wantList bool
+		wantTags bool
+	}{
+		{kind: "mock", wantList: true, wantTags: true},
+		{kind: "env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			c := startHelper(t, tt.kind, tt.kind)
+			if info := c.Info(); info.Name != tt.kind || info.ProtocolVersion != ProtocolVersion {
+				t.Errorf("Info = %+v", info)
+			}
+			b := c.Backend()
+			if _, ok := b.(backend.Lister); ok != tt.wantList {
+				t.Errorf("implements Lister = %v, want %v", ok, tt.wantList)
+			}
+			if _, err := backend.AsTagger(b); (err == nil) != tt.wantTags {
+				t.Errorf("AsTagger error = %v, want tags %v", err, tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	b := startHelper(t, "env", "env").Backend()
+	ctx := context.Background()
+
+	if got, err := b.Get(ctx, "env:BUNDR_PLUGIN_TEST_VAR", backend.GetOptions{}); err != nil || got != "from-env" {
+		t.Errorf("Get = %q, %v; want from-env", got, err)
+	}
+	if err := b.Put(ctx, "env:X", backend.PutOptions{Value: "v"}); !errors.Is(err, backend.ErrReadOnly) {
+		t.Errorf("Put error = %v, want backend.ErrReadOnly", err)
+	}
+	if _, err := b.Get(ctx, "env:BUNDR_PLUGIN_TEST_MISSING", backend.GetOptions{}); !errors.Is(err, backend.ErrNotFound) || !strings.Contains(err.Error(), "BUNDR_PLUGIN_TEST_MISSING") {
+		t.Errorf("Get of a missing variable: error = %v, want backend.ErrNotFound", err)
+	}
+}
+
+func TestStart_HandshakeFailure(t *testing.T) {
+	// 何も応答せずに終了するプラグイン
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "BUNDR_PLUGIN_TEST=exit")
+	if _, err := Start("broken", cmd); err == nil {
+		t.Fatal("expected a handshake error")
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	// プラグインプロセスは打ち切り時に kill され、以後の呼び出しは失敗する
+	c := startHelper(t, "hang", "hang")
+	c.timeout = 100 * time.Millisecond
+	b := c.Backend()
+	ctx := context.Background()
+
+	start := time.Now()
+	if _, err := b.Get(ctx, "hang:/app/key", backend.GetOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Get returned after %v", elapsed)
+	}
+	if err := b.Put(ctx, "hang:/app/key", backend.PutOptions{Value: "v"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Put after a timeout: error = %v, want the timeout", err)
+	}
+}
+
+func TestClient_ContextCanceled(t *testing.T) {
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	go func() {
+		_ = Serve(reqR, respW, "hang", hangBackend{backend.NewMockBackend()})
+		respW.Close()
+	}()
+	c, err := Connect("hang", respR, reqW)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := c.Backend().Get(ctx, "hang:/app/key", backend.GetOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get error = %v, want context.Canceled", err)
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	// ワイヤ形式は backend パッケージのフィールド名に依存しない
+	params := PutParams{Ref: "foo:/k", Options: toPutOptions(backend.PutOptions{Value: "v", StoreMode: "raw", KMSKeyID: "key", ExactTags: true})}
+	data, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"ref":"foo:/k","options":{"value":"v","store_mode":"raw","kms_key_id":"key","exact_tags":true}}`
+	if string(data) != want {
+		t.Errorf("put params = %s, want %s", data, want)
+	}
+
+	rotation := true
+	entry := toListEntry(backend.ListEntry{Path: "/k", Version: 2, RotationEnabled: &rotation, Managed: true, StoreMode: "json"})
+	data, err = json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `{"path":"/k","version":2,"rotation_enabled":true,"managed":true,"store_mode":"json"}`
+	if string(data) != want {
+		t.Errorf("list entry = %s, want %s", data, want)
+	}
+}
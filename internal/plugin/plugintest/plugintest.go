@@ -0,0 +1,167 @@
+// Package plugintest is the conformance suite of backend plugins: it checks that a
+// backend reached through the plugin protocol behaves like bundr's built-in backends.
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// Run runs the conformance suite. newBackend must return an empty backend for refs
+// with the given prefix ("foo"), whose keys are "/"-separated paths like Parameter
+// Store's. Lister and Tagger checks run when the backend implements them.
+func Run(t *testing.T, prefix string, newBackend func(t *testing.T) backend.Backend) {
+	ref := func(path string) string { return prefix + ":" + path }
+	ctx := context.Background()
+
+	put := func(t *testing.T, b backend.Backend, path string, opts backend.PutOptions) {
+		t.Helper()
+		if err := b.Put(ctx, ref(path), opts); err != nil {
+			t.Fatalf("Put(%s): %v", ref(path), err)
+		}
+	}
+
+	t.Run("GetStoreModes", func(t *testing.T) {
+		b := newBackend(t)
+		put(t, b, "/app/raw", backend.PutOptions{Value: "localhost", StoreMode: tags.StoreModeRaw})
+		put(t, b, "/app/object", backend.PutOptions{Value: `{"port":5432}`, StoreMode: tags.StoreModeJSON})
+		put(t, b, "/app/scalar", backend.PutOptions{Value: "hello", StoreMode: tags.StoreModeJSON})
+
+		tests := []struct {
+			path string
+			opts backend.GetOptions
+			want string
+		}{
+			{path: "/app/raw", want: "localhost"},
+			{path: "/app/raw", opts: backend.GetOptions{ForceRaw: true}, want: "localhost"},
+			{path: "/app/object", want: `{"port":5432}`},
+			{path: "/app/scalar", want: "hello"},
+			{path: "/app/scalar", opts: backend.GetOptions{ForceRaw: true}, want: `"hello"`},
+		}
+		for _, tt := range tests {
+			got, err := b.Get(ctx, ref(tt.path), tt.opts)
+			if err != nil || got != tt.want {
+				t.Errorf("Get(%s, %+v) = %q, %v; want %q", ref(tt.path), tt.opts, got, err, tt.want)
+			}
+		}
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		b := newBackend(t)
+		put(t, b, "/app/key", backend.PutOptions{Value: "v1", StoreMode: tags.StoreModeRaw})
+		put(t, b, "/app/key", backend.PutOptions{Value: "v2", StoreMode: tags.StoreModeRaw})
+		if got, err := b.Get(ctx, ref("/app/key"), backend.GetOptions{}); err != nil || got != "v2" {
+			t.Errorf("Get after overwrite = %q, %v; want v2", got, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := newBackend(t)
+		if _, err := b.Get(ctx, ref("/missing"), backend.GetOptions{}); !errors.Is(err, backend.ErrNotFound) {
+			t.Errorf("Get of a missing key: error = %v, want backend.ErrNotFound", err)
+		}
+		if _, err := b.Describe(ctx, ref("/missing")); !errors.Is(err, backend.ErrNotFound) {
+			t.Errorf("Describe of a missing key: error = %v, want backend.ErrNotFound", err)
+		}
+	})
+
+	t.Run("GetByPrefix", func(t *testing.T) {
+		b := newBackend(t)
+		put(t, b, "/app/a", backend.PutOptions{Value: "1", StoreMode: tags.StoreModeRaw})
+		put(t, b, "/app/b", backend.PutOptions{Value: `{"k":"v"}`, StoreMode: tags.StoreModeJSON})
+		put(t, b, "/app/sub/c", backend.PutOptions{Value: "3", StoreMode: tags.StoreModeRaw})
+		put(t, b, "/other/d", backend.PutOptions{Value: "4", StoreMode: tags.StoreModeRaw})
+
+		tests := []struct {
+			name      string
+			recursive bool
+			want      []string
+		}{
+			{name: "recursive", recursive: true, want: []string{"/app/a", "/app/b", "/app/sub/c"}},
+			{name: "one level", want: []string{"/app/a", "/app/b"}},
+		}
+		for _, tt := range tests {
+			entries, err := b.GetByPrefix(ctx, "/app/", backend.GetByPrefixOptions{Recursive: tt.recursive})
+			if err != nil {
+				t.Fatalf("GetByPrefix(/app/, %s): %v", tt.name, err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Path)
+				if e.Path == "/app/b" && (e.StoreMode != tags.StoreModeJSON || e.Value != `{"k":"v"}`) {
+					t.Errorf("GetByPrefix(/app/, %s) entry %+v: want the raw JSON value and store mode json", tt.name, e)
+				}
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("GetByPrefix(/app/, %s) paths = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+
+		entries, err := b.GetByPrefix(ctx, "/none/", backend.GetByPrefixOptions{Recursive: true})
+		if err != nil || len(entries) != 0 {
+			t.Errorf("GetByPrefix(/none/) = %v, %v; want no entries", entries, err)
+		}
+	})
+
+	t.Run("Describe", func(t *testing.T) {
+		b := newBackend(t)
+		put(t, b, "/app/key", backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw})
+		meta, err := b.Describe(ctx, ref("/app/key"))
+		if err != nil || len(meta) == 0 {
+			t.Errorf("Describe = %v, %v; want metadata", meta, err)
+		}
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		b := newBackend(t)
+		tagger, err := backend.AsTagger(b)
+		if err != nil {
+			t.Skip("backend has no tags")
+		}
+		put(t, b, "/app/key", backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw, Tags: map[string]string{"team": "a"}})
+		got, err := tagger.Tags(ctx, ref("/app/key"))
+		if err != nil {
+			t.Fatalf("Tags: %v", err)
+		}
+		if got[tags.TagCLI] != tags.TagCLIValue || got[tags.TagStoreMode] != tags.StoreModeRaw || got["team"] != "a" {
+			t.Errorf("Tags after Put = %v; want the managed tags and team=a", got)
+		}
+		if err := tagger.AddTags(ctx, ref("/app/key"), map[string]string{"env": "prod"}); err != nil {
+			t.Fatalf("AddTags: %v", err)
+		}
+		if err := tagger.RemoveTags(ctx, ref("/app/key"), []string{"team", "absent"}); err != nil {
+			t.Fatalf("RemoveTags: %v", err)
+		}
+		got, err = tagger.Tags(ctx, ref("/app/key"))
+		if err != nil || got["env"] != "prod" || got["team"] != "" {
+			t.Errorf("Tags after AddTags/RemoveTags = %v, %v; want env=prod without team", got, err)
+		}
+		if _, err := tagger.Tags(ctx, ref("/missing")); !errors.Is(err, backend.ErrNotFound) {
+			t.Errorf("Tags of a missing key: error = %v, want backend.ErrNotFound", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		b := newBackend(t)
+		if _, ok := b.(backend.Lister); !ok {
+			t.Skip("backend has no filtered listing")
+		}
+		put(t, b, "/app/a", backend.PutOptions{Value: "1", StoreMode: tags.StoreModeRaw, Tags: map[string]string{"team": "a"}})
+		put(t, b, "/app/b", backend.PutOptions{Value: "2", StoreMode: tags.StoreModeRaw})
+		var got []string
+		err := backend.List(ctx, b, "/app/", backend.ListOptions{Recursive: true, Tags: map[string]string{"team": "a"}}, func(e backend.ListEntry) error {
+			got = append(got, e.Path)
+			return nil
+		})
+		if err != nil || strings.Join(got, ",") != "/app/a" {
+			t.Errorf("List(/app/, team=a) = %v, %v; want [/app/a]", got, err)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+// Package plugin implements bundr's external backend protocol. For a ref prefix that is
+// not built in ("foo:"), bundr runs the "bundr-backend-foo" executable found on PATH and
+// talks JSON-RPC 2.0 to it over the plugin's stdin and stdout, one JSON object per line.
+// The plugin's stderr is passed through to the user.
+//
+// The first request is "initialize", which agrees on the protocol version and reports
+// the plugin's capabilities. The other methods mirror backend.Backend and its optional
+// interfaces; refs are passed as written ("foo:/app/key") and entry paths are returned
+// without the prefix ("/app/key"), as with built-in backends.
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/youyo/bundr/internal/backend"
+)
+
+// ProtocolVersion is the version of the protocol spoken by this package.
+const ProtocolVersion = 1
+
+// Methods of the protocol.
+const (
+	MethodInitialize  = "initialize"    // InitializeParams -> InitializeResult
+	MethodPut         = "put"           // PutParams -> null
+	MethodGet         = "get"           // GetParams -> GetResult
+	MethodGetByPrefix = "get_by_prefix" // GetByPrefixParams -> EntriesResult
+	MethodDescribe    = "describe"      // RefParams -> map of metadata
+	MethodList        = "list"          // ListParams -> ListResult (capability "list")
+	MethodTags        = "tags"          // RefParams -> TagsResult (capability "tags")
+	MethodAddTags     = "add_tags"      // AddTagsParams -> null (capability "tags")
+	MethodRemoveTags  = "remove_tags"   // RemoveTagsParams -> null (capability "tags")
+)
+
+// Error codes. Besides the JSON-RPC ones, plugins report missing entries and refused
+// writes with their own codes so that bundr can tell them apart from failures.
+const (
+	CodeMethodNotFound = -32601 // JSON-RPC: the method is not implemented
+	CodeInvalidParams  = -32602 // JSON-RPC: the params cannot be decoded
+	CodeInternal       = -32000 // any other failure
+	CodeNotFound       = -32001 // the entry does not exist (backend.ErrNotFound)
+	CodeReadOnly       = -32002 // the backend does not accept writes (backend.ErrReadOnly)
+)
+
+// request is a JSON-RPC 2.0 request.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC 2.0 response.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is an error returned by a plugin. NotFound and ReadOnly errors match
+// backend.ErrNotFound and backend.ErrReadOnly with errors.Is.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap maps the error code to the matching backend error.
+func (e *Error) Unwrap() error {
+	switch e.Code {
+	case CodeNotFound:
+		return backend.ErrNotFound
+	case CodeReadOnly:
+		return backend.ErrReadOnly
+	}
+	return nil
+}
+
+// toError converts an error of a backend to a protocol error.
+func toError(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, backend.ErrNotFound):
+		return &Error{Code: CodeNotFound, Message: err.Error()}
+	case errors.Is(err, backend.ErrReadOnly):
+		return &Error{Code: CodeReadOnly, Message: err.Error()}
+	}
+	return &Error{Code: CodeInternal, Message: err.Error()}
+}
+
+// InitializeParams are sent by bundr in the first request.
+type InitializeParams struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	Prefix          string `json:"prefix"` // ref prefix the plugin was started for ("foo")
+}
+
+// InitializeResult is the plugin's answer to "initialize".
+type InitializeResult struct {
+	ProtocolVersion int          `json:"protocol_version"`
+	Name            string       `json:"name"`
+	Capabilities    Capabilities `json:"capabilities"`
+}
+
+// Capabilities are the optional parts of the protocol a plugin implements.
+type Capabilities struct {
+	List bool `json:"list"` // "list" (backend.Lister)
+	Tags bool `json:"tags"` // "tags", "add_tags", "remove_tags" (backend.Tagger)
+}
+
+// RefParams name a single entry.
+type RefParams struct {
+	Ref string `json:"ref"`
+}
+
+// PutParams are the params of "put".
+type PutParams struct {
+	Ref     string     `json:"ref"`
+	Options PutOptions `json:"options"`
+}
+
+// GetParams are the params of "get".
+type GetParams struct {
+	Ref     string     `json:"ref"`
+	Options GetOptions `json:"options"`
+}
+
+// GetResult is the result of "get".
+type GetResult struct {
+	Value string `json:"value"`
+}
+
+// GetByPrefixParams are the params of "get_by_prefix". Prefix is the path of the ref
+// without the backend prefix ("/app/").
+type GetByPrefixParams struct {
+	Prefix  string             `json:"prefix"`
+	Options GetByPrefixOptions `json:"options"`
+}
+
+// EntriesResult is the result of "get_by_prefix".
+type EntriesResult struct {
+	Entries []Entry `json:"entries"`
+}
+
+// ListParams are the params of "list".
+type ListParams struct {
+	Prefix  string      `json:"prefix"`
+	Options ListOptions `json:"options"`
+}
+
+// ListResult is the result of "list".
+type ListResult struct {
+	Entries []ListEntry `json:"entries"`
+}
+
+// TagsResult is the result of "tags".
+type TagsResult struct {
+	Tags map[string]string `json:"tags"`
+}
+
+// AddTagsParams are the params of "add_tags".
+type AddTagsParams struct {
+	Ref  string            `json:"ref"`
+	Tags map[string]string `json:"tags"`
+}
+
+// RemoveTagsParams are the params of "remove_tags".
+type RemoveTagsParams struct {
+	Ref  string   `json:"ref"`
+	Keys []string `json:"keys"`
+}
+
+// errUnsupported is returned for methods outside the plugin's capabilities.
+func errUnsupported(method string) *Error {
+	return &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %s is not supported", method)}
+}
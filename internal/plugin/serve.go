@@ -0,0 +1,133 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/youyo/bundr/internal/backend"
+)
+
+// Serve answers the requests read from r on w with b until r is closed; it is the
+// whole main loop of a plugin written in Go. The capabilities reported in the
+// handshake follow the optional interfaces b implements (backend.Lister, backend.Tagger).
+func Serve(r io.Reader, w io.Writer, name string, b backend.Backend) error {
+	ctx := context.Background()
+	dec := json.NewDecoder(bufio.NewReader(r))
+	enc := json.NewEncoder(w)
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		resp := response{JSONRPC: "2.0", ID: req.ID}
+		result, err := dispatch(ctx, name, b, req)
+		if err != nil {
+			resp.Error = toError(err)
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			resp.Error = toError(err)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch calls the method of b named by req.
+func dispatch(ctx context.Context, name string, b backend.Backend, req request) (any, error) {
+	lister, canList := b.(backend.Lister)
+	tagger, canTag := b.(backend.Tagger)
+	decode := func(v any) error {
+		if err := json.Unmarshal(req.Params, v); err != nil {
+			return &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch req.Method {
+	case MethodInitialize:
+		var p InitializeParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Name:            name,
+			Capabilities:    Capabilities{List: canList, Tags: canTag},
+		}, nil
+	case MethodPut:
+		var p PutParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return nil, b.Put(ctx, p.Ref, p.Options.toBackend())
+	case MethodGet:
+		var p GetParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		v, err := b.Get(ctx, p.Ref, p.Options.toBackend())
+		return GetResult{Value: v}, err
+	case MethodGetByPrefix:
+		var p GetByPrefixParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		entries, err := b.GetByPrefix(ctx, p.Prefix, p.Options.toBackend())
+		return EntriesResult{Entries: toEntries(entries)}, err
+	case MethodDescribe:
+		var p RefParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return b.Describe(ctx, p.Ref)
+	case MethodList:
+		if !canList {
+			return nil, errUnsupported(req.Method)
+		}
+		var p ListParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		var entries []ListEntry
+		err := lister.List(ctx, p.Prefix, p.Options.toBackend(), func(e backend.ListEntry) error {
+			entries = append(entries, toListEntry(e))
+			return nil
+		})
+		return ListResult{Entries: entries}, err
+	case MethodTags:
+		if !canTag {
+			return nil, errUnsupported(req.Method)
+		}
+		var p RefParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		t, err := tagger.Tags(ctx, p.Ref)
+		return TagsResult{Tags: t}, err
+	case MethodAddTags:
+		if !canTag {
+			return nil, errUnsupported(req.Method)
+		}
+		var p AddTagsParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return nil, tagger.AddTags(ctx, p.Ref, p.Tags)
+	case MethodRemoveTags:
+		if !canTag {
+			return nil, errUnsupported(req.Method)
+		}
+		var p RemoveTagsParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return nil, tagger.RemoveTags(ctx, p.Ref, p.Keys)
+	}
+	return nil, errUnsupported(req.Method)
+}
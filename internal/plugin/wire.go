@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+)
+
+// The types below are the wire form of the backend package's option and entry structs.
+// They belong to the protocol, so renaming a field inside bundr never changes what
+// plugins read and write.
+
+// PutOptions is the wire form of backend.PutOptions.
+type PutOptions struct {
+	Value        string            `json:"value"`
+	StoreMode    string            `json:"store_mode"`           // "raw" or "json"
+	ValueType    string            `json:"value_type,omitempty"` // "string", "secure" or "stringlist"
+	KMSKeyID     string            `json:"kms_key_id,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	AdvancedTier bool              `json:"advanced_tier,omitempty"`
+	TierExplicit bool              `json:"tier_explicit,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Policies     string            `json:"policies,omitempty"`
+	ExactTags    bool              `json:"exact_tags,omitempty"` // write tags as given, without the managed ones
+}
+
+// GetOptions is the wire form of backend.GetOptions.
+type GetOptions struct {
+	ForceRaw  bool `json:"force_raw,omitempty"`
+	ForceJSON bool `json:"force_json,omitempty"`
+}
+
+// GetByPrefixOptions is the wire form of backend.GetByPrefixOptions.
+type GetByPrefixOptions struct {
+	Recursive       bool `json:"recursive,omitempty"`
+	SkipTagFetch    bool `json:"skip_tag_fetch,omitempty"`
+	IncludeMetadata bool `json:"include_metadata,omitempty"`
+}
+
+// Entry is the wire form of backend.ParameterEntry.
+type Entry struct {
+	Path      string            `json:"path"`
+	Value     string            `json:"value"`
+	StoreMode string            `json:"store_mode,omitempty"`
+	Flatten   string            `json:"flatten,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Metadata  map[string]any    `json:"metadata,omitempty"`
+}
+
+// ListOptions is the wire form of backend.ListOptions.
+type ListOptions struct {
+	Recursive      bool              `json:"recursive,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	Type           string            `json:"type,omitempty"`
+	ResolveManaged bool              `json:"resolve_managed,omitempty"`
+}
+
+// ListEntry is the wire form of backend.ListEntry.
+type ListEntry struct {
+	Path            string     `json:"path"`
+	Type            string     `json:"type,omitempty"`
+	Tier            string     `json:"tier,omitempty"`
+	Version         int64      `json:"version,omitempty"`
+	LastModified    *time.Time `json:"last_modified,omitempty"`
+	KMSKeyID        string     `json:"kms_key_id,omitempty"`
+	Description     string     `json:"description,omitempty"`
+	Policies        string     `json:"policies,omitempty"`
+	RotationEnabled *bool      `json:"rotation_enabled,omitempty"`
+	Managed         bool       `json:"managed,omitempty"`
+	StoreMode       string     `json:"store_mode,omitempty"`
+}
+
+func toPutOptions(o backend.PutOptions) PutOptions {
+	return PutOptions{
+		Value: o.Value, StoreMode: o.StoreMode, ValueType: o.ValueType, KMSKeyID: o.KMSKeyID, Tags: o.Tags,
+		AdvancedTier: o.AdvancedTier, TierExplicit: o.TierExplicit, Description: o.Description,
+		Policies: o.Policies, ExactTags: o.ExactTags,
+	}
+}
+
+func (o PutOptions) toBackend() backend.PutOptions {
+	return backend.PutOptions{
+		Value: o.Value, StoreMode: o.StoreMode, ValueType: o.ValueType, KMSKeyID: o.KMSKeyID, Tags: o.Tags,
+		AdvancedTier: o.AdvancedTier, TierExplicit: o.TierExplicit, Description: o.Description,
+		Policies: o.Policies, ExactTags: o.ExactTags,
+	}
+}
+
+func toGetOptions(o backend.GetOptions) GetOptions {
+	return GetOptions{ForceRaw: o.ForceRaw, ForceJSON: o.ForceJSON}
+}
+
+func (o GetOptions) toBackend() backend.GetOptions {
+	return backend.GetOptions{ForceRaw: o.ForceRaw, ForceJSON: o.ForceJSON}
+}
+
+func toGetByPrefixOptions(o backend.GetByPrefixOptions) GetByPrefixOptions {
+	return GetByPrefixOptions{Recursive: o.Recursive, SkipTagFetch: o.SkipTagFetch, IncludeMetadata: o.IncludeMetadata}
+}
+
+func (o GetByPrefixOptions) toBackend() backend.GetByPrefixOptions {
+	return backend.GetByPrefixOptions{Recursive: o.Recursive, SkipTagFetch: o.SkipTagFetch, IncludeMetadata: o.IncludeMetadata}
+}
+
+func toEntries(entries []backend.ParameterEntry) []Entry {
+	out := make([]Entry, len(entries))
+	for i, e := range entries {
+		out[i] = Entry{Path: e.Path, Value: e.Value, StoreMode: e.StoreMode, Flatten: e.Flatten, Tags: e.Tags, Metadata: e.Metadata}
+	}
+	return out
+}
+
+func fromEntries(entries []Entry) []backend.ParameterEntry {
+	out := make([]backend.ParameterEntry, len(entries))
+	for i, e := range entries {
+		out[i] = backend.ParameterEntry{Path: e.Path, Value: e.Value, StoreMode: e.StoreMode, Flatten: e.Flatten, Tags: e.Tags, Metadata: e.Metadata}
+	}
+	return out
+}
+
+func toListOptions(o backend.ListOptions) ListOptions {
+	return ListOptions{Recursive: o.Recursive, Tags: o.Tags, Type: o.Type, ResolveManaged: o.ResolveManaged}
+}
+
+func (o ListOptions) toBackend() backend.ListOptions {
+	return backend.ListOptions{Recursive: o.Recursive, Tags: o.Tags, Type: o.Type, ResolveManaged: o.ResolveManaged}
+}
+
+func toListEntry(e backend.ListEntry) ListEntry {
+	return ListEntry{
+		Path: e.Path, Type: e.Type, Tier: e.Tier, Version: e.Version, LastModified: e.LastModified,
+		KMSKeyID: e.KMSKeyID, Description: e.Description, Policies: e.Policies,
+		RotationEnabled: e.RotationEnabled, Managed: e.Managed, StoreMode: e.StoreMode,
+	}
+}
+
+func (e ListEntry) toBackend() backend.ListEntry {
+	return backend.ListEntry{
+		Path: e.Path, Type: e.Type, Tier: e.Tier, Version: e.Version, LastModified: e.LastModified,
+		KMSKeyID: e.KMSKeyID, Description: e.Description, Policies: e.Policies,
+		RotationEnabled: e.RotationEnabled, Managed: e.Managed, StoreMode: e.StoreMode,
+	}
+}
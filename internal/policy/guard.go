@@ -0,0 +1,196 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// WriteGuard checks every write (Put and tag changes) made through the backends it
+// wraps against the [[protect]] and [[policy]] rules and the value schemas. Share one WriteGuard between
+// the backends of a command so that a confirmation is asked only once.
+type WriteGuard struct {
+	Policies *Set
+	Protect  *ProtectSet
+	Schemas  *SchemaSet
+	// Confirmed skips the confirmation of confirm rules (--yes-i-mean-prod).
+	Confirmed bool
+	// Confirm asks the user to type want and reports whether they did.
+	// nil, or an error, means no interactive confirmation is possible.
+	Confirm func(prompt, want string) (bool, error)
+
+	mu        sync.Mutex
+	confirmed map[string]bool // rule labels confirmed in this run
+}
+
+// Wrap returns b with its writes checked. profile and region are those b talks to;
+// they select the [[protect]] rules bound to a profile or region.
+// Everything else, including the optional interfaces (PrefixWalker, BatchGetter,
+// Lister, Tagger), passes through.
+func (g *WriteGuard) Wrap(b backend.Backend, profile, region string) backend.Backend {
+	if g.Policies.Empty() && g.Protect.Empty() && g.Schemas.Empty() {
+		return b
+	}
+	return &guardedBackend{Backend: b, guard: g, profile: profile, region: region}
+}
+
+// checkProtected rejects writes to read-only refs and asks for confirmation (once per
+// rule) before the first write to a confirm ref.
+func (g *WriteGuard) checkProtected(ref, profile, region string) error {
+	rules := g.Protect.matching(ref, profile, region)
+	for _, r := range rules {
+		if r.ReadOnly {
+			return fmt.Errorf("%w: %s is read-only (protect %s)", ErrProtected, ref, r.label)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, r := range rules {
+		if g.Confirmed || g.confirmed[r.label] {
+			continue
+		}
+		want := r.confirmText()
+		if g.Confirm == nil {
+			return fmt.Errorf("%w: %s needs confirmation (protect %s); pass --yes-i-mean-prod", ErrProtected, ref, r.label)
+		}
+		ok, err := g.Confirm(fmt.Sprintf("%s is protected (protect %s).", ref, r.label), want)
+		if err != nil {
+			return fmt.Errorf("%w: %s needs confirmation (protect %s): %v; pass --yes-i-mean-prod", ErrProtected, ref, r.label, err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s: confirmation did not match %q", ErrProtected, ref, want)
+		}
+		if g.confirmed == nil {
+			g.confirmed = map[string]bool{}
+		}
+		g.confirmed[r.label] = true
+	}
+	return nil
+}
+
+type guardedBackend struct {
+	backend.Backend
+	guard           *WriteGuard
+	profile, region string
+}
+
+// Put checks the write against the rules and forwards it when it is allowed.
+// Policy violations and schema mismatches are reported before any confirmation is asked.
+func (g *guardedBackend) Put(ctx context.Context, ref string, opts backend.PutOptions) error {
+	parsed, err := backend.ParseRef(ref)
+	if err != nil {
+		// 検査できない書き込みは通さない
+		return fmt.Errorf("%w: %s cannot be checked: %v", ErrProtected, ref, err)
+	}
+	obj := WriteObject(parsed, opts)
+	_, schemaTagged := opts.Tags[tags.TagValueSchema]
+	if g.guard.Policies.RequiresTags(obj.Ref()) || (g.guard.Schemas.Covers(obj.Ref()) && !schemaTagged) {
+		// 既存エントリのタグは上書き時も残るため、書き込むタグとマージして判定する
+		if t, err := backend.AsTagger(g.Backend); err == nil {
+			if existing, err := t.Tags(ctx, ref); err == nil {
+				for k, v := range obj.Tags {
+					existing[k] = v
+				}
+				obj.Tags = existing
+			}
+		}
+	}
+	if v := g.guard.Policies.Check(obj); len(v) > 0 {
+		return &ViolationError{Violations: v}
+	}
+	if src := g.guard.Schemas.Source(obj.Ref(), obj.Tags); src != "" {
+		if err := g.guard.Schemas.Validate(obj.Ref(), src, opts.Value); err != nil {
+			return err
+		}
+	}
+	if err := g.guard.checkProtected(obj.Ref(), g.profile, g.region); err != nil {
+		return err
+	}
+	return g.Backend.Put(ctx, ref, opts)
+}
+
+// WriteObject describes what a Put with opts would store at ref. The tier is only
+// known when it is set explicitly; otherwise the backend keeps the existing one.
+func WriteObject(ref backend.Ref, opts backend.PutOptions) Object {
+	obj := Object{
+		Type:     ref.Type,
+		Path:     ref.Path,
+		KMSKeyID: opts.KMSKeyID,
+		Size:     len(opts.Value),
+		Tags:     tags.ManagedTags(opts.StoreMode),
+	}
+	for k, v := range opts.Tags {
+		obj.Tags[k] = v
+	}
+	if ref.Type == backend.BackendTypePS {
+		obj.ParamType = "String"
+		switch opts.ValueType {
+		case backend.ValueTypeSecure:
+			obj.ParamType = "SecureString"
+		case backend.ValueTypeStringList:
+			obj.ParamType = "StringList"
+		}
+		switch {
+		case opts.AdvancedTier:
+			obj.Tier = "Advanced"
+		case opts.TierExplicit:
+			obj.Tier = "Standard"
+		}
+	}
+	return obj
+}
+
+// checkTagWrite applies the [[protect]] rules to a tag change of ref.
+func (g *guardedBackend) checkTagWrite(ref string) error {
+	parsed, err := backend.ParseRef(ref)
+	if err != nil {
+		return fmt.Errorf("%w: %s cannot be checked: %v", ErrProtected, ref, err)
+	}
+	return g.guard.checkProtected(string(parsed.Type)+":"+parsed.Path, g.profile, g.region)
+}
+
+func (g *guardedBackend) WalkPrefix(ctx context.Context, prefix string, opts backend.GetByPrefixOptions, fn func(backend.ParameterEntry) error) error {
+	return backend.WalkPrefix(ctx, g.Backend, prefix, opts, fn)
+}
+
+func (g *guardedBackend) GetMany(ctx context.Context, refs []string, opts backend.GetOptions) (map[string]string, []string, error) {
+	return backend.GetMany(ctx, g.Backend, refs, opts)
+}
+
+func (g *guardedBackend) List(ctx context.Context, prefix string, opts backend.ListOptions, fn func(backend.ListEntry) error) error {
+	return backend.List(ctx, g.Backend, prefix, opts, fn)
+}
+
+func (g *guardedBackend) Tags(ctx context.Context, ref string) (map[string]string, error) {
+	t, err := backend.AsTagger(g.Backend)
+	if err != nil {
+		return nil, err
+	}
+	return t.Tags(ctx, ref)
+}
+
+func (g *guardedBackend) AddTags(ctx context.Context, ref string, tagMap map[string]string) error {
+	t, err := backend.AsTagger(g.Backend)
+	if err != nil {
+		return err
+	}
+	if err := g.checkTagWrite(ref); err != nil {
+		return err
+	}
+	return t.AddTags(ctx, ref, tagMap)
+}
+
+func (g *guardedBackend) RemoveTags(ctx context.Context, ref string, keys []string) error {
+	t, err := backend.AsTagger(g.Backend)
+	if err != nil {
+		return err
+	}
+	if err := g.checkTagWrite(ref); err != nil {
+		return err
+	}
+	return t.RemoveTags(ctx, ref, keys)
+}
@@ -0,0 +1,238 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+func TestGuard_Put(t *testing.T) {
+	set, err := Compile([]config.PolicyRule{
+		{Name: "prod", Match: "ps:/app/prod/**", RequireSecure: true, RequiredTags: []string{"team"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error: %v", err)
+	}
+	ctx := context.Background()
+	mb := backend.NewMockBackend()
+	if err := mb.Put(ctx, "ps:/app/prod/existing", backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw, ValueType: backend.ValueTypeSecure}); err != nil {
+		t.Fatal(err)
+	}
+	mb.SetTags("ps:/app/prod/existing", map[string]string{"team": "core"})
+	b := (&WriteGuard{Policies: set}).Wrap(mb, "", "")
+
+	tests := []struct {
+		name    string
+		ref     string
+		opts    backend.PutOptions
+		wantErr string
+	}{
+		{
+			name: "outside the rule",
+			ref:  "ps:/app/dev/db",
+			opts: backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw},
+		},
+		{
+			name:    "plain string and no tags",
+			ref:     "ps:/app/prod/db",
+			opts:    backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw},
+			wantErr: "policy violation: ps:/app/prod/db: must be SecureString (is String) (policy prod); ps:/app/prod/db: missing required tags: team (policy prod)",
+		},
+		{
+			name: "tags given on write",
+			ref:  "ps:/app/prod/db",
+			opts: backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw, ValueType: backend.ValueTypeSecure, Tags: map[string]string{"team": "core"}},
+		},
+		{
+			name: "existing tags are kept",
+			ref:  "ps:/app/prod/existing",
+			opts: backend.PutOptions{Value: "v2", StoreMode: tags.StoreModeRaw, ValueType: backend.ValueTypeSecure},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.Put(ctx, tt.ref, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Put() error: %v", err)
+				}
+				if got, _ := mb.Get(ctx, tt.ref, backend.GetOptions{}); got != tt.opts.Value {
+					t.Errorf("stored value = %q, want %q", got, tt.opts.Value)
+				}
+				return
+			}
+			var verr *ViolationError
+			if !errors.As(err, &verr) || err.Error() != tt.wantErr {
+				t.Fatalf("Put() error = %v, want %q", err, tt.wantErr)
+			}
+			if _, err := mb.Get(ctx, tt.ref, backend.GetOptions{}); err == nil {
+				t.Error("rejected write reached the backend")
+			}
+		})
+	}
+}
+
+// a ref the guard cannot parse is rejected instead of being forwarded unchecked
+func TestGuard_PutUnparsableRef(t *testing.T) {
+	set, _ := Compile([]config.PolicyRule{{Match: "ps:/**", RequireSecure: true}})
+	mb := backend.NewMockBackend()
+	b := (&WriteGuard{Policies: set}).Wrap(mb, "", "")
+
+	err := b.Put(context.Background(), "psa:/app/db", backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw})
+	if !errors.Is(err, ErrProtected) || !strings.Contains(err.Error(), "psa:/app/db cannot be checked") {
+		t.Errorf("Put() error = %v, want ErrProtected for an unparsable ref", err)
+	}
+	if len(mb.PutCalls) != 0 {
+		t.Errorf("Put forwarded %d times, want 0", len(mb.PutCalls))
+	}
+}
+
+func TestGuard_PassesThroughOptionalInterfaces(t *testing.T) {
+	set, _ := Compile([]config.PolicyRule{{Match: "ps:/**"}})
+	b := (&WriteGuard{Policies: set}).Wrap(backend.NewMockBackend(), "", "")
+	if _, ok := b.(backend.Lister); !ok {
+		t.Error("guarded backend is not a Lister")
+	}
+	if _, ok := b.(backend.BatchGetter); !ok {
+		t.Error("guarded backend is not a BatchGetter")
+	}
+	if _, err := backend.AsTagger(b); err != nil {
+		t.Errorf("AsTagger() error: %v", err)
+	}
+
+	mb := backend.NewMockBackend()
+	if (&WriteGuard{}).Wrap(mb, "", "") != backend.Backend(mb) {
+		t.Error("Wrap without rules should return the backend unchanged")
+	}
+}
+
+func TestGuard_Protect(t *testing.T) {
+	protect, err := CompileProtect([]config.ProtectRule{
+		{Name: "prod", Match: "ps:/app/prod/**", Confirm: true},
+		{Name: "frozen", Match: "ps:/app/frozen/**", ReadOnly: true},
+		{Name: "prod-account", Profile: "prod", Confirm: true},
+		{Name: "dr", Region: "us-west-2", ReadOnly: true},
+	})
+	if err != nil {
+		t.Fatalf("CompileProtect() error: %v", err)
+	}
+	ctx := context.Background()
+	opts := backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw}
+
+	tests := []struct {
+		name      string
+		profile   string
+		region    string
+		confirmed bool
+		answer    string // typed at the prompt; "" = no terminal
+		writes    []string
+		wantErr   string
+		wantAsked []string
+	}{
+		{name: "unprotected", writes: []string{"ps:/app/dev/a"}},
+		{name: "read-only", confirmed: true, writes: []string{"ps:/app/frozen/a"}, wantErr: "write protected: ps:/app/frozen/a is read-only (protect frozen)"},
+		{name: "read-only region", region: "us-west-2", writes: []string{"ps:/app/dev/a"}, wantErr: "is read-only (protect dr)"},
+		{name: "confirm flag", confirmed: true, writes: []string{"ps:/app/prod/a"}},
+		{name: "no terminal", writes: []string{"ps:/app/prod/a"}, wantErr: "write protected: ps:/app/prod/a needs confirmation (protect prod): no terminal; pass --yes-i-mean-prod"},
+		{name: "typed prefix, asked once", answer: "ps:/app/prod/", writes: []string{"ps:/app/prod/a", "ps:/app/prod/b"}, wantAsked: []string{"ps:/app/prod/"}},
+		{name: "wrong answer", answer: "yes", writes: []string{"ps:/app/prod/a"}, wantErr: `write protected: ps:/app/prod/a: confirmation did not match "ps:/app/prod/"`, wantAsked: []string{"ps:/app/prod/"}},
+		{name: "profile", profile: "prod", answer: "prod", writes: []string{"ps:/app/dev/a"}, wantAsked: []string{"prod"}},
+		{name: "other profile", profile: "dev", writes: []string{"ps:/app/dev/a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var asked []string
+			g := &WriteGuard{Protect: protect, Confirmed: tt.confirmed}
+			g.Confirm = func(prompt, want string) (bool, error) {
+				asked = append(asked, want)
+				if tt.answer == "" {
+					return false, errors.New("no terminal")
+				}
+				return tt.answer == want, nil
+			}
+			mb := backend.NewMockBackend()
+			b := g.Wrap(mb, tt.profile, tt.region)
+
+			var err error
+			for _, ref := range tt.writes {
+				if err = b.Put(ctx, ref, opts); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.Is(err, ErrProtected) {
+					t.Fatalf("Put() error = %v, want %q", err, tt.wantErr)
+				}
+				if len(mb.PutCalls) != 0 {
+					t.Errorf("rejected write reached the backend")
+				}
+			} else if err != nil {
+				t.Fatalf("Put() error: %v", err)
+			}
+			if tt.answer != "" && strings.Join(asked, ",") != strings.Join(tt.wantAsked, ",") {
+				t.Errorf("asked %v, want %v", asked, tt.wantAsked)
+			}
+		})
+	}
+}
+
+func TestGuard_ProtectTagWrites(t *testing.T) {
+	protect, _ := CompileProtect([]config.ProtectRule{{Match: "ps:/app/prod/**", ReadOnly: true}})
+	ctx := context.Background()
+	mb := backend.NewMockBackend()
+	_ = mb.Put(ctx, "ps:/app/prod/a", backend.PutOptions{Value: "v", StoreMode: tags.StoreModeRaw})
+	tagger, err := backend.AsTagger((&WriteGuard{Protect: protect}).Wrap(mb, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tagger.AddTags(ctx, "ps:/app/prod/a", map[string]string{"team": "core"}); !errors.Is(err, ErrProtected) {
+		t.Errorf("AddTags() error = %v, want ErrProtected", err)
+	}
+	if err := tagger.RemoveTags(ctx, "ps:/app/prod/a", []string{"team"}); !errors.Is(err, ErrProtected) {
+		t.Errorf("RemoveTags() error = %v, want ErrProtected", err)
+	}
+	if _, err := tagger.Tags(ctx, "ps:/app/prod/a"); err != nil {
+		t.Errorf("Tags() error: %v", err)
+	}
+}
+
+func TestCompileProtect_Errors(t *testing.T) {
+	tests := []struct {
+		rule config.ProtectRule
+		want string
+	}{
+		{config.ProtectRule{ReadOnly: true}, "protect #1: set at least one of match, profile and region"},
+		{config.ProtectRule{Name: "x", Match: "ps:/**"}, "protect x: set read_only or confirm"},
+	}
+	for _, tt := range tests {
+		if _, err := CompileProtect([]config.ProtectRule{tt.rule}); err == nil || err.Error() != tt.want {
+			t.Errorf("CompileProtect() error = %v, want %q", err, tt.want)
+		}
+	}
+}
+
+func TestWriteObject(t *testing.T) {
+	ref, _ := backend.ParseRef("ps:/app/db")
+	obj := WriteObject(ref, backend.PutOptions{Value: "abc", StoreMode: tags.StoreModeJSON, ValueType: backend.ValueTypeSecure, KMSKeyID: "alias/app", AdvancedTier: true, Tags: map[string]string{"team": "core"}})
+	if obj.Ref() != "ps:/app/db" || obj.ParamType != "SecureString" || obj.KMSKeyID != "alias/app" || obj.Tier != "Advanced" || obj.Size != 3 {
+		t.Errorf("unexpected object: %+v", obj)
+	}
+	if obj.Tags["team"] != "core" || obj.Tags[tags.TagStoreMode] != tags.StoreModeJSON {
+		t.Errorf("unexpected tags: %v", obj.Tags)
+	}
+	if o := WriteObject(ref, backend.PutOptions{}); o.Tier != "" || o.ParamType != "String" {
+		t.Errorf("auto tier should be unknown: %+v", o)
+	}
+	if o := WriteObject(ref, backend.PutOptions{ValueType: backend.ValueTypeStringList}); o.ParamType != "StringList" {
+		t.Errorf("stringlist type = %q, want StringList", o.ParamType)
+	}
+	smRef, _ := backend.ParseRef("sm:db")
+	if o := WriteObject(smRef, backend.PutOptions{}); o.ParamType != "" {
+		t.Errorf("secrets have no parameter type: %+v", o)
+	}
+}
@@ -0,0 +1,236 @@
+// Package policy checks writes and existing entries against the [[policy]] rules
+// declared in the configuration, and guards writes with the [[protect]] rules.
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+)
+
+// validTiers are the Parameter Store tiers accepted in rules.
+var validTiers = []string{"Standard", "Advanced", "Intelligent-Tiering"}
+
+// Set is a compiled list of rules.
+type Set struct {
+	rules []rule
+}
+
+type rule struct {
+	config.PolicyRule
+	label string
+	match *regexp.Regexp
+	name  *regexp.Regexp
+}
+
+// Compile validates rules and compiles their globs and patterns.
+// Rules without a name are labelled by their position ("#1", "#2", ...).
+func Compile(rules []config.PolicyRule) (*Set, error) {
+	set := &Set{}
+	for i, r := range rules {
+		label := r.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+		if r.Match == "" {
+			return nil, fmt.Errorf("policy %s: match is required", label)
+		}
+		compiled := rule{PolicyRule: r, label: label, match: globRegexp(r.Match)}
+		if r.NamePattern != "" {
+			re, err := regexp.Compile(r.NamePattern)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: invalid name_pattern: %w", label, err)
+			}
+			compiled.name = re
+		}
+		for _, t := range r.Tiers {
+			if !containsFold(validTiers, t) {
+				return nil, fmt.Errorf("policy %s: unknown tier %q (want %s)", label, t, strings.Join(validTiers, ", "))
+			}
+		}
+		if r.MaxSize < 0 {
+			return nil, fmt.Errorf("policy %s: max_size must not be negative", label)
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	return set, nil
+}
+
+// Empty reports whether the set has no rules.
+func (s *Set) Empty() bool {
+	return s == nil || len(s.rules) == 0
+}
+
+// Object describes an entry (or a pending write) to check. Unknown fields are skipped
+// by the rules that need them.
+type Object struct {
+	Type      backend.BackendType
+	Path      string
+	ParamType string            // Parameter Store type (String, StringList, SecureString); "" for secrets
+	KMSKeyID  string            // "" = AWS managed key
+	Tier      string            // "" = unknown
+	Size      int               // value size in bytes; -1 = unknown
+	Tags      map[string]string // nil = unknown
+}
+
+// Ref returns the ref of o, e.g. ps:/app/prod/DB_HOST.
+func (o Object) Ref() string {
+	return string(o.Type) + ":" + o.Path
+}
+
+// Violation is one rule broken by one ref.
+type Violation struct {
+	Ref     string
+	Policy  string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s (policy %s)", v.Ref, v.Message, v.Policy)
+}
+
+// ViolationError is returned by guarded writes that break a rule.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "policy violation: " + strings.Join(msgs, "; ")
+}
+
+// Matches reports whether any rule applies to ref.
+func (s *Set) Matches(ref string) bool {
+	if s == nil {
+		return false
+	}
+	for _, r := range s.rules {
+		if r.match.MatchString(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresTags reports whether a rule applying to ref has required_tags.
+func (s *Set) RequiresTags(ref string) bool {
+	if s == nil {
+		return false
+	}
+	for _, r := range s.rules {
+		if len(r.RequiredTags) > 0 && r.match.MatchString(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresSize reports whether a rule applying to ref has max_size.
+func (s *Set) RequiresSize(ref string) bool {
+	if s == nil {
+		return false
+	}
+	for _, r := range s.rules {
+		if r.MaxSize > 0 && r.match.MatchString(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns the violations of o against every rule whose glob matches its ref.
+func (s *Set) Check(o Object) []Violation {
+	if s == nil {
+		return nil
+	}
+	ref := o.Ref()
+	var out []Violation
+	for _, r := range s.rules {
+		if !r.match.MatchString(ref) {
+			continue
+		}
+		for _, msg := range r.check(o) {
+			out = append(out, Violation{Ref: ref, Policy: r.label, Message: msg})
+		}
+	}
+	return out
+}
+
+func (r rule) check(o Object) []string {
+	var msgs []string
+	ps := o.Type == backend.BackendTypePS
+
+	if r.RequireSecure && ps && o.ParamType != "" && o.ParamType != "SecureString" {
+		msgs = append(msgs, fmt.Sprintf("must be SecureString (is %s)", o.ParamType))
+	}
+	if r.KMSKeyID != "" && (o.Type == backend.BackendTypeSM || o.ParamType == "SecureString") && o.KMSKeyID != r.KMSKeyID {
+		current := o.KMSKeyID
+		if current == "" || strings.HasPrefix(current, "alias/aws/") {
+			current = "the AWS managed key"
+		}
+		msgs = append(msgs, fmt.Sprintf("must be encrypted with KMS key %s (uses %s)", r.KMSKeyID, current))
+	}
+	if len(r.Tiers) > 0 && ps && o.Tier != "" && !containsFold(r.Tiers, o.Tier) {
+		msgs = append(msgs, fmt.Sprintf("tier %s is not allowed (allowed: %s)", o.Tier, strings.Join(r.Tiers, ", ")))
+	}
+	if r.name != nil && !r.name.MatchString(o.Path) {
+		msgs = append(msgs, fmt.Sprintf("name does not match %s", r.NamePattern))
+	}
+	if r.MaxSize > 0 && o.Size > r.MaxSize {
+		msgs = append(msgs, fmt.Sprintf("value is %d bytes (max %d)", o.Size, r.MaxSize))
+	}
+	if len(r.RequiredTags) > 0 && o.Tags != nil {
+		var missing []string
+		for _, t := range r.RequiredTags {
+			k, v, hasValue := strings.Cut(t, "=")
+			got, ok := o.Tags[k]
+			if !ok || (hasValue && got != v) {
+				missing = append(missing, t)
+			}
+		}
+		if len(missing) > 0 {
+			msgs = append(msgs, fmt.Sprintf("missing required tags: %s", strings.Join(missing, ", ")))
+		}
+	}
+	return msgs
+}
+
+// globRegexp converts a ref glob to an anchored regular expression.
+// "*" and "?" do not cross "/"; "**" does, and "**/" also matches no directory at all.
+func globRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		glob string
+		ref  string
+		want bool
+	}{
+		{"ps:/app/prod/**", "ps:/app/prod/DB_HOST", true},
+		{"ps:/app/prod/**", "ps:/app/prod/db/password", true},
+		{"ps:/app/prod/**", "ps:/app/staging/DB_HOST", false},
+		{"ps:/app/*/DB_HOST", "ps:/app/prod/DB_HOST", true},
+		{"ps:/app/*/DB_HOST", "ps:/app/prod/eu/DB_HOST", false},
+		{"ps:/**/prod/**", "ps:/prod/DB_HOST", true},
+		{"ps:/**/prod/**", "ps:/team/app/prod/DB_HOST", true},
+		{"ps:/**/prod/**", "ps:/team/production/DB_HOST", false},
+		{"sm:prod-?", "sm:prod-1", true},
+		{"sm:prod-?", "sm:prod-12", false},
+		{"sm:**", "ps:/app", false},
+		{"ps:/a.b", "ps:/aXb", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.ref, func(t *testing.T) {
+			if got := globRegexp(tt.glob).MatchString(tt.ref); got != tt.want {
+				t.Errorf("glob %q on %q = %v, want %v", tt.glob, tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.PolicyRule
+		want string
+	}{
+		{"no match", config.PolicyRule{Name: "x"}, "policy x: match is required"},
+		{"bad regex", config.PolicyRule{Match: "ps:/**", NamePattern: "("}, "policy #1: invalid name_pattern"},
+		{"bad tier", config.PolicyRule{Match: "ps:/**", Tiers: []string{"Premium"}}, `unknown tier "Premium"`},
+		{"negative size", config.PolicyRule{Match: "ps:/**", MaxSize: -1}, "max_size must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]config.PolicyRule{tt.rule})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Check(t *testing.T) {
+	set, err := Compile([]config.PolicyRule{
+		{Name: "prod", Match: "ps:/**/prod/**", RequireSecure: true, KMSKeyID: "alias/app", Tiers: []string{"standard"}},
+		{Match: "ps:/**", NamePattern: `^/[a-z0-9_/]+$`, MaxSize: 10},
+		{Name: "owned", Match: "sm:**", KMSKeyID: "alias/app", RequiredTags: []string{"team", "env=prod"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		obj  Object
+		want []string
+	}{
+		{
+			name: "compliant",
+			obj:  Object{Type: backend.BackendTypePS, Path: "/app/prod/db", ParamType: "SecureString", KMSKeyID: "alias/app", Tier: "Standard", Size: 5},
+		},
+		{
+			name: "plain string under prod",
+			obj:  Object{Type: backend.BackendTypePS, Path: "/app/prod/db", ParamType: "String", Size: 5},
+			want: []string{"ps:/app/prod/db: must be SecureString (is String) (policy prod)"},
+		},
+		{
+			name: "default key, advanced tier, bad name, too large",
+			obj:  Object{Type: backend.BackendTypePS, Path: "/app/prod/DB", ParamType: "SecureString", KMSKeyID: "alias/aws/ssm", Tier: "Advanced", Size: 11},
+			want: []string{
+				"ps:/app/prod/DB: must be encrypted with KMS key alias/app (uses the AWS managed key) (policy prod)",
+				"ps:/app/prod/DB: tier Advanced is not allowed (allowed: standard) (policy prod)",
+				"ps:/app/prod/DB: name does not match ^/[a-z0-9_/]+$ (policy #2)",
+				"ps:/app/prod/DB: value is 11 bytes (max 10) (policy #2)",
+			},
+		},
+		{
+			name: "unknown fields are skipped",
+			obj:  Object{Type: backend.BackendTypePS, Path: "/app/prod/db", Size: -1},
+			want: nil,
+		},
+		{
+			name: "secret with other key and missing tags",
+			obj:  Object{Type: backend.BackendTypeSM, Path: "db", KMSKeyID: "alias/other", Size: -1, Tags: map[string]string{"env": "dev"}},
+			want: []string{
+				"sm:db: must be encrypted with KMS key alias/app (uses alias/other) (policy owned)",
+				"sm:db: missing required tags: team, env=prod (policy owned)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range set.Check(tt.obj) {
+				got = append(got, v.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Check() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/youyo/bundr/internal/config"
+)
+
+// ErrProtected is wrapped by errors of writes rejected by a [[protect]] rule, and of
+// writes to refs the write guard cannot parse.
+var ErrProtected = errors.New("write protected")
+
+// DefaultProfile stands for the AWS profile used when none is configured.
+const DefaultProfile = "default"
+
+// ProtectSet is a compiled list of [[protect]] rules.
+type ProtectSet struct {
+	rules []protectRule
+}
+
+type protectRule struct {
+	config.ProtectRule
+	label string
+	match *regexp.Regexp // nil = every ref
+}
+
+// CompileProtect validates rules and compiles their globs.
+// Rules without a name are labelled by their position ("#1", "#2", ...).
+func CompileProtect(rules []config.ProtectRule) (*ProtectSet, error) {
+	set := &ProtectSet{}
+	for i, r := range rules {
+		label := r.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+		if r.Match == "" && r.Profile == "" && r.Region == "" {
+			return nil, fmt.Errorf("protect %s: set at least one of match, profile and region", label)
+		}
+		if !r.ReadOnly && !r.Confirm {
+			return nil, fmt.Errorf("protect %s: set read_only or confirm", label)
+		}
+		compiled := protectRule{ProtectRule: r, label: label}
+		if r.Match != "" {
+			compiled.match = globRegexp(r.Match)
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	return set, nil
+}
+
+// Empty reports whether the set has no rules.
+func (s *ProtectSet) Empty() bool {
+	return s == nil || len(s.rules) == 0
+}
+
+// matching returns the rules that apply to a write of ref with the given profile and region.
+func (s *ProtectSet) matching(ref, profile, region string) []protectRule {
+	if s == nil {
+		return nil
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	var out []protectRule
+	for _, r := range s.rules {
+		if r.Profile != "" && r.Profile != profile {
+			continue
+		}
+		if r.Region != "" && r.Region != region {
+			continue
+		}
+		if r.match != nil && !r.match.MatchString(ref) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// confirmText is what the user has to type to confirm a write under r: the literal
+// part of the glob before its first wildcard (e.g. "ps:/app/prod/"), otherwise the
+// profile or region.
+func (r protectRule) confirmText() string {
+	if r.Match != "" {
+		if i := strings.IndexAny(r.Match, "*?"); i > 0 {
+			return r.Match[:i]
+		}
+		return r.Match
+	}
+	if r.Profile != "" {
+		return r.Profile
+	}
+	return r.Region
+}
+
+// ConfirmOnTerminal prints prompt to the controlling terminal and reports whether the
+// answer equals want. It returns an error when there is no terminal (e.g. in CI), so
+// stdin stays available for commands that read values from it.
+func ConfirmOnTerminal(prompt, want string) (bool, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false, fmt.Errorf("no terminal to confirm on")
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "%s\nType %q to continue: ", prompt, want)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, err
+	}
+	return strings.TrimSpace(answer) == want, nil
+}
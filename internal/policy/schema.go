@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/jsonschema"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// SchemaSet finds the JSON Schema of a ref and validates values against it.
+// The source in the entry's cli-value-schema tag wins over the [[schema]] rules;
+// among the rules with a schema, the first whose glob matches applies. A rule without
+// a schema only makes writes to the refs it matches honour their tag.
+type SchemaSet struct {
+	rules []schemaRule
+	load  func(source string) ([]byte, error)
+
+	mu     sync.Mutex
+	loaded map[string]*jsonschema.Schema
+}
+
+type schemaRule struct {
+	config.SchemaRule
+	match *regexp.Regexp
+}
+
+// CompileSchemas validates rules. load reads a schema source (see SchemaLoader).
+func CompileSchemas(rules []config.SchemaRule, load func(source string) ([]byte, error)) (*SchemaSet, error) {
+	set := &SchemaSet{load: load, loaded: map[string]*jsonschema.Schema{}}
+	for i, r := range rules {
+		if r.Match == "" {
+			return nil, fmt.Errorf("schema #%d: match is required", i+1)
+		}
+		set.rules = append(set.rules, schemaRule{SchemaRule: r, match: globRegexp(r.Match)})
+	}
+	return set, nil
+}
+
+// Empty reports whether the set has no rules, so that writes need no schema check
+// beyond a cli-value-schema tag they set themselves.
+func (s *SchemaSet) Empty() bool {
+	return s == nil || len(s.rules) == 0
+}
+
+// Covers reports whether a rule matches ref, so that a write to it must read the
+// entry's cli-value-schema tag.
+func (s *SchemaSet) Covers(ref string) bool {
+	if s == nil {
+		return false
+	}
+	for _, r := range s.rules {
+		if r.match.MatchString(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// Source returns the schema source for ref, given the entry's tags (nil = unknown),
+// or "" when no schema applies.
+func (s *SchemaSet) Source(ref string, tagMap map[string]string) string {
+	if s == nil {
+		return ""
+	}
+	if src := tagMap[tags.TagValueSchema]; src != "" {
+		return src
+	}
+	for _, r := range s.rules {
+		if r.Schema != "" && r.match.MatchString(ref) {
+			return r.Schema
+		}
+	}
+	return ""
+}
+
+// Validate checks value against the schema at source. A value that is not valid JSON
+// is validated as a JSON string, so raw text values can be described too.
+func (s *SchemaSet) Validate(ref, source, value string) error {
+	schema, err := s.schema(source)
+	if err != nil {
+		return err
+	}
+	v, err := jsonschema.Decode([]byte(value))
+	if err != nil {
+		v = value
+	}
+	if err := schema.Validate(v); err != nil {
+		return &SchemaError{Ref: ref, Schema: source, Err: err}
+	}
+	return nil
+}
+
+// schema loads and compiles source once per run.
+func (s *SchemaSet) schema(source string) (*jsonschema.Schema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if schema, ok := s.loaded[source]; ok {
+		return schema, nil
+	}
+	data, err := s.load(source)
+	if err != nil {
+		return nil, fmt.Errorf("load schema %s: %w", source, err)
+	}
+	schema, err := jsonschema.Compile(data)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", source, err)
+	}
+	s.loaded[source] = schema
+	return schema, nil
+}
+
+// SchemaError is returned when a value does not match its schema.
+type SchemaError struct {
+	Ref    string
+	Schema string
+	Err    error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s does not match schema %s: %v", e.Ref, e.Schema, e.Err)
+}
+
+func (e *SchemaError) Unwrap() error { return e.Err }
+
+// SchemaLoader returns a loader for schema sources: refs (ps:, sm:) are read
+// raw from the backend made by factory, anything else is a file path relative to the
+// current directory.
+func SchemaLoader(factory func(backend.BackendType) (backend.Backend, error)) func(string) ([]byte, error) {
+	return func(source string) ([]byte, error) {
+		if !IsRefSource(source) {
+			return os.ReadFile(source)
+		}
+		ref, err := backend.ParseRef(source)
+		if err != nil {
+			return nil, err
+		}
+		b, err := factory(ref.Type)
+		if err != nil {
+			return nil, err
+		}
+		v, err := b.Get(context.Background(), source, backend.GetOptions{ForceRaw: true})
+		if err != nil {
+			return nil, err
+		}
+		return []byte(v), nil
+	}
+}
+
+// IsRefSource reports whether a schema source is a ref rather than a file path:
+// a ps: or sm: ref that backend.ParseRef accepts.
+func IsRefSource(source string) bool {
+	ref, err := backend.ParseRef(source)
+	if err != nil {
+		return false
+	}
+	return ref.Type == backend.BackendTypePS || ref.Type == backend.BackendTypeSM
+}
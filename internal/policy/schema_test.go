@@ -0,0 +1,195 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+const portSchema = `{"type":"object","required":["port"],"properties":{"port":{"type":"integer","minimum":1}}}`
+
+func TestSchemaSet_SourceAndValidate(t *testing.T) {
+	loads := 0
+	set, err := CompileSchemas([]config.SchemaRule{
+		{Match: "ps:/app/*/config", Schema: "port.json"},
+		{Match: "ps:/app/tagged/**"},
+		{Match: "ps:/app/**", Schema: "name.json"},
+	}, func(source string) ([]byte, error) {
+		loads++
+		switch source {
+		case "port.json":
+			return []byte(portSchema), nil
+		case "name.json":
+			return []byte(`{"type":"string","pattern":"^[a-z]+$"}`), nil
+		}
+		return nil, os.ErrNotExist
+	})
+	if err != nil {
+		t.Fatalf("CompileSchemas() error: %v", err)
+	}
+
+	sources := []struct {
+		ref    string
+		tagMap map[string]string
+		want   string
+	}{
+		{"ps:/app/prod/config", nil, "port.json"},
+		{"ps:/app/prod/name", nil, "name.json"},
+		{"ps:/app/tagged/x", nil, "name.json"},
+		{"ps:/other/x", nil, ""},
+		{"ps:/other/x", map[string]string{tags.TagValueSchema: "port.json"}, "port.json"},
+	}
+	for _, s := range sources {
+		if got := set.Source(s.ref, s.tagMap); got != s.want {
+			t.Errorf("Source(%s, %v) = %q, want %q", s.ref, s.tagMap, got, s.want)
+		}
+	}
+
+	tests := []struct {
+		source, value, wantErr string
+	}{
+		{"port.json", `{"port":5432}`, ""},
+		{"port.json", `{"port":0}`, "ps:/x does not match schema port.json: /port: must be >= 1"},
+		{"port.json", `not json`, "ps:/x does not match schema port.json: /: expected object, got string"},
+		{"name.json", `web`, ""},
+		{"name.json", `Web`, "/: must match pattern ^[a-z]+$"},
+		{"missing.json", `{}`, "load schema missing.json: file does not exist"},
+	}
+	for _, tt := range tests {
+		err := set.Validate("ps:/x", tt.source, tt.value)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Validate(%s, %s) error: %v", tt.source, tt.value, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Validate(%s, %s) error = %v, want %q", tt.source, tt.value, err, tt.wantErr)
+		}
+	}
+	if loads != 3 {
+		t.Errorf("schemas loaded %d times, want 3 (once per source)", loads)
+	}
+
+	var se *SchemaError
+	if err := set.Validate("ps:/x", "port.json", `{}`); !errors.As(err, &se) || se.Ref != "ps:/x" {
+		t.Errorf("Validate() error = %v, want *SchemaError", err)
+	}
+}
+
+func TestCompileSchemas_Errors(t *testing.T) {
+	if _, err := CompileSchemas([]config.SchemaRule{{Schema: "s.json"}}, nil); err == nil || !strings.Contains(err.Error(), "schema #1: match is required") {
+		t.Errorf("CompileSchemas() error = %v", err)
+	}
+
+	set, err := CompileSchemas([]config.SchemaRule{{Match: "ps:/a/**"}}, nil)
+	if err != nil || set.Empty() || !set.Covers("ps:/a/b") || set.Covers("ps:/b") {
+		t.Errorf("CompileSchemas(match only) = %+v, %v; want a set covering ps:/a/**", set, err)
+	}
+	if set, _ := CompileSchemas(nil, nil); !set.Empty() {
+		t.Error("a set without rules is not empty")
+	}
+}
+
+func TestSchemaLoader(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "s.json")
+	if err := os.WriteFile(file, []byte(portSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mb := backend.NewMockBackend()
+	if err := mb.Put(context.Background(), "ps:/schemas/port", backend.PutOptions{Value: portSchema, StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatal(err)
+	}
+	load := SchemaLoader(func(backend.BackendType) (backend.Backend, error) { return mb, nil })
+
+	for _, source := range []string{file, "ps:/schemas/port"} {
+		data, err := load(source)
+		if err != nil || string(data) != portSchema {
+			t.Errorf("load(%s) = %q, %v", source, data, err)
+		}
+	}
+	if _, err := load("ps:/schemas/missing"); !errors.Is(err, backend.ErrNotFound) {
+		t.Errorf("load(missing ref) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestIsRefSource(t *testing.T) {
+	for source, want := range map[string]bool{
+		"ps:/schemas/port":   true,
+		"sm:schemas/port":    true,
+		"psa:/schemas/port":  false, // ParseRef rejects psa:
+		"vault:schemas/port": false,
+		"schemas/port.json":  false,
+	} {
+		if got := IsRefSource(source); got != want {
+			t.Errorf("IsRefSource(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestGuard_Schema(t *testing.T) {
+	ctx := context.Background()
+	mb := backend.NewMockBackend()
+	if err := mb.Put(ctx, "ps:/schemas/port", backend.PutOptions{Value: portSchema, StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mb.Put(ctx, "ps:/svc/tagged", backend.PutOptions{Value: `{"port":1}`, StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatal(err)
+	}
+	mb.SetTags("ps:/svc/tagged", map[string]string{tags.TagValueSchema: "ps:/schemas/port"})
+	if err := mb.Put(ctx, "ps:/other/tagged", backend.PutOptions{Value: `{"port":1}`, StoreMode: tags.StoreModeRaw}); err != nil {
+		t.Fatal(err)
+	}
+	mb.SetTags("ps:/other/tagged", map[string]string{tags.TagValueSchema: "ps:/schemas/port"})
+	schemas, err := CompileSchemas([]config.SchemaRule{{Match: "ps:/app/**", Schema: "ps:/schemas/port"}, {Match: "ps:/svc/**"}},
+		SchemaLoader(func(backend.BackendType) (backend.Backend, error) { return mb, nil }))
+	if err != nil {
+		t.Fatalf("CompileSchemas() error: %v", err)
+	}
+	b := (&WriteGuard{Schemas: schemas}).Wrap(mb, "", "")
+
+	tests := []struct {
+		name    string
+		ref     string
+		value   string
+		tagMap  map[string]string
+		wantErr string
+		noTags  bool // the entry's tags must not be read
+	}{
+		{name: "rule, valid", ref: "ps:/app/db", value: `{"port":5432}`},
+		{name: "rule, invalid", ref: "ps:/app/db", value: `{"port":"5432"}`, wantErr: "ps:/app/db does not match schema ps:/schemas/port: /port: expected integer, got string"},
+		{name: "no schema", ref: "ps:/other/db", value: `anything`, noTags: true},
+		{name: "tag outside the rules", ref: "ps:/other/tagged", value: `{}`, noTags: true},
+		{name: "existing tag", ref: "ps:/svc/tagged", value: `{}`, wantErr: "/: missing required properties: port"},
+		{name: "tag in the write", ref: "ps:/other/db", value: `{}`, tagMap: map[string]string{tags.TagValueSchema: "ps:/schemas/port"}, wantErr: "missing required properties: port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, tagReads := len(mb.PutCalls), len(mb.TagsCalls)
+			err := b.Put(ctx, tt.ref, backend.PutOptions{Value: tt.value, StoreMode: tags.StoreModeRaw, Tags: tt.tagMap})
+			if tt.noTags && len(mb.TagsCalls) != tagReads {
+				t.Errorf("Put read the tags of %v", mb.TagsCalls[tagReads:])
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Put() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Put() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(mb.PutCalls) != before {
+				t.Errorf("invalid value reached the backend")
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Replacement is written in place of every masked value.
+const Replacement = "***"
+
+// DefaultFlushDelay is how long held-back bytes wait for more output before they are
+// written anyway, so prompts without a trailing newline are not stuck in the buffer.
+const DefaultFlushDelay = 50 * time.Millisecond
+
+// Writer is an io.Writer that replaces secret values in a byte stream with Replacement
+// before passing it to the underlying writer.
+//
+// Output that could be the beginning of a secret is held back until enough bytes
+// arrive to decide, so values split across Write calls are still masked. Held-back
+// bytes are written after the flush delay if no more output arrives; a value split
+// across writes further apart than that is not masked.
+// Call Flush after the last Write to emit any held-back bytes.
+type Writer struct {
+	mu      sync.Mutex
+	dst     io.Writer
+	minLen  int
+	secrets [][]byte // sorted by length, longest first (longest match wins)
+	seen    map[string]bool
+	maxLen  int
+	pending []byte
+	delay   time.Duration
+	timer   *time.Timer
+	err     error // error of a write done by the timer, returned by the next call
+}
+
+// NewWriter returns a Writer that writes to dst. Values shorter than minLen are never masked,
+// which keeps short, common strings (e.g. "true", "80") from destroying the output.
+func NewWriter(dst io.Writer, minLen int) *Writer {
+	return &Writer{
+		dst:    dst,
+		minLen: minLen,
+		seen:   make(map[string]bool),
+		delay:  DefaultFlushDelay,
+	}
+}
+
+// SetFlushDelay sets how long held-back bytes wait for more output. Zero holds them
+// until the next Write or Flush.
+func (w *Writer) SetFlushDelay(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.delay = d
+}
+
+// AddValues registers values to mask, together with their base64 and URL-encoded forms.
+func (w *Writer) AddValues(values ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, v := range values {
+		if len(v) < w.minLen {
+			continue
+		}
+		for _, form := range Variants(v) {
+			if w.seen[form] {
+				continue
+			}
+			w.seen[form] = true
+			w.secrets = append(w.secrets, []byte(form))
+			if len(form) > w.maxLen {
+				w.maxLen = len(form)
+			}
+		}
+	}
+	sort.SliceStable(w.secrets, func(i, j int) bool {
+		return len(w.secrets[i]) > len(w.secrets[j])
+	})
+}
+
+// Variants returns v and the encoded forms under which it commonly leaks into logs:
+// standard and URL-safe base64 (padded and raw), query escaping and path escaping.
+func Variants(v string) []string {
+	b := []byte(v)
+	forms := []string{
+		v,
+		base64.StdEncoding.EncodeToString(b),
+		base64.RawStdEncoding.EncodeToString(b),
+		base64.URLEncoding.EncodeToString(b),
+		base64.RawURLEncoding.EncodeToString(b),
+		url.QueryEscape(v),
+		url.PathEscape(v),
+	}
+	seen := make(map[string]bool, len(forms))
+	result := make([]string, 0, len(forms))
+	for _, f := range forms {
+		if !seen[f] {
+			seen[f] = true
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// Write masks p (plus any bytes held back from earlier calls) and writes the result.
+// It always reports len(p) on success so callers such as io.Copy keep streaming.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.takeErr(); err != nil {
+		return 0, err
+	}
+	buf := append(w.pending, p...)
+	out, rest := w.mask(buf, false)
+	w.pending = append([]byte(nil), rest...)
+	if len(out) > 0 {
+		if _, err := w.dst.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	w.schedule()
+	return len(p), nil
+}
+
+// Flush masks and writes any held-back bytes.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if err := w.takeErr(); err != nil {
+		return err
+	}
+	return w.flushPending()
+}
+
+// schedule arms the flush timer while bytes are held back and stops it otherwise.
+func (w *Writer) schedule() {
+	switch {
+	case len(w.pending) == 0 || w.delay <= 0:
+		if w.timer != nil {
+			w.timer.Stop()
+		}
+	case w.timer == nil:
+		w.timer = time.AfterFunc(w.delay, w.flushTimer)
+	default:
+		w.timer.Reset(w.delay)
+	}
+}
+
+// flushTimer writes the held-back bytes when no more output arrived within the delay.
+func (w *Writer) flushTimer() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.flushPending(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// flushPending masks and writes the held-back bytes. The caller holds w.mu.
+func (w *Writer) flushPending() error {
+	out, _ := w.mask(w.pending, true)
+	w.pending = nil
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := w.dst.Write(out)
+	return err
+}
+
+// takeErr returns and clears the error of a write done by the timer.
+func (w *Writer) takeErr() error {
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// mask returns the masked output and the unprocessed tail that may still turn into a match.
+// With final=true nothing is held back.
+func (w *Writer) mask(buf []byte, final bool) ([]byte, []byte) {
+	if len(w.secrets) == 0 {
+		return buf, nil
+	}
+
+	out := make([]byte, 0, len(buf))
+	i := 0
+	for i < len(buf) {
+		rest := buf[i:]
+		if !final && len(rest) < w.maxLen && w.isPartial(rest) {
+			break
+		}
+		if n := w.matchAt(rest); n > 0 {
+			out = append(out, Replacement...)
+			i += n
+			continue
+		}
+		out = append(out, buf[i])
+		i++
+	}
+	return out, buf[i:]
+}
+
+// matchAt returns the length of the longest secret that b starts with, or 0.
+func (w *Writer) matchAt(b []byte) int {
+	for _, s := range w.secrets {
+		if bytes.HasPrefix(b, s) {
+			return len(s)
+		}
+	}
+	return 0
+}
+
+// isPartial reports whether b is a proper prefix of some secret (more data could complete it).
+func (w *Writer) isPartial(b []byte) bool {
+	for _, s := range w.secrets {
+		if len(s) > len(b) && bytes.HasPrefix(s, b) {
+			return true
+		}
+	}
+	return false
+}
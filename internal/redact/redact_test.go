@@ -0,0 +1,144 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriter_Mask(t *testing.T) {
+	const secret = "p@ss w0rd!"
+
+	tests := []struct {
+		id    string
+		input string
+		want  string
+	}{
+		{id: "plain", input: "password is p@ss w0rd!\n", want: "password is ***\n"},
+		{id: "twice", input: "p@ss w0rd!p@ss w0rd!", want: "******"},
+		{id: "base64", input: "auth: " + base64.StdEncoding.EncodeToString([]byte(secret)), want: "auth: ***"},
+		{id: "url-encoded", input: "dsn=postgres://u:" + url.QueryEscape(secret) + "@db", want: "dsn=postgres://u:***@db"},
+		{id: "no-secret", input: "nothing to see", want: "nothing to see"},
+		{id: "partial-at-end", input: "p@ss w0", want: "p@ss w0"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf, 6)
+			w.AddValues(secret)
+			if _, err := w.Write([]byte(tc.input)); err != nil {
+				t.Fatalf("Write() error: %v", err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() error: %v", err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("output = %q, want %q", buf.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestWriter_SplitAcrossWrites(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 6)
+	w.AddValues("supersecret")
+
+	input := "token=supersecret done"
+	// Feed one byte at a time: every boundary splits the value somewhere.
+	for i := 0; i < len(input); i++ {
+		if _, err := w.Write([]byte{input[i]}); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if got := buf.String(); got != "token=*** done" {
+		t.Errorf("output = %q", got)
+	}
+}
+
+// A prompt whose tail could start a secret is written after the flush delay,
+// without waiting for a newline or Flush.
+func TestWriter_FlushDelay(t *testing.T) {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	w := NewWriter(lockedWriter{&mu, &buf}, 6)
+	w.SetFlushDelay(10 * time.Millisecond)
+	w.AddValues("supersecret")
+
+	if _, err := w.Write([]byte("Password for super")); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := buf.String()
+		mu.Unlock()
+		if got == "Password for super" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("output = %q, want the prompt after the flush delay", got)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+}
+
+// lockedWriter serializes writes to w, which may come from the flush timer.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+func TestWriter_LongestMatchWins(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 6)
+	w.AddValues("secret", "secret-extended")
+
+	_, _ = w.Write([]byte("secret-"))
+	_, _ = w.Write([]byte("extended!"))
+	_ = w.Flush()
+
+	if got := buf.String(); got != "***!" {
+		t.Errorf("output = %q, want %q", got, "***!")
+	}
+}
+
+func TestWriter_MinLength(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 6)
+	w.AddValues("true", "80")
+
+	_, _ = w.Write([]byte("enabled=true port=80"))
+	_ = w.Flush()
+
+	if got := buf.String(); got != "enabled=true port=80" {
+		t.Errorf("short values must not be masked: %q", got)
+	}
+}
+
+func TestVariants(t *testing.T) {
+	got := Variants("a/b c")
+	joined := strings.Join(got, "\n")
+	for _, want := range []string{"a/b c", "a%2Fb+c", "a%2Fb%20c", base64.StdEncoding.EncodeToString([]byte("a/b c"))} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Variants() missing %q: %v", want, got)
+		}
+	}
+}
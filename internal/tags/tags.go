@@ -1,11 +1,15 @@
 package tags
 
+import "strings"
+
 const (
 	TagCLI       = "cli"
 	TagStoreMode = "cli-store-mode"
 	TagSchema    = "cli-schema"
 	TagFlatten   = "cli-flatten"
 	TagOwner     = "cli-owner"
+	// TagValueSchema points to the JSON Schema of the value: a file path or a ps:/sm: ref.
+	TagValueSchema = "cli-value-schema"
 
 	TagCLIValue    = "bundr"
 	TagSchemaValue = "v1"
@@ -22,3 +26,9 @@ func ManagedTags(storeMode string) map[string]string {
 		TagSchema:    TagSchemaValue,
 	}
 }
+
+// IsReserved reports whether key is one of bundr's own tags ("cli" or "cli-*"),
+// which drive how values are stored and read.
+func IsReserved(key string) bool {
+	return key == TagCLI || strings.HasPrefix(key, TagCLI+"-")
+}
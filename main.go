@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 
+	"filippo.io/age"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
@@ -16,6 +21,9 @@ import (
 	"github.com/youyo/bundr/internal/backend"
 	"github.com/youyo/bundr/internal/cache"
 	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/envelope"
+	"github.com/youyo/bundr/internal/plugin"
+	"github.com/youyo/bundr/internal/policy"
 )
 
 var version = "0.7.6" // goreleaser ldflags で上書き（-X main.version=...）
@@ -42,7 +50,7 @@ func main() {
 	bgLauncher := &cmd.ExecBGLauncher{}
 
 	// 3.5. 補完用 BackendFactory を事前構築（CLIフラグ反映前の cfg を使用）
-	completionFactory := cmd.BackendFactory(newBackendFactory(cfg))
+	completionFactory := cmd.BackendFactory(newBackendFactory(cfg, false))
 
 	// 4. Kong パーサー構築
 	cli := cmd.CLI{}
@@ -54,6 +62,7 @@ func main() {
 	kongplete.Complete(parser,
 		kongplete.WithPredictor("ref", cmd.NewRefPredictor(cacheStore, bgLauncher, completionFactory)),
 		kongplete.WithPredictor("prefix", cmd.NewPrefixPredictor(cacheStore, bgLauncher, completionFactory)),
+		kongplete.WithPredictor("field", cmd.NewFieldPredictor(completionFactory)),
 	)
 
 	// 6. --version フラグを早期チェック（Parse 前に処理）
@@ -78,7 +87,7 @@ func main() {
 	config.ApplyCLIOverrides(cfg, cli.Region, cli.Profile, cli.KMSKeyID)
 
 	// 9. BackendFactory 構築（最終的な cfg で）
-	factory := newBackendFactory(cfg)
+	factory := newBackendFactory(cfg, cli.YesIMeanProd)
 
 	// 10. コマンドを実行
 	err = kctx.Run(&cmd.Context{
@@ -86,6 +95,10 @@ func main() {
 		BackendFactory: factory,
 		CacheStore:     cacheStore,
 		BGLauncher:     bgLauncher,
+		Output:         cli.Output,
+		RegionContext: func(region string) (*cmd.Context, error) {
+			return newRegionContext(cfg, region, identifier, bgLauncher, cli.Output, cli.YesIMeanProd), nil
+		},
 	})
 	if err != nil {
 		var exitErr *cmd.ExitCodeError
@@ -97,30 +110,208 @@ func main() {
 	}
 }
 
+// newRegionContext returns a Context whose backends and completion cache use region
+// instead of the configured one (used by commands that search several regions).
+func newRegionContext(cfg *config.Config, region, identifier string, bgLauncher cmd.BGLauncher, output string, confirmed bool) *cmd.Context {
+	regionCfg := *cfg
+	regionCfg.AWS.Region = region
+
+	var cacheStore cache.Store = cache.NewNoopStore()
+	if fs, err := cache.NewFileStore(region, identifier); err == nil {
+		cacheStore = fs
+	}
+	return &cmd.Context{
+		Config:         &regionCfg,
+		BackendFactory: newBackendFactory(&regionCfg, confirmed),
+		CacheStore:     cacheStore,
+		BGLauncher:     bgLauncher,
+		Output:         output,
+	}
+}
+
 // newBackendFactory returns a BackendFactory that creates real AWS backends.
-func newBackendFactory(cfg *config.Config) func(backend.BackendType) (backend.Backend, error) {
+// Writes go through one WriteGuard with the [[policy]], [[protect]] and [[schema]]
+// rules of cfg; confirmed skips the confirmation of confirm rules (--yes-i-mean-prod).
+func newBackendFactory(cfg *config.Config, confirmed bool) func(backend.BackendType) (backend.Backend, error) {
+	// file: ストアは復号結果をキャッシュするので 1 つのインスタンスを共有する
+	fileBackend := sync.OnceValues(func() (*backend.FileBackend, error) { return newFileBackend(cfg) })
+	plugins := &pluginBackends{backends: map[backend.BackendType]backend.Backend{}}
+	raw := func(bt backend.BackendType) (backend.Backend, error) {
+		b, _, err := newRawBackend(cfg, bt, fileBackend, plugins)
+		return b, err
+	}
+	guard, guardErr := newWriteGuard(cfg, confirmed, raw)
 	return func(bt backend.BackendType) (backend.Backend, error) {
-		opts := []func(*awsconfig.LoadOptions) error{}
-		if cfg.AWS.Region != "" {
-			opts = append(opts, awsconfig.WithRegion(cfg.AWS.Region))
+		if guardErr != nil {
+			return nil, guardErr
 		}
-		if cfg.AWS.Profile != "" {
-			opts = append(opts, awsconfig.WithSharedConfigProfile(cfg.AWS.Profile))
+		b, region, err := newRawBackend(cfg, bt, fileBackend, plugins)
+		if err != nil {
+			return nil, err
 		}
+		return guard.Wrap(b, cfg.AWS.Profile, region), nil
+	}
+}
 
-		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+// newRawBackend creates an unguarded backend for bt and reports the AWS region it talks
+// to ("" for the vault: backend, plugins and the local file:, env: and dotenv: backends).
+func newRawBackend(cfg *config.Config, bt backend.BackendType, fileBackend func() (*backend.FileBackend, error), plugins *pluginBackends) (backend.Backend, string, error) {
+	switch bt {
+	case backend.BackendTypePS, backend.BackendTypeSM:
+		return newAWSBackend(cfg, bt)
+	case backend.BackendTypeFile:
+		b, err := fileBackend()
+		if err != nil {
+			return nil, "", err
+		}
+		return b, "", nil
+	case backend.BackendTypeEnv:
+		return backend.NewEnvBackend(), "", nil
+	case backend.BackendTypeDotenv:
+		return backend.NewDotenvBackend(), "", nil
+	case backend.BackendTypeVault:
+		b, err := newVaultBackend(cfg)
 		if err != nil {
-			return nil, fmt.Errorf("load AWS config: %w", err)
+			return nil, "", err
 		}
+		return b, "", nil
+	}
+	b, err := plugins.get(bt)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, "", nil
+}
+
+// pluginBackends starts the plugin of each ref prefix that is not built in (at most
+// once per run) and keeps it running until bundr exits and closes its stdin.
+type pluginBackends struct {
+	mu       sync.Mutex
+	backends map[backend.BackendType]backend.Backend
+}
+
+// get returns the backend of the bundr-backend-PREFIX plugin found on PATH.
+func (p *pluginBackends) get(bt backend.BackendType) (backend.Backend, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if b, ok := p.backends[bt]; ok {
+		return b, nil
+	}
+	name := backend.PluginExecutable(string(bt))
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown backend prefix %q: no %s executable on PATH", string(bt)+":", name)
+	}
+	c, err := plugin.Start(string(bt), exec.Command(path))
+	if err != nil {
+		return nil, err
+	}
+	p.backends[bt] = c.Backend()
+	return p.backends[bt], nil
+}
 
-		switch bt {
-		case backend.BackendTypePS:
-			// psa: refs are normalized to BackendTypePS by ParseRef
-			return backend.NewPSBackend(ssm.NewFromConfig(awsCfg)), nil
-		case backend.BackendTypeSM:
-			return backend.NewSMBackend(secretsmanager.NewFromConfig(awsCfg)), nil
-		default:
-			return nil, fmt.Errorf("unsupported backend type: %s", bt)
+// newVaultBackend creates the vault: backend from the [vault] settings. The token is
+// $VAULT_TOKEN, or the ~/.vault-token file written by "vault login".
+func newVaultBackend(cfg *config.Config) (*backend.VaultBackend, error) {
+	if cfg.Vault.Address == "" {
+		return nil, fmt.Errorf("vault backend: no address (set VAULT_ADDR or [vault] address)")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(data))
+			}
 		}
 	}
+	if token == "" {
+		return nil, fmt.Errorf("vault backend: no token (set VAULT_TOKEN or run vault login)")
+	}
+	return backend.NewVaultBackend(backend.VaultOptions{
+		Address:   cfg.Vault.Address,
+		Token:     token,
+		Namespace: cfg.Vault.Namespace,
+		Mount:     cfg.Vault.Mount,
+	}), nil
+}
+
+// newFileBackend creates the file: backend. Files are opened with $BUNDR_FILE_PASSPHRASE
+// and the [file] identity; new files are encrypted to the [file] recipients, or to the
+// identity's own recipient when none are configured.
+func newFileBackend(cfg *config.Config) (*backend.FileBackend, error) {
+	keys := backend.FileKeys{
+		Passphrase: os.Getenv("BUNDR_FILE_PASSPHRASE"),
+		Recipients: cfg.File.Recipients,
+	}
+	if cfg.File.Identity != "" {
+		f, err := os.Open(cfg.File.Identity)
+		if err != nil {
+			return nil, fmt.Errorf("file backend identity: %w", err)
+		}
+		defer f.Close()
+		ids, err := envelope.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("file backend identity %s: %w", cfg.File.Identity, err)
+		}
+		keys.Identities = ids
+		if len(keys.Recipients) == 0 {
+			for _, id := range ids {
+				if x, ok := id.(*age.X25519Identity); ok {
+					keys.Recipients = append(keys.Recipients, x.Recipient().String())
+				}
+			}
+		}
+	}
+	return backend.NewFileBackend(keys), nil
+}
+
+// newAWSBackend creates an unguarded AWS backend for bt and reports the region it talks to.
+func newAWSBackend(cfg *config.Config, bt backend.BackendType) (backend.Backend, string, error) {
+	opts := []func(*awsconfig.LoadOptions) error{}
+	if cfg.AWS.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.AWS.Region))
+	}
+	if cfg.AWS.Profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(cfg.AWS.Profile))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, "", fmt.Errorf("load AWS config: %w", err)
+	}
+
+	switch bt {
+	case backend.BackendTypePS:
+		// psa: refs are normalized to BackendTypePS by ParseRef
+		return backend.NewPSBackend(ssm.NewFromConfig(awsCfg)), awsCfg.Region, nil
+	case backend.BackendTypeSM:
+		return backend.NewSMBackend(secretsmanager.NewFromConfig(awsCfg)), awsCfg.Region, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported backend type: %s", bt)
+	}
+}
+
+// newWriteGuard compiles the [[policy]], [[protect]] and [[schema]] rules of cfg.
+// Schemas stored in parameters are read through raw. Confirmations are asked on the
+// terminal.
+func newWriteGuard(cfg *config.Config, confirmed bool, raw func(backend.BackendType) (backend.Backend, error)) (*policy.WriteGuard, error) {
+	policies, err := policy.Compile(cfg.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid [[policy]] configuration: %w", err)
+	}
+	protect, err := policy.CompileProtect(cfg.Protect)
+	if err != nil {
+		return nil, fmt.Errorf("invalid [[protect]] configuration: %w", err)
+	}
+	schemas, err := policy.CompileSchemas(cfg.Schemas, policy.SchemaLoader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid [[schema]] configuration: %w", err)
+	}
+	return &policy.WriteGuard{
+		Policies:  policies,
+		Protect:   protect,
+		Schemas:   schemas,
+		Confirmed: confirmed,
+		Confirm:   policy.ConfirmOnTerminal,
+	}, nil
 }
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"filippo.io/age"
 	"github.com/posener/complete"
 	"github.com/youyo/bundr/cmd"
+	"github.com/youyo/bundr/internal/backend"
 	"github.com/youyo/bundr/internal/cache"
+	"github.com/youyo/bundr/internal/config"
+	"github.com/youyo/bundr/internal/plugin"
 )
 
 // MockBGLauncher はテスト用のバックグラウンドランチャー。
@@ -35,7 +42,7 @@ func (m *TestMockStore) Read(backendType string) ([]cache.CacheEntry, error) {
 	return nil, cache.ErrCacheNotFound
 }
 
-func (m *TestMockStore) Write(backendType string, entries []cache.CacheEntry) error {
+func (m *TestMockStore) Write(backendType, _ string, entries []cache.CacheEntry) error {
 	if m.WriteFunc != nil {
 		return m.WriteFunc(backendType, entries)
 	}
@@ -49,6 +56,8 @@ func (m *TestMockStore) LastRefreshedAt(backendType string) time.Time {
 	return time.Time{}
 }
 
+func (m *TestMockStore) Scope(_ string) (string, bool) { return "", false }
+
 func (m *TestMockStore) Clear() error { return nil }
 
 // pred-001: キャッシュあり、prefix="ps:/app" → candidate paths を含む候補リスト
@@ -258,3 +267,115 @@ func TestRefPredictorNoThrottle(t *testing.T) {
 		t.Errorf("expected no BGLauncher.Launch call within 10s throttle, but got %d calls", len(bgLauncher.LaunchCalls))
 	}
 }
+
+func TestNewFileBackend_IdentityRecipient(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "dev.txt")
+	if err := os.WriteFile(keyFile, []byte("# dev key\n"+id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BUNDR_FILE_PASSPHRASE", "")
+
+	// recipients 未設定なら identity 自身の受信者で新しいファイルを暗号化する
+	cfg := &config.Config{File: config.FileConfig{Identity: keyFile}}
+	factory := newBackendFactory(cfg, false)
+	b, err := factory(backend.BackendTypeFile)
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	ref := "file:" + filepath.Join(dir, "dev.bundr") + ":/app/key"
+	ctx := context.Background()
+	if err := b.Put(ctx, ref, backend.PutOptions{Value: "v"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	reopened, err := newFileBackend(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.Get(ctx, ref, backend.GetOptions{}); err != nil || got != "v" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+
+	cfg.File.Identity = filepath.Join(dir, "missing.txt")
+	if _, err := newBackendFactory(cfg, false)(backend.BackendTypeFile); err == nil {
+		t.Fatal("factory with a missing identity file succeeded")
+	}
+}
+
+func TestNewVaultBackend_Token(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("VAULT_TOKEN", "")
+	cfg := &config.Config{Vault: config.VaultConfig{Address: "http://127.0.0.1:8200"}}
+
+	if _, err := newVaultBackend(cfg); err == nil {
+		t.Fatal("expected an error without a token")
+	}
+	// vault login が書く ~/.vault-token をフォールバックとして読む
+	if err := os.WriteFile(filepath.Join(home, ".vault-token"), []byte("hvs.file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newVaultBackend(cfg); err != nil {
+		t.Fatalf("token file: %v", err)
+	}
+	cfg.Vault.Address = ""
+	if _, err := newVaultBackend(cfg); err == nil || !strings.Contains(err.Error(), "VAULT_ADDR") {
+		t.Fatalf("error = %v, want a missing address error", err)
+	}
+}
+
+// TestMain lets the test binary act as a backend plugin when BUNDR_TEST_PLUGIN is set.
+func TestMain(m *testing.M) {
+	if os.Getenv("BUNDR_TEST_PLUGIN") != "" {
+		if err := plugin.Serve(os.Stdin, os.Stdout, "demo", backend.NewMockBackend()); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestNewBackendFactory_Plugin(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Symlink(os.Args[0], filepath.Join(dir, backend.PluginExecutable("demo"))); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("BUNDR_TEST_PLUGIN", "1")
+
+	ref, err := backend.ParseRef("demo:/app/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	factory := newBackendFactory(&config.Config{}, false)
+	b, err := factory(ref.Type)
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	ctx := context.Background()
+	if err := b.Put(ctx, "demo:/app/key", backend.PutOptions{Value: "v", StoreMode: "raw"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	// 同じプラグインプロセスが再利用される
+	again, err := factory(ref.Type)
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	if got, err := again.Get(ctx, "demo:/app/key", backend.GetOptions{}); err != nil || got != "v" {
+		t.Fatalf("Get = %q, %v; want v", got, err)
+	}
+	if _, err := backend.AsTagger(b); err != nil {
+		t.Errorf("AsTagger: %v", err)
+	}
+	if !backend.IsRef("demo:/app/key") || backend.IsRef("absent:/app/key") {
+		t.Error("IsRef should accept installed plugin prefixes only")
+	}
+
+	if _, err := factory(backend.BackendType("absent")); err == nil || !strings.Contains(err.Error(), "bundr-backend-absent") {
+		t.Errorf("missing plugin: error = %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+// Command bundr-backend-jsonfile is the reference backend plugin. Installed on PATH, it
+// makes bundr accept "jsonfile:/path/to/key" refs, stored unencrypted in the JSON file
+// named by $BUNDR_JSONFILE_PATH (default: bundr-store.json in the current directory).
+//
+//	go install github.com/youyo/bundr/plugins/bundr-backend-jsonfile@latest
+//	bundr put jsonfile:/app/db_host -v localhost
+//
+// It is meant as a starting point for plugins, not for secrets.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/youyo/bundr/internal/plugin"
+)
+
+func main() {
+	path := os.Getenv("BUNDR_JSONFILE_PATH")
+	if path == "" {
+		path = "bundr-store.json"
+	}
+	if err := plugin.Serve(os.Stdin, os.Stdout, "jsonfile", newStore(path)); err != nil {
+		fmt.Fprintf(os.Stderr, "bundr-backend-jsonfile: %v\n", err)
+		os.Exit(1)
+	}
+}
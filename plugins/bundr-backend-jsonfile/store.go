@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/tags"
+)
+
+// entry is one stored key.
+type entry struct {
+	Value        string            `json:"value"`
+	StoreMode    string            `json:"store_mode"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	Version      int64             `json:"version"`
+	LastModified time.Time         `json:"last_modified"`
+}
+
+// store keeps entries by key path ("/app/key") in a JSON file. It implements
+// backend.Backend and backend.Tagger, but not backend.Lister, so the handshake
+// reports the "tags" capability only.
+type store struct {
+	path string
+}
+
+func newStore(path string) *store {
+	return &store{path: path}
+}
+
+// load reads the file; a missing file is an empty store.
+func (s *store) load() (map[string]*entry, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]*entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]*entry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return entries, nil
+}
+
+// save writes the file atomically.
+func (s *store) save(entries map[string]*entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".bundr-jsonfile-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// key returns the key path of ref.
+func key(ref string) (string, error) {
+	parsed, err := backend.ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(parsed.Path, "/") || strings.HasSuffix(parsed.Path, "/") {
+		return "", fmt.Errorf("%s: expected a key path like /app/key", ref)
+	}
+	return parsed.Path, nil
+}
+
+// lookup returns the entry of ref.
+func (s *store) lookup(ref string) (*entry, error) {
+	k, err := key(ref)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	e, ok := entries[k]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", backend.ErrNotFound, ref)
+	}
+	return e, nil
+}
+
+// update applies fn to the entry of ref and saves the file.
+func (s *store) update(ref string, fn func(e *entry) error) error {
+	k, err := key(ref)
+	if err != nil {
+		return err
+	}
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	e, ok := entries[k]
+	if !ok {
+		return fmt.Errorf("%w: %s", backend.ErrNotFound, ref)
+	}
+	if err := fn(e); err != nil {
+		return err
+	}
+	return s.save(entries)
+}
+
+// Put stores a value. Like Parameter Store, scalars are JSON-encoded in json store mode.
+func (s *store) Put(_ context.Context, ref string, opts backend.PutOptions) error {
+	k, err := key(ref)
+	if err != nil {
+		return err
+	}
+	value := opts.Value
+	if opts.StoreMode == tags.StoreModeJSON && !json.Valid([]byte(value)) {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		value = string(encoded)
+	}
+	tagMap := map[string]string{}
+	if !opts.ExactTags {
+		tagMap = tags.ManagedTags(opts.StoreMode)
+	}
+	for tk, tv := range opts.Tags {
+		tagMap[tk] = tv
+	}
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	var version int64
+	if old, ok := entries[k]; ok {
+		version = old.Version
+	}
+	entries[k] = &entry{Value: value, StoreMode: opts.StoreMode, Tags: tagMap, Version: version + 1, LastModified: time.Now().UTC()}
+	return s.save(entries)
+}
+
+// Get returns the value of ref, decoded according to its store mode.
+func (s *store) Get(_ context.Context, ref string, opts backend.GetOptions) (string, error) {
+	e, err := s.lookup(ref)
+	if err != nil {
+		return "", err
+	}
+	if opts.ForceRaw || (!opts.ForceJSON && e.StoreMode != tags.StoreModeJSON) {
+		return e.Value, nil
+	}
+	var str string
+	if err := json.Unmarshal([]byte(e.Value), &str); err == nil {
+		return str, nil
+	}
+	if !json.Valid([]byte(e.Value)) {
+		return "", fmt.Errorf("invalid JSON: %s", e.Value)
+	}
+	return e.Value, nil
+}
+
+// GetByPrefix returns the entries under prefix ("/app/"), sorted by path.
+func (s *store) GetByPrefix(_ context.Context, prefix string, opts backend.GetByPrefixOptions) ([]backend.ParameterEntry, error) {
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	var keys []string
+	for k := range entries {
+		rest, ok := strings.CutPrefix(k, prefix)
+		if !ok || (!opts.Recursive && strings.Contains(rest, "/")) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]backend.ParameterEntry, 0, len(keys))
+	for _, k := range keys {
+		e := entries[k]
+		result = append(result, backend.ParameterEntry{
+			Path:      k,
+			Value:     e.Value,
+			StoreMode: e.StoreMode,
+			Flatten:   e.Tags[tags.TagFlatten],
+			Tags:      e.Tags,
+		})
+	}
+	return result, nil
+}
+
+// Describe returns the metadata, value and tags of ref.
+func (s *store) Describe(_ context.Context, ref string) (map[string]any, error) {
+	e, err := s.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	k, _ := key(ref)
+	return map[string]any{
+		"Name":             k,
+		"Value":            e.Value,
+		"Version":          e.Version,
+		"LastModifiedDate": e.LastModified,
+		"Tags":             e.Tags,
+	}, nil
+}
+
+// Tags returns all tags of ref.
+func (s *store) Tags(_ context.Context, ref string) (map[string]string, error) {
+	e, err := s.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	return e.Tags, nil
+}
+
+// AddTags adds or overwrites tags of ref.
+func (s *store) AddTags(_ context.Context, ref string, tagMap map[string]string) error {
+	return s.update(ref, func(e *entry) error {
+		if e.Tags == nil {
+			e.Tags = map[string]string{}
+		}
+		for k, v := range tagMap {
+			e.Tags[k] = v
+		}
+		return nil
+	})
+}
+
+// RemoveTags removes tag keys from ref. Missing keys are ignored.
+func (s *store) RemoveTags(_ context.Context, ref string, keys []string) error {
+	return s.update(ref, func(e *entry) error {
+		for _, k := range keys {
+			delete(e.Tags, k)
+		}
+		return nil
+	})
+}
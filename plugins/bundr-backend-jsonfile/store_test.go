@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/youyo/bundr/internal/backend"
+	"github.com/youyo/bundr/internal/plugin"
+	"github.com/youyo/bundr/internal/plugin/plugintest"
+)
+
+func TestConformance(t *testing.T) {
+	plugintest.Run(t, "jsonfile", func(t *testing.T) backend.Backend {
+		s := newStore(filepath.Join(t.TempDir(), "store.json"))
+		reqR, reqW := io.Pipe()
+		respR, respW := io.Pipe()
+		go func() {
+			_ = plugin.Serve(reqR, respW, "jsonfile", s)
+			respW.Close()
+		}()
+		c, err := plugin.Connect("jsonfile", respR, reqW)
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		if caps := c.Info().Capabilities; caps.List || !caps.Tags {
+			t.Errorf("capabilities = %+v, want tags only", caps)
+		}
+		return c.Backend()
+	})
+}